@@ -128,6 +128,11 @@ func (f *FileNode) GetItem(i Item) (Token, Comment) {
 	return f.fileInfo.GetItem(i)
 }
 
+// SourcePos returns the position in the file for the given byte offset.
+func (f *FileNode) SourcePos(offset int) SourcePos {
+	return f.fileInfo.SourcePos(offset)
+}
+
 func (f *FileNode) Items() Sequence[Item] {
 	return f.fileInfo.Items()
 }
@@ -252,7 +257,10 @@ type ImportNode struct {
 	// Optional; if present indicates this is a public import
 	Public *KeywordNode
 	// Optional; if present indicates this is a weak import
-	Weak      *KeywordNode
+	Weak *KeywordNode
+	// Optional; if present indicates this is an option import, whose
+	// contents may only be used in options (edition 2024 and later)
+	Option    *KeywordNode
 	Name      StringValueNode
 	Semicolon *RuneNode
 }
@@ -269,6 +277,36 @@ type ImportNode struct {
 //   - name: The actual imported file name.
 //   - semicolon: The token corresponding to the ";" rune that ends the declaration.
 func NewImportNode(keyword *KeywordNode, public *KeywordNode, weak *KeywordNode, name StringValueNode, semicolon *RuneNode) *ImportNode {
+	var modifier *KeywordNode
+	if public != nil {
+		modifier = public
+	} else if weak != nil {
+		modifier = weak
+	}
+	ret := newImportNode(keyword, modifier, name, semicolon)
+	ret.Public = public
+	if public == nil {
+		ret.Weak = weak
+	}
+	return ret
+}
+
+// NewOptionImportNode creates a new *ImportNode for an option import. All
+// arguments must be non-nil except semicolon:
+//   - keyword: The token corresponding to the "import" keyword.
+//   - option: The token corresponding to the "option" keyword.
+//   - name: The actual imported file name.
+//   - semicolon: The token corresponding to the ";" rune that ends the declaration.
+func NewOptionImportNode(keyword *KeywordNode, option *KeywordNode, name StringValueNode, semicolon *RuneNode) *ImportNode {
+	if option == nil {
+		panic("option is nil")
+	}
+	ret := newImportNode(keyword, option, name, semicolon)
+	ret.Option = option
+	return ret
+}
+
+func newImportNode(keyword *KeywordNode, modifier *KeywordNode, name StringValueNode, semicolon *RuneNode) *ImportNode {
 	if keyword == nil {
 		panic("keyword is nil")
 	}
@@ -279,15 +317,13 @@ func NewImportNode(keyword *KeywordNode, public *KeywordNode, weak *KeywordNode,
 	if semicolon == nil {
 		numChildren++
 	}
-	if public != nil || weak != nil {
+	if modifier != nil {
 		numChildren++
 	}
 	children := make([]Node, 0, numChildren)
 	children = append(children, keyword)
-	if public != nil {
-		children = append(children, public)
-	} else if weak != nil {
-		children = append(children, weak)
+	if modifier != nil {
+		children = append(children, modifier)
 	}
 	children = append(children, name)
 	if semicolon != nil {
@@ -299,8 +335,6 @@ func NewImportNode(keyword *KeywordNode, public *KeywordNode, weak *KeywordNode,
 			children: children,
 		},
 		Keyword:   keyword,
-		Public:    public,
-		Weak:      weak,
 		Name:      name,
 		Semicolon: semicolon,
 	}
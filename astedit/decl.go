@@ -0,0 +1,246 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astedit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Decl is a declaration that can be added to a file with an Editor. Unlike
+// AST nodes, declarations don't need any position information. They are
+// rendered as source in the style of package format, indented to match the
+// location where they are added.
+//
+// The types in this package are the only implementations of Decl.
+type Decl interface {
+	writeTo(w *declWriter)
+}
+
+// Text is a declaration given as source text, which is inserted as is, except
+// that lines after the first are indented to match the insertion point. It
+// can be used for declarations that have no dedicated type in this package,
+// like reserved ranges or groups.
+type Text string
+
+// Import is an import statement.
+type Import struct {
+	Path string
+	// Modifier is "public", "weak", or "option"; it is empty for a
+	// regular import.
+	Modifier string
+}
+
+// Option is an option. In the body of an element, it is rendered as an
+// option statement. In the options of a field or enum value, it is rendered
+// as a compact option.
+type Option struct {
+	// Name is the name of the option, like "deprecated" or "(foo.bar).baz".
+	Name string
+	// Value is the source of the option's value, like "true", "FOO", or
+	// "{ name: \"abc\" }". Use strconv.Quote to produce a string literal.
+	Value string
+}
+
+// Field is a field declaration.
+type Field struct {
+	// Label is "optional", "required", or "repeated"; it is empty for a
+	// field without a label.
+	Label   string
+	Type    string
+	Name    string
+	Number  int32
+	Options []Option
+}
+
+// EnumValue is an enum value declaration.
+type EnumValue struct {
+	Name    string
+	Number  int32
+	Options []Option
+}
+
+// Message is a message declaration.
+type Message struct {
+	Name  string
+	Decls []Decl
+}
+
+// Enum is an enum declaration.
+type Enum struct {
+	Name  string
+	Decls []Decl
+}
+
+// Oneof is a oneof declaration.
+type Oneof struct {
+	Name  string
+	Decls []Decl
+}
+
+// Extend is a block of extension declarations.
+type Extend struct {
+	Extendee string
+	Decls    []Decl
+}
+
+// Service is a service declaration.
+type Service struct {
+	Name  string
+	Decls []Decl
+}
+
+// Method is an RPC method declaration.
+type Method struct {
+	Name            string
+	InputType       string
+	OutputType      string
+	ClientStreaming bool
+	ServerStreaming bool
+	Options         []Option
+}
+
+// declWriter renders declarations.
+type declWriter struct {
+	buf strings.Builder
+	// the indentation of the current line
+	indent string
+	// the indentation added for each level of nesting
+	unit string
+}
+
+func (w *declWriter) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(&w.buf, format, args...)
+}
+
+func (w *declWriter) body(decls []Decl) {
+	if len(decls) == 0 {
+		w.buf.WriteString("{}")
+		return
+	}
+	w.buf.WriteString("{")
+	outer := w.indent
+	w.indent += w.unit
+	for _, decl := range decls {
+		w.buf.WriteString("\n" + w.indent)
+		decl.writeTo(w)
+	}
+	w.indent = outer
+	w.buf.WriteString("\n" + outer + "}")
+}
+
+func render(decl Decl, indent, unit string) string {
+	w := &declWriter{indent: indent, unit: unit}
+	decl.writeTo(w)
+	return w.buf.String()
+}
+
+func (t Text) writeTo(w *declWriter) {
+	lines := strings.Split(strings.TrimRight(string(t), "\n"), "\n")
+	for i, line := range lines {
+		if i > 0 {
+			w.buf.WriteByte('\n')
+			if strings.TrimSpace(line) != "" {
+				w.buf.WriteString(w.indent)
+			}
+		}
+		w.buf.WriteString(line)
+	}
+}
+
+func (i Import) writeTo(w *declWriter) {
+	if i.Modifier != "" {
+		w.printf("import %s %s;", i.Modifier, strconv.Quote(i.Path))
+		return
+	}
+	w.printf("import %s;", strconv.Quote(i.Path))
+}
+
+func (o Option) writeTo(w *declWriter) {
+	w.printf("option %s = %s;", o.Name, o.Value)
+}
+
+func (o Option) compact() string {
+	return o.Name + " = " + o.Value
+}
+
+func compactOptions(opts []Option) string {
+	if len(opts) == 0 {
+		return ""
+	}
+	strs := make([]string, len(opts))
+	for i, opt := range opts {
+		strs[i] = opt.compact()
+	}
+	return " [" + strings.Join(strs, ", ") + "]"
+}
+
+func (f Field) writeTo(w *declWriter) {
+	if f.Label != "" {
+		w.printf("%s ", f.Label)
+	}
+	w.printf("%s %s = %d%s;", f.Type, f.Name, f.Number, compactOptions(f.Options))
+}
+
+func (v EnumValue) writeTo(w *declWriter) {
+	w.printf("%s = %d%s;", v.Name, v.Number, compactOptions(v.Options))
+}
+
+func (m Message) writeTo(w *declWriter) {
+	w.printf("message %s ", m.Name)
+	w.body(m.Decls)
+}
+
+func (e Enum) writeTo(w *declWriter) {
+	w.printf("enum %s ", e.Name)
+	w.body(e.Decls)
+}
+
+func (o Oneof) writeTo(w *declWriter) {
+	w.printf("oneof %s ", o.Name)
+	w.body(o.Decls)
+}
+
+func (e Extend) writeTo(w *declWriter) {
+	w.printf("extend %s ", e.Extendee)
+	w.body(e.Decls)
+}
+
+func (s Service) writeTo(w *declWriter) {
+	w.printf("service %s ", s.Name)
+	w.body(s.Decls)
+}
+
+func (m Method) writeTo(w *declWriter) {
+	var inStream, outStream string
+	if m.ClientStreaming {
+		inStream = "stream "
+	}
+	if m.ServerStreaming {
+		outStream = "stream "
+	}
+	w.printf("rpc %s(%s%s) returns (%s%s)", m.Name, inStream, m.InputType, outStream, m.OutputType)
+	if len(m.Options) == 0 {
+		w.buf.WriteString(";")
+		return
+	}
+	decls := make([]Decl, len(m.Options))
+	for i, opt := range m.Options {
+		decls[i] = opt
+	}
+	w.buf.WriteString(" ")
+	w.body(decls)
+}
@@ -0,0 +1,627 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package astedit provides an API for programmatically changing the
+// declarations in a parsed proto source file.
+//
+// Creating AST nodes with the factory functions in package ast requires
+// position information for every token, which makes them unsuitable for
+// generating code. Instead, an Editor records changes to an existing AST,
+// like adding a field, setting an option, or deleting a message, as a list
+// of text edits to the original source. All text that is not affected by
+// a change, including comments and formatting, is preserved.
+//
+// The edits can be applied to produce the new source, which can then be
+// parsed to get an updated AST.
+package astedit
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bufbuild/protocompile/ast"
+)
+
+// TextEdit replaces a range of the original source with new text.
+type TextEdit struct {
+	// The byte offsets of the replaced range in the original source. Start
+	// is inclusive and End is exclusive. They are equal for insertions.
+	Start, End int
+	NewText    string
+}
+
+// Apply returns the result of applying the given edits to src. The edits
+// must be sorted and must not overlap, like those returned by Editor.Edits.
+func Apply(src []byte, edits []TextEdit) []byte {
+	var result []byte
+	pos := 0
+	for _, edit := range edits {
+		result = append(result, src[pos:edit.Start]...)
+		result = append(result, edit.NewText...)
+		pos = edit.End
+	}
+	return append(result, src[pos:]...)
+}
+
+// Editor records changes to the declarations in a file.
+//
+// Changes refer to nodes in the original AST, so a node cannot be changed
+// once it or one of its ancestors has been deleted or replaced. Such
+// conflicting changes are reported as an error by Edits and Bytes.
+type Editor struct {
+	file    *ast.FileNode
+	src     []byte
+	parents map[ast.Node]ast.Node
+	// the indentation added for each level of nesting
+	indentUnit string
+	edits      []TextEdit
+}
+
+// NewEditor returns an editor for the given file.
+func NewEditor(file *ast.FileNode) *Editor {
+	e := &Editor{
+		file:    file,
+		parents: map[ast.Node]ast.Node{},
+	}
+	// The AST has all text of the file, including whitespace and comments.
+	var src strings.Builder
+	items := file.Items()
+	for item, ok := items.First(); ok; item, ok = items.Next(item) {
+		info := file.ItemInfo(item)
+		src.WriteString(info.LeadingWhitespace())
+		src.WriteString(info.RawText())
+	}
+	e.src = []byte(src.String())
+	e.indentUnit = detectIndentUnit(e.src)
+	e.addParents(file)
+	return e
+}
+
+func (e *Editor) addParents(node ast.Node) {
+	composite, ok := node.(ast.CompositeNode)
+	if !ok {
+		return
+	}
+	for _, child := range composite.Children() {
+		e.parents[child] = node
+		e.addParents(child)
+	}
+}
+
+// detectIndentUnit returns the indentation of the first indented line in the
+// given source, or two spaces if there is none.
+func detectIndentUnit(src []byte) string {
+	for _, line := range strings.Split(string(src), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || len(trimmed) == len(line) {
+			continue
+		}
+		if line[0] == '\t' {
+			return "\t"
+		}
+		return line[:len(line)-len(trimmed)]
+	}
+	return "  "
+}
+
+// Edits returns the text edits for the changes recorded so far, sorted by
+// position. An error is returned if changes conflict.
+func (e *Editor) Edits() ([]TextEdit, error) {
+	edits := make([]TextEdit, len(e.edits))
+	copy(edits, e.edits)
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].Start != edits[j].Start {
+			return edits[i].Start < edits[j].Start
+		}
+		return edits[i].End < edits[j].End
+	})
+	for i := 1; i < len(edits); i++ {
+		if edits[i-1].End > edits[i].Start {
+			return nil, fmt.Errorf("%s: conflicting changes at %v and %v", e.file.Name(),
+				e.pos(edits[i-1].Start), e.pos(edits[i].Start))
+		}
+	}
+	return edits, nil
+}
+
+// Bytes returns the source of the file with all changes applied.
+func (e *Editor) Bytes() ([]byte, error) {
+	edits, err := e.Edits()
+	if err != nil {
+		return nil, err
+	}
+	return Apply(e.src, edits), nil
+}
+
+func (e *Editor) pos(offset int) ast.SourcePos {
+	// columns are computed like in ast.SourcePos, with tab stops every
+	// eight columns
+	line, col := 1, 0
+	for _, c := range e.src[:offset] {
+		switch {
+		case c == '\n':
+			line++
+			col = 0
+		case c == '\t':
+			col += 8 - col%8
+		case utf8.RuneStart(c):
+			col++
+		}
+	}
+	col++
+	return ast.SourcePos{Filename: e.file.Name(), Line: line, Col: col, Offset: offset}
+}
+
+func (e *Editor) edit(start, end int, text string) {
+	e.edits = append(e.edits, TextEdit{Start: start, End: end, NewText: text})
+}
+
+func (e *Editor) checkNode(node ast.Node) error {
+	if node == nil {
+		return errors.New("node is nil")
+	}
+	if _, ok := e.parents[node]; !ok && node != ast.Node(e.file) {
+		return fmt.Errorf("%T is not part of %s", node, e.file.Name())
+	}
+	return nil
+}
+
+// Add adds the given declaration to the body of the given parent, which must
+// be a *ast.FileNode, *ast.MessageNode, *ast.GroupNode, *ast.EnumNode,
+// *ast.OneofNode, *ast.ExtendNode, *ast.ServiceNode, or *ast.RPCNode.
+//
+// Imports and options are added after the existing imports and options,
+// respectively. Other declarations are added at the end of the body.
+func (e *Editor) Add(parent ast.Node, decl Decl) error {
+	if err := e.checkNode(parent); err != nil {
+		return err
+	}
+	switch parent := parent.(type) {
+	case *ast.FileNode:
+		e.addToFile(decl)
+	case *ast.MessageNode:
+		e.addToBody(parent, parent.OpenBrace, nodes(parent.Decls), parent.CloseBrace, decl)
+	case *ast.GroupNode:
+		e.addToBody(parent, parent.OpenBrace, nodes(parent.Decls), parent.CloseBrace, decl)
+	case *ast.EnumNode:
+		e.addToBody(parent, parent.OpenBrace, nodes(parent.Decls), parent.CloseBrace, decl)
+	case *ast.OneofNode:
+		e.addToBody(parent, parent.OpenBrace, nodes(parent.Decls), parent.CloseBrace, decl)
+	case *ast.ExtendNode:
+		e.addToBody(parent, parent.OpenBrace, nodes(parent.Decls), parent.CloseBrace, decl)
+	case *ast.ServiceNode:
+		e.addToBody(parent, parent.OpenBrace, nodes(parent.Decls), parent.CloseBrace, decl)
+	case *ast.RPCNode:
+		if parent.OpenBrace == nil {
+			// replace the semicolon with a body
+			indent := e.lineIndent(e.start(parent))
+			start, end := e.span(parent.Semicolon)
+			inner := indent + e.indentUnit
+			e.edit(start, end, " {\n"+inner+render(decl, inner, e.indentUnit)+"\n"+indent+"}")
+			return nil
+		}
+		e.addToBody(parent, parent.OpenBrace, nodes(parent.Decls), parent.CloseBrace, decl)
+	default:
+		return fmt.Errorf("cannot add declarations to %T", parent)
+	}
+	return nil
+}
+
+func nodes[T ast.Node](elems []T) []ast.Node {
+	result := make([]ast.Node, len(elems))
+	for i, elem := range elems {
+		result[i] = elem
+	}
+	return result
+}
+
+func (e *Editor) addToFile(decl Decl) {
+	var lastSyntax, lastPackage, lastImport, lastOption, lastDecl ast.Node
+	if e.file.Syntax != nil {
+		lastSyntax = e.file.Syntax
+	} else if e.file.Edition != nil {
+		lastSyntax = e.file.Edition
+	}
+	for _, d := range e.file.Decls {
+		switch d.(type) {
+		case *ast.EmptyDeclNode:
+			continue
+		case *ast.PackageNode:
+			lastPackage = d
+		case *ast.ImportNode:
+			lastImport = d
+		case *ast.OptionNode:
+			lastOption = d
+		}
+		lastDecl = d
+	}
+	var candidates []ast.Node
+	switch decl.(type) {
+	case Import:
+		candidates = []ast.Node{lastImport, lastPackage, lastSyntax}
+	case Option:
+		candidates = []ast.Node{lastOption, lastImport, lastPackage, lastSyntax}
+	default:
+		candidates = []ast.Node{lastDecl, lastSyntax}
+	}
+	text := render(decl, "", e.indentUnit)
+	for _, anchor := range candidates {
+		if anchor == nil {
+			continue
+		}
+		sep := "\n\n"
+		if sameKind(anchor, decl) {
+			sep = "\n"
+		}
+		end := e.endWithComments(anchor)
+		e.edit(end, end, sep+text)
+		return
+	}
+	// No anchor, so add the declaration before the first one, if any.
+	if len(e.file.Decls) == 0 {
+		if len(strings.TrimSpace(string(e.src))) == 0 {
+			e.edit(0, len(e.src), text+"\n")
+		} else {
+			end := len(strings.TrimRight(string(e.src), " \t\r\n"))
+			e.edit(end, end, "\n\n"+text)
+		}
+		return
+	}
+	start := e.start(e.file.Decls[0])
+	e.edit(start, start, text+"\n\n")
+}
+
+// sameKind returns true if the given node and declaration are simple
+// statements of the same kind, which are not separated by blank lines. Text
+// is never separated by blank lines, since it can include them itself.
+func sameKind(node ast.Node, decl Decl) bool {
+	if _, ok := decl.(Text); ok {
+		return true
+	}
+	switch node.(type) {
+	case *ast.ImportNode:
+		_, ok := decl.(Import)
+		return ok
+	case *ast.OptionNode:
+		_, ok := decl.(Option)
+		return ok
+	default:
+		return false
+	}
+}
+
+func (e *Editor) addToBody(parent ast.Node, openBrace *ast.RuneNode, decls []ast.Node, closeBrace *ast.RuneNode, decl Decl) {
+	var lastOption, lastDecl ast.Node
+	for _, d := range decls {
+		switch d.(type) {
+		case *ast.EmptyDeclNode:
+			continue
+		case *ast.OptionNode:
+			lastOption = d
+		}
+		lastDecl = d
+	}
+	anchor := lastDecl
+	if _, ok := decl.(Option); ok {
+		anchor = lastOption
+	}
+
+	outer := e.lineIndent(e.start(parent))
+	indent := outer + e.indentUnit
+	if lastDecl != nil {
+		indent = e.lineIndent(e.start(lastDecl))
+	}
+	text := render(decl, indent, e.indentUnit)
+	if anchor != nil {
+		end := e.endWithComments(anchor)
+		e.edit(end, end, "\n"+indent+text)
+		return
+	}
+
+	// add at the start of the body
+	start := e.endWithComments(openBrace)
+	closeStart := e.leadingStart(closeBrace, true)
+	if lastDecl == nil && !strings.Contains(string(e.src[start:closeStart]), "\n") {
+		// The body is empty and on one line, so the close brace goes on a
+		// new line, too.
+		e.edit(start, closeStart, "\n"+indent+text+"\n"+outer)
+		return
+	}
+	e.edit(start, start, "\n"+indent+text)
+}
+
+// InsertBefore inserts the given declaration before the given node, which must
+// be a declaration in the body of a file or element.
+func (e *Editor) InsertBefore(node ast.Node, decl Decl) error {
+	if err := e.checkNode(node); err != nil {
+		return err
+	}
+	start := e.leadingStart(node, false)
+	indent := e.lineIndent(start)
+	text := render(decl, indent, e.indentUnit)
+	if !e.atLineStart(start) {
+		e.edit(start, start, text+" ")
+		return nil
+	}
+	sep := "\n"
+	if _, ok := e.parents[node].(*ast.FileNode); ok && !sameKind(node, decl) {
+		sep = "\n\n"
+	}
+	e.edit(start, start, text+sep+indent)
+	return nil
+}
+
+// InsertAfter inserts the given declaration after the given node, which must
+// be a declaration in the body of a file or element.
+func (e *Editor) InsertAfter(node ast.Node, decl Decl) error {
+	if err := e.checkNode(node); err != nil {
+		return err
+	}
+	indent := e.lineIndent(e.start(node))
+	sep := "\n"
+	if _, ok := e.parents[node].(*ast.FileNode); ok && !sameKind(node, decl) {
+		sep = "\n\n"
+	}
+	end := e.endWithComments(node)
+	e.edit(end, end, sep+indent+render(decl, indent, e.indentUnit))
+	return nil
+}
+
+// Replace replaces the given node with the given declaration. Comments for
+// the node are kept.
+func (e *Editor) Replace(node ast.Node, decl Decl) error {
+	if err := e.checkNode(node); err != nil {
+		return err
+	}
+	start, end := e.span(node)
+	e.edit(start, end, render(decl, e.lineIndent(start), e.indentUnit))
+	return nil
+}
+
+// Delete removes the given node, which must be a declaration in the body of a
+// file or element, or an option in a list of compact options. Comments for the
+// node are removed, too.
+func (e *Editor) Delete(node ast.Node) error {
+	if err := e.checkNode(node); err != nil {
+		return err
+	}
+	if node == ast.Node(e.file) {
+		return errors.New("cannot delete the file node")
+	}
+	if opts, ok := e.parents[node].(*ast.CompactOptionsNode); ok {
+		e.deleteCompactOption(opts, node)
+		return nil
+	}
+
+	start := e.leadingStart(node, false)
+	end := e.endWithComments(node)
+	lineStart := start
+	for lineStart > 0 && isSpace(e.src[lineStart-1]) {
+		lineStart--
+	}
+	lineEnd := end
+	for lineEnd < len(e.src) && (isSpace(e.src[lineEnd]) || e.src[lineEnd] == '\r') {
+		lineEnd++
+	}
+	atLineStart := lineStart == 0 || e.src[lineStart-1] == '\n'
+	atLineEnd := lineEnd == len(e.src) || e.src[lineEnd] == '\n'
+	switch {
+	case atLineStart && atLineEnd:
+		// remove whole lines
+		start, end = lineStart, lineEnd
+		if end < len(e.src) {
+			end++
+		}
+		prevBlank := start > 0 && e.isBlankLine(e.lineStart(start-1))
+		switch {
+		case prevBlank && end < len(e.src) && e.isBlankLine(end):
+			end = e.lineEnd(end)
+		case prevBlank && e.nextIsCloseOrEOF(end):
+			start = e.lineStart(start - 1)
+		}
+	case atLineEnd:
+		start, end = lineStart, lineEnd
+	default:
+		end = lineEnd
+	}
+	e.edit(start, end, "")
+	return nil
+}
+
+func (e *Editor) deleteCompactOption(opts *ast.CompactOptionsNode, node ast.Node) {
+	if len(opts.Options) == 1 {
+		start, end := e.span(opts)
+		for start > 0 && isSpace(e.src[start-1]) {
+			start--
+		}
+		e.edit(start, end, "")
+		return
+	}
+	for i, opt := range opts.Options {
+		if ast.Node(opt) != node {
+			continue
+		}
+		if i < len(opts.Options)-1 {
+			// remove the option, its comma, and the space after it
+			e.edit(e.start(opt), e.start(opts.Options[i+1]), "")
+		} else {
+			_, prevEnd := e.span(opts.Options[i-1])
+			e.edit(prevEnd, e.endWithComments(opt), "")
+		}
+	}
+}
+
+// SetOption sets the option with the given name for the given node. If the
+// option is already set, its value is replaced. Otherwise, an option is added.
+// The value is the source of the option's value, as in Option.
+//
+// For fields (including groups), enum values, and extension ranges, the option
+// is a compact option. For other elements, it is an option statement in the
+// element's body.
+func (e *Editor) SetOption(node ast.Node, name, value string) error {
+	if err := e.checkNode(node); err != nil {
+		return err
+	}
+	var compact *ast.CompactOptionsNode
+	var compactAnchor ast.Node
+	var opts []*ast.OptionNode
+	switch node := node.(type) {
+	case *ast.FieldNode:
+		compact, compactAnchor = node.Options, node.Tag
+	case *ast.MapFieldNode:
+		compact, compactAnchor = node.Options, node.Tag
+	case *ast.GroupNode:
+		compact, compactAnchor = node.Options, node.Tag
+	case *ast.EnumValueNode:
+		compact, compactAnchor = node.Options, node.Number
+	case *ast.ExtensionRangeNode:
+		compact, compactAnchor = node.Options, node.Ranges[len(node.Ranges)-1]
+	case *ast.FileNode:
+		for _, decl := range node.Decls {
+			if opt, ok := decl.(*ast.OptionNode); ok {
+				opts = append(opts, opt)
+			}
+		}
+	case ast.CompositeNode:
+		for _, child := range node.Children() {
+			if opt, ok := child.(*ast.OptionNode); ok {
+				opts = append(opts, opt)
+			}
+		}
+	}
+	if compact != nil {
+		opts = compact.Options
+	}
+	for _, opt := range opts {
+		if optionName(e.file, opt.Name) == strings.Join(strings.Fields(name), "") {
+			start, end := e.span(opt.Val)
+			e.edit(start, end, value)
+			return nil
+		}
+	}
+
+	newOpt := Option{Name: name, Value: value}
+	switch {
+	case compactAnchor == nil:
+		return e.Add(node, newOpt)
+	case compact == nil:
+		_, end := e.span(compactAnchor)
+		e.edit(end, end, compactOptions([]Option{newOpt}))
+	default:
+		last := compact.Options[len(compact.Options)-1]
+		_, end := e.span(last)
+		if e.pos(e.start(compact.OpenBracket)).Line == e.pos(end).Line {
+			e.edit(end, end, ", "+newOpt.compact())
+		} else {
+			e.edit(end, end, ",\n"+e.lineIndent(e.start(last))+newOpt.compact())
+		}
+	}
+	return nil
+}
+
+func optionName(file *ast.FileNode, name *ast.OptionNameNode) string {
+	return strings.Join(strings.Fields(file.NodeInfo(name).RawText()), "")
+}
+
+func (e *Editor) start(node ast.Node) int {
+	start, _ := e.span(node)
+	return start
+}
+
+// span returns the byte range of the given node, excluding comments.
+func (e *Editor) span(node ast.Node) (int, int) {
+	info := e.file.NodeInfo(node)
+	start := info.Start().Offset
+	return start, start + len(info.RawText())
+}
+
+// endWithComments returns the end of the given node, including its trailing
+// comments.
+func (e *Editor) endWithComments(node ast.Node) int {
+	_, end := e.span(node)
+	comments := e.file.NodeInfo(node).TrailingComments()
+	if comments.Len() > 0 {
+		last := comments.Index(comments.Len() - 1)
+		end = last.Start().Offset + len(last.RawText())
+	}
+	return end
+}
+
+// leadingStart returns the start of the given node, including its leading
+// comments. If all is false, only the comments that are not separated from
+// the node by a blank line are included.
+func (e *Editor) leadingStart(node ast.Node, all bool) int {
+	info := e.file.NodeInfo(node)
+	start := info.Start().Offset
+	comments := info.LeadingComments()
+	for i := comments.Len() - 1; i >= 0; i-- {
+		comment := comments.Index(i)
+		commentEnd := comment.Start().Offset + len(comment.RawText())
+		if !all && strings.Count(string(e.src[commentEnd:start]), "\n") > 1 {
+			break
+		}
+		start = comment.Start().Offset
+	}
+	return start
+}
+
+// lineIndent returns the indentation of the line that contains the given
+// offset.
+func (e *Editor) lineIndent(offset int) string {
+	start := e.lineStart(offset)
+	end := start
+	for end < len(e.src) && isSpace(e.src[end]) {
+		end++
+	}
+	return string(e.src[start:end])
+}
+
+func (e *Editor) lineStart(offset int) int {
+	for offset > 0 && e.src[offset-1] != '\n' {
+		offset--
+	}
+	return offset
+}
+
+// lineEnd returns the offset after the newline that ends the line with the
+// given offset.
+func (e *Editor) lineEnd(offset int) int {
+	for offset < len(e.src) && e.src[offset] != '\n' {
+		offset++
+	}
+	if offset < len(e.src) {
+		offset++
+	}
+	return offset
+}
+
+func (e *Editor) atLineStart(offset int) bool {
+	return strings.TrimLeft(string(e.src[e.lineStart(offset):offset]), " \t") == ""
+}
+
+func (e *Editor) isBlankLine(lineStart int) bool {
+	return strings.TrimSpace(string(e.src[lineStart:e.lineEnd(lineStart)])) == ""
+}
+
+func (e *Editor) nextIsCloseOrEOF(offset int) bool {
+	rest := strings.TrimSpace(string(e.src[offset:]))
+	return rest == "" || rest[0] == '}'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
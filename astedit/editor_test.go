@@ -0,0 +1,301 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astedit
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+const testSource = `// License header.
+
+syntax = "proto3";
+
+package foo.bar;
+
+import "a.proto";
+
+option java_package = "foo.bar";
+
+// Foo is a message.
+message Foo {
+	// The name.
+	string name = 1; // trailing
+	repeated int32 ids = 2 [packed = true];
+}
+
+message Empty {}
+
+// Detached.
+
+// Bar is deleted.
+message Bar {
+	int32 x = 1;
+}
+
+enum Kind {
+	KIND_UNSPECIFIED = 0;
+}
+
+service Svc {
+	rpc Do(Foo) returns (Foo);
+}
+`
+
+func TestEditor(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		edit     func(e *Editor, file *ast.FileNode) error
+		expected string
+	}{
+		"add field": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				return e.Add(message(file, "Foo"), Field{Type: "bool", Name: "ok", Number: 3, Options: []Option{{Name: "deprecated", Value: "true"}}})
+			},
+			expected: `	repeated int32 ids = 2 [packed = true];
+	bool ok = 3 [deprecated = true];
+}
+`,
+		},
+		"add nested message to empty message": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				return e.Add(message(file, "Empty"), Message{Name: "Inner", Decls: []Decl{
+					Field{Label: "optional", Type: "string", Name: "s", Number: 1},
+				}})
+			},
+			expected: `message Empty {
+	message Inner {
+		optional string s = 1;
+	}
+}
+`,
+		},
+		"add option to message": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				return e.Add(message(file, "Foo"), Option{Name: "deprecated", Value: "true"})
+			},
+			expected: `message Foo {
+	option deprecated = true;
+	// The name.
+`,
+		},
+		"add import": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				return e.Add(file, Import{Path: "b.proto", Modifier: "public"})
+			},
+			expected: `import "a.proto";
+import public "b.proto";
+
+option`,
+		},
+		"add file option": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				return e.SetOption(file, "go_package", strconv.Quote("foo/bar"))
+			},
+			expected: `option java_package = "foo.bar";
+option go_package = "foo/bar";
+`,
+		},
+		"replace file option": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				return e.SetOption(file, "java_package", strconv.Quote("com.foo"))
+			},
+			expected: `option java_package = "com.foo";`,
+		},
+		"add service at end": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				return e.Add(file, Service{Name: "Other", Decls: []Decl{
+					Method{Name: "Stream", InputType: "Foo", OutputType: "Foo", ServerStreaming: true},
+				}})
+			},
+			expected: `	rpc Do(Foo) returns (Foo);
+}
+
+service Other {
+	rpc Stream(Foo) returns (stream Foo);
+}
+`,
+		},
+		"add method option": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				svc := findDecl[*ast.ServiceNode](file, func(*ast.ServiceNode) bool { return true })
+				return e.SetOption(svc.Decls[0].(*ast.RPCNode), "deprecated", "true")
+			},
+			expected: `	rpc Do(Foo) returns (Foo) {
+		option deprecated = true;
+	}
+`,
+		},
+		"delete message": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				return e.Delete(message(file, "Bar"))
+			},
+			expected: `message Empty {}
+
+// Detached.
+
+enum Kind {`,
+		},
+		"delete field": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				return e.Delete(message(file, "Foo").Decls[0])
+			},
+			expected: `message Foo {
+	repeated int32 ids = 2 [packed = true];
+}`,
+		},
+		"set compact options": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				foo := message(file, "Foo")
+				if err := e.SetOption(foo.Decls[0], "json_name", strconv.Quote("n")); err != nil {
+					return err
+				}
+				if err := e.SetOption(foo.Decls[1], "packed", "false"); err != nil {
+					return err
+				}
+				return e.SetOption(foo.Decls[1], "(custom).x", "1")
+			},
+			expected: `	string name = 1 [json_name = "n"]; // trailing
+	repeated int32 ids = 2 [packed = false, (custom).x = 1];
+`,
+		},
+		"delete compact option": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				field := message(file, "Foo").Decls[1].(*ast.FieldNode)
+				return e.Delete(field.Options.Options[0])
+			},
+			expected: `	repeated int32 ids = 2;
+`,
+		},
+		"insert and replace": {
+			edit: func(e *Editor, file *ast.FileNode) error {
+				enum := findDecl[*ast.EnumNode](file, func(*ast.EnumNode) bool { return true })
+				if err := e.InsertAfter(enum.Decls[0], EnumValue{Name: "KIND_A", Number: 1}); err != nil {
+					return err
+				}
+				if err := e.InsertBefore(enum, Text("// Kind is a kind.")); err != nil {
+					return err
+				}
+				return e.Replace(message(file, "Empty"), Message{Name: "Empty", Decls: []Decl{Text("reserved 1 to 5;")}})
+			},
+			expected: `message Empty {
+	reserved 1 to 5;
+}
+
+// Detached.
+
+// Bar is deleted.
+message Bar {
+	int32 x = 1;
+}
+
+// Kind is a kind.
+enum Kind {
+	KIND_UNSPECIFIED = 0;
+	KIND_A = 1;
+}`,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			file := parse(t, testSource)
+			editor := NewEditor(file)
+			require.NoError(t, testCase.edit(editor, file))
+			result, err := editor.Bytes()
+			require.NoError(t, err)
+			assert.Contains(t, string(result), testCase.expected)
+			// the result is still valid
+			parse(t, string(result))
+		})
+	}
+}
+
+func TestEditor_Edits(t *testing.T) {
+	t.Parallel()
+	const source = "syntax = \"proto3\";\nmessage Foo {\n  string name = 1;\n}\n"
+	file := parse(t, source)
+	editor := NewEditor(file)
+	foo := message(file, "Foo")
+	require.NoError(t, editor.Add(foo, Field{Type: "int32", Name: "id", Number: 2}))
+	require.NoError(t, editor.SetOption(foo.Decls[0], "deprecated", "true"))
+	edits, err := editor.Edits()
+	require.NoError(t, err)
+	nameEnd := strings.Index(source, " = 1") + len(" = 1")
+	fieldEnd := strings.Index(source, "= 1;") + len("= 1;")
+	assert.Equal(t, []TextEdit{
+		{Start: nameEnd, End: nameEnd, NewText: " [deprecated = true]"},
+		{Start: fieldEnd, End: fieldEnd, NewText: "\n  int32 id = 2;"},
+	}, edits)
+	assert.Equal(t, "syntax = \"proto3\";\nmessage Foo {\n  string name = 1 [deprecated = true];\n  int32 id = 2;\n}\n",
+		string(Apply([]byte(source), edits)))
+}
+
+func TestEditor_Conflicts(t *testing.T) {
+	t.Parallel()
+	file := parse(t, testSource)
+	editor := NewEditor(file)
+	foo := message(file, "Foo")
+	require.NoError(t, editor.Delete(foo))
+	require.NoError(t, editor.SetOption(foo.Decls[0], "deprecated", "true"))
+	_, err := editor.Bytes()
+	require.ErrorContains(t, err, "test.proto: conflicting changes at test.proto:11:1 and test.proto:14:24")
+
+	other := parse(t, testSource)
+	require.ErrorContains(t, editor.Delete(other), "*ast.FileNode is not part of test.proto")
+	require.ErrorContains(t, editor.Add(foo.Decls[0], Text("")), "cannot add declarations to *ast.FieldNode")
+}
+
+func TestEditor_EmptyFile(t *testing.T) {
+	t.Parallel()
+	file := parse(t, "")
+	editor := NewEditor(file)
+	require.NoError(t, editor.Add(file, Message{Name: "Foo"}))
+	result, err := editor.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, "message Foo {}\n", string(result))
+}
+
+func parse(t *testing.T, source string) *ast.FileNode {
+	t.Helper()
+	file, err := parser.Parse("test.proto", strings.NewReader(source), reporter.NewHandler(nil))
+	require.NoError(t, err)
+	return file
+}
+
+func message(file *ast.FileNode, name string) *ast.MessageNode {
+	return findDecl[*ast.MessageNode](file, func(msg *ast.MessageNode) bool {
+		return msg.Name.Val == name
+	})
+}
+
+func findDecl[T ast.FileElement](file *ast.FileNode, match func(T) bool) T {
+	for _, decl := range file.Decls {
+		if d, ok := decl.(T); ok && match(d) {
+			return d
+		}
+	}
+	var zero T
+	return zero
+}
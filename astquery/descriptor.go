@@ -0,0 +1,515 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astquery
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/protoutil"
+)
+
+// Descriptor returns the descriptor that the last node in the given path
+// refers to. The path must be in the AST of the given result, like a path
+// returned by NodeAt for res.AST().
+//
+// The node must be an identifier (or the string literal in an import
+// statement). Supported identifiers are:
+//   - The names of declared elements, which refer to the declared element
+//     itself. For groups, this is the field.
+//   - Type names, in field types, extendees, and RPC input and output types.
+//   - Option names and field names in message literals, which refer to
+//     fields and extensions of options messages and option values.
+//   - Enum value names in option values, including default values.
+//   - Import paths, which refer to the imported file.
+//
+// Identifiers that are part of a qualified name refer to the element that
+// the whole name refers to. For other nodes, or if the identifier cannot be
+// resolved, nil is returned.
+func Descriptor(res linker.Result, path Path) protoreflect.Descriptor {
+	if len(path) < 2 {
+		return nil
+	}
+	q := &query{res: res, path: path}
+	index := len(path) - 1
+	switch node := path[index].(type) {
+	case *ast.IdentNode:
+		if _, ok := path[index-1].(*ast.CompoundIdentNode); ok {
+			index--
+		}
+	case *ast.CompoundIdentNode:
+	case *ast.StringLiteralNode:
+		if _, ok := path[index-1].(*ast.ImportNode); ok {
+			if f := res.FindImportByPath(node.Val); f != nil {
+				return f
+			}
+			return nil
+		}
+		return nil
+	default:
+		return nil
+	}
+	return q.resolve(index)
+}
+
+type query struct {
+	res  linker.Result
+	path Path
+}
+
+// resolve returns the descriptor for the identifier at the given index in the
+// path.
+func (q *query) resolve(index int) protoreflect.Descriptor {
+	if index < 1 {
+		return nil
+	}
+	ident := q.path[index]
+	switch parent := q.path[index-1].(type) {
+	case *ast.MessageNode, *ast.EnumNode, *ast.EnumValueNode, *ast.ServiceNode, *ast.RPCNode, *ast.OneofNode, *ast.GroupNode, *ast.MapFieldNode:
+		return q.declared(parent)
+	case *ast.FieldNode:
+		fld, _ := q.declared(parent).(protoreflect.FieldDescriptor)
+		if fld == nil {
+			return nil
+		}
+		if ident == ast.Node(parent.Name) {
+			return fld
+		}
+		return typeOf(fld)
+	case *ast.MapTypeNode:
+		fld, _ := q.declared(q.path[index-2]).(protoreflect.FieldDescriptor)
+		if fld == nil || ident != ast.Node(parent.ValueType) {
+			return nil
+		}
+		return typeOf(fld.MapValue())
+	case *ast.ExtendNode:
+		for _, decl := range parent.Decls {
+			if ext, ok := q.declared(decl).(protoreflect.FieldDescriptor); ok {
+				return ext.ContainingMessage()
+			}
+		}
+		return nil
+	case *ast.RPCTypeNode:
+		rpc, ok := q.path[index-2].(*ast.RPCNode)
+		if !ok {
+			return nil
+		}
+		mtd, _ := q.declared(rpc).(protoreflect.MethodDescriptor)
+		switch {
+		case mtd == nil:
+			return nil
+		case parent == rpc.Input:
+			return mtd.Input()
+		case parent == rpc.Output:
+			return mtd.Output()
+		}
+		return nil
+	case *ast.FieldReferenceNode:
+		return q.fieldReference(index - 1)
+	case *ast.OptionNode, *ast.MessageFieldNode, *ast.ArrayLiteralNode:
+		// enum value in an option value
+		fld := q.valueField(index)
+		if fld == nil || fld.Enum() == nil {
+			return nil
+		}
+		name, ok := ident.(ast.IdentValueNode)
+		if !ok {
+			return nil
+		}
+		return fld.Enum().Values().ByName(protoreflect.Name(name.AsIdentifier()))
+	default:
+		return nil
+	}
+}
+
+func typeOf(fld protoreflect.FieldDescriptor) protoreflect.Descriptor {
+	if fld.Message() != nil {
+		return fld.Message()
+	}
+	if fld.Enum() != nil {
+		return fld.Enum()
+	}
+	return nil
+}
+
+// declared returns the descriptor for the given declaration node.
+func (q *query) declared(node ast.Node) protoreflect.Descriptor {
+	var found protoreflect.Descriptor
+	_ = walkDescriptors(q.res, func(d protoreflect.Descriptor) bool {
+		if q.res.Node(protoutil.ProtoFromDescriptor(d)) == node {
+			found = d
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// walkDescriptors calls fn for each descriptor in the given file, until fn
+// returns false. Fields are visited before nested messages, so that the field
+// is found first for groups and map fields, whose nodes are also the nodes for
+// their messages.
+func walkDescriptors(file protoreflect.FileDescriptor, fn func(protoreflect.Descriptor) bool) bool {
+	return walkExtensions(file.Extensions(), fn) &&
+		walkMessages(file.Messages(), fn) &&
+		walkEnums(file.Enums(), fn) &&
+		walkServices(file.Services(), fn)
+}
+
+func walkMessages(msgs protoreflect.MessageDescriptors, fn func(protoreflect.Descriptor) bool) bool {
+	for i := 0; i < msgs.Len(); i++ {
+		msg := msgs.Get(i)
+		if !fn(msg) {
+			return false
+		}
+		fields := msg.Fields()
+		for j := 0; j < fields.Len(); j++ {
+			if !fn(fields.Get(j)) {
+				return false
+			}
+		}
+		oneofs := msg.Oneofs()
+		for j := 0; j < oneofs.Len(); j++ {
+			if !fn(oneofs.Get(j)) {
+				return false
+			}
+		}
+		if !walkExtensions(msg.Extensions(), fn) ||
+			!walkMessages(msg.Messages(), fn) ||
+			!walkEnums(msg.Enums(), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func walkExtensions(exts protoreflect.ExtensionDescriptors, fn func(protoreflect.Descriptor) bool) bool {
+	for i := 0; i < exts.Len(); i++ {
+		if !fn(exts.Get(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+func walkEnums(enums protoreflect.EnumDescriptors, fn func(protoreflect.Descriptor) bool) bool {
+	for i := 0; i < enums.Len(); i++ {
+		enum := enums.Get(i)
+		if !fn(enum) {
+			return false
+		}
+		vals := enum.Values()
+		for j := 0; j < vals.Len(); j++ {
+			if !fn(vals.Get(j)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func walkServices(svcs protoreflect.ServiceDescriptors, fn func(protoreflect.Descriptor) bool) bool {
+	for i := 0; i < svcs.Len(); i++ {
+		svc := svcs.Get(i)
+		if !fn(svc) {
+			return false
+		}
+		mtds := svc.Methods()
+		for j := 0; j < mtds.Len(); j++ {
+			if !fn(mtds.Get(j)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// fieldReference returns the descriptor for the field reference at the
+// given index in the path, which is part of an option name or the name of
+// a field in a message literal.
+func (q *query) fieldReference(index int) protoreflect.Descriptor {
+	ref := q.path[index].(*ast.FieldReferenceNode)
+	switch parent := q.path[index-1].(type) {
+	case *ast.OptionNameNode:
+		for i, part := range parent.Parts {
+			if part == ref {
+				fld := q.optionNameField(index-2, i)
+				if fld == nil {
+					return nil
+				}
+				return fld
+			}
+		}
+		return nil
+	case *ast.MessageFieldNode:
+		return q.messageFieldTarget(index-1, ref)
+	default:
+		return nil
+	}
+}
+
+// optionNameField returns the field for the part with the given index of the
+// name of the option at the given index in the path.
+func (q *query) optionNameField(optIndex int, part int) protoreflect.FieldDescriptor {
+	opt, ok := q.path[optIndex].(*ast.OptionNode)
+	if !ok {
+		return nil
+	}
+	optsMsg, scope := q.optionsMessage(optIndex)
+	if optsMsg == nil {
+		return nil
+	}
+	msg := optsMsg
+	var fld protoreflect.FieldDescriptor
+	for i := 0; i <= part && i < len(opt.Name.Parts); i++ {
+		if msg == nil {
+			return nil
+		}
+		ref := opt.Name.Parts[i]
+		name := string(ref.Name.AsIdentifier())
+		if ref.IsExtension() {
+			fld = q.findExtension(name, scope, msg)
+		} else {
+			fld = msg.Fields().ByName(protoreflect.Name(name))
+		}
+		if fld == nil {
+			return nil
+		}
+		msg = fld.Message()
+	}
+	return fld
+}
+
+// optionsMessage returns the options message for the option at the given
+// index in the path, along with the scope used to resolve extension names.
+func (q *query) optionsMessage(optIndex int) (protoreflect.MessageDescriptor, string) {
+	owner := q.path[optIndex-1]
+	compact := false
+	if _, ok := owner.(*ast.CompactOptionsNode); ok {
+		compact = true
+		owner = q.path[optIndex-2]
+	}
+	var opts protoreflect.ProtoMessage
+	switch owner.(type) {
+	case *ast.FileNode:
+		opts = (*descriptorpb.FileOptions)(nil)
+	case *ast.MessageNode:
+		opts = (*descriptorpb.MessageOptions)(nil)
+	case *ast.GroupNode:
+		if compact {
+			opts = (*descriptorpb.FieldOptions)(nil)
+		} else {
+			opts = (*descriptorpb.MessageOptions)(nil)
+		}
+	case *ast.FieldNode, *ast.MapFieldNode:
+		opts = (*descriptorpb.FieldOptions)(nil)
+	case *ast.OneofNode:
+		opts = (*descriptorpb.OneofOptions)(nil)
+	case *ast.EnumNode:
+		opts = (*descriptorpb.EnumOptions)(nil)
+	case *ast.EnumValueNode:
+		opts = (*descriptorpb.EnumValueOptions)(nil)
+	case *ast.ServiceNode:
+		opts = (*descriptorpb.ServiceOptions)(nil)
+	case *ast.RPCNode:
+		opts = (*descriptorpb.MethodOptions)(nil)
+	case *ast.ExtensionRangeNode:
+		opts = (*descriptorpb.ExtensionRangeOptions)(nil)
+	default:
+		return nil, ""
+	}
+	md := opts.ProtoReflect().Descriptor()
+	// Prefer the version of descriptor.proto that the file uses, if any.
+	if d, ok := q.findVisible(md.FullName()).(protoreflect.MessageDescriptor); ok {
+		md = d
+	}
+	return md, q.scope(optIndex - 1)
+}
+
+// scope returns the fully-qualified name of the innermost declared element
+// that encloses the node at the given index in the path.
+func (q *query) scope(index int) string {
+	for i := index; i > 0; i-- {
+		switch q.path[i].(type) {
+		case *ast.MessageNode, *ast.GroupNode, *ast.EnumNode, *ast.ServiceNode, *ast.RPCNode,
+			*ast.FieldNode, *ast.MapFieldNode, *ast.EnumValueNode, *ast.OneofNode:
+			if d := q.declared(q.path[i]); d != nil {
+				return string(d.FullName())
+			}
+		}
+	}
+	return string(q.res.Package())
+}
+
+// findExtension resolves the given extension name, relative to the given
+// scope, to an extension of the given message.
+func (q *query) findExtension(name, scope string, msg protoreflect.MessageDescriptor) protoreflect.FieldDescriptor {
+	check := func(fullName string) protoreflect.FieldDescriptor {
+		ext, ok := q.findVisible(protoreflect.FullName(fullName)).(protoreflect.FieldDescriptor)
+		if ok && ext.IsExtension() && ext.ContainingMessage().FullName() == msg.FullName() {
+			return ext
+		}
+		return nil
+	}
+	if strings.HasPrefix(name, ".") {
+		return check(name[1:])
+	}
+	for s := scope; ; {
+		fullName := name
+		if s != "" {
+			fullName = s + "." + name
+		}
+		if ext := check(fullName); ext != nil {
+			return ext
+		}
+		if s == "" {
+			return nil
+		}
+		pos := strings.LastIndexByte(s, '.')
+		if pos < 0 {
+			s = ""
+		} else {
+			s = s[:pos]
+		}
+	}
+}
+
+// findVisible returns the descriptor with the given name from the file or
+// the files it imports, or nil if there is none.
+func (q *query) findVisible(name protoreflect.FullName) protoreflect.Descriptor {
+	return findInFile(q.res, name, false, map[string]bool{})
+}
+
+func findInFile(file linker.File, name protoreflect.FullName, publicOnly bool, checked map[string]bool) protoreflect.Descriptor {
+	if checked[file.Path()] {
+		return nil
+	}
+	checked[file.Path()] = true
+	if d := file.FindDescriptorByName(name); d != nil {
+		return d
+	}
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		imp := imports.Get(i)
+		if publicOnly && !imp.IsPublic {
+			continue
+		}
+		dep := file.FindImportByPath(imp.Path())
+		if dep == nil {
+			continue
+		}
+		if d := findInFile(dep, name, true, checked); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// messageFieldTarget returns the descriptor for the name of the message
+// literal field at the given index in the path. This is a field, or, for
+// type references in google.protobuf.Any values, a message.
+func (q *query) messageFieldTarget(index int, ref *ast.FieldReferenceNode) protoreflect.Descriptor {
+	msg := q.literalType(index - 1)
+	if msg == nil {
+		return nil
+	}
+	name := string(ref.Name.AsIdentifier())
+	switch {
+	case ref.IsAnyTypeReference():
+		d, _ := q.findVisible(protoreflect.FullName(name)).(protoreflect.MessageDescriptor)
+		if d == nil {
+			return nil
+		}
+		return d
+	case ref.IsExtension():
+		fqn := strings.TrimPrefix(q.res.ResolveMessageLiteralExtensionName(ref.Name), ".")
+		if fqn == "" {
+			return nil
+		}
+		ext, _ := q.findVisible(protoreflect.FullName(fqn)).(protoreflect.FieldDescriptor)
+		if ext == nil {
+			return nil
+		}
+		return ext
+	default:
+		fields := msg.Fields()
+		if fld := fields.ByTextName(name); fld != nil {
+			return fld
+		}
+		if fld := fields.ByName(protoreflect.Name(name)); fld != nil {
+			return fld
+		}
+		return nil
+	}
+}
+
+// literalType returns the message type of the message literal at the given
+// index in the path.
+func (q *query) literalType(index int) protoreflect.MessageDescriptor {
+	if _, ok := q.path[index].(*ast.MessageLiteralNode); !ok || index < 1 {
+		return nil
+	}
+	parentIndex := index - 1
+	if _, ok := q.path[parentIndex].(*ast.ArrayLiteralNode); ok {
+		parentIndex--
+	}
+	switch parent := q.path[parentIndex].(type) {
+	case *ast.OptionNode:
+		fld := q.optionNameField(parentIndex, len(parent.Name.Parts)-1)
+		if fld == nil {
+			return nil
+		}
+		return fld.Message()
+	case *ast.MessageFieldNode:
+		switch target := q.messageFieldTarget(parentIndex, parent.Name).(type) {
+		case protoreflect.FieldDescriptor:
+			return target.Message()
+		case protoreflect.MessageDescriptor:
+			return target
+		}
+	}
+	return nil
+}
+
+// valueField returns the field whose value includes the identifier at the
+// given index in the path.
+func (q *query) valueField(index int) protoreflect.FieldDescriptor {
+	parentIndex := index - 1
+	if _, ok := q.path[parentIndex].(*ast.ArrayLiteralNode); ok {
+		parentIndex--
+	}
+	switch parent := q.path[parentIndex].(type) {
+	case *ast.OptionNode:
+		if q.path[parentIndex+1] != ast.Node(parent.Val) {
+			return nil
+		}
+		if len(parent.Name.Parts) == 1 && !parent.Name.Parts[0].IsExtension() && parent.Name.Parts[0].Name.AsIdentifier() == "default" {
+			// the default value of a field
+			if _, ok := q.path[parentIndex-1].(*ast.CompactOptionsNode); ok {
+				fld, _ := q.declared(q.path[parentIndex-2]).(protoreflect.FieldDescriptor)
+				return fld
+			}
+		}
+		return q.optionNameField(parentIndex, len(parent.Name.Parts)-1)
+	case *ast.MessageFieldNode:
+		fld, _ := q.messageFieldTarget(parentIndex, parent.Name).(protoreflect.FieldDescriptor)
+		return fld
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package astquery provides queries of a file's AST by source position, as
+// needed by editor features like hover and go-to-definition.
+//
+// NodeAt and NodeAtOffset find the innermost node at a position, along with
+// its ancestors. For a linked file, Descriptor then returns the descriptor
+// that an identifier at that position refers to.
+package astquery
+
+import (
+	"sort"
+
+	"github.com/bufbuild/protocompile/ast"
+)
+
+// Path is a chain of nodes in an AST, from the root *ast.FileNode to a
+// node in the tree. Each node in the path is a child of the node before it.
+type Path []ast.Node
+
+// Node returns the last node in the path, or nil if the path is empty.
+func (p Path) Node() ast.Node {
+	if len(p) == 0 {
+		return nil
+	}
+	return p[len(p)-1]
+}
+
+// Parent returns the second to last node in the path, or nil if the path has
+// fewer than two nodes.
+func (p Path) Parent() ast.Node {
+	if len(p) < 2 {
+		return nil
+	}
+	return p[len(p)-2]
+}
+
+// NodeAt returns the path to the innermost node in the given file that
+// contains the given position. Lines and columns are one-based, and columns
+// are computed like those of ast.SourcePos, so a tab advances the column to
+// the next multiple of eight.
+//
+// A node contains the positions from its first character up to, but not
+// including, the position after its last character. If the position is in
+// whitespace or a comment between nodes, the innermost node that contains
+// the position is returned. If the position is not in the file, the result
+// is empty.
+func NodeAt(file *ast.FileNode, line, col int) Path {
+	return find(file, ast.SourcePos{Line: line, Col: col})
+}
+
+// NodeAtOffset is like NodeAt, except that the position is given as a
+// zero-based byte offset.
+func NodeAtOffset(file *ast.FileNode, offset int) Path {
+	end := file.NodeInfo(file.EOF).Start().Offset
+	if offset < 0 || offset >= end {
+		return nil
+	}
+	return find(file, file.SourcePos(offset))
+}
+
+func find(file *ast.FileNode, pos ast.SourcePos) Path {
+	if pos.Line <= 0 || pos.Col <= 0 {
+		return nil
+	}
+	var node ast.Node = file
+	path := Path{file}
+	for {
+		composite, ok := node.(ast.CompositeNode)
+		if !ok {
+			return path
+		}
+		children := composite.Children()
+		// The children are sorted by position, so find the first one that
+		// ends after the position.
+		i := sort.Search(len(children), func(i int) bool {
+			return before(pos, file.NodeInfo(children[i]).End())
+		})
+		if i == len(children) || before(pos, file.NodeInfo(children[i]).Start()) {
+			if node == ast.Node(file) {
+				// the position is after the end of the file
+				if i == len(children) {
+					return nil
+				}
+			}
+			return path
+		}
+		node = children[i]
+		path = append(path, node)
+	}
+}
+
+// before returns true if position a is before position b.
+func before(a, b ast.SourcePos) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Col < b.Col
+}
@@ -0,0 +1,186 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package astquery
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/linker"
+)
+
+const depSource = `syntax = "proto2";
+package other;
+import "google/protobuf/descriptor.proto";
+message Ref {
+  optional string name = 1;
+  extensions 100 to 200;
+}
+enum Color {
+  RED = 0;
+  GREEN = 1;
+}
+extend google.protobuf.FieldOptions {
+  optional Ref ref = 5000;
+  optional Color color = 5001;
+}
+`
+
+const testSource = `syntax = "proto2";
+
+package foo.bar;
+
+import "dep.proto";
+
+// Foo is a message.
+message Foo {
+  optional other.Ref ref = 1 [(other.ref) = { name: "abc" }, (other.color) = GREEN];
+  optional other.Color color = 2 [default = RED];
+  map<string, Foo> children = 3;
+  optional group Grp = 4 {
+    optional int32 x = 1;
+  }
+}
+
+extend other.Ref {
+  optional Foo foo = 100;
+}
+
+service Svc {
+  rpc Get(Foo) returns (other.Ref) {
+    option deprecated = true;
+  }
+}
+`
+
+func TestNodeAt(t *testing.T) {
+	t.Parallel()
+	res := compile(t)
+	file := res.AST()
+
+	path := NodeAt(file, 8, 9)
+	require.Len(t, path, 3)
+	assert.Same(t, file, path[0])
+	msg, ok := path[1].(*ast.MessageNode)
+	require.True(t, ok)
+	assert.Same(t, msg.Name, path.Node())
+	assert.Same(t, msg, path.Parent())
+
+	// whitespace in a message body
+	path = NodeAt(file, 8, 14)
+	assert.Same(t, msg, path.Node())
+	// whitespace between declarations, and in comments
+	assert.Equal(t, Path{file}, NodeAt(file, 2, 1))
+	assert.Equal(t, Path{file}, NodeAt(file, 7, 4))
+	// positions outside of the file
+	assert.Empty(t, NodeAt(file, 0, 1))
+	assert.Empty(t, NodeAt(file, 100, 1))
+	assert.Empty(t, NodeAtOffset(file, -1))
+	assert.Empty(t, NodeAtOffset(file, len(testSource)))
+
+	// offsets and positions agree
+	for offset := 0; offset < len(testSource); offset++ {
+		pos := file.SourcePos(offset)
+		require.Equal(t, NodeAt(file, pos.Line, pos.Col), NodeAtOffset(file, offset), "offset %d", offset)
+	}
+}
+
+func TestDescriptor(t *testing.T) {
+	t.Parallel()
+	res := compile(t)
+	testCases := []struct {
+		// the marker is found in the source, and the query is at the
+		// start of the marker plus the offset
+		marker   string
+		offset   int
+		nodeType ast.Node
+		expected string
+	}{
+		{marker: "Foo is", nodeType: (*ast.FileNode)(nil)},
+		{marker: "Foo {", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.Foo"},
+		{marker: "other.Ref ref", nodeType: (*ast.IdentNode)(nil), expected: "other.Ref"},
+		{marker: "other.Ref ref", offset: 7, nodeType: (*ast.IdentNode)(nil), expected: "other.Ref"},
+		{marker: "other.Ref ref", offset: 5, nodeType: (*ast.RuneNode)(nil)},
+		{marker: "ref = 1", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.Foo.ref"},
+		{marker: "(other.ref)", offset: 7, nodeType: (*ast.IdentNode)(nil), expected: "other.ref"},
+		{marker: "name: ", nodeType: (*ast.IdentNode)(nil), expected: "other.Ref.name"},
+		{marker: "GREEN]", nodeType: (*ast.IdentNode)(nil), expected: "other.GREEN"},
+		{marker: "RED]", nodeType: (*ast.IdentNode)(nil), expected: "other.RED"},
+		{marker: "default", nodeType: (*ast.IdentNode)(nil)},
+		{marker: "Foo> children", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.Foo"},
+		{marker: "children", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.Foo.children"},
+		{marker: "Grp", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.Foo.grp"},
+		{marker: "x = 1", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.Foo.Grp.x"},
+		{marker: "Ref {", nodeType: (*ast.IdentNode)(nil), expected: "other.Ref"},
+		{marker: "Foo foo", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.Foo"},
+		{marker: "foo = 100", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.foo"},
+		{marker: "Svc", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.Svc"},
+		{marker: "Get", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.Svc.Get"},
+		{marker: "Foo) returns", nodeType: (*ast.IdentNode)(nil), expected: "foo.bar.Foo"},
+		{marker: "Ref) {", nodeType: (*ast.IdentNode)(nil), expected: "other.Ref"},
+		{marker: "deprecated", nodeType: (*ast.IdentNode)(nil), expected: "google.protobuf.MethodOptions.deprecated"},
+		{marker: "optional int32", nodeType: (*ast.KeywordNode)(nil)},
+		{marker: "int32", nodeType: (*ast.IdentNode)(nil)},
+		{marker: `"dep.proto"`, nodeType: (*ast.StringLiteralNode)(nil), expected: "dep.proto"},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.marker, func(t *testing.T) {
+			t.Parallel()
+			offset := strings.Index(testSource, testCase.marker)
+			require.GreaterOrEqual(t, offset, 0)
+			path := NodeAtOffset(res.AST(), offset+testCase.offset)
+			require.NotEmpty(t, path)
+			assert.Equal(t, reflect.TypeOf(testCase.nodeType), reflect.TypeOf(path.Node()))
+			d := Descriptor(res, path)
+			if testCase.expected == "" {
+				assert.Nil(t, d)
+				return
+			}
+			require.NotNil(t, d)
+			if file, ok := d.(interface{ Path() string }); ok {
+				assert.Equal(t, testCase.expected, file.Path())
+				return
+			}
+			assert.Equal(t, testCase.expected, string(d.FullName()))
+		})
+	}
+}
+
+func compile(t *testing.T) linker.Result {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"test.proto": testSource,
+				"dep.proto":  depSource,
+			}),
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+		RetainASTs:     true,
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	res, ok := files[0].(linker.Result)
+	require.True(t, ok)
+	return res
+}
@@ -0,0 +1,372 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocompile
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/bufbuild/protocompile/internal"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/parser/fastscan"
+)
+
+// Cache is a store of compiled files. When a Compiler is configured with a
+// cache, files whose source code (and whose dependencies) have not changed
+// since they were last compiled are loaded from the cache instead of being
+// parsed, linked, and having their options interpreted.
+//
+// Entries are keyed by a hash of the file's name, its source code, the
+// compiler's SourceInfoMode, and the keys of all of its dependencies
+// (including option dependencies, from "import option" statements). So a
+// key always identifies the same output, and entries never need to be
+// invalidated. Values are serialized FileDescriptorProto messages, including
+// interpreted options and (if enabled) source code info.
+//
+// No other compiler options are part of the key. Files loaded from the cache
+// never include an AST, regardless of RetainASTs. And files with errors are
+// never stored in the cache, so AllowUnresolvable cannot change the contents
+// of an entry.
+//
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the data stored for the given key. If the cache contains
+	// no such entry, or it cannot be read, it returns false.
+	Get(key string) ([]byte, bool)
+	// Put stores the given data for the given key. Failures to store an
+	// entry are not reported since the cache is merely an optimization.
+	Put(key string, data []byte)
+}
+
+// DirCache is a Cache that stores entries as files in a directory on disk.
+// This allows compilation results to be re-used across processes. The
+// directory is created if it does not already exist.
+//
+// Entries are never evicted. So it is up to the caller to clear out the
+// directory if it grows too large.
+type DirCache string
+
+var _ Cache = DirCache("")
+
+// Get implements the Cache interface.
+func (d DirCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put implements the Cache interface.
+func (d DirCache) Put(key string, data []byte) {
+	path := d.path(key)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+	// Write to a temp file and then rename, so that concurrent readers
+	// (possibly in other processes) never observe a partially written entry.
+	tmp, err := os.CreateTemp(dir, key+".tmp*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func (d DirCache) path(key string) string {
+	// Shard entries across sub-directories so no single directory gets
+	// too large.
+	return filepath.Join(string(d), key[:2], key)
+}
+
+// cacheKeyVersion is included in all cache keys. It should be changed
+// whenever the compiler's output changes, so that stale entries produced
+// by prior versions are not used.
+const cacheKeyVersion = "protocompile/v1"
+
+// getCacheKey returns the cache key for the given completed result. If the
+// result was loaded or compiled from source, this key was computed from the
+// source code. Otherwise, it is computed from the file's descriptor.
+func (r *result) getCacheKey() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cacheKey == "" {
+		data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(protodesc.ToFileDescriptorProto(r.res))
+		sum := sha256.Sum256(data)
+		r.cacheKey = hex.EncodeToString(sum[:])
+	}
+	return r.cacheKey
+}
+
+func (r *result) setCacheKey(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cacheKey = key
+}
+
+// loadFromCache tries to load the file with the given name and source code from
+// the compiler's cache. This first scans the source for imports and waits for
+// those dependencies to be compiled, since their cache keys are inputs to
+// this file's key.
+//
+// If the file is found in the cache, it is returned. Otherwise, this returns
+// nil, and the file must be compiled from source. If that succeeds, the file
+// should be stored in the cache using t.storeInCache.
+//
+// This returns an error only if the given context is cancelled. Any problem
+// with the file or its dependencies just results in a cache miss, so that the
+// normal compilation path will report the problem.
+func (t *task) loadFromCache(ctx context.Context, name string, src []byte) (linker.File, error) {
+	scan, err := fastscan.Scan(name, bytes.NewReader(src))
+	if err != nil {
+		return nil, nil // malformed source is reported when parsed
+	}
+	imports := make([]string, 0, len(scan.Imports))
+	var optionImports []string
+	for _, imp := range scan.Imports {
+		if imp.Path == name {
+			return nil, nil
+		}
+		if imp.IsOption {
+			optionImports = append(optionImports, imp.Path)
+			continue
+		}
+		imports = append(imports, imp.Path)
+	}
+	// Like when compiling from source, option dependencies come after the
+	// other dependencies. Their keys are part of this file's key, too, since
+	// their extensions may be used in this file's options.
+	imports = append(imports, optionImports...)
+	t.cacheImports = imports
+
+	allImports := imports
+	if t.e.hasOverrideDescriptorProto() && name != descriptorProtoPath {
+		var includesDescriptorProto bool
+		for _, dep := range imports {
+			if dep == descriptorProtoPath {
+				includesDescriptorProto = true
+				break
+			}
+		}
+		if !includesDescriptorProto {
+			allImports = append(allImports[:len(allImports):len(allImports)], descriptorProtoPath)
+		}
+	}
+
+	results := make([]*result, len(allImports))
+	if len(allImports) > 0 {
+		t.r.setBlockedOn(allImports)
+		t.blockedOn = allImports
+		checked := map[string]struct{}{}
+		for i, dep := range allImports {
+			res := t.e.compile(ctx, dep)
+			if t.e.findDependencyCycle(res, []string{name, dep}, checked) != nil {
+				// let the normal path report the cycle
+				t.r.setBlockedOn(nil)
+				t.cacheImports = nil
+				return nil, nil
+			}
+			results[i] = res
+		}
+
+		// release our semaphore so dependencies can be processed w/out risk of deadlock
+		t.e.s.Release(1)
+		t.released = true
+		for _, res := range results {
+			select {
+			case <-res.ready:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		t.r.setBlockedOn(nil)
+		// reacquire semaphore so we can proceed
+		if err := t.e.s.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+		t.released = false
+	}
+
+	h := sha256.New()
+	writeKeyPart := func(s string) {
+		var l [binary.MaxVarintLen64]byte
+		h.Write(l[:binary.PutUvarint(l[:], uint64(len(s)))])
+		h.Write([]byte(s))
+	}
+	writeKeyPart(cacheKeyVersion)
+	writeKeyPart(name)
+	var mode [binary.MaxVarintLen64]byte
+	h.Write(mode[:binary.PutUvarint(mode[:], uint64(t.e.c.SourceInfoMode))])
+	writeKeyPart(string(src))
+	deps := make(linker.Files, len(imports))
+	for i, res := range results {
+		if res.err != nil {
+			if i >= len(imports) {
+				// descriptor.proto wasn't explicitly imported, so we can ignore a failure
+				continue
+			}
+			t.cacheImports = nil
+			return nil, nil
+		}
+		writeKeyPart(res.name)
+		writeKeyPart(res.getCacheKey())
+		if i < len(imports) {
+			deps[i] = res.res
+		}
+	}
+	t.cacheKey = hex.EncodeToString(h.Sum(nil))
+
+	data, ok := t.e.c.Cache.Get(t.cacheKey)
+	if !ok {
+		return nil, nil
+	}
+	file, err := fileFromCache(data, deps)
+	if err != nil || file.Path() != name {
+		// corrupt or incompatible entry; treat as a miss
+		return nil, nil
+	}
+	if err := t.e.sym.Import(file, t.h); err != nil {
+		return nil, err
+	}
+	t.r.setCacheKey(t.cacheKey)
+	return file, nil
+}
+
+// storeInCache stores the given file, which was just compiled from source, in
+// the compiler's cache. This is a no-op if loadFromCache did not compute a key for
+// the file.
+func (t *task) storeInCache(file linker.File) {
+	if t.cacheKey == "" {
+		return
+	}
+	res, ok := file.(linker.Result)
+	if !ok {
+		return
+	}
+	fileProto := res.FileDescriptorProto()
+	// The key was computed from imports found by scanning the source. If
+	// those do not agree with the parsed imports, the key does not account
+	// for all dependencies, so it is not safe to store the result.
+	deps := fileProto.Dependency
+	if optionDeps := internal.OptionDependencies(fileProto); len(optionDeps) > 0 {
+		deps = append(append([]string(nil), deps...), optionDeps...)
+	}
+	if len(deps) != len(t.cacheImports) {
+		return
+	}
+	for i, dep := range deps {
+		if dep != t.cacheImports[i] {
+			return
+		}
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(fileProto)
+	if err != nil {
+		return
+	}
+	t.e.c.Cache.Put(t.cacheKey, data)
+	t.r.setCacheKey(t.cacheKey)
+}
+
+// fileFromCache constructs a file from the given serialized descriptor
+// proto, which was stored in a cache. The given dependencies must be the
+// files that it imports, in order, followed by its option dependencies.
+func fileFromCache(data []byte, deps linker.Files) (linker.File, error) {
+	var fileProto descriptorpb.FileDescriptorProto
+	if err := proto.Unmarshal(data, &fileProto); err != nil {
+		return nil, err
+	}
+	fd, err := protodesc.NewFile(&fileProto, cacheDepsResolver(deps))
+	if err != nil {
+		return nil, err
+	}
+	file, err := linker.NewFile(fd, deps)
+	if err != nil {
+		return nil, err
+	}
+	// Custom options were stored as unrecognized fields when unmarshalling
+	// above since we didn't yet have a way to resolve them. Now that we have
+	// a file, we can unmarshal again and resolve them. Option dependencies
+	// are not imports of the file, so they are searched separately.
+	resolver := append(cacheDepsResolver{file}, deps[len(fileProto.Dependency):]...)
+	fileProto.Reset()
+	if err := (proto.UnmarshalOptions{Resolver: resolver}).Unmarshal(data, &fileProto); err != nil {
+		return nil, err
+	}
+	fd, err = protodesc.NewFile(&fileProto, cacheDepsResolver(deps))
+	if err != nil {
+		return nil, err
+	}
+	return linker.NewFile(fd, deps)
+}
+
+// cacheDepsResolver resolves elements in the given files and in any files
+// that they import.
+type cacheDepsResolver linker.Files
+
+func (r cacheDepsResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	for _, f := range r {
+		if d, err := linker.ResolverFromFile(f).FindFileByPath(path); err == nil {
+			return d, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+func (r cacheDepsResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	for _, f := range r {
+		if d, err := linker.ResolverFromFile(f).FindDescriptorByName(name); err == nil {
+			return d, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+func (r cacheDepsResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	for _, f := range r {
+		if ext, err := linker.ResolverFromFile(f).FindExtensionByName(field); err == nil {
+			return ext, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+func (r cacheDepsResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	for _, f := range r {
+		if ext, err := linker.ResolverFromFile(f).FindExtensionByNumber(message, field); err == nil {
+			return ext, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
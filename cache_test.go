@@ -0,0 +1,230 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocompile
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/bufbuild/protocompile/linker"
+)
+
+type memCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+	hits    int
+}
+
+func (c *memCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.entries[key]
+	if ok {
+		c.hits++
+	}
+	return data, ok
+}
+
+func (c *memCache) Put(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = data
+}
+
+func (c *memCache) takeHits() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hits := c.hits
+	c.hits = 0
+	return hits
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+	srcs := map[string]string{
+		"a.proto": `
+			syntax = "proto3";
+			package test;
+			import "google/protobuf/descriptor.proto";
+			extend google.protobuf.MessageOptions { string label = 50000; }
+			// A is a message.
+			message A { option (label) = "a"; }`,
+		"b.proto": `
+			syntax = "proto3";
+			package test;
+			import public "a.proto";`,
+		"c.proto": `
+			syntax = "proto3";
+			package test;
+			import "b.proto";
+			message C { option (label) = "c"; A a = 1; }`,
+	}
+	cache := &memCache{entries: map[string][]byte{}}
+	newCompiler := func() *Compiler {
+		return &Compiler{
+			Resolver:       WithStandardImports(&SourceResolver{Accessor: SourceAccessorFromMap(srcs)}),
+			SourceInfoMode: SourceInfoStandard,
+			Cache:          cache,
+		}
+	}
+	ctx := context.Background()
+
+	files, err := newCompiler().Compile(ctx, "c.proto")
+	require.NoError(t, err)
+	assert.Zero(t, cache.takeHits())
+	assert.Len(t, cache.entries, 3)
+	_, ok := files[0].(linker.Result)
+	assert.True(t, ok)
+
+	cached, err := newCompiler().Compile(ctx, "c.proto")
+	require.NoError(t, err)
+	assert.Equal(t, 3, cache.takeHits())
+	_, ok = cached[0].(linker.Result)
+	assert.False(t, ok)
+	assertSameFile(t, files[0], cached[0])
+	assertSameFile(t, files[0].FindImportByPath("b.proto").FindImportByPath("a.proto"),
+		cached[0].FindImportByPath("b.proto").FindImportByPath("a.proto"))
+
+	// custom options are recognized in cached files
+	opts := cached[0].Messages().ByName("C").Options().ProtoReflect()
+	assert.Empty(t, opts.GetUnknown())
+	label, err := linker.ResolverFromFile(cached[0]).FindExtensionByName("test.label")
+	require.NoError(t, err)
+	assert.Equal(t, "c", opts.Get(label.TypeDescriptor()).String())
+
+	// changing a dependency changes the keys of all files that depend on it
+	srcs["a.proto"] += "\nmessage A2 {}"
+	_, err = newCompiler().Compile(ctx, "c.proto")
+	require.NoError(t, err)
+	assert.Zero(t, cache.takeHits())
+	assert.Len(t, cache.entries, 6)
+
+	// changing compiler configuration also changes the keys
+	compiler := newCompiler()
+	compiler.SourceInfoMode = SourceInfoNone
+	_, err = compiler.Compile(ctx, "c.proto")
+	require.NoError(t, err)
+	assert.Zero(t, cache.takeHits())
+}
+
+func TestCache_OptionImports(t *testing.T) {
+	t.Parallel()
+	srcs := map[string]string{
+		"options.proto": `
+			syntax = "proto2";
+			package test;
+			import "google/protobuf/descriptor.proto";
+			extend google.protobuf.MessageOptions { optional string label = 50000; }`,
+		"a.proto": `
+			edition = "2024";
+			package test;
+			import option "options.proto";
+			message A { option (label) = "a"; }`,
+	}
+	cache := &memCache{entries: map[string][]byte{}}
+	newCompiler := func() *Compiler {
+		return &Compiler{
+			Resolver: WithStandardImports(&SourceResolver{Accessor: SourceAccessorFromMap(srcs)}),
+			Cache:    cache,
+		}
+	}
+	ctx := context.Background()
+
+	files, err := newCompiler().Compile(ctx, "a.proto")
+	require.NoError(t, err)
+	assert.Zero(t, cache.takeHits())
+	assert.Len(t, cache.entries, 2)
+
+	cached, err := newCompiler().Compile(ctx, "a.proto")
+	require.NoError(t, err)
+	assert.Equal(t, 2, cache.takeHits())
+	assertSameFile(t, files[0], cached[0])
+
+	// custom options from option dependencies are recognized in cached files
+	opts := cached[0].Messages().ByName("A").Options().ProtoReflect()
+	assert.Empty(t, opts.GetUnknown())
+	label, err := linker.ResolverFromFile(files[0]).FindExtensionByName("test.label")
+	require.NoError(t, err)
+	assert.Equal(t, "a", opts.Get(label.TypeDescriptor()).String())
+
+	// changing an option dependency changes the key of the file that uses it
+	srcs["options.proto"] += "\nmessage Other {}"
+	_, err = newCompiler().Compile(ctx, "a.proto")
+	require.NoError(t, err)
+	assert.Zero(t, cache.takeHits())
+	assert.Len(t, cache.entries, 4)
+}
+
+func TestCache_Errors(t *testing.T) {
+	t.Parallel()
+	srcs := map[string]string{
+		"a.proto": `syntax = "proto3"; import "b.proto";`,
+		"b.proto": `syntax = "proto3"; import "a.proto";`,
+		"c.proto": `syntax = "proto3"; message C { Unknown u = 1; }`,
+	}
+	cache := &memCache{entries: map[string][]byte{}}
+	compiler := &Compiler{
+		Resolver: &SourceResolver{Accessor: SourceAccessorFromMap(srcs)},
+		Cache:    cache,
+	}
+	for i := 0; i < 2; i++ {
+		_, err := compiler.Compile(context.Background(), "a.proto")
+		require.ErrorContains(t, err, "cycle found in imports")
+		_, err = compiler.Compile(context.Background(), "c.proto")
+		require.ErrorContains(t, err, `field C.u: unknown type Unknown`)
+	}
+	assert.Empty(t, cache.entries)
+}
+
+func TestDirCache(t *testing.T) {
+	t.Parallel()
+	cache := DirCache(t.TempDir())
+	_, ok := cache.Get("0123abcd")
+	assert.False(t, ok)
+	cache.Put("0123abcd", []byte("foo"))
+	data, ok := cache.Get("0123abcd")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("foo"), data)
+	cache.Put("0123abcd", []byte("bar"))
+	data, ok = cache.Get("0123abcd")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("bar"), data)
+}
+
+func assertSameFile(t *testing.T, expected, actual protoreflect.FileDescriptor) {
+	t.Helper()
+	expectedProto := protodesc.ToFileDescriptorProto(expected)
+	actualProto := protodesc.ToFileDescriptorProto(actual)
+	// round-trip through bytes, so that custom options in both are unrecognized
+	// and can be compared
+	roundTrip := func(m proto.Message) {
+		data, err := proto.Marshal(m)
+		require.NoError(t, err)
+		proto.Reset(m)
+		require.NoError(t, proto.Unmarshal(data, m))
+	}
+	roundTrip(expectedProto)
+	roundTrip(actualProto)
+	assert.Empty(t, cmp.Diff(expectedProto, actualProto, protocmp.Transform()))
+}
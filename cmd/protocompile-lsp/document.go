@@ -0,0 +1,171 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/linker"
+)
+
+// document is a file that is open in the editor. Its text is the editor's
+// buffer, which may differ from the file's contents on disk.
+type document struct {
+	uri     string
+	path    string
+	version int
+	text    []byte
+	// the offsets at which the lines start
+	lines []int
+	// The result of the last compilation of the document, which is nil if
+	// it couldn't be linked.
+	result linker.Result
+}
+
+func newDocument(uri, path string, version int, text []byte) *document {
+	doc := &document{uri: uri, path: path, version: version}
+	doc.setText(text)
+	return doc
+}
+
+func (d *document) setText(text []byte) {
+	d.text = text
+	d.lines = textLines(text)
+	d.result = nil
+}
+
+// applyChange applies the given change from the editor to the text.
+func (d *document) applyChange(change textDocumentContentChangeEvent) error {
+	if change.Range == nil {
+		d.setText([]byte(change.Text))
+		return nil
+	}
+	start, end := d.offset(change.Range.Start), d.offset(change.Range.End)
+	if start > end {
+		return fmt.Errorf("invalid range in change to %s: start is after end", d.uri)
+	}
+	text := make([]byte, 0, len(d.text)-(end-start)+len(change.Text))
+	text = append(text, d.text[:start]...)
+	text = append(text, change.Text...)
+	text = append(text, d.text[end:]...)
+	d.setText(text)
+	return nil
+}
+
+// offset returns the offset in the text of the given position. Positions
+// past the end of a line are clamped to the line's end.
+func (d *document) offset(pos position) int {
+	return positionOffset(d.text, d.lines, pos)
+}
+
+// position returns the position of the given offset in the text.
+func (d *document) position(offset int) position {
+	return offsetPosition(d.text, d.lines, offset)
+}
+
+// spanRange returns the range of the given span in the document.
+func (d *document) spanRange(span ast.SourceSpan) textRange {
+	return spanRange(d.text, d.lines, span)
+}
+
+func textLines(text []byte) []int {
+	lines := []int{0}
+	for i, b := range text {
+		if b == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+func positionOffset(text []byte, lines []int, pos position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(lines) {
+		return len(text)
+	}
+	offset := lines[pos.Line]
+	for units := 0; units < pos.Character && offset < len(text) && text[offset] != '\n'; {
+		r, size := utf8.DecodeRune(text[offset:])
+		units += utf16Len(r)
+		offset += size
+	}
+	return offset
+}
+
+func offsetPosition(text []byte, lines []int, offset int) position {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	line := sort.Search(len(lines), func(i int) bool { return lines[i] > offset }) - 1
+	units := 0
+	for i := lines[line]; i < offset; {
+		r, size := utf8.DecodeRune(text[i:])
+		units += utf16Len(r)
+		i += size
+	}
+	return position{Line: line, Character: units}
+}
+
+// spanRange returns the range of the given span in the given text. The end
+// of a span is the position of its last character, unless the span is empty.
+func spanRange(text []byte, lines []int, span ast.SourceSpan) textRange {
+	start, end := span.Start().Offset, span.End().Offset
+	if span.End() != span.Start() && end < len(text) {
+		_, size := utf8.DecodeRune(text[end:])
+		end += size
+	}
+	return textRange{Start: offsetPosition(text, lines, start), End: offsetPosition(text, lines, end)}
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// uriToPath returns the file system path for the given file URI.
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" || u.Path == "" {
+		return "", fmt.Errorf("unsupported URI %q: only file URIs are supported", uri)
+	}
+	path := filepath.FromSlash(u.Path)
+	if filepath.VolumeName(path[1:]) != "" {
+		// a Windows path, like "/C:/foo"
+		path = path[1:]
+	}
+	return filepath.Clean(path), nil
+}
+
+// pathToURI returns the file URI for the given absolute path.
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if path[0] != '/' {
+		// a Windows path
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,353 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/astquery"
+	"github.com/bufbuild/protocompile/format"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/protoutil"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// codeRequestFailed is the LSP error code for a request that is valid but
+// failed.
+const codeRequestFailed = -32803
+
+// descriptorAt returns the node at the given position in the document and
+// the descriptor that it declares or refers to. It returns nil if the
+// document didn't compile or if there's no descriptor at the position.
+func (s *server) descriptorAt(params *textDocumentPositionParams) (*document, astquery.Path, protoreflect.Descriptor) {
+	doc := s.document(params.TextDocument.URI)
+	if doc == nil || doc.result == nil || doc.result.AST() == nil {
+		return nil, nil, nil
+	}
+	path := astquery.NodeAtOffset(doc.result.AST(), doc.offset(params.Position))
+	if path == nil {
+		return nil, nil, nil
+	}
+	d := astquery.Descriptor(doc.result, path)
+	if d == nil {
+		return nil, nil, nil
+	}
+	return doc, path, d
+}
+
+func (s *server) hover(params *textDocumentPositionParams) (interface{}, error) {
+	doc, path, d := s.descriptorAt(params)
+	if d == nil {
+		return nil, nil
+	}
+	text := "```proto\n" + signature(d) + "\n```"
+	if comments := leadingComments(d); comments != "" {
+		text += "\n\n" + comments
+	}
+	rng := doc.spanRange(doc.result.AST().NodeInfo(path.Node()))
+	return &hover{Contents: markupContent{Kind: "markdown", Value: text}, Range: &rng}, nil
+}
+
+// signature returns a summary of the declaration of the given element.
+func signature(d protoreflect.Descriptor) string {
+	switch d := d.(type) {
+	case protoreflect.FileDescriptor:
+		return fmt.Sprintf("import %q;", d.Path())
+	case protoreflect.MessageDescriptor:
+		return "message " + string(d.FullName())
+	case protoreflect.EnumDescriptor:
+		return "enum " + string(d.FullName())
+	case protoreflect.EnumValueDescriptor:
+		return fmt.Sprintf("%s = %d", d.FullName(), d.Number())
+	case protoreflect.FieldDescriptor:
+		var sb strings.Builder
+		if d.IsExtension() {
+			fmt.Fprintf(&sb, "extend %s { ", d.ContainingMessage().FullName())
+		}
+		switch {
+		case d.IsMap():
+			fmt.Fprintf(&sb, "map<%s, %s>", typeName(d.MapKey()), typeName(d.MapValue()))
+		case d.IsList():
+			sb.WriteString("repeated " + typeName(d))
+		default:
+			sb.WriteString(typeName(d))
+		}
+		fmt.Fprintf(&sb, " %s = %d", d.FullName(), d.Number())
+		if d.IsExtension() {
+			sb.WriteString("; }")
+		}
+		return sb.String()
+	case protoreflect.OneofDescriptor:
+		return "oneof " + string(d.FullName())
+	case protoreflect.ServiceDescriptor:
+		return "service " + string(d.FullName())
+	case protoreflect.MethodDescriptor:
+		var in, out string
+		if d.IsStreamingClient() {
+			in = "stream "
+		}
+		if d.IsStreamingServer() {
+			out = "stream "
+		}
+		return fmt.Sprintf("rpc %s(%s%s) returns (%s%s)", d.FullName(), in, d.Input().FullName(), out, d.Output().FullName())
+	default:
+		return string(d.FullName())
+	}
+}
+
+func typeName(fld protoreflect.FieldDescriptor) string {
+	switch {
+	case fld.Message() != nil:
+		return string(fld.Message().FullName())
+	case fld.Enum() != nil:
+		return string(fld.Enum().FullName())
+	default:
+		return fld.Kind().String()
+	}
+}
+
+// leadingComments returns the text of the comments before the declaration
+// of the given element.
+func leadingComments(d protoreflect.Descriptor) string {
+	if _, ok := d.(protoreflect.FileDescriptor); ok {
+		return ""
+	}
+	res, ok := d.ParentFile().(linker.Result)
+	if !ok || res.AST() == nil {
+		// no source, but there may be source code info
+		return strings.TrimSpace(d.ParentFile().SourceLocations().ByDescriptor(d).LeadingComments)
+	}
+	node := res.Node(protoutil.ProtoFromDescriptor(d))
+	if node == nil {
+		return ""
+	}
+	comments := res.AST().NodeInfo(node).LeadingComments()
+	lines := make([]string, 0, comments.Len())
+	for i := 0; i < comments.Len(); i++ {
+		lines = append(lines, commentText(comments.Index(i).RawText()))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// commentText returns the text of the given comment, without the comment
+// markers.
+func commentText(raw string) string {
+	if strings.HasPrefix(raw, "//") {
+		return strings.TrimPrefix(strings.TrimPrefix(raw, "//"), " ")
+	}
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "/*"), "*/")
+	lines := strings.Split(raw, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i > 0 {
+			line = strings.TrimPrefix(strings.TrimPrefix(line, "*"), " ")
+		}
+		lines[i] = line
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func (s *server) definition(params *textDocumentPositionParams) (interface{}, error) {
+	doc, _, d := s.descriptorAt(params)
+	if d == nil {
+		return nil, nil
+	}
+	res, ok := d.ParentFile().(linker.Result)
+	if !ok || res.AST() == nil {
+		return nil, nil
+	}
+	path := s.findFile(doc, res.Path())
+	if path == "" {
+		return nil, nil
+	}
+	text := s.fileText(path)
+	if text == nil {
+		return nil, nil
+	}
+	var rng textRange
+	if _, ok := d.(protoreflect.FileDescriptor); !ok {
+		node := declaredName(res.Node(protoutil.ProtoFromDescriptor(d)))
+		if node == nil {
+			return nil, nil
+		}
+		rng = spanRange(text, textLines(text), res.AST().NodeInfo(node))
+	}
+	return []location{{URI: pathToURI(path), Range: rng}}, nil
+}
+
+// findFile returns the path on the file system of the file with the given
+// name, as imported by the given document. It returns the empty string if
+// the file isn't on the file system, like the standard imports.
+func (s *server) findFile(doc *document, name string) string {
+	importPath, _ := s.importPath(doc)
+	for _, dir := range append([]string{importPath}, s.importPaths...) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if _, ok := s.docs[path]; ok {
+			return path
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// fileText returns the contents of the file with the given path, which is
+// the buffer if the file is open. It returns nil if the file can't be read.
+func (s *server) fileText(path string) []byte {
+	if doc := s.docs[path]; doc != nil {
+		return doc.text
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return text
+}
+
+// declaredName returns the name in the given declaration.
+func declaredName(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.MessageNode:
+		return node.Name
+	case *ast.GroupNode:
+		return node.Name
+	case *ast.EnumNode:
+		return node.Name
+	case *ast.EnumValueNode:
+		return node.Name
+	case *ast.FieldNode:
+		return node.Name
+	case *ast.MapFieldNode:
+		return node.Name
+	case *ast.OneofNode:
+		return node.Name
+	case *ast.ServiceNode:
+		return node.Name
+	case *ast.RPCNode:
+		return node.Name
+	default:
+		return node
+	}
+}
+
+func (s *server) documentSymbols(params *documentSymbolParams) (interface{}, error) {
+	doc := s.document(params.TextDocument.URI)
+	if doc == nil {
+		return nil, &responseError{Code: codeRequestFailed, Message: fmt.Sprintf("%s is not open", params.TextDocument.URI)}
+	}
+	// The document is parsed on its own, so that its symbols are available
+	// even if it doesn't compile.
+	handler := reporter.NewHandler(reporter.NewReporter(func(reporter.ErrorWithPos) error { return nil }, nil))
+	file, _ := parser.Parse(doc.path, bytes.NewReader(doc.text), handler)
+	if file == nil {
+		return []documentSymbol{}, nil
+	}
+	return childSymbols(doc, file, file.Decls), nil
+}
+
+func childSymbols[T ast.Node](doc *document, file *ast.FileNode, decls []T) []documentSymbol {
+	syms := []documentSymbol{}
+	for _, decl := range decls {
+		if sym := nodeSymbol(doc, file, decl); sym != nil {
+			syms = append(syms, *sym)
+		}
+	}
+	return syms
+}
+
+// nodeSymbol returns the symbol for the given declaration, or nil if it
+// doesn't declare a symbol.
+func nodeSymbol(doc *document, file *ast.FileNode, node ast.Node) *documentSymbol {
+	var name ast.Node
+	sym := &documentSymbol{}
+	switch node := node.(type) {
+	case *ast.PackageNode:
+		name, sym.Kind = node.Name, symbolKindPackage
+		sym.Name = string(node.Name.AsIdentifier())
+	case *ast.MessageNode:
+		name, sym.Kind = node.Name, symbolKindStruct
+		sym.Children = childSymbols(doc, file, node.Decls)
+	case *ast.GroupNode:
+		name, sym.Kind, sym.Detail = node.Name, symbolKindStruct, "group"
+		sym.Children = childSymbols(doc, file, node.Decls)
+	case *ast.FieldNode:
+		name, sym.Kind = node.Name, symbolKindField
+		sym.Detail = string(node.FldType.AsIdentifier())
+	case *ast.MapFieldNode:
+		name, sym.Kind = node.Name, symbolKindField
+		sym.Detail = fmt.Sprintf("map<%s, %s>", node.MapType.KeyType.Val, node.MapType.ValueType.AsIdentifier())
+	case *ast.OneofNode:
+		name, sym.Kind, sym.Detail = node.Name, symbolKindStruct, "oneof"
+		sym.Children = childSymbols(doc, file, node.Decls)
+	case *ast.EnumNode:
+		name, sym.Kind = node.Name, symbolKindEnum
+		sym.Children = childSymbols(doc, file, node.Decls)
+	case *ast.EnumValueNode:
+		name, sym.Kind = node.Name, symbolKindEnumMember
+	case *ast.ExtendNode:
+		name, sym.Kind = node.Extendee, symbolKindNamespace
+		sym.Name = "extend " + string(node.Extendee.AsIdentifier())
+		sym.Children = childSymbols(doc, file, node.Decls)
+	case *ast.ServiceNode:
+		name, sym.Kind = node.Name, symbolKindInterface
+		sym.Children = childSymbols(doc, file, node.Decls)
+	case *ast.RPCNode:
+		name, sym.Kind = node.Name, symbolKindMethod
+		sym.Detail = fmt.Sprintf("(%s) returns (%s)", rpcType(node.Input), rpcType(node.Output))
+	default:
+		return nil
+	}
+	if ident, ok := name.(*ast.IdentNode); ok && sym.Name == "" {
+		sym.Name = ident.Val
+	}
+	sym.Range = doc.spanRange(file.NodeInfo(node))
+	sym.SelectionRange = doc.spanRange(file.NodeInfo(name))
+	return sym
+}
+
+func rpcType(node *ast.RPCTypeNode) string {
+	if node.Stream != nil {
+		return "stream " + string(node.MessageType.AsIdentifier())
+	}
+	return string(node.MessageType.AsIdentifier())
+}
+
+func (s *server) formatting(params *documentFormattingParams) (interface{}, error) {
+	doc := s.document(params.TextDocument.URI)
+	if doc == nil {
+		return nil, &responseError{Code: codeRequestFailed, Message: fmt.Sprintf("%s is not open", params.TextDocument.URI)}
+	}
+	formatted, err := format.Source(doc.path, doc.text)
+	if err != nil {
+		return nil, &responseError{Code: codeRequestFailed, Message: err.Error()}
+	}
+	if bytes.Equal(formatted, doc.text) {
+		return []textEdit{}, nil
+	}
+	// the edit replaces the whole document
+	return []textEdit{{
+		Range:   textRange{End: doc.position(len(doc.text))},
+		NewText: string(formatted),
+	}}, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	// LSP-specific: a request arrived before initialize.
+	codeServerNotInitialized = -32002
+)
+
+// message is a JSON-RPC 2.0 request, notification, or response. Requests have
+// an ID and a method, notifications only a method, and responses only an ID.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+// conn reads and writes JSON-RPC messages, framed by the base protocol of
+// the Language Server Protocol: each message has a header with its length.
+type conn struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{r: bufio.NewReader(r), w: w}
+}
+
+// read reads the next message. It returns io.EOF if there are no more
+// messages.
+func (c *conn) read() (*message, error) {
+	header, err := textproto.NewReader(c.r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read message header: %w", err)
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header: %q", header.Get("Content-Length"))
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, &responseError{Code: codeParseError, Message: err.Error()}
+	}
+	return &msg, nil
+}
+
+// write writes the given message.
+func (c *conn) write(msg *message) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(data)
+	return err
+}
+
+// reply writes the response to the request with the given ID. If err is
+// not nil, the response is an error.
+func (c *conn) reply(id json.RawMessage, result interface{}, err error) error {
+	msg := &message{ID: id}
+	if err != nil {
+		respErr, ok := err.(*responseError)
+		if !ok {
+			respErr = &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+		msg.Error = respErr
+		return c.write(msg)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	msg.Result = data
+	return c.write(msg)
+}
+
+// notify writes a notification.
+func (c *conn) notify(method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(&message{Method: method, Params: data})
+}
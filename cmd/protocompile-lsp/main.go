@@ -0,0 +1,101 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command protocompile-lsp is a language server for proto source files. It
+// speaks the Language Server Protocol over stdin and stdout, so it works
+// with any editor that supports the protocol.
+//
+// Usage:
+//
+//	protocompile-lsp [-I path ...]
+//
+// The -I flag adds an import path, like protoc's flag of the same name, and
+// may be repeated. Without it, the roots of the editor's workspace are the
+// import paths. Files that aren't in an import path are compiled relative
+// to their directory.
+//
+// Open files are compiled with the same compiler as the protocompile
+// command, with the editor's unsaved buffers in place of the files on disk,
+// so the diagnostics are the same as for a build. The server also provides
+// hover information with the comments of the element under the cursor,
+// go-to-definition, document symbols, and formatting, which uses the same
+// conventions as protofmt.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run serves the client that communicates over the given stdin and stdout
+// until it exits, and returns the process's exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("protocompile-lsp", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var importPaths stringsFlag
+	flags.Var(&importPaths, "I", "add an import path; may be repeated")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if flags.NArg() > 0 {
+		_, _ = fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+		return 1
+	}
+	s := &server{
+		conn: newConn(stdin, stdout),
+		docs: map[string]*document{},
+	}
+	for _, path := range importPaths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		s.importPaths = append(s.importPaths, abs)
+	}
+	code, err := s.serve(ctx)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+	}
+	return code
+}
+
+// stringsFlag is a flag that may be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
@@ -0,0 +1,270 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testA = `syntax = "proto3";
+package test;
+
+// Thing is a thing.
+// It has a name.
+message Thing {
+  string name = 1;
+}
+`
+
+const testB = `syntax = "proto3";
+
+package test;
+
+import "a.proto";
+
+service Svc {
+  rpc Get(Thing) returns (Thing);
+}
+`
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.proto"), []byte(testA), 0o644))
+	client := startServer(t, "-I", dir)
+	uriA, uriB := pathToURI(filepath.Join(dir, "a.proto")), pathToURI(filepath.Join(dir, "b.proto"))
+
+	var initResult initializeResult
+	client.call("initialize", initializeParams{}, &initResult)
+	assert.True(t, initResult.Capabilities.HoverProvider)
+	client.notify("initialized", struct{}{})
+
+	// b.proto is only in the editor
+	client.notify("textDocument/didOpen", didOpenTextDocumentParams{
+		TextDocument: textDocumentItem{URI: uriB, LanguageID: "protobuf", Version: 1, Text: testB},
+	})
+	diags := client.diagnostics(uriB)
+	assert.Empty(t, diags.Diagnostics)
+
+	// hover over the input type shows the comments from a.proto
+	var hoverResult hover
+	client.call("textDocument/hover", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: uriB},
+		Position:     position{Line: 7, Character: 11},
+	}, &hoverResult)
+	assert.Equal(t, "```proto\nmessage test.Thing\n```\n\nThing is a thing.\nIt has a name.", hoverResult.Contents.Value)
+	assert.Equal(t, &textRange{Start: position{Line: 7, Character: 10}, End: position{Line: 7, Character: 15}}, hoverResult.Range)
+
+	var locations []location
+	client.call("textDocument/definition", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: uriB},
+		Position:     position{Line: 7, Character: 27},
+	}, &locations)
+	assert.Equal(t, []location{{
+		URI:   uriA,
+		Range: textRange{Start: position{Line: 5, Character: 8}, End: position{Line: 5, Character: 13}},
+	}}, locations)
+
+	var symbols []documentSymbol
+	client.call("textDocument/documentSymbol", documentSymbolParams{TextDocument: textDocumentIdentifier{URI: uriB}}, &symbols)
+	require.Len(t, symbols, 2)
+	assert.Equal(t, "test", symbols[0].Name)
+	assert.Equal(t, "Svc", symbols[1].Name)
+	require.Len(t, symbols[1].Children, 1)
+	assert.Equal(t, "Get", symbols[1].Children[0].Name)
+	assert.Equal(t, "(Thing) returns (Thing)", symbols[1].Children[0].Detail)
+
+	var edits []textEdit
+	client.call("textDocument/formatting", documentFormattingParams{TextDocument: textDocumentIdentifier{URI: uriB}}, &edits)
+	assert.Empty(t, edits)
+
+	// an unsaved change to a.proto breaks b.proto
+	client.notify("textDocument/didOpen", didOpenTextDocumentParams{
+		TextDocument: textDocumentItem{URI: uriA, LanguageID: "protobuf", Version: 1, Text: testA},
+	})
+	client.diagnostics(uriA)
+	client.diagnostics(uriB)
+	client.notify("textDocument/didChange", didChangeTextDocumentParams{
+		TextDocument: versionedTextDocumentIdentifier{URI: uriA, Version: 2},
+		ContentChanges: []textDocumentContentChangeEvent{{
+			Range: &textRange{Start: position{Line: 5, Character: 8}, End: position{Line: 5, Character: 13}},
+			Text:  "Other",
+		}},
+	})
+	diags = client.diagnostics(uriA)
+	assert.Empty(t, diags.Diagnostics)
+	assert.Equal(t, 2, *diags.Version)
+	diags = client.diagnostics(uriB)
+	require.Len(t, diags.Diagnostics, 2)
+	assert.Equal(t, `method test.Svc.Get: unknown request type Thing`, diags.Diagnostics[0].Message)
+	assert.Equal(t, textRange{Start: position{Line: 7, Character: 10}, End: position{Line: 7, Character: 15}}, diags.Diagnostics[0].Range)
+	assert.Equal(t, severityError, diags.Diagnostics[0].Severity)
+	assert.Equal(t, "unresolved-reference", diags.Diagnostics[0].Code)
+
+	// the formatting edit replaces the whole document
+	client.notify("textDocument/didChange", didChangeTextDocumentParams{
+		TextDocument:   versionedTextDocumentIdentifier{URI: uriA, Version: 3},
+		ContentChanges: []textDocumentContentChangeEvent{{Text: "syntax='proto3';\nmessage  Other{}"}},
+	})
+	client.diagnostics(uriA)
+	client.diagnostics(uriB)
+	client.call("textDocument/formatting", documentFormattingParams{TextDocument: textDocumentIdentifier{URI: uriA}}, &edits)
+	assert.Equal(t, []textEdit{{
+		Range:   textRange{End: position{Line: 1, Character: 16}},
+		NewText: "syntax = 'proto3';\n\nmessage Other {}\n",
+	}}, edits)
+
+	client.call("shutdown", nil, nil)
+	client.notify("exit", nil)
+	assert.Equal(t, 0, client.wait())
+}
+
+func TestServer_Errors(t *testing.T) {
+	t.Parallel()
+	client := startServer(t)
+	resp := client.request("textDocument/hover", textDocumentPositionParams{})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codeServerNotInitialized, resp.Error.Code)
+
+	client.call("initialize", initializeParams{}, nil)
+	resp = client.request("textDocument/unknown", struct{}{})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codeMethodNotFound, resp.Error.Code)
+
+	// exit without shutdown
+	client.notify("exit", nil)
+	assert.Equal(t, 1, client.wait())
+}
+
+func TestDocumentPositions(t *testing.T) {
+	t.Parallel()
+	// "é" is two bytes and one UTF-16 unit, "😀" is four bytes and two units
+	doc := newDocument("file:///a.proto", "/a.proto", 1, []byte("ab\né😀x\n"))
+	for offset, pos := range map[int]position{
+		0:  {Line: 0, Character: 0},
+		2:  {Line: 0, Character: 2},
+		3:  {Line: 1, Character: 0},
+		5:  {Line: 1, Character: 1},
+		9:  {Line: 1, Character: 3},
+		10: {Line: 1, Character: 4},
+		11: {Line: 2, Character: 0},
+	} {
+		assert.Equal(t, pos, doc.position(offset), "offset %d", offset)
+		assert.Equal(t, offset, doc.offset(pos), "position %v", pos)
+	}
+	// past the end of the line
+	assert.Equal(t, 2, doc.offset(position{Line: 0, Character: 10}))
+
+	require.NoError(t, doc.applyChange(textDocumentContentChangeEvent{
+		Range: &textRange{Start: position{Line: 1, Character: 1}, End: position{Line: 1, Character: 3}},
+		Text:  "y",
+	}))
+	assert.Equal(t, "ab\néyx\n", string(doc.text))
+}
+
+type testClient struct {
+	t      *testing.T
+	conn   *conn
+	nextID int
+	// notifications that were read while waiting for a response
+	pending []*message
+	done    chan int
+}
+
+func startServer(t *testing.T, args ...string) *testClient {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	client := &testClient{t: t, conn: newConn(clientIn, clientOut), done: make(chan int, 1)}
+	go func() {
+		var stderr bytes.Buffer
+		code := run(context.Background(), args, serverIn, serverOut, &stderr)
+		_ = serverOut.Close()
+		client.done <- code
+	}()
+	t.Cleanup(func() {
+		_ = clientOut.Close()
+	})
+	return client
+}
+
+// request sends a request and returns its response.
+func (c *testClient) request(method string, params interface{}) *message {
+	c.t.Helper()
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	data, err := json.Marshal(params)
+	require.NoError(c.t, err)
+	require.NoError(c.t, c.conn.write(&message{ID: id, Method: method, Params: data}))
+	for {
+		msg, err := c.conn.read()
+		require.NoError(c.t, err)
+		if msg.Method != "" {
+			c.pending = append(c.pending, msg)
+			continue
+		}
+		require.Equal(c.t, string(id), string(msg.ID))
+		return msg
+	}
+}
+
+// call sends a request and unmarshals its result into result.
+func (c *testClient) call(method string, params, result interface{}) {
+	c.t.Helper()
+	resp := c.request(method, params)
+	require.Nil(c.t, resp.Error)
+	if result != nil {
+		require.NoError(c.t, json.Unmarshal(resp.Result, result))
+	}
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.notify(method, params))
+}
+
+// diagnostics returns the next diagnostics that are published, which must
+// be for the given document.
+func (c *testClient) diagnostics(uri string) *publishDiagnosticsParams {
+	c.t.Helper()
+	var msg *message
+	if len(c.pending) > 0 {
+		msg, c.pending = c.pending[0], c.pending[1:]
+	} else {
+		var err error
+		msg, err = c.conn.read()
+		require.NoError(c.t, err)
+	}
+	require.Equal(c.t, "textDocument/publishDiagnostics", msg.Method)
+	var params publishDiagnosticsParams
+	require.NoError(c.t, json.Unmarshal(msg.Params, &params))
+	require.Equal(c.t, uri, params.URI)
+	return &params
+}
+
+// wait waits for the server to exit and returns its exit code.
+func (c *testClient) wait() int {
+	return <-c.done
+}
@@ -0,0 +1,376 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// errExit is returned by handle when the client sends the exit notification.
+var errExit = errors.New("exit")
+
+type server struct {
+	conn *conn
+	// the import paths from the command line, which are absolute
+	importPaths []string
+	// the open documents, keyed by path
+	docs        map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// serve handles the messages from the client until it exits, and returns
+// the process's exit code.
+func (s *server) serve(ctx context.Context) (int, error) {
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			var respErr *responseError
+			if errors.As(err, &respErr) {
+				// the message isn't valid JSON, so we don't know its ID
+				if err := s.conn.reply(json.RawMessage("null"), nil, respErr); err != nil {
+					return 1, err
+				}
+				continue
+			}
+			if err == io.EOF {
+				// the client went away without asking us to exit
+				return 1, nil
+			}
+			return 1, err
+		}
+		if err := s.handle(ctx, msg); err != nil {
+			if errors.Is(err, errExit) {
+				if s.shutdown {
+					return 0, nil
+				}
+				return 1, nil
+			}
+			return 1, err
+		}
+	}
+}
+
+// handle handles the given message. It only returns an error if the
+// connection fails or the client asks the server to exit.
+func (s *server) handle(ctx context.Context, msg *message) error {
+	if msg.ID == nil {
+		if msg.Method == "" {
+			// a response, but we don't send requests
+			return nil
+		}
+		return s.handleNotification(ctx, msg)
+	}
+	result, err := s.handleRequest(ctx, msg)
+	return s.conn.reply(msg.ID, result, err)
+}
+
+func (s *server) handleRequest(_ context.Context, msg *message) (interface{}, error) {
+	switch {
+	case msg.Method == "initialize":
+		var params initializeParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.initialize(&params)
+	case !s.initialized:
+		return nil, &responseError{Code: codeServerNotInitialized, Message: "server is not initialized"}
+	case s.shutdown:
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is shut down"}
+	}
+	switch msg.Method {
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(&params)
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(&params)
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.documentSymbols(&params)
+	case "textDocument/formatting":
+		var params documentFormattingParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return s.formatting(&params)
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q is not supported", msg.Method)}
+	}
+}
+
+func (s *server) handleNotification(ctx context.Context, msg *message) error {
+	switch msg.Method {
+	case "exit":
+		return errExit
+	case "initialized":
+		return nil
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if unmarshalParams(msg, &params) != nil {
+			// notifications have no response to report the error in
+			return nil
+		}
+		path, pathErr := uriToPath(params.TextDocument.URI)
+		if pathErr != nil {
+			return nil
+		}
+		s.docs[path] = newDocument(params.TextDocument.URI, path, params.TextDocument.Version, []byte(params.TextDocument.Text))
+		return s.compileAll(ctx)
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if unmarshalParams(msg, &params) != nil {
+			// notifications have no response to report the error in
+			return nil
+		}
+		doc := s.document(params.TextDocument.URI)
+		if doc == nil {
+			return nil
+		}
+		for _, change := range params.ContentChanges {
+			if doc.applyChange(change) != nil {
+				return nil
+			}
+		}
+		doc.version = params.TextDocument.Version
+		return s.compileAll(ctx)
+	case "textDocument/didSave":
+		// The buffer was already up-to-date, but other files on disk may
+		// have changed, too.
+		return s.compileAll(ctx)
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if unmarshalParams(msg, &params) != nil {
+			// notifications have no response to report the error in
+			return nil
+		}
+		doc := s.document(params.TextDocument.URI)
+		if doc == nil {
+			return nil
+		}
+		delete(s.docs, doc.path)
+		if err := s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: doc.uri, Diagnostics: []diagnostic{}}); err != nil {
+			return err
+		}
+		// files that import the closed one now see its contents on disk
+		return s.compileAll(ctx)
+	default:
+		// other notifications, like "$/cancelRequest", are ignored
+		return nil
+	}
+}
+
+func unmarshalParams(msg *message, params interface{}) error {
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("invalid params for %s: %v", msg.Method, err)}
+	}
+	return nil
+}
+
+func (s *server) initialize(params *initializeParams) (interface{}, error) {
+	if s.initialized {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is already initialized"}
+	}
+	s.initialized = true
+	if len(s.importPaths) == 0 {
+		// Without import paths on the command line, the roots of the
+		// workspace are the import paths.
+		for _, folder := range params.WorkspaceFolders {
+			if path, err := uriToPath(folder.URI); err == nil {
+				s.importPaths = append(s.importPaths, path)
+			}
+		}
+		if len(s.importPaths) == 0 && params.RootURI != "" {
+			if path, err := uriToPath(params.RootURI); err == nil {
+				s.importPaths = append(s.importPaths, path)
+			}
+		}
+	}
+	return &initializeResult{
+		Capabilities: serverCapabilities{
+			TextDocumentSync: textDocumentSyncOptions{
+				OpenClose: true,
+				Change:    syncIncremental,
+				Save:      true,
+			},
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			DocumentSymbolProvider:     true,
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: serverInfo{Name: "protocompile-lsp", Version: version()},
+	}, nil
+}
+
+func (s *server) document(uri string) *document {
+	path, err := uriToPath(uri)
+	if err != nil {
+		return nil
+	}
+	return s.docs[path]
+}
+
+// importPath returns the import path that the given document is in, and the
+// document's path relative to it, which is its name when compiled. If the
+// document isn't in one of the import paths, its directory is used.
+func (s *server) importPath(doc *document) (string, string) {
+	for _, importPath := range s.importPaths {
+		rel, err := filepath.Rel(importPath, doc.path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return importPath, filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Dir(doc.path), filepath.Base(doc.path)
+}
+
+// compileAll compiles all open documents and publishes their diagnostics.
+// Every document is compiled, since any of them may import one that changed.
+func (s *server) compileAll(ctx context.Context) error {
+	paths := make([]string, 0, len(s.docs))
+	for path := range s.docs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		doc := s.docs[path]
+		diags := s.compile(ctx, doc)
+		version := doc.version
+		params := publishDiagnosticsParams{URI: doc.uri, Version: &version, Diagnostics: diags}
+		if err := s.conn.notify("textDocument/publishDiagnostics", params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compile compiles the given document, with the buffers of all open
+// documents overlaid on the file system, and returns its diagnostics. These
+// are the errors and warnings that the compiler reports for the document,
+// which are the same as for a build with the same import paths.
+func (s *server) compile(ctx context.Context, doc *document) []diagnostic {
+	importPath, name := s.importPath(doc)
+	importPaths := s.importPaths
+	if !containsString(importPaths, importPath) {
+		importPaths = append([]string{importPath}, importPaths...)
+	}
+	// The accessor may be called concurrently, so it uses a copy of the
+	// buffers.
+	overlay := make(map[string][]byte, len(s.docs))
+	for path, doc := range s.docs {
+		overlay[path] = doc.text
+	}
+	diags := []diagnostic{}
+	// the errors in other files, which are only reported if there are none
+	// in the document
+	var others []diagnostic
+	report := func(err reporter.ErrorWithPos, severity int) {
+		if err.GetPosition().Filename != name {
+			if severity == severityError {
+				others = append(others, diagnostic{Severity: severity, Source: "protocompile", Message: err.Error()})
+			}
+			return
+		}
+		diags = append(diags, diagnostic{
+			Range:    doc.spanRange(err),
+			Severity: severity,
+			Code:     string(reporter.CodeOf(err)),
+			Source:   "protocompile",
+			Message:  err.Unwrap().Error(),
+		})
+	}
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: importPaths,
+			Accessor: func(path string) (io.ReadCloser, error) {
+				if abs, err := filepath.Abs(path); err == nil {
+					if text, ok := overlay[abs]; ok {
+						return io.NopCloser(bytes.NewReader(text)), nil
+					}
+				}
+				return os.Open(path)
+			},
+		}),
+		Reporter: reporter.NewReporter(
+			func(err reporter.ErrorWithPos) error {
+				report(err, severityError)
+				return nil
+			},
+			func(err reporter.ErrorWithPos) {
+				report(err, severityWarning)
+			},
+		),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+		RetainASTs:     true,
+		// so that the document can still be navigated while it has errors
+		AllowUnresolvable: true,
+	}
+	files, err := compiler.Compile(ctx, name)
+	doc.result = nil
+	if len(files) > 0 {
+		doc.result, _ = files[0].(linker.Result)
+	}
+	if err != nil && !hasErrors(diags) {
+		if len(others) > 0 {
+			// errors in the files that the document imports
+			return append(diags, others...)
+		}
+		// not an error in the source, like a failure to read the file
+		diags = append(diags, diagnostic{Severity: severityError, Source: "protocompile", Message: err.Error()})
+	}
+	return diags
+}
+
+func hasErrors(diags []diagnostic) bool {
+	for _, diag := range diags {
+		if diag.Severity == severityError {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const usage = `Usage: protocompile [OPTION] PROTO_FILES
+Parse PROTO_FILES and generate output based on the options given:
+  -IPATH, --proto_path=PATH   Specify the directory in which to search for
+                              imports.  May be specified multiple times;
+                              directories will be searched in order.  If not
+                              given, the current working directory is used.
+  --version                   Show version info and exit.
+  -h, --help                  Show this text and exit.
+  --descriptor_set_in=FILES   Specifies a delimited list of FILES
+                              each containing a FileDescriptorSet (a
+                              protocol buffer defined in descriptor.proto).
+                              The FileDescriptor for each of the PROTO_FILES
+                              provided will be loaded from these
+                              FileDescriptorSets. If a FileDescriptor
+                              appears multiple times, the first occurrence
+                              will be used.
+  -oFILE,                     Writes a FileDescriptorSet (a protocol buffer,
+    --descriptor_set_out=FILE defined in descriptor.proto) containing all of
+                              the input files to FILE.
+  --include_imports           When using --descriptor_set_out, also include
+                              all dependencies of the input files in the
+                              set, so that the set is self-contained.
+  --include_source_info       When using --descriptor_set_out, do not strip
+                              SourceCodeInfo from the FileDescriptorProto.
+                              This results in vastly larger descriptors that
+                              include information about the original
+                              location of each decl in the source file as
+                              well as surrounding comments.
+  --retain_options            When using --descriptor_set_out, do not strip
+                              any options from the FileDescriptorProto.
+                              This results in potentially larger descriptors
+                              that include information about options that were
+                              only meant to be useful during compilation.
+  --edition_defaults_out=FILE Writes a FeatureSetDefaults (a protocol
+                              buffer, defined in descriptor.proto) containing
+                              the defaults of all features, including custom
+                              features defined in the input files, to FILE.
+  --edition_defaults_minimum=EDITION
+                              The minimum edition to include in the
+                              FeatureSetDefaults. Defaults to PROTO2.
+  --edition_defaults_maximum=EDITION
+                              The maximum edition to include in the
+                              FeatureSetDefaults. Defaults to 2023.
+  --error_format=FORMAT       Set the format in which to print errors.
+                              FORMAT may be 'gcc' (the default) or 'msvs'
+                              (Microsoft Visual Studio format).
+  --plugin=EXECUTABLE         Specifies a plugin executable to use.
+                              Normally, protocompile searches the PATH for
+                              plugins, but you may specify additional
+                              executables not in the path using this flag.
+                              Additionally, EXECUTABLE may be of the form
+                              NAME=PATH, in which case the given plugin name
+                              is mapped to the given executable even if
+                              the executable's own name differs.
+  --NAME_out=[PARAMS:]DIR     Runs the plugin protoc-gen-NAME and writes the
+                              files it generates to DIR. PARAMS, if given,
+                              are passed to the plugin as its parameter.
+  --NAME_opt=PARAMS           Passes additional parameters to the plugin
+                              protoc-gen-NAME. May be specified multiple
+                              times; all parameters are joined with commas.
+`
+
+// errorFormat indicates how errors and warnings are printed.
+type errorFormat int
+
+const (
+	errorFormatGCC = errorFormat(iota)
+	errorFormatMSVS
+)
+
+// config is the configuration given via command-line arguments.
+type config struct {
+	importPaths       []string
+	descriptorSetIn   []string
+	descriptorSetOut  string
+	includeImports    bool
+	includeSourceInfo bool
+	retainOptions     bool
+	errorFormat       errorFormat
+	// output file for --edition_defaults_out and its edition range
+	editionDefaultsOut string
+	editionDefaultsMin descriptorpb.Edition
+	editionDefaultsMax descriptorpb.Edition
+	inputs             []string
+	// plugins to run, in the order their output flags were given
+	plugins []pluginConfig
+	// plugin executables given via --plugin, keyed by plugin name
+	pluginPaths map[string]string
+
+	printHelp    bool
+	printVersion bool
+}
+
+// pluginConfig is the configuration for running a single plugin.
+type pluginConfig struct {
+	name      string
+	outDir    string
+	parameter string
+}
+
+// parseArgs parses the given command-line arguments, using the same
+// conventions as protoc: flags with values can be given as "--name=value"
+// or "--name value", and short flags as "-Xvalue" or "-X value".
+func parseArgs(args []string) (*config, error) {
+	opts := &config{
+		editionDefaultsMin: descriptorpb.Edition_EDITION_PROTO2,
+		editionDefaultsMax: descriptorpb.Edition_EDITION_2023,
+	}
+	pluginParams := map[string][]string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			opts.inputs = append(opts.inputs, arg)
+			continue
+		}
+
+		var name, value string
+		var hasValue bool
+		if strings.HasPrefix(arg, "--") {
+			name, value, hasValue = strings.Cut(arg, "=")
+		} else {
+			// short flags: "-Ipath" or "-I path"
+			name = arg[:2]
+			if len(arg) > 2 {
+				value, hasValue = arg[2:], true
+			}
+		}
+
+		switch name {
+		case "-h", "--help":
+			opts.printHelp = true
+			continue
+		case "--version":
+			opts.printVersion = true
+			continue
+		case "--include_imports", "--include_source_info", "--retain_options":
+			if hasValue {
+				return nil, fmt.Errorf("%s does not take a parameter", name)
+			}
+			switch name {
+			case "--include_imports":
+				opts.includeImports = true
+			case "--include_source_info":
+				opts.includeSourceInfo = true
+			default:
+				opts.retainOptions = true
+			}
+			continue
+		case "-I", "--proto_path", "--descriptor_set_in", "-o", "--descriptor_set_out", "--error_format", "--plugin",
+			"--edition_defaults_out", "--edition_defaults_minimum", "--edition_defaults_maximum":
+		default:
+			if !isPluginFlag(name) {
+				return nil, fmt.Errorf("Unknown flag: %s", name) //nolint:stylecheck // matches protoc
+			}
+		}
+
+		if !hasValue {
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("Missing value for flag: %s", name) //nolint:stylecheck // matches protoc
+			}
+			value = args[i]
+		}
+		switch name {
+		case "-I", "--proto_path":
+			opts.importPaths = append(opts.importPaths, filepath.SplitList(value)...)
+		case "--descriptor_set_in":
+			if len(opts.descriptorSetIn) > 0 {
+				return nil, fmt.Errorf("%s may only be passed once", name)
+			}
+			opts.descriptorSetIn = filepath.SplitList(value)
+		case "-o", "--descriptor_set_out":
+			if opts.descriptorSetOut != "" {
+				return nil, fmt.Errorf("%s may only be passed once", name)
+			}
+			opts.descriptorSetOut = value
+		case "--error_format":
+			switch value {
+			case "gcc":
+				opts.errorFormat = errorFormatGCC
+			case "msvs":
+				opts.errorFormat = errorFormatMSVS
+			default:
+				return nil, fmt.Errorf("Unknown error format: %s", value) //nolint:stylecheck // matches protoc
+			}
+		case "--edition_defaults_out":
+			if opts.editionDefaultsOut != "" {
+				return nil, fmt.Errorf("%s may only be passed once", name)
+			}
+			opts.editionDefaultsOut = value
+		case "--edition_defaults_minimum", "--edition_defaults_maximum":
+			edition, ok := descriptorpb.Edition_value["EDITION_"+value]
+			if !ok {
+				return nil, fmt.Errorf("%s is not a valid edition", value)
+			}
+			if name == "--edition_defaults_minimum" {
+				opts.editionDefaultsMin = descriptorpb.Edition(edition)
+			} else {
+				opts.editionDefaultsMax = descriptorpb.Edition(edition)
+			}
+		case "--plugin":
+			pluginName, path, ok := strings.Cut(value, "=")
+			if !ok {
+				// name is derived from the executable, which
+				// must be named "protoc-gen-NAME"
+				path = value
+				pluginName = strings.TrimSuffix(filepath.Base(value), ".exe")
+			}
+			if opts.pluginPaths == nil {
+				opts.pluginPaths = map[string]string{}
+			}
+			opts.pluginPaths[strings.TrimPrefix(pluginName, "protoc-gen-")] = path
+		default:
+			pluginName := strings.TrimPrefix(name[:len(name)-len("_out")], "--")
+			if strings.HasSuffix(name, "_opt") {
+				pluginParams[pluginName] = append(pluginParams[pluginName], value)
+				continue
+			}
+			plugin := pluginConfig{name: pluginName, outDir: value}
+			// Parameters precede the directory, separated by a colon. But the colon
+			// in a Windows volume name, like "C:", is part of the directory.
+			if pos := strings.LastIndexByte(value, ':'); pos >= len(filepath.VolumeName(value)) {
+				plugin.parameter, plugin.outDir = value[:pos], value[pos+1:]
+			}
+			opts.plugins = append(opts.plugins, plugin)
+		}
+	}
+	for i, plugin := range opts.plugins {
+		params := pluginParams[plugin.name]
+		if plugin.parameter != "" {
+			params = append([]string{plugin.parameter}, params...)
+		}
+		opts.plugins[i].parameter = strings.Join(params, ",")
+	}
+
+	if opts.printHelp || opts.printVersion {
+		return opts, nil
+	}
+	if len(opts.inputs) == 0 {
+		return nil, errors.New("Missing input file.") //nolint:stylecheck // matches protoc
+	}
+	if opts.descriptorSetOut == "" && opts.editionDefaultsOut == "" && len(opts.plugins) == 0 {
+		return nil, errors.New("Missing output directives.") //nolint:stylecheck // matches protoc
+	}
+	if len(opts.importPaths) == 0 && len(opts.descriptorSetIn) == 0 {
+		opts.importPaths = []string{"."}
+	}
+	return opts, nil
+}
+
+// isPluginFlag returns true if the given flag name is "--NAME_out" or
+// "--NAME_opt", for running the plugin protoc-gen-NAME.
+func isPluginFlag(name string) bool {
+	if !strings.HasPrefix(name, "--") {
+		return false
+	}
+	return len(name) > len("--_out") && (strings.HasSuffix(name, "_out") || strings.HasSuffix(name, "_opt"))
+}
@@ -0,0 +1,310 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command protocompile is a protobuf compiler that accepts the same
+// command-line flags as protoc, the reference compiler. It can be used
+// in place of protoc to produce descriptor sets and to run plugins that
+// generate code.
+//
+// Errors are printed to stderr in the same formats as protoc. The exit
+// code is non-zero if any file could not be compiled.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/internal/descset"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/protoplugin"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the compiler with the given arguments and returns the process's
+// exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	opts, err := parseArgs(args)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if opts.printHelp {
+		_, _ = fmt.Fprint(stdout, usage)
+		return 0
+	}
+	if opts.printVersion {
+		_, _ = fmt.Fprintf(stdout, "protocompile %s\n", version())
+		return 0
+	}
+
+	var resolvers protocompile.CompositeResolver
+	if len(opts.descriptorSetIn) > 0 {
+		res, err := loadDescriptorSets(opts.descriptorSetIn)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		resolvers = append(resolvers, res)
+	}
+	if len(opts.importPaths) > 0 {
+		resolvers = append(resolvers, &protocompile.SourceResolver{ImportPaths: opts.importPaths})
+	}
+
+	names := make([]string, len(opts.inputs))
+	for i, input := range opts.inputs {
+		name, err := virtualPath(input, opts.importPaths)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		names[i] = name
+	}
+
+	printed := map[string]struct{}{}
+	printMessage := func(msg string) {
+		if _, ok := printed[msg]; ok {
+			return
+		}
+		printed[msg] = struct{}{}
+		_, _ = fmt.Fprintln(stderr, msg)
+	}
+	sourceInfoMode := protocompile.SourceInfoNone
+	if opts.includeSourceInfo || len(opts.plugins) > 0 {
+		sourceInfoMode = protocompile.SourceInfoStandard
+	}
+	compiler := &protocompile.Compiler{
+		Resolver:       protocompile.WithStandardImports(resolvers),
+		SourceInfoMode: sourceInfoMode,
+		Reporter: reporter.NewReporter(
+			func(err reporter.ErrorWithPos) error {
+				printMessage(formatError(opts.errorFormat, err, false))
+				return nil
+			},
+			func(err reporter.ErrorWithPos) {
+				printMessage(formatError(opts.errorFormat, err, true))
+			},
+		),
+	}
+	results, err := compiler.CompileAll(ctx, names...)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	for _, res := range results.Files {
+		switch {
+		case res.Err == nil, len(res.Errors) > 0, errors.Is(res.Err, reporter.ErrInvalidSource):
+			// success, or errors were already printed by the reporter
+		case res.FailedImport == "" && (errors.Is(res.Err, fs.ErrNotExist) || errors.Is(res.Err, protoregistry.NotFound)):
+			printMessage(res.Path + ": File not found.")
+		default:
+			var errWithPos reporter.ErrorWithPos
+			if errors.As(res.Err, &errWithPos) {
+				printMessage(formatError(opts.errorFormat, errWithPos, false))
+			} else {
+				printMessage(res.Err.Error())
+			}
+		}
+	}
+	if results.Err() != nil {
+		return 1
+	}
+
+	if err := runPlugins(ctx, results.Linked(), opts, stderr); err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if opts.editionDefaultsOut != "" {
+		defaults, err := linker.ComputeFeatureSetDefaults(results.Linked(), opts.editionDefaultsMin, opts.editionDefaultsMax)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		if err := writeMessage(opts.editionDefaultsOut, defaults); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	if opts.descriptorSetOut != "" {
+		fds, err := descriptorSet(results.Linked(), opts)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		if err := writeMessage(opts.descriptorSetOut, fds); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// writeMessage writes the given message, in binary format, to the given file.
+func writeMessage(path string, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o666); err != nil { //nolint:gosec // same permissions as protoc
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// runPlugins runs the configured plugins, in order, for the given files. Like
+// protoc, generated files are only written once all plugins have succeeded,
+// so that later plugins can insert content into files from earlier ones.
+func runPlugins(ctx context.Context, files linker.Files, opts *config, stderr io.Writer) error {
+	var dirs []string
+	outputs := map[string]*protoplugin.Output{}
+	for _, cfg := range opts.plugins {
+		req, err := protoplugin.NewRequest(files, cfg.parameter)
+		if err != nil {
+			return err
+		}
+		plugin := &protoplugin.Plugin{Name: cfg.name, Path: opts.pluginPaths[cfg.name], Stderr: stderr}
+		resp, err := plugin.Run(ctx, req)
+		if err != nil {
+			return fmt.Errorf("--%s_out: %w", cfg.name, err)
+		}
+		output := outputs[cfg.outDir]
+		if output == nil {
+			output = &protoplugin.Output{}
+			outputs[cfg.outDir] = output
+			dirs = append(dirs, cfg.outDir)
+		}
+		if err := output.Add(resp); err != nil {
+			return fmt.Errorf("--%s_out: %w", cfg.name, err)
+		}
+	}
+	for _, dir := range dirs {
+		if err := outputs[dir].WriteToDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// formatError formats the given error or warning in the given format.
+func formatError(format errorFormat, err reporter.ErrorWithPos, isWarning bool) string {
+	pos := err.GetPosition()
+	msg := err.Unwrap().Error()
+	if format == errorFormatMSVS {
+		kind := "error"
+		if isWarning {
+			kind = "warning"
+		}
+		return fmt.Sprintf("%s(%d) : %s in column=%d: %s", pos.Filename, pos.Line, kind, pos.Col, msg)
+	}
+	if isWarning {
+		return fmt.Sprintf("%s:%d:%d: warning: %s", pos.Filename, pos.Line, pos.Col, msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", pos.Filename, pos.Line, pos.Col, msg)
+}
+
+// virtualPath returns the name of the given input file, relative to the import
+// path that contains it. If the file is not on disk, the input is assumed to
+// already be relative to an import path (or to be a file in a descriptor set).
+func virtualPath(input string, importPaths []string) (string, error) {
+	absInput, err := filepath.Abs(input)
+	if err != nil {
+		return "", err
+	}
+	for _, importPath := range importPaths {
+		absImportPath, err := filepath.Abs(importPath)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(absImportPath, absInput)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return filepath.ToSlash(rel), nil
+	}
+	if _, err := os.Stat(input); err == nil && len(importPaths) > 0 {
+		return "", fmt.Errorf("%s: File does not reside within any path specified using --proto_path (or -I).  "+
+			"You must specify a --proto_path which encompasses this file.", input)
+	}
+	return filepath.ToSlash(input), nil
+}
+
+// loadDescriptorSets returns a resolver for the files in the descriptor sets
+// at the given paths. If a file appears in more than one set, the first
+// occurrence is used.
+func loadDescriptorSets(paths []string) (protocompile.Resolver, error) {
+	merged := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]struct{}{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var fds descriptorpb.FileDescriptorSet
+		if err := proto.Unmarshal(data, &fds); err != nil {
+			return nil, fmt.Errorf("%s: Unable to parse.", path) //nolint:stylecheck // matches protoc
+		}
+		for _, fd := range fds.File {
+			if _, ok := seen[fd.GetName()]; ok {
+				continue
+			}
+			seen[fd.GetName()] = struct{}{}
+			merged.File = append(merged.File, fd)
+		}
+	}
+	return protocompile.NewDescriptorSetResolver(merged)
+}
+
+// descriptorSet returns a descriptor set with the given files. If the options
+// indicate that imports should be included, files appear in topological
+// order, so every file appears after all of its dependencies.
+func descriptorSet(files linker.Files, opts *config) (*descriptorpb.FileDescriptorSet, error) {
+	if opts.includeImports {
+		files = descset.Transitive(files)
+	}
+	fds := &descriptorpb.FileDescriptorSet{File: make([]*descriptorpb.FileDescriptorProto, len(files))}
+	for i, file := range files {
+		fd, err := descset.FileProto(file, opts.retainOptions)
+		if err != nil {
+			return nil, err
+		}
+		if !opts.includeSourceInfo && fd.SourceCodeInfo != nil {
+			fd = proto.Clone(fd).(*descriptorpb.FileDescriptorProto) //nolint:errcheck
+			fd.SourceCodeInfo = nil
+		}
+		fds.File[i] = fd
+	}
+	return fds, nil
+}
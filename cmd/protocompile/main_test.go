@@ -0,0 +1,315 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// When the test binary is run with this environment variable set, it acts as
+// a plugin instead of running tests.
+const testPluginEnv = "PROTOCOMPILE_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) != "" {
+		runTestPlugin()
+		return
+	}
+	if err := os.Setenv(testPluginEnv, "1"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin generates a file for each file to generate, listing its
+// messages. If the parameter is "insert", it instead inserts the parameter
+// into those files.
+func runTestPlugin() {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		panic(err)
+	}
+	var req pluginpb.CodeGeneratorRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		panic(err)
+	}
+	var resp pluginpb.CodeGeneratorResponse
+	for _, fd := range req.ProtoFile {
+		if fd.GetName() != req.FileToGenerate[0] {
+			continue
+		}
+		file := &pluginpb.CodeGeneratorResponse_File{
+			Name: proto.String(strings.TrimSuffix(fd.GetName(), ".proto") + ".txt"),
+		}
+		if req.GetParameter() == "insert" {
+			file.InsertionPoint = proto.String("end")
+			file.Content = proto.String("inserted\n")
+		} else {
+			var sb strings.Builder
+			_, _ = fmt.Fprintf(&sb, "params: %s\n", req.GetParameter())
+			for _, msg := range fd.MessageType {
+				_, _ = fmt.Fprintf(&sb, "message %s\n", msg.GetName())
+			}
+			sb.WriteString("@@protoc_insertion_point(end)\n")
+			file.Content = proto.String(sb.String())
+		}
+		resp.File = append(resp.File, file)
+	}
+	data, err = proto.Marshal(&resp)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := os.Stdout.Write(data); err != nil {
+		panic(err)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	t.Parallel()
+	opts, err := parseArgs([]string{
+		"-Ifoo", "-I", "bar", "--proto_path=baz", "--proto_path", "a" + string(filepath.ListSeparator) + "b",
+		"-oout.pb", "--include_imports", "--error_format=msvs", "x.proto", "y.proto",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar", "baz", "a", "b"}, opts.importPaths)
+	assert.Equal(t, "out.pb", opts.descriptorSetOut)
+	assert.True(t, opts.includeImports)
+	assert.False(t, opts.includeSourceInfo)
+	assert.Equal(t, errorFormatMSVS, opts.errorFormat)
+	assert.Equal(t, []string{"x.proto", "y.proto"}, opts.inputs)
+
+	opts, err = parseArgs([]string{"--descriptor_set_out", "out.pb", "x.proto"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"."}, opts.importPaths)
+
+	opts, err = parseArgs([]string{
+		"--foo_opt=c", "--foo_out=a,b:out", "--plugin=bin/protoc-gen-foo", "--bar_out", "out2",
+		"--plugin=protoc-gen-bar=baz", "--foo_opt", "d", "x.proto",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []pluginConfig{
+		{name: "foo", outDir: "out", parameter: "a,b,c,d"},
+		{name: "bar", outDir: "out2"},
+	}, opts.plugins)
+	assert.Equal(t, map[string]string{"foo": "bin/protoc-gen-foo", "bar": "baz"}, opts.pluginPaths)
+	assert.Empty(t, opts.descriptorSetOut)
+
+	opts, err = parseArgs([]string{"--edition_defaults_out=defaults.pb", "--edition_defaults_maximum", "PROTO3", "x.proto"})
+	require.NoError(t, err)
+	assert.Equal(t, "defaults.pb", opts.editionDefaultsOut)
+	assert.Equal(t, descriptorpb.Edition_EDITION_PROTO2, opts.editionDefaultsMin)
+	assert.Equal(t, descriptorpb.Edition_EDITION_PROTO3, opts.editionDefaultsMax)
+
+	testCases := map[string][]string{
+		"Unknown flag: --foo":                         {"--foo", "-oout.pb", "x.proto"},
+		"Missing value for flag: -o":                  {"x.proto", "-o"},
+		"Missing input file.":                         {"-oout.pb"},
+		"Missing output directives.":                  {"x.proto"},
+		"Unknown error format: json":                  {"--error_format=json", "-oout.pb", "x.proto"},
+		"--include_imports does not take a parameter": {"--include_imports=true", "-oout.pb", "x.proto"},
+		"2025 is not a valid edition":                 {"--edition_defaults_out=out.pb", "--edition_defaults_maximum=2025", "x.proto"},
+	}
+	for expectedErr, args := range testCases {
+		_, err := parseArgs(args)
+		assert.EqualError(t, err, expectedErr)
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "protos/foo/a.proto"), `
+		syntax = "proto3";
+		package foo;
+		import "google/protobuf/descriptor.proto";
+		import "foo/b.proto";
+		extend google.protobuf.MessageOptions {
+			string tag = 50000 [retention = RETENTION_SOURCE];
+		}
+		// A is a message.
+		message A {
+			option (tag) = "abc";
+			B b = 1;
+		}`)
+	writeFile(t, filepath.Join(dir, "protos/foo/b.proto"), `
+		syntax = "proto3";
+		package foo;
+		message B {}`)
+	out := filepath.Join(dir, "out.pb")
+
+	var stderr bytes.Buffer
+	code := run(context.Background(), []string{
+		"-I", filepath.Join(dir, "protos"), "-o", out,
+		filepath.Join(dir, "protos/foo/a.proto"),
+	}, &bytes.Buffer{}, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	fds := readDescriptorSet(t, out)
+	require.Len(t, fds.File, 1)
+	assert.Equal(t, "foo/a.proto", fds.File[0].GetName())
+	assert.Nil(t, fds.File[0].SourceCodeInfo)
+	// source-retention option was stripped
+	assert.Nil(t, fds.File[0].MessageType[0].Options)
+
+	code = run(context.Background(), []string{
+		"--proto_path=" + filepath.Join(dir, "protos"), "--descriptor_set_out=" + out,
+		"--include_imports", "--include_source_info", "--retain_options", "foo/a.proto",
+	}, &bytes.Buffer{}, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	fds = readDescriptorSet(t, out)
+	var names []string
+	for _, fd := range fds.File {
+		names = append(names, fd.GetName())
+	}
+	assert.Equal(t, []string{"google/protobuf/descriptor.proto", "foo/b.proto", "foo/a.proto"}, names)
+	assert.NotNil(t, fds.File[2].SourceCodeInfo)
+	assert.NotNil(t, fds.File[2].MessageType[0].Options)
+
+	// Now use that output as input, with no sources.
+	out2 := filepath.Join(dir, "out2.pb")
+	code = run(context.Background(), []string{
+		"--descriptor_set_in=" + out, "-o", out2, "foo/a.proto",
+	}, &bytes.Buffer{}, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	fds = readDescriptorSet(t, out2)
+	require.Len(t, fds.File, 1)
+	assert.Equal(t, "foo/a.proto", fds.File[0].GetName())
+	assert.Empty(t, stderr.String())
+}
+
+func TestRun_Plugins(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "foo/a.proto"), `
+		syntax = "proto3";
+		package foo;
+		message A {}
+		message B {}`)
+	out := filepath.Join(dir, "out")
+	require.NoError(t, os.Mkdir(out, 0o755))
+
+	var stderr bytes.Buffer
+	code := run(context.Background(), []string{
+		"-I", dir, "--plugin=protoc-gen-test=" + os.Args[0], "--plugin=protoc-gen-insert=" + os.Args[0],
+		"--test_out=x:" + out, "--test_opt=y", "--insert_out=insert:" + out, "foo/a.proto",
+	}, &bytes.Buffer{}, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	data, err := os.ReadFile(filepath.Join(out, "foo/a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "params: x,y\nmessage A\nmessage B\ninserted\n@@protoc_insertion_point(end)\n", string(data))
+
+	stderr.Reset()
+	code = run(context.Background(), []string{
+		"-I", dir, "--plugin=protoc-gen-insert=" + os.Args[0], "--insert_out=insert:" + out, "foo/a.proto",
+	}, &bytes.Buffer{}, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "--insert_out: foo/a.txt: Tried to insert into file that doesn't exist.\n", stderr.String())
+}
+
+func TestRun_EditionDefaults(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "features.proto"), `
+		edition = "2023";
+		import "google/protobuf/descriptor.proto";
+		extend google.protobuf.FeatureSet {
+			Features custom = 9995;
+		}
+		message Features {
+			bool flag = 1 [
+				targets = TARGET_TYPE_FILE,
+				edition_defaults = { edition: EDITION_PROTO2, value: "true" }
+			];
+		}`)
+	out := filepath.Join(dir, "defaults.pb")
+
+	var stderr bytes.Buffer
+	code := run(context.Background(), []string{
+		"-I", dir, "--edition_defaults_out=" + out, "--edition_defaults_minimum=2023", "features.proto",
+	}, &bytes.Buffer{}, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var defaults descriptorpb.FeatureSetDefaults
+	require.NoError(t, proto.Unmarshal(data, &defaults))
+	assert.Equal(t, descriptorpb.Edition_EDITION_2023, defaults.GetMinimumEdition())
+	assert.Equal(t, descriptorpb.Edition_EDITION_2023, defaults.GetMaximumEdition())
+	require.Len(t, defaults.Defaults, 3)
+	// the custom feature is present, as an unrecognized field
+	assert.NotEmpty(t, defaults.Defaults[0].GetFeatures().ProtoReflect().GetUnknown())
+}
+
+func TestRun_Errors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.proto"), `syntax = "proto3";
+message A {
+  B b = 1;
+  C c = 2;
+}`)
+	writeFile(t, filepath.Join(dir, "b.proto"), `syntax = "proto3";
+import "c.proto";`)
+	out := filepath.Join(dir, "out.pb")
+
+	var stderr bytes.Buffer
+	code := run(context.Background(), []string{"-I", dir, "-o", out, "a.proto", "b.proto", "d.proto"}, &bytes.Buffer{}, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, `a.proto:3:3: field A.b: unknown type B
+a.proto:4:3: field A.c: unknown type C
+b.proto:2:8: open `+filepath.Join(dir, "c.proto")+`: no such file or directory
+d.proto: File not found.
+`, stderr.String())
+	_, err := os.Stat(out)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	stderr.Reset()
+	code = run(context.Background(), []string{"-I", dir, "-o", out, "--error_format=msvs", "a.proto"}, &bytes.Buffer{}, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, `a.proto(3) : error in column=3: field A.b: unknown type B
+a.proto(4) : error in column=3: field A.c: unknown type C
+`, stderr.String())
+
+	stderr.Reset()
+	code = run(context.Background(), []string{"-I", filepath.Join(dir, "other"), "-o", out, filepath.Join(dir, "a.proto")}, &bytes.Buffer{}, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "File does not reside within any path specified using --proto_path (or -I).")
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644)) //nolint:gosec
+}
+
+func readDescriptorSet(t *testing.T, path string) *descriptorpb.FileDescriptorSet {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var fds descriptorpb.FileDescriptorSet
+	require.NoError(t, proto.Unmarshal(data, &fds))
+	return &fds
+}
@@ -0,0 +1,132 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command protofmt formats proto source files, like gofmt does for Go. See
+// package format for the formatting conventions.
+//
+// Usage:
+//
+//	protofmt [-l] [-w] [path ...]
+//
+// Paths may be files or directories. Directories are searched recursively
+// for files with a ".proto" extension. Without any paths, protofmt formats
+// its standard input. By default, the formatted source is printed to stdout.
+//
+// The -l flag lists the files whose formatting differs from protofmt's
+// instead, and -w rewrites those files in place. The exit code is non-zero
+// if any file could not be parsed.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bufbuild/protocompile/format"
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run formats the files named in the given arguments and returns the
+// process's exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("protofmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	list := flags.Bool("l", false, "list files whose formatting differs from protofmt's")
+	write := flags.Bool("w", false, "write the result to the files instead of to stdout")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			_, _ = fmt.Fprintln(stderr, "cannot use -w with standard input")
+			return 1
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		if err := formatFile("<standard input>", src, *list, false, stdout); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	exitCode := 0
+	for _, arg := range flags.Args() {
+		err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if d.IsDir() || (path != arg && filepath.Ext(path) != ".proto") {
+				return nil
+			}
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := formatFile(path, src, *list, *write, stdout); err != nil {
+				// report the error, but keep going with other files
+				_, _ = fmt.Fprintln(stderr, err)
+				exitCode = 1
+			}
+			return nil
+		})
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+func formatFile(path string, src []byte, list, write bool, stdout io.Writer) error {
+	formatted, err := format.Source(path, src)
+	if err != nil {
+		return err
+	}
+	if !list && !write {
+		_, err := stdout.Write(formatted)
+		return err
+	}
+	if bytes.Equal(src, formatted) {
+		return nil
+	}
+	if list {
+		if _, err := fmt.Fprintln(stdout, path); err != nil {
+			return err
+		}
+	}
+	if write {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, formatted, info.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFile := func(name, contents string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644))
+	}
+	writeFile("a.proto", "syntax = \"proto3\";\n\nmessage A {}\n")
+	writeFile("sub/b.proto", "syntax=\"proto3\";message B{string name=1;}")
+	writeFile("sub/notes.txt", "not a proto file")
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-l", dir}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, filepath.Join(dir, "sub", "b.proto")+"\n", stdout.String())
+
+	stdout.Reset()
+	code = run(context.Background(), []string{filepath.Join(dir, "sub", "b.proto")}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	const formatted = `syntax = "proto3";
+
+message B {
+  string name = 1;
+}
+`
+	assert.Equal(t, formatted, stdout.String())
+
+	stdout.Reset()
+	code = run(context.Background(), []string{"-w", dir}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Empty(t, stdout.String())
+	data, err := os.ReadFile(filepath.Join(dir, "sub", "b.proto"))
+	require.NoError(t, err)
+	assert.Equal(t, formatted, string(data))
+	data, err = os.ReadFile(filepath.Join(dir, "sub", "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "not a proto file", string(data))
+
+	code = run(context.Background(), []string{"-l", dir}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Empty(t, stdout.String())
+}
+
+func TestRun_Stdin(t *testing.T) {
+	t.Parallel()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), nil, strings.NewReader("syntax='proto2';"), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "syntax = 'proto2';\n", stdout.String())
+
+	stdout.Reset()
+	code = run(context.Background(), nil, strings.NewReader("message {"), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "<standard input>:1:9: syntax error")
+}
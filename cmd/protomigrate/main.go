@@ -0,0 +1,131 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command protomigrate rewrites proto files that use proto2 or proto3 syntax
+// so that they use editions instead. See package migrate for details.
+//
+// Each file is compiled, migrated, and then verified: the migrated source is
+// compiled again and compared to the original. Files that are already using
+// editions are skipped. By default, the migrated source is printed to stdout.
+// With -w, the files are rewritten in place.
+//
+// Usage:
+//
+//	protomigrate [-I path]... [-w] file...
+//
+// File names are relative to an import path, like with protoc. The exit code
+// is non-zero if any file could not be migrated.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/migrate"
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+type importPaths []string
+
+func (p *importPaths) String() string {
+	return strings.Join(*p, string(filepath.ListSeparator))
+}
+
+func (p *importPaths) Set(s string) error {
+	*p = append(*p, filepath.SplitList(s)...)
+	return nil
+}
+
+// run migrates the files named in the given arguments and returns the
+// process's exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("protomigrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var paths importPaths
+	flags.Var(&paths, "I", "directory in which to search for imports; may be repeated")
+	write := flags.Bool("w", false, "write the migrated source to the files instead of to stdout")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if flags.NArg() == 0 {
+		_, _ = fmt.Fprintln(stderr, "missing input file")
+		return 1
+	}
+	if len(paths) == 0 {
+		paths = importPaths{"."}
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: paths,
+		}),
+		RetainASTs: true,
+	}
+	files, err := compiler.Compile(ctx, flags.Args()...)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	exitCode := 0
+	for _, file := range files {
+		res, ok := file.(linker.Result)
+		if !ok {
+			_, _ = fmt.Fprintf(stderr, "%s: not compiled from source\n", file.Path())
+			exitCode = 1
+			continue
+		}
+		if res.FileDescriptorProto().GetSyntax() == "editions" {
+			_, _ = fmt.Fprintf(stderr, "%s: skipped, already uses editions\n", file.Path())
+			continue
+		}
+		if err := migrateFile(ctx, res, paths, *write, stdout); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+func migrateFile(ctx context.Context, file linker.Result, paths []string, write bool, stdout io.Writer) error {
+	migrated, err := migrate.ToEditions(file)
+	if err != nil {
+		return err
+	}
+	if err := migrate.Verify(ctx, file, migrated); err != nil {
+		return fmt.Errorf("%s: migrated file is not equivalent: %w", file.Path(), err)
+	}
+	if !write {
+		_, err := stdout.Write(migrated)
+		return err
+	}
+	for _, dir := range paths {
+		name := filepath.Join(dir, filepath.FromSlash(file.Path()))
+		info, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		return os.WriteFile(name, migrated, info.Mode().Perm())
+	}
+	return fmt.Errorf("%s: could not find file in import paths", file.Path())
+}
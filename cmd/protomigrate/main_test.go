@@ -0,0 +1,71 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFile := func(name, contents string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644))
+	}
+	writeFile("a.proto", `syntax = "proto3";
+import "b.proto";
+message A { optional B b = 1; }
+`)
+	writeFile("b.proto", `syntax = "proto2";
+message B { required string name = 1; }
+`)
+	writeFile("c.proto", `edition = "2023";
+message C {}
+`)
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-I", dir, "a.proto"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, `edition = "2023";
+import "b.proto";
+message A { B b = 1; }
+`, stdout.String())
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run(context.Background(), []string{"-I", dir, "-w", "b.proto", "c.proto"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Empty(t, stdout.String())
+	assert.Equal(t, "c.proto: skipped, already uses editions\n", stderr.String())
+	data, err := os.ReadFile(filepath.Join(dir, "b.proto"))
+	require.NoError(t, err)
+	assert.Equal(t, `edition = "2023";
+
+option features.json_format = LEGACY_BEST_EFFORT;
+message B { string name = 1 [features.field_presence = LEGACY_REQUIRED, features.utf8_validation = NONE]; }
+`, string(data))
+
+	stderr.Reset()
+	code = run(context.Background(), []string{"-I", dir, "missing.proto"}, &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "missing.proto")
+}
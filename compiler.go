@@ -17,18 +17,21 @@ package protocompile
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"runtime"
 	"runtime/debug"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/sync/semaphore"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 
 	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/internal"
 	"github.com/bufbuild/protocompile/linker"
 	"github.com/bufbuild/protocompile/options"
 	"github.com/bufbuild/protocompile/parser"
@@ -89,6 +92,33 @@ type Compiler struct {
 	// will be removed as soon as it's no longer needed. This can help reduce
 	// total memory usage for operations involving a large number of files.
 	RetainASTs bool
+
+	// If non-nil, compiled files are stored in and loaded from this cache.
+	// This only applies to files for which the Resolver returns source code.
+	// A file found in the cache is not parsed or linked, and its options are
+	// not interpreted. So, like files for which the Resolver returns
+	// descriptors, it will not implement linker.Result, and any warnings
+	// that were reported when it was first compiled are not reported again.
+	Cache Cache
+
+	// If non-nil, this observer is notified of the progress of compiling each
+	// file, including how long each phase of compilation takes.
+	Observer Observer
+
+	// If true, a file is still linked when its imports or the types that it
+	// references can't be resolved, like with protobuf-go's
+	// protodesc.FileOptions.AllowUnresolvable. An import that can't be found
+	// is replaced with a placeholder file (see linker.NewPlaceholderFile),
+	// and references to types that can't be resolved refer to placeholder
+	// messages. Options are interpreted as far as they can be resolved.
+	//
+	// These problems are still passed to the reporter as errors. But if the
+	// reporter returns nil for them, so that the compilation continues, then
+	// the returned files include the ones that had errors during linking or
+	// option interpretation. So editors, or builds of partial checkouts, can
+	// still get descriptors for files with missing dependencies. Syntax
+	// errors still prevent a file from being compiled.
+	AllowUnresolvable bool
 }
 
 // SourceInfoMode indicates how source code info is generated by a Compiler.
@@ -121,6 +151,11 @@ const (
 // or source code). That result will contain a full AST for the file if the
 // compiler had to parse it (i.e. the resolver provided source code for that
 // file).
+//
+// If any file fails to compile, the corresponding element in the returned
+// files will be nil, unless the compiler allows unresolvable references and
+// the file could still be linked. Use CompileAll to find out which errors
+// belong to which files.
 func (c *Compiler) Compile(ctx context.Context, files ...string) (linker.Files, error) {
 	if len(files) == 0 {
 		return nil, nil
@@ -129,6 +164,11 @@ func (c *Compiler) Compile(ctx context.Context, files ...string) (linker.Files,
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	e := c.newExecutor(cancel, &linker.Symbols{}, map[string]*result{})
+	return e.compileFiles(ctx, files)
+}
+
+func (c *Compiler) newExecutor(cancel context.CancelFunc, sym *linker.Symbols, results map[string]*result) *executor {
 	par := c.MaxParallelism
 	if par <= 0 {
 		par = runtime.GOMAXPROCS(-1)
@@ -138,33 +178,18 @@ func (c *Compiler) Compile(ctx context.Context, files ...string) (linker.Files,
 		}
 	}
 
-	h := reporter.NewHandler(c.Reporter)
-
-	e := executor{
+	return &executor{
 		c:       c,
-		h:       h,
+		h:       reporter.NewHandler(c.Reporter),
 		s:       semaphore.NewWeighted(int64(par)),
 		cancel:  cancel,
-		sym:     &linker.Symbols{},
-		results: map[string]*result{},
+		sym:     sym,
+		results: results,
 	}
+}
 
-	// We lock now and create all tasks under lock to make sure that no
-	// async task can create a duplicate result. For example, if files
-	// contains both "foo.proto" and "bar.proto", then there is a race
-	// after we start compiling "foo.proto" between this loop and the
-	// async compilation task to create the result for "bar.proto". But
-	// we need to know if the file is directly requested for compilation,
-	// so we need this loop to define the result. So this loop holds the
-	// lock the whole time so async tasks can't create a result first.
-	results := make([]*result, len(files))
-	func() {
-		e.mu.Lock()
-		defer e.mu.Unlock()
-		for i, f := range files {
-			results[i] = e.compileLocked(ctx, f, true)
-		}
-	}()
+func (e *executor) compileFiles(ctx context.Context, files []string) (linker.Files, error) {
+	results := e.startFiles(ctx, files)
 
 	descs := make([]linker.File, len(files))
 	var firstError error
@@ -182,7 +207,7 @@ func (c *Compiler) Compile(ctx context.Context, files ...string) (linker.Files,
 		descs[i] = r.res
 	}
 
-	if err := h.Error(); err != nil {
+	if err := e.h.Error(); err != nil {
 		return descs, err
 	}
 	// this should probably never happen; if any task returned an
@@ -190,6 +215,28 @@ func (c *Compiler) Compile(ctx context.Context, files ...string) (linker.Files,
 	return descs, firstError
 }
 
+// startFiles starts compiling the given files, which were explicitly
+// requested, and returns their results.
+func (e *executor) startFiles(ctx context.Context, files []string) []*result {
+	// We lock now and create all tasks under lock to make sure that no
+	// async task can create a duplicate result. For example, if files
+	// contains both "foo.proto" and "bar.proto", then there is a race
+	// after we start compiling "foo.proto" between this loop and the
+	// async compilation task to create the result for "bar.proto". But
+	// we need to know if the file is directly requested for compilation,
+	// so we need this loop to define the result. So this loop holds the
+	// lock the whole time so async tasks can't create a result first.
+	results := make([]*result, len(files))
+	func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		for i, f := range files {
+			results[i] = e.compileLocked(ctx, f, true)
+		}
+	}()
+	return results
+}
+
 type result struct {
 	name  string
 	ready chan struct{}
@@ -206,6 +253,15 @@ type result struct {
 	// the results that are dependencies of this result; this result is
 	// blocked, waiting on these dependencies to complete
 	blockedOn []string
+	// the key used to store this result in the compiler's cache; computed
+	// lazily for results that were not compiled from source
+	cacheKey string
+
+	// only populated when the executor is in partial mode: the errors
+	// reported while compiling this file and, if it failed because one of
+	// its imports failed, the path of that import
+	errs         []reporter.ErrorWithPos
+	failedImport string
 }
 
 func (r *result) fail(err error) {
@@ -240,6 +296,15 @@ type executor struct {
 	descriptorProtoCheck    sync.Once
 	descriptorProtoIsCustom bool
 
+	// tracks all running tasks, so callers can wait for them to finish
+	wg sync.WaitGroup
+
+	// if true, errors are attributed to the file that reported them, and
+	// errors in one file do not abort the compilation of other files
+	partial bool
+	// serializes calls to the compiler's reporter in partial mode
+	reporterMu sync.Mutex
+
 	mu      sync.Mutex
 	results map[string]*result
 }
@@ -263,7 +328,9 @@ func (e *executor) compileLocked(ctx context.Context, file string, explicitFile
 		explicitFile: explicitFile,
 	}
 	e.results[file] = r
+	e.wg.Add(1)
 	go func() {
+		defer e.wg.Done()
 		defer func() {
 			if p := recover(); p != nil {
 				if r.err == nil {
@@ -326,6 +393,10 @@ func (e errFailedToResolve) Unwrap() error {
 	return e.err
 }
 
+func (e errFailedToResolve) Code() reporter.Code {
+	return reporter.CodeUnresolvedImport
+}
+
 func (e *executor) hasOverrideDescriptorProto() bool {
 	e.descriptorProtoCheck.Do(func() {
 		defer func() {
@@ -339,18 +410,44 @@ func (e *executor) hasOverrideDescriptorProto() bool {
 }
 
 func (e *executor) doCompile(ctx context.Context, file string, r *result) {
-	t := task{e: e, h: e.h.SubHandler(), r: r}
+	t := task{e: e, h: e.newHandler(r), r: r}
+	start := time.Now()
+	// Every task ends with exactly one EventDone, so observers can pair it
+	// with the task's other events.
+	var done bool
+	finish := func(ev Event) {
+		done = true
+		ev.Kind = EventDone
+		ev.Duration = time.Since(start)
+		t.observe(ev)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			if !done {
+				finish(Event{Err: PanicError{File: file, Value: p, Stack: string(debug.Stack())}})
+			}
+			// let compileLocked record the panic as the result's error
+			panic(p)
+		}
+	}()
+
 	if err := e.s.Acquire(ctx, 1); err != nil {
+		finish(Event{Err: err})
 		r.fail(err)
 		return
 	}
 	defer t.release()
 
+	resolveStart := time.Now()
 	sr, err := e.c.Resolver.FindFileByPath(file)
 	if err != nil {
-		r.fail(errFailedToResolve{err: err, path: file})
+		err = errFailedToResolve{err: err, path: file}
+		finish(Event{Err: err})
+		r.fail(err)
 		return
 	}
+	kind := kindOf(sr)
+	t.observe(Event{Kind: EventResolved, Duration: time.Since(resolveStart), Resolved: kind})
 
 	defer func() {
 		// if results included a result, don't leave it open if it can be closed
@@ -363,7 +460,11 @@ func (e *executor) doCompile(ctx context.Context, file string, r *result) {
 	}()
 
 	desc, err := t.asFile(ctx, file, sr)
+	finish(Event{Resolved: kind, FromCache: t.fromCache, Err: err})
 	if err != nil {
+		// desc is only non-nil if the compiler allows unresolvable references
+		// and the file could be linked despite its errors
+		r.res = desc
 		r.fail(err)
 		return
 	}
@@ -384,6 +485,16 @@ type task struct {
 
 	// the result that is populated by this task
 	r *result
+
+	// if the compiler has a cache, the key for this task's file and the
+	// imports used to compute it
+	cacheKey     string
+	cacheImports []string
+	// true if this task's file was loaded from the compiler's cache
+	fromCache bool
+
+	// the imports this task waited on, reported to the compiler's observer
+	blockedOn []string
 }
 
 func (t *task) release() {
@@ -403,6 +514,30 @@ func (t *task) asFile(ctx context.Context, name string, r SearchResult) (linker.
 		return linker.NewFileRecursive(r.Desc)
 	}
 
+	if r.Source != nil && t.e.c.Cache != nil {
+		src, err := io.ReadAll(r.Source)
+		if err != nil {
+			return nil, err
+		}
+		file, err := t.loadFromCache(ctx, name, src)
+		if err != nil || file != nil {
+			t.fromCache = file != nil
+			return file, err
+		}
+		r.Source = bytes.NewReader(src)
+		file, err = t.asFileFromSource(ctx, name, r)
+		if err != nil {
+			// don't cache a file with errors
+			return file, err
+		}
+		t.storeInCache(file)
+		return file, nil
+	}
+
+	return t.asFileFromSource(ctx, name, r)
+}
+
+func (t *task) asFileFromSource(ctx context.Context, name string, r SearchResult) (linker.File, error) {
 	parseRes, err := t.asParseResult(name, r)
 	if err != nil {
 		return nil, err
@@ -416,13 +551,19 @@ func (t *task) asFile(ctx context.Context, name string, r SearchResult) (linker.
 	var deps []linker.File
 	fileDescriptorProto := parseRes.FileDescriptorProto()
 	var wantsDescriptorProto bool
-	imports := fileDescriptorProto.Dependency
+	// Option dependencies may only be used in options, but they are
+	// otherwise compiled and linked the same as other dependencies.
+	depNames := fileDescriptorProto.Dependency
+	if optionDeps := internal.OptionDependencies(fileDescriptorProto); len(optionDeps) > 0 {
+		depNames = append(append([]string(nil), depNames...), optionDeps...)
+	}
+	imports := depNames
 
 	if t.e.hasOverrideDescriptorProto() {
 		// we only consider implicitly including descriptor.proto if it's overridden
 		if name != descriptorProtoPath {
 			var includesDescriptorProto bool
-			for _, dep := range fileDescriptorProto.Dependency {
+			for _, dep := range depNames {
 				if dep == descriptorProtoPath {
 					includesDescriptorProto = true
 					break
@@ -443,10 +584,11 @@ func (t *task) asFile(ctx context.Context, name string, r SearchResult) (linker.
 	var overrideDescriptorProto linker.File
 	if len(imports) > 0 {
 		t.r.setBlockedOn(imports)
+		t.blockedOn = imports
 
-		results := make([]*result, len(fileDescriptorProto.Dependency))
+		results := make([]*result, len(depNames))
 		checked := map[string]struct{}{}
-		for i, dep := range fileDescriptorProto.Dependency {
+		for i, dep := range depNames {
 			span := findImportSpan(parseRes, dep)
 			if name == dep {
 				// doh! file imports itself
@@ -456,7 +598,7 @@ func (t *task) asFile(ctx context.Context, name string, r SearchResult) (linker.
 
 			res := t.e.compile(ctx, dep)
 			// check for dependency cycle to prevent deadlock
-			if err := t.e.checkForDependencyCycle(res, []string{name, dep}, span, checked); err != nil {
+			if err := t.e.checkForDependencyCycle(t.h, res, []string{name, dep}, span, checked); err != nil {
 				return nil, err
 			}
 			results[i] = res
@@ -475,7 +617,16 @@ func (t *task) asFile(ctx context.Context, name string, r SearchResult) (linker.
 		for i, res := range results {
 			select {
 			case <-res.ready:
+				if res.err != nil && t.e.c.AllowUnresolvable {
+					dep, err := t.unresolvedImport(parseRes, res)
+					if err != nil {
+						return nil, err
+					}
+					deps[i] = dep
+					continue
+				}
 				if res.err != nil {
+					t.r.failedImport = res.name
 					if rerr, ok := res.err.(errFailedToResolve); ok {
 						// We don't report errors to get file from resolver to handler since
 						// it's usually considered immediately fatal. However, if the reason
@@ -513,7 +664,39 @@ func (t *task) asFile(ctx context.Context, name string, r SearchResult) (linker.
 	return t.link(parseRes, deps, overrideDescriptorProto)
 }
 
-func (e *executor) checkForDependencyCycle(res *result, sequence []string, span ast.SourceSpan, checked map[string]struct{}) error {
+// unresolvedImport returns the file to use for the given import, which
+// failed, when the compiler allows unresolvable imports. If the import was
+// linked despite its errors, that is used. If it couldn't be resolved, the
+// failure is reported at the import statement and a placeholder is used.
+// Otherwise, the import's error is returned.
+func (t *task) unresolvedImport(parseRes parser.Result, res *result) (linker.File, error) {
+	if res.res != nil {
+		// the import's errors were reported when it was compiled
+		return res.res, nil
+	}
+	rerr, ok := res.err.(errFailedToResolve)
+	if !ok {
+		t.r.failedImport = res.name
+		return nil, res.err
+	}
+	if err := t.h.HandleErrorWithPos(findImportSpan(parseRes, res.name), rerr); err != nil {
+		t.r.failedImport = res.name
+		return nil, err
+	}
+	return linker.NewPlaceholderFile(res.name), nil
+}
+
+func (e *executor) checkForDependencyCycle(h *reporter.Handler, res *result, sequence []string, span ast.SourceSpan, checked map[string]struct{}) error {
+	if cycle := e.findDependencyCycle(res, sequence, checked); cycle != nil {
+		handleImportCycle(h, span, cycle[:len(cycle)-1], cycle[len(cycle)-1])
+		return h.Error()
+	}
+	return nil
+}
+
+// findDependencyCycle returns the import sequence that forms a cycle, ending
+// with the file that is imported twice, or nil if there is no cycle.
+func (e *executor) findDependencyCycle(res *result, sequence []string, checked map[string]struct{}) []string {
 	if _, ok := checked[res.name]; ok {
 		// already checked this one
 		return nil
@@ -524,8 +707,7 @@ func (e *executor) checkForDependencyCycle(res *result, sequence []string, span
 		// is this a cycle?
 		for _, file := range sequence {
 			if file == dep {
-				handleImportCycle(e.h, span, sequence, dep)
-				return e.h.Error()
+				return append(sequence[:len(sequence):len(sequence)], dep)
 			}
 		}
 
@@ -535,8 +717,8 @@ func (e *executor) checkForDependencyCycle(res *result, sequence []string, span
 		if depRes == nil {
 			continue
 		}
-		if err := e.checkForDependencyCycle(depRes, append(sequence, dep), span, checked); err != nil {
-			return err
+		if cycle := e.findDependencyCycle(depRes, append(sequence, dep), checked); cycle != nil {
+			return cycle
 		}
 	}
 	return nil
@@ -550,7 +732,7 @@ func handleImportCycle(h *reporter.Handler, span ast.SourceSpan, importSequence
 	}
 	_, _ = fmt.Fprintf(&buf, "%q", dep)
 	// error is saved and returned in caller
-	_ = h.HandleErrorf(span, buf.String())
+	_ = h.HandleErrorWithPos(span, reporter.WithCode(reporter.CodeImportCycle, errors.New(buf.String())))
 }
 
 func findImportSpan(res parser.Result, dep string) ast.SourceSpan {
@@ -583,11 +765,14 @@ func (t *task) link(parseRes parser.Result, deps linker.Files, overrideDescripto
 			return nil, err
 		}
 	}
+	start := time.Now()
 	file, err := linker.Link(parseRes, r, deps, t.e.sym, t.h)
-	if err != nil {
+	if err != nil && (file == nil || !t.tolerated(err)) {
 		return nil, err
 	}
+	t.observe(Event{Kind: EventLinked, Duration: time.Since(start)})
 
+	start = time.Now()
 	var interpretOpts []options.InterpreterOption
 	if overrideDescriptorProtoRes != nil {
 		interpretOpts = []options.InterpreterOption{options.WithOverrideDescriptorProto(overrideDescriptorProtoRes)}
@@ -601,12 +786,17 @@ func (t *task) link(parseRes parser.Result, deps linker.Files, overrideDescripto
 	if err := file.ValidateOptions(t.h); err != nil {
 		return nil, err
 	}
-	if t.r.explicitFile {
+	// If linking continued after errors, an import may only seem unused
+	// because a reference to one of its types couldn't be resolved.
+	if t.r.explicitFile && (!t.e.c.AllowUnresolvable || t.h.Error() == nil) {
 		file.CheckForUnusedImports(t.h)
 	}
-	if err := t.h.Error(); err != nil {
-		return nil, err
+	// the errors that were reported, if the compilation can continue anyway
+	linkErr := t.h.Error()
+	if linkErr != nil && !t.tolerated(linkErr) {
+		return nil, linkErr
 	}
+	t.observe(Event{Kind: EventOptionsInterpreted, Duration: time.Since(start)})
 
 	if needsSourceInfo(parseRes, t.e.c.SourceInfoMode) {
 		var srcInfoOpts []sourceinfo.GenerateOption
@@ -616,7 +806,9 @@ func (t *task) link(parseRes parser.Result, deps linker.Files, overrideDescripto
 		if t.e.c.SourceInfoMode&SourceInfoExtraOptionLocations != 0 {
 			srcInfoOpts = append(srcInfoOpts, sourceinfo.WithExtraOptionLocations())
 		}
+		start = time.Now()
 		parseRes.FileDescriptorProto().SourceCodeInfo = sourceinfo.GenerateSourceInfo(parseRes.AST(), optsIndex, srcInfoOpts...)
+		t.observe(Event{Kind: EventSourceInfoGenerated, Duration: time.Since(start)})
 	} else if t.e.c.SourceInfoMode == SourceInfoNone {
 		// If results came from unlinked FileDescriptorProto, it could have
 		// source info that we should strip.
@@ -631,7 +823,15 @@ func (t *task) link(parseRes parser.Result, deps linker.Files, overrideDescripto
 	if !t.e.c.RetainASTs {
 		file.RemoveAST()
 	}
-	return file, nil
+	return file, linkErr
+}
+
+// tolerated returns true if the given error, from linking a file or
+// interpreting its options, only indicates that errors were reported and
+// that the reporter allowed the compilation to continue. In that case, the
+// file is still returned if the compiler allows unresolvable references.
+func (t *task) tolerated(err error) bool {
+	return t.e.c.AllowUnresolvable && errors.Is(err, reporter.ErrInvalidSource) && t.h.ReporterError() == nil
 }
 
 func needsSourceInfo(parseRes parser.Result, mode SourceInfoMode) bool {
@@ -661,12 +861,19 @@ func (t *task) asParseResult(name string, r SearchResult) (parser.Result, error)
 		return parser.ResultWithoutAST(descProto), nil
 	}
 
+	start := time.Now()
+	t.observe(Event{Kind: EventParseStarted})
 	file, err := t.asAST(name, r)
 	if err != nil {
 		return nil, err
 	}
 
-	return parser.ResultFromAST(file, true, t.h)
+	res, err := parser.ResultFromAST(file, true, t.h)
+	if err != nil {
+		return nil, err
+	}
+	t.observe(Event{Kind: EventParseFinished, Duration: time.Since(start)})
+	return res, nil
 }
 
 func (t *task) asAST(name string, r SearchResult) (*ast.FileNode, error) {
@@ -388,3 +388,94 @@ func TestDescriptorProtoPath(t *testing.T) {
 	path := (*descriptorpb.FileDescriptorProto)(nil).ProtoReflect().Descriptor().ParentFile().Path()
 	require.Equal(t, descriptorProtoPath, path)
 }
+
+func TestAllowUnresolvable(t *testing.T) {
+	t.Parallel()
+	srcs := map[string]string{
+		"a.proto": `syntax = "proto3";
+package a;
+import "missing.proto";
+import "b.proto";
+import "google/protobuf/descriptor.proto";
+extend google.protobuf.MessageOptions {
+  string label = 5000;
+}
+message A {
+  option deprecated = true;
+  option (a.label) = "a";
+  option (missing.opt) = 1;
+  missing.Thing thing = 1;
+  b.B b = 2;
+}
+service Svc {
+  rpc Get(missing.Request) returns (A);
+}
+`,
+		"b.proto": `syntax = "proto3"; package b; message B {}`,
+		"c.proto": `syntax = "proto3"; package c; import "a.proto"; message C { a.A a = 1; }`,
+	}
+	var errs []string
+	var codes []reporter.Code
+	compiler := Compiler{
+		Resolver: WithStandardImports(&SourceResolver{Accessor: SourceAccessorFromMap(srcs)}),
+		Reporter: reporter.NewReporter(func(err reporter.ErrorWithPos) error {
+			errs = append(errs, err.Error())
+			codes = append(codes, reporter.CodeOf(err))
+			return nil
+		}, nil),
+		AllowUnresolvable: true,
+	}
+	files, err := compiler.Compile(context.Background(), "c.proto", "a.proto")
+	require.ErrorIs(t, err, reporter.ErrInvalidSource)
+	assert.Equal(t, []string{
+		`a.proto:3:8: could not resolve path "missing.proto": file does not exist`,
+		`a.proto:12:10: message a.A: unknown extension missing.opt`,
+		`a.proto:13:3: field a.A.thing: unknown type missing.Thing`,
+		`a.proto:17:11: method a.Svc.Get: unknown request type missing.Request`,
+	}, errs)
+	assert.Equal(t, []reporter.Code{
+		reporter.CodeUnresolvedImport,
+		reporter.CodeUnresolvedReference,
+		reporter.CodeUnresolvedReference,
+		reporter.CodeUnresolvedReference,
+	}, codes)
+
+	// files that import a file with errors are still linked
+	require.Len(t, files, 2)
+	require.NotNil(t, files[0])
+	assert.Equal(t, protoreflect.FullName("a.A"), files[0].Messages().Get(0).Fields().Get(0).Message().FullName())
+
+	fileA := files[1]
+	require.NotNil(t, fileA)
+	missing := fileA.Imports().Get(0)
+	assert.Equal(t, "missing.proto", missing.Path())
+	assert.True(t, missing.IsPlaceholder())
+
+	msg := fileA.Messages().Get(0)
+	thing := msg.Fields().ByName("thing")
+	assert.Equal(t, protoreflect.MessageKind, thing.Kind())
+	assert.Equal(t, protoreflect.FullName("missing.Thing"), thing.Message().FullName())
+	assert.True(t, thing.Message().IsPlaceholder())
+	b := msg.Fields().ByName("b")
+	assert.Equal(t, protoreflect.FullName("b.B"), b.Message().FullName())
+	assert.False(t, b.Message().IsPlaceholder())
+	input := fileA.Services().Get(0).Methods().Get(0).Input()
+	assert.Equal(t, protoreflect.FullName("missing.Request"), input.FullName())
+	assert.True(t, input.IsPlaceholder())
+
+	// the options that could be resolved are interpreted
+	opts, ok := msg.Options().(*descriptorpb.MessageOptions)
+	require.True(t, ok)
+	assert.True(t, opts.GetDeprecated())
+	label := fileA.Extensions().ByName("label")
+	require.NotNil(t, label)
+	assert.Equal(t, "a", opts.ProtoReflect().Get(label).String())
+
+	// without the option, no file is returned
+	compiler.AllowUnresolvable = false
+	errs = nil
+	files, err = compiler.Compile(context.Background(), "c.proto", "a.proto")
+	require.Error(t, err)
+	assert.Nil(t, files[0])
+	assert.Nil(t, files[1])
+}
@@ -0,0 +1,566 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package completion computes completion candidates for a location in a
+// proto source file that is being edited, and so may not be valid. The
+// context of the location is determined from the tokens that the parser
+// produces, and the declarations in the file from the AST that the parser
+// recovers despite syntax errors.
+package completion
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/internal"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// Kind is the kind of element that a completion candidate is.
+type Kind int
+
+// The kinds of completion candidates.
+const (
+	KindKeyword = Kind(iota + 1)
+	KindMessage
+	KindEnum
+	KindField
+	KindExtension
+	KindEnumValue
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindKeyword:
+		return "keyword"
+	case KindMessage:
+		return "message"
+	case KindEnum:
+		return "enum"
+	case KindField:
+		return "field"
+	case KindExtension:
+		return "extension"
+	case KindEnumValue:
+		return "enum value"
+	default:
+		return fmt.Sprintf("unknown kind (%d)", int(k))
+	}
+}
+
+// Candidate is a possible completion.
+type Candidate struct {
+	// The text to insert.
+	Label string
+	Kind  Kind
+	// Details about the candidate, such as the fully-qualified name of a
+	// type or the type of a field. May be empty.
+	Detail string
+}
+
+// Result is the result of computing completions.
+type Result struct {
+	// The offset at which the word that the candidates complete starts. The
+	// text from there up to the cursor is replaced by a chosen candidate.
+	Start int
+	// The candidates, sorted by kind and then by label. Only candidates that
+	// start with the text between Start and the cursor are included.
+	Candidates []Candidate
+}
+
+var scalarTypes = []string{
+	"double", "float", "int32", "int64", "uint32", "uint64", "sint32", "sint64",
+	"fixed32", "fixed64", "sfixed32", "sfixed64", "bool", "string", "bytes",
+}
+
+var mapKeyTypes = []string{
+	"int32", "int64", "uint32", "uint64", "sint32", "sint64",
+	"fixed32", "fixed64", "sfixed32", "sfixed64", "bool", "string",
+}
+
+// Complete returns the completion candidates for the given offset in the
+// given source, which is the contents of the file with the given name. The
+// source may have errors, since it is typically being edited. The given
+// resolver is used to load the files that the source imports, so that their
+// types and extensions can be offered. Imports that can't be loaded are
+// ignored.
+//
+// The candidates depend on the context: they are keywords, type names that
+// are visible from the current scope, option names, field names in message
+// literals in option values, or enum values for option values and field
+// defaults. An error is returned if the offset is outside the source.
+func Complete(ctx context.Context, filename string, source []byte, offset int, resolver protocompile.Resolver) (*Result, error) {
+	if offset < 0 || offset > len(source) {
+		return nil, fmt.Errorf("offset %d is outside of %q, which has %d bytes", offset, filename, len(source))
+	}
+	res := &Result{Start: offset}
+	file := parse(filename, source)
+	tokens, ok := tokensBefore(file, source, offset)
+	if !ok {
+		// in a comment or string
+		return res, nil
+	}
+	var prefix string
+	if n := len(tokens); n > 0 && tokens[n-1].end == offset && tokens[n-1].kind != tokenPunct {
+		if tokens[n-1].kind != tokenName {
+			// nothing to complete in a number
+			return res, nil
+		}
+		prefix = tokens[n-1].text
+		res.Start = tokens[n-1].start
+		tokens = tokens[:n-1]
+	}
+	cur := analyze(tokens)
+
+	if depth := openBlocks(file); depth > 0 {
+		// The parser can't recover from a file that ends inside a block,
+		// which is common while typing at the end of a file. So the blocks
+		// are closed before parsing again.
+		repaired := append(source[:len(source):len(source)], strings.Repeat("}", depth)...)
+		file = parse(filename, repaired)
+	}
+	handler := reporter.NewHandler(reporter.NewReporter(func(reporter.ErrorWithPos) error { return nil }, nil))
+	parseRes, err := parser.ResultFromAST(file, false, handler)
+	if err != nil {
+		// not expected, since the handler ignores errors
+		return nil, err
+	}
+	fd := parseRes.FileDescriptorProto()
+	c := &completer{
+		cur:    cur,
+		syms:   visibleSymbols(ctx, file, fd, resolver),
+		pkg:    protoreflect.FullName(fd.GetPackage()),
+		syntax: fd.GetSyntax(),
+		prefix: prefix,
+		seen:   map[Candidate]struct{}{},
+	}
+	if c.syntax == "editions" && fd.GetEdition() >= internal.Edition2024 {
+		c.syntax = "2024"
+	}
+	c.scope = c.pkg
+	for _, blk := range cur.blocks {
+		if blk.kind == blockMessage {
+			c.scope = qualify(c.scope, blk.name)
+		}
+	}
+	c.complete()
+	if c.start >= 0 {
+		res.Start += c.start
+	}
+	res.Candidates = c.candidates
+	sort.Slice(res.Candidates, func(i, j int) bool {
+		if res.Candidates[i].Kind != res.Candidates[j].Kind {
+			return res.Candidates[i].Kind < res.Candidates[j].Kind
+		}
+		return res.Candidates[i].Label < res.Candidates[j].Label
+	})
+	return res, nil
+}
+
+// parse parses the given source, ignoring any errors. The parser recovers
+// from most errors, so the returned AST includes the declarations around the
+// statement that is being edited.
+func parse(filename string, source []byte) *ast.FileNode {
+	handler := reporter.NewHandler(reporter.NewReporter(func(reporter.ErrorWithPos) error { return nil }, nil))
+	// errors are ignored, and a bytes.Reader can't fail, so the AST is never nil
+	file, _ := parser.Parse(filename, bytes.NewReader(source), handler)
+	return file
+}
+
+type completer struct {
+	cur    *cursorContext
+	syms   *symbols
+	pkg    protoreflect.FullName
+	syntax string
+	// the innermost message, or the package if not in a message
+	scope protoreflect.FullName
+	// the text of the word that is being completed
+	prefix string
+	// the offset in the prefix where the completed part starts, if it
+	// doesn't start at the beginning of the prefix
+	start      int
+	candidates []Candidate
+	seen       map[Candidate]struct{}
+}
+
+func (c *completer) add(label string, kind Kind, detail string) {
+	if !strings.HasPrefix(label, c.prefix) {
+		return
+	}
+	cand := Candidate{Label: label, Kind: kind, Detail: detail}
+	if _, ok := c.seen[cand]; ok {
+		return
+	}
+	c.seen[cand] = struct{}{}
+	c.candidates = append(c.candidates, cand)
+}
+
+func (c *completer) addKeywords(keywords ...string) {
+	for _, kw := range keywords {
+		c.add(kw, KindKeyword, "")
+	}
+}
+
+func (c *completer) labels() []string {
+	switch c.syntax {
+	case "proto3":
+		return []string{"optional", "repeated"}
+	case "editions", "2024":
+		return []string{"repeated"}
+	default:
+		return []string{"optional", "required", "repeated"}
+	}
+}
+
+func (c *completer) complete() {
+	c.start = -1
+	if c.cur.block().kind == blockLiteral {
+		c.completeLiteral()
+		return
+	}
+	stmt := c.cur.stmt
+	if optsMsg, name, isValue := c.cur.option(); optsMsg != "" {
+		if isValue {
+			c.completeOptionValue(optsMsg, name)
+		} else {
+			c.completeOptionName(optsMsg, name)
+		}
+		return
+	}
+	blk := c.cur.declBlock()
+	if len(stmt) == 0 {
+		switch blk.kind {
+		case blockFile:
+			c.addKeywords("syntax", "edition", "package", "import", "option", "message", "enum", "service", "extend")
+		case blockMessage:
+			c.addKeywords("message", "enum", "option", "oneof", "map", "extend", "reserved")
+			c.addKeywords(c.labels()...)
+			if c.syntax != "proto3" {
+				// proto3 doesn't allow extension ranges
+				c.addKeywords("extensions")
+			}
+			if c.syntax != "proto2" && c.syntax != "" {
+				// labels are optional
+				c.addTypes(false)
+			}
+		case blockOneof:
+			c.addKeywords("option")
+			c.addTypes(false)
+			if c.syntax == "proto2" || c.syntax == "" {
+				c.addKeywords("group")
+			}
+		case blockExtend:
+			c.addKeywords(c.labels()...)
+			if c.syntax != "proto2" && c.syntax != "" {
+				c.addTypes(false)
+			}
+		case blockEnum:
+			c.addKeywords("option", "reserved")
+		case blockService:
+			c.addKeywords("option", "rpc")
+		case blockMethod:
+			c.addKeywords("option")
+		}
+		return
+	}
+	first := stmt[0].text
+	switch {
+	case first == "import" && len(stmt) == 1 && blk.kind == blockFile:
+		c.addKeywords("public", "weak")
+		if c.syntax == "2024" {
+			c.addKeywords("option")
+		}
+	case first == "extend" && len(stmt) == 1:
+		c.addTypes(true)
+	case first == "rpc" && blk.kind == blockService:
+		last := stmt[len(stmt)-1].text
+		switch {
+		case last == "(":
+			c.addKeywords("stream")
+			c.addTypes(true)
+		case last == "stream" && stmt[len(stmt)-2].text == "(":
+			c.addTypes(true)
+		case last == ")" && len(stmt) <= 6:
+			c.addKeywords("returns")
+		}
+	case first == "map" && len(stmt) == 2 && stmt[1].text == "<":
+		for _, name := range mapKeyTypes {
+			c.add(name, KindKeyword, "")
+		}
+	case first == "map" && len(stmt) == 4 && stmt[3].text == ",":
+		c.addTypes(false)
+	case len(stmt) == 1 && isLabel(first) && (blk.kind == blockMessage || blk.kind == blockExtend):
+		c.addTypes(false)
+		if c.syntax == "proto2" || c.syntax == "" {
+			c.addKeywords("group")
+		}
+	}
+}
+
+func isLabel(text string) bool {
+	return text == "optional" || text == "required" || text == "repeated"
+}
+
+// scopes returns the scopes in which names are resolved at the cursor, from
+// outermost to innermost.
+func (c *completer) scopes() []protoreflect.FullName {
+	return scopesFor(c.scope)
+}
+
+// relativeName returns the shortest name for the given fully-qualified name
+// that is relative to one of the enclosing scopes. If the prefix is
+// fully-qualified, so is the returned name.
+func (c *completer) relativeName(name protoreflect.FullName) string {
+	if strings.HasPrefix(c.prefix, ".") {
+		return "." + string(name)
+	}
+	scopes := c.scopes()
+	for i := len(scopes) - 1; i >= 0; i-- {
+		if strings.HasPrefix(string(name), string(scopes[i])+".") {
+			return string(name[len(scopes[i])+1:])
+		}
+	}
+	return string(name)
+}
+
+// addTypes adds the types that are visible, and the scalar types unless
+// onlyMessages is true.
+func (c *completer) addTypes(onlyMessages bool) {
+	if !onlyMessages {
+		for _, name := range scalarTypes {
+			c.add(name, KindKeyword, "")
+		}
+	}
+	for name, sym := range c.syms.types {
+		if !sym.visible || (onlyMessages && sym.isEnum) {
+			continue
+		}
+		kind := KindMessage
+		if sym.isEnum {
+			kind = KindEnum
+		}
+		c.add(c.relativeName(name), kind, string(name))
+	}
+}
+
+// completeOptionName adds the candidates for an option name, where name is
+// the part of the name that precedes the prefix.
+func (c *completer) completeOptionName(optsMsg protoreflect.FullName, name []token) {
+	text := tokensText(name) + c.prefix
+	if strings.Count(text, "(") > strings.Count(text, ")") {
+		// the name of an extension
+		if strings.LastIndexByte(text, '(') != len(text)-len(c.prefix)-1 {
+			// the name doesn't start right after the parenthesis
+			return
+		}
+		c.addExtensions(optsMsg)
+		return
+	}
+	// the text up to the last dot names a message field
+	msg := optsMsg
+	if dot := strings.LastIndexByte(text, '.'); dot >= 0 {
+		if dot < len(text)-len(c.prefix) {
+			// a dot token that isn't part of the prefix
+			return
+		}
+		c.start = dot - (len(text) - len(c.prefix)) + 1
+		c.prefix = c.prefix[c.start:]
+		fld := c.resolveOptionName(optsMsg, text[:dot])
+		if fld == nil || fld.kind != protoreflect.MessageKind && fld.kind != protoreflect.GroupKind {
+			return
+		}
+		msg = fld.typeName
+	} else if optsMsg == "google.protobuf.FieldOptions" {
+		c.addKeywords("default", "json_name")
+	}
+	c.addFields(msg)
+}
+
+// completeOptionValue adds the candidates for the value of the option with
+// the given name.
+func (c *completer) completeOptionValue(optsMsg protoreflect.FullName, name []token) {
+	stmt := c.cur.stmt
+	if stmt[len(stmt)-1].text != "=" {
+		return
+	}
+	text := tokensText(name)
+	if text == "default" && optsMsg == "google.protobuf.FieldOptions" {
+		// the type of the field precedes its name, which precedes the first
+		// equals sign
+		for i, tok := range stmt {
+			if tok.text == "=" {
+				if i >= 2 {
+					c.addValues(&field{kind: protoreflect.EnumKind, typeName: c.syms.resolveType(stmt[i-2].text, c.scopes())})
+				}
+				break
+			}
+		}
+		return
+	}
+	c.addValues(c.resolveOptionName(optsMsg, text))
+}
+
+// resolveOptionName returns the field that the given option name, like
+// "(foo.bar).baz", refers to, or nil if it can't be resolved.
+func (c *completer) resolveOptionName(optsMsg protoreflect.FullName, name string) *field {
+	msg := optsMsg
+	var fld *field
+	for name != "" {
+		if fld != nil {
+			if !strings.HasPrefix(name, ".") {
+				return nil
+			}
+			msg = fld.typeName
+			name = name[1:]
+		}
+		var part string
+		if strings.HasPrefix(name, "(") {
+			end := strings.IndexByte(name, ')')
+			if end < 0 {
+				return nil
+			}
+			part, name = name[1:end], name[end+1:]
+			ext := c.syms.resolveExtension(part, c.scopes())
+			if ext == nil || ext.extendee != msg {
+				return nil
+			}
+			fld = &ext.field
+			continue
+		}
+		end := strings.IndexByte(name, '.')
+		if end < 0 {
+			end = len(name)
+		}
+		part, name = name[:end], name[end:]
+		if fld = c.syms.field(msg, part); fld == nil {
+			return nil
+		}
+	}
+	return fld
+}
+
+// completeLiteral adds the candidates for a location in a message literal.
+func (c *completer) completeLiteral() {
+	msg := c.literalType(c.cur.block())
+	if msg == "" {
+		return
+	}
+	entry := c.cur.entry
+	switch {
+	case len(entry) == 0:
+		c.addFields(msg)
+	case len(entry) == 1 && entry[0].text == "[":
+		c.addExtensions(msg)
+	default:
+		for i, tok := range entry {
+			if tok.text != ":" {
+				continue
+			}
+			if last := entry[len(entry)-1].text; last == ":" || last == "[" || last == "," {
+				c.addValues(c.literalField(msg, literalField(entry[:i])))
+			}
+			return
+		}
+	}
+}
+
+// literalField returns the field with the given name, as written in a
+// message literal, in the given message. It returns nil if there is no such
+// field.
+func (c *completer) literalField(msg protoreflect.FullName, name string) *field {
+	if !strings.HasPrefix(name, "[") {
+		return c.syms.field(msg, name)
+	}
+	ext := c.syms.resolveExtension(strings.Trim(name, "[]"), []protoreflect.FullName{c.pkg})
+	if ext == nil || ext.extendee != msg {
+		return nil
+	}
+	return &ext.field
+}
+
+// literalType returns the name of the message type of the given message
+// literal, or the empty string if it can't be determined.
+func (c *completer) literalType(blk *block) protoreflect.FullName {
+	if blk.parent == nil {
+		fld := c.resolveOptionName(blk.optionsMessage, tokensText(blk.optionName))
+		if fld == nil {
+			return ""
+		}
+		return fld.typeName
+	}
+	msg := c.literalType(blk.parent)
+	if msg == "" {
+		return ""
+	}
+	fld := c.literalField(msg, blk.field)
+	if fld == nil {
+		return ""
+	}
+	return fld.typeName
+}
+
+func (c *completer) addFields(msg protoreflect.FullName) {
+	for _, fld := range c.syms.fields(msg) {
+		c.add(fld.name, KindField, fieldType(fld))
+	}
+}
+
+func (c *completer) addExtensions(msg protoreflect.FullName) {
+	for _, ext := range c.syms.exts {
+		if ext.extendee == msg {
+			c.add(c.relativeName(ext.name), KindExtension, fieldType(ext.field))
+		}
+	}
+}
+
+// addValues adds the values that the given field can have, if they are
+// enumerated.
+func (c *completer) addValues(fld *field) {
+	if fld == nil {
+		return
+	}
+	switch fld.kind {
+	case protoreflect.BoolKind:
+		c.addKeywords("true", "false")
+	case protoreflect.EnumKind:
+		if sym := c.syms.types[fld.typeName]; sym != nil && sym.isEnum {
+			for _, val := range sym.values {
+				c.add(val, KindEnumValue, string(fld.typeName))
+			}
+		}
+	}
+}
+
+func fieldType(fld field) string {
+	if fld.typeName != "" {
+		return string(fld.typeName)
+	}
+	return fld.kind.String()
+}
+
+func tokensText(tokens []token) string {
+	var sb strings.Builder
+	for _, tok := range tokens {
+		sb.WriteString(tok.text)
+	}
+	return sb.String()
+}
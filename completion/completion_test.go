@@ -0,0 +1,274 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package completion
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bufbuild/protocompile"
+)
+
+var testImports = map[string]string{
+	"dep.proto": `syntax = "proto3";
+package dep;
+import public "public.proto";
+message Dep {}
+enum Color {
+  RED = 0;
+  GREEN = 1;
+}
+`,
+	"public.proto": `syntax = "proto3";
+package pub;
+message Public {}
+`,
+	"opts.proto": `syntax = "proto2";
+package opts;
+import "google/protobuf/descriptor.proto";
+message Rule {
+  optional string name = 1;
+  optional Level level = 2;
+  optional Rule nested = 3;
+}
+enum Level {
+  LOW = 0;
+  HIGH = 1;
+}
+extend google.protobuf.MessageOptions {
+  optional Rule rule = 5000;
+  optional bool flag = 5001;
+}
+extend google.protobuf.FieldOptions {
+  optional Level level = 5000;
+}
+`,
+}
+
+func TestComplete(t *testing.T) {
+	t.Parallel()
+	// The cursor is at the "|" in each source.
+	testCases := []struct {
+		name     string
+		source   string
+		expected []string
+		start    string
+	}{
+		{
+			name:     "file keywords",
+			source:   "syntax = \"proto3\";\npa|",
+			expected: []string{"keyword package"},
+			start:    "pa",
+		},
+		{
+			name: "message body",
+			source: `syntax = "proto3";
+message Foo {
+  |
+}`,
+			expected: []string{
+				"keyword bool", "keyword bytes", "keyword double", "keyword enum",
+				"keyword extend", "keyword fixed32", "keyword fixed64",
+				"keyword float", "keyword int32", "keyword int64", "keyword map",
+				"keyword message", "keyword oneof", "keyword option", "keyword optional",
+				"keyword repeated", "keyword reserved", "keyword sfixed32", "keyword sfixed64",
+				"keyword sint32", "keyword sint64", "keyword string", "keyword uint32",
+				"keyword uint64", "message Foo",
+			},
+		},
+		{
+			name: "proto2 message body",
+			source: `syntax = "proto2";
+message Foo {
+  ext|
+}`,
+			expected: []string{"keyword extend", "keyword extensions"},
+			start:    "ext",
+		},
+		{
+			name: "types",
+			source: `syntax = "proto3";
+package foo.bar;
+import "dep.proto";
+message Foo {
+  message Inner {}
+  optional |
+}
+message Baz {}`,
+			expected: []string{
+				"keyword bool", "keyword bytes", "keyword double", "keyword fixed32",
+				"keyword fixed64", "keyword float", "keyword int32", "keyword int64",
+				"keyword sfixed32", "keyword sfixed64", "keyword sint32", "keyword sint64",
+				"keyword string", "keyword uint32", "keyword uint64",
+				"message Baz", "message Foo", "message Inner", "message dep.Dep",
+				"message pub.Public", "enum dep.Color",
+			},
+		},
+		{
+			name: "qualified type",
+			source: `syntax = "proto3";
+package foo;
+import "dep.proto";
+message Foo {
+  dep.|
+}`,
+			expected: []string{"message dep.Dep", "enum dep.Color"},
+			start:    "dep.",
+		},
+		{
+			name: "mid-edit at end of file",
+			source: `syntax = "proto2";
+package foo;
+message Foo {
+  message Bar {}
+  optional B|`,
+			expected: []string{"message Bar"},
+			start:    "B",
+		},
+		{
+			name: "extendee",
+			source: `syntax = "proto2";
+import "dep.proto";
+extend |`,
+			expected: []string{"message dep.Dep", "message pub.Public"},
+		},
+		{
+			name:     "option name",
+			source:   "syntax = \"proto3\";\noption java_m|",
+			expected: []string{"field java_multiple_files"},
+			start:    "java_m",
+		},
+		{
+			name:     "option value",
+			source:   "syntax = \"proto3\";\noption optimize_for = |",
+			expected: []string{"enum value CODE_SIZE", "enum value LITE_RUNTIME", "enum value SPEED"},
+		},
+		{
+			name: "custom option name",
+			source: `syntax = "proto2";
+import "opts.proto";
+message Foo {
+  option (|
+}`,
+			expected: []string{"extension opts.flag", "extension opts.rule"},
+		},
+		{
+			name: "custom option field",
+			source: `syntax = "proto2";
+import "opts.proto";
+message Foo {
+  option (opts.rule).n|
+}`,
+			expected: []string{"field name", "field nested"},
+			start:    "n",
+		},
+		{
+			name: "custom option bool value",
+			source: `syntax = "proto2";
+import "opts.proto";
+message Foo {
+  option (opts.flag) = |;
+}`,
+			expected: []string{"keyword false", "keyword true"},
+		},
+		{
+			name: "message literal field",
+			source: `syntax = "proto2";
+import "opts.proto";
+message Foo {
+  option (opts.rule) = { name: "abc" nested { | } };
+}`,
+			expected: []string{"field level", "field name", "field nested"},
+		},
+		{
+			name: "message literal value",
+			source: `syntax = "proto2";
+import "opts.proto";
+message Foo {
+  option (opts.rule) = {
+    level: |`,
+			expected: []string{"enum value HIGH", "enum value LOW"},
+		},
+		{
+			name: "compact option",
+			source: `syntax = "proto2";
+import "opts.proto";
+message Foo {
+  optional string name = 1 [(opts.l|
+}`,
+			expected: []string{"extension opts.level"},
+			start:    "opts.l",
+		},
+		{
+			name: "default value",
+			source: `syntax = "proto2";
+message Foo {
+  optional Kind kind = 1 [default = |];
+  enum Kind {
+    A = 0;
+    B = 1;
+  }
+}`,
+			expected: []string{"enum value A", "enum value B"},
+		},
+		{
+			name: "rpc",
+			source: `syntax = "proto3";
+import "dep.proto";
+service Svc {
+  rpc Do(|
+}`,
+			expected: []string{"keyword stream", "message dep.Dep", "message pub.Public"},
+		},
+		{
+			name:   "comment",
+			source: "syntax = \"proto3\";\n// mess|",
+		},
+		{
+			name:   "unterminated string",
+			source: "syntax = \"proto3\";\nimport \"fo|",
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			offset := strings.Index(testCase.source, "|")
+			require.GreaterOrEqual(t, offset, 0)
+			source := testCase.source[:offset] + testCase.source[offset+1:]
+			resolver := protocompile.WithStandardImports(&protocompile.SourceResolver{
+				Accessor: protocompile.SourceAccessorFromMap(testImports),
+			})
+			res, err := Complete(context.Background(), "test.proto", []byte(source), offset, resolver)
+			require.NoError(t, err)
+			var actual []string
+			for _, cand := range res.Candidates {
+				actual = append(actual, cand.Kind.String()+" "+cand.Label)
+			}
+			assert.Equal(t, testCase.expected, actual)
+			assert.Equal(t, offset-len(testCase.start), res.Start)
+		})
+	}
+}
+
+func TestComplete_InvalidOffset(t *testing.T) {
+	t.Parallel()
+	_, err := Complete(context.Background(), "test.proto", []byte("syntax"), 7, nil)
+	require.EqualError(t, err, `offset 7 is outside of "test.proto", which has 6 bytes`)
+}
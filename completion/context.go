@@ -0,0 +1,306 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package completion
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type blockKind int
+
+const (
+	blockFile = blockKind(iota)
+	blockMessage
+	blockEnum
+	blockService
+	blockMethod
+	blockOneof
+	blockExtend
+	// a message literal in an option value
+	blockLiteral
+	// braces that we don't understand
+	blockOther
+)
+
+type block struct {
+	kind blockKind
+	// the name of the message, for message blocks
+	name string
+	// For literals that are option values, the options message and the
+	// tokens of the option name. For literals nested in other literals, the
+	// name of the field whose value the literal is, which is in brackets for
+	// an extension.
+	optionsMessage protoreflect.FullName
+	optionName     []token
+	field          string
+	parent         *block
+	// the tokens of the parent literal's current field, which are restored
+	// when the literal ends
+	parentEntry []token
+}
+
+// cursorContext describes the location of the cursor, in terms of the tokens
+// that precede it.
+type cursorContext struct {
+	// the enclosing blocks, from outermost to innermost
+	blocks []*block
+	// the tokens of the current statement, in the innermost block that is not
+	// a message literal
+	stmt []token
+	// the tokens of the current field in the innermost message literal, if
+	// the cursor is in one
+	entry []token
+}
+
+func (c *cursorContext) block() *block {
+	return c.blocks[len(c.blocks)-1]
+}
+
+// declBlock returns the innermost block that is not a message literal.
+func (c *cursorContext) declBlock() *block {
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		if c.blocks[i].kind != blockLiteral {
+			return c.blocks[i]
+		}
+	}
+	return c.blocks[0]
+}
+
+// analyze computes the context of the cursor, which is after the given
+// tokens.
+func analyze(tokens []token) *cursorContext {
+	c := &cursorContext{blocks: []*block{{kind: blockFile}}}
+	for _, tok := range tokens {
+		top := c.block()
+		if top.kind == blockLiteral {
+			c.literalToken(top, tok)
+			continue
+		}
+		switch tok.text {
+		case "{":
+			if blk := c.literalStart(); blk != nil {
+				c.blocks = append(c.blocks, blk)
+				c.entry = nil
+				continue
+			}
+			c.blocks = append(c.blocks, c.declStart())
+			c.stmt = nil
+		case "}":
+			if len(c.blocks) > 1 {
+				c.blocks = c.blocks[:len(c.blocks)-1]
+			}
+			c.stmt = nil
+		case ";":
+			c.stmt = nil
+		default:
+			c.stmt = append(c.stmt, tok)
+		}
+	}
+	return c
+}
+
+func (c *cursorContext) literalToken(top *block, tok token) {
+	switch tok.text {
+	case "{", "<":
+		c.blocks = append(c.blocks, &block{kind: blockLiteral, field: literalField(c.entry), parent: top, parentEntry: c.entry})
+		c.entry = nil
+	case "}", ">":
+		c.blocks = c.blocks[:len(c.blocks)-1]
+		c.entry = top.parentEntry
+		if !inList(c.entry) {
+			// the field ends with its value, unless it's a list
+			c.entry = nil
+		}
+	case ",", ";":
+		if !inList(c.entry) {
+			c.entry = nil
+			return
+		}
+		c.entry = append(c.entry, tok)
+	default:
+		if entryComplete(c.entry) {
+			// fields don't need to be separated
+			c.entry = nil
+		}
+		c.entry = append(c.entry, tok)
+	}
+}
+
+// literalStart returns the block for the message literal that starts with
+// the brace after the current statement, or nil if the brace starts a
+// declaration's body instead.
+func (c *cursorContext) literalStart() *block {
+	if len(c.stmt) == 0 || c.stmt[len(c.stmt)-1].text != "=" {
+		return nil
+	}
+	optsMsg, name, _ := c.option()
+	if optsMsg == "" {
+		return nil
+	}
+	return &block{kind: blockLiteral, optionsMessage: optsMsg, optionName: name}
+}
+
+// option returns the options message and the tokens of the option name in
+// the option that the current statement ends with, or an empty name if the
+// statement doesn't end with an option. The name tokens end before the
+// equals sign, if there is one, in which case isValue is true.
+func (c *cursorContext) option() (optsMsg protoreflect.FullName, name []token, isValue bool) {
+	if start := openBracket(c.stmt); start >= 0 {
+		name = c.stmt[start+1:]
+		for i := len(name) - 1; i >= 0; i-- {
+			if name[i].text == "," {
+				name = name[i+1:]
+				break
+			}
+		}
+		optsMsg = compactOptionsMessage(c.declBlock().kind, c.stmt)
+	} else if len(c.stmt) > 0 && c.stmt[0].text == "option" {
+		name = c.stmt[1:]
+		optsMsg = optionsMessage(c.declBlock().kind)
+	} else {
+		return "", nil, false
+	}
+	for i, tok := range name {
+		if tok.text == "=" {
+			return optsMsg, name[:i], true
+		}
+	}
+	return optsMsg, name, false
+}
+
+// declStart returns the block for the declaration whose body starts with the
+// brace after the current statement.
+func (c *cursorContext) declStart() *block {
+	if len(c.stmt) == 0 {
+		return &block{kind: blockOther}
+	}
+	switch c.stmt[0].text {
+	case "message":
+		if len(c.stmt) > 1 {
+			return &block{kind: blockMessage, name: c.stmt[1].text}
+		}
+	case "enum":
+		return &block{kind: blockEnum}
+	case "service":
+		return &block{kind: blockService}
+	case "rpc":
+		return &block{kind: blockMethod}
+	case "oneof":
+		return &block{kind: blockOneof}
+	case "extend":
+		return &block{kind: blockExtend}
+	}
+	for i, tok := range c.stmt {
+		if tok.text == "group" && i+1 < len(c.stmt) {
+			// the name of a group's message is the group's name
+			return &block{kind: blockMessage, name: c.stmt[i+1].text}
+		}
+	}
+	return &block{kind: blockOther}
+}
+
+// openBracket returns the index of the bracket that starts the compact
+// options that the given statement ends in, or -1 if it doesn't end in
+// compact options.
+func openBracket(stmt []token) int {
+	depth := 0
+	for i := len(stmt) - 1; i >= 0; i-- {
+		switch stmt[i].text {
+		case "]":
+			depth++
+		case "[":
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// inList returns true if the given field in a message literal ends in a
+// list value that isn't closed.
+func inList(entry []token) bool {
+	for i, tok := range entry {
+		if tok.text == ":" {
+			return openBracket(entry[i+1:]) >= 0
+		}
+	}
+	return false
+}
+
+// entryComplete returns true if the given field in a message literal has a
+// complete value, so that the next token starts another field.
+func entryComplete(entry []token) bool {
+	for i, tok := range entry {
+		if tok.text != ":" {
+			continue
+		}
+		value := entry[i+1:]
+		return len(value) > 0 && value[len(value)-1].text != "-" && openBracket(value) < 0
+	}
+	return false
+}
+
+// literalField returns the name of the field in the given field of a message
+// literal.
+func literalField(entry []token) string {
+	if len(entry) == 0 {
+		return ""
+	}
+	if entry[0].text != "[" {
+		return entry[0].text
+	}
+	name := "["
+	for _, tok := range entry[1:] {
+		name += tok.text
+		if tok.text == "]" {
+			break
+		}
+	}
+	return name
+}
+
+func optionsMessage(kind blockKind) protoreflect.FullName {
+	switch kind {
+	case blockFile:
+		return "google.protobuf.FileOptions"
+	case blockMessage:
+		return "google.protobuf.MessageOptions"
+	case blockEnum:
+		return "google.protobuf.EnumOptions"
+	case blockService:
+		return "google.protobuf.ServiceOptions"
+	case blockMethod:
+		return "google.protobuf.MethodOptions"
+	case blockOneof:
+		return "google.protobuf.OneofOptions"
+	default:
+		return ""
+	}
+}
+
+func compactOptionsMessage(kind blockKind, stmt []token) protoreflect.FullName {
+	switch {
+	case stmt[0].text == "extensions":
+		return "google.protobuf.ExtensionRangeOptions"
+	case kind == blockEnum:
+		return "google.protobuf.EnumValueOptions"
+	case kind == blockMessage || kind == blockOneof || kind == blockExtend:
+		return "google.protobuf.FieldOptions"
+	default:
+		return ""
+	}
+}
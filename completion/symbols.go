@@ -0,0 +1,282 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package completion
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/walk"
+)
+
+// field is a field of a message, or an extension.
+type field struct {
+	name string
+	kind protoreflect.Kind
+	// the fully-qualified name of the message or enum type, if any
+	typeName protoreflect.FullName
+}
+
+// typeSymbol is a message or an enum.
+type typeSymbol struct {
+	isEnum bool
+	// false for types that are known but can't be referred to from the file,
+	// like the options messages when descriptor.proto isn't imported
+	visible bool
+	// the names of the values, for enums
+	values []string
+	// the fields, for messages
+	fields []field
+}
+
+// extSymbol is an extension that is visible in the file.
+type extSymbol struct {
+	field
+	name protoreflect.FullName
+	// the fully-qualified name of the extended message
+	extendee protoreflect.FullName
+}
+
+type symbols struct {
+	types map[protoreflect.FullName]*typeSymbol
+	exts  []*extSymbol
+}
+
+// visibleSymbols returns the types and extensions that are visible in the
+// given file: those declared in it, those in the files it imports, and those
+// in the files that they import publicly. The imports are compiled with the
+// given resolver. Imports that can't be compiled are ignored.
+func visibleSymbols(ctx context.Context, file *ast.FileNode, fd *descriptorpb.FileDescriptorProto, resolver protocompile.Resolver) *symbols {
+	syms := &symbols{types: map[protoreflect.FullName]*typeSymbol{}}
+	compiler := protocompile.Compiler{Resolver: resolver}
+	checked := map[string]struct{}{}
+	for _, decl := range file.Decls {
+		imp, ok := decl.(*ast.ImportNode)
+		if !ok || imp.Name == nil {
+			continue
+		}
+		files, err := compiler.Compile(ctx, imp.Name.AsString())
+		if err != nil {
+			continue
+		}
+		syms.addFile(files[0], true, checked)
+	}
+	// The options messages are needed for option names, even if
+	// descriptor.proto isn't imported.
+	syms.addFile(descriptorpb.File_google_protobuf_descriptor_proto, false, checked)
+	syms.addProto(fd)
+	return syms
+}
+
+// addFile adds the types and extensions in the given file and in the files
+// that it imports publicly.
+func (s *symbols) addFile(fd protoreflect.FileDescriptor, visible bool, checked map[string]struct{}) {
+	if _, ok := checked[fd.Path()]; ok {
+		return
+	}
+	checked[fd.Path()] = struct{}{}
+	_ = walk.Descriptors(fd, func(d protoreflect.Descriptor) error {
+		switch d := d.(type) {
+		case protoreflect.MessageDescriptor:
+			if d.IsMapEntry() {
+				return nil
+			}
+			sym := &typeSymbol{visible: visible}
+			for i := 0; i < d.Fields().Len(); i++ {
+				sym.fields = append(sym.fields, fieldFromDescriptor(d.Fields().Get(i)))
+			}
+			s.types[d.FullName()] = sym
+		case protoreflect.EnumDescriptor:
+			sym := &typeSymbol{isEnum: true, visible: visible}
+			for i := 0; i < d.Values().Len(); i++ {
+				sym.values = append(sym.values, string(d.Values().Get(i).Name()))
+			}
+			s.types[d.FullName()] = sym
+		case protoreflect.FieldDescriptor:
+			if d.IsExtension() && visible {
+				s.exts = append(s.exts, &extSymbol{
+					field:    fieldFromDescriptor(d),
+					name:     d.FullName(),
+					extendee: d.ContainingMessage().FullName(),
+				})
+			}
+		}
+		return nil
+	})
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if imp := imports.Get(i); imp.IsPublic && imp.FileDescriptor != nil {
+			s.addFile(imp.FileDescriptor, visible, checked)
+		}
+	}
+}
+
+func fieldFromDescriptor(fd protoreflect.FieldDescriptor) field {
+	fld := field{name: string(fd.Name()), kind: fd.Kind()}
+	if fd.Message() != nil {
+		fld.typeName = fd.Message().FullName()
+	} else if fd.Enum() != nil {
+		fld.typeName = fd.Enum().FullName()
+	}
+	return fld
+}
+
+// addProto adds the types and extensions declared in the given file, which
+// is not linked, so its references are resolved on a best-effort basis.
+func (s *symbols) addProto(fd *descriptorpb.FileDescriptorProto) {
+	type pending struct {
+		scope  protoreflect.FullName
+		fields []*descriptorpb.FieldDescriptorProto
+		msg    *typeSymbol
+	}
+	// the fields and extensions are resolved once all types are known
+	var resolve []pending
+	var addMessages func(scope protoreflect.FullName, msgs []*descriptorpb.DescriptorProto)
+	addEnums := func(scope protoreflect.FullName, enums []*descriptorpb.EnumDescriptorProto) {
+		for _, enum := range enums {
+			sym := &typeSymbol{isEnum: true, visible: true}
+			for _, val := range enum.Value {
+				sym.values = append(sym.values, val.GetName())
+			}
+			s.types[qualify(scope, enum.GetName())] = sym
+		}
+	}
+	addMessages = func(scope protoreflect.FullName, msgs []*descriptorpb.DescriptorProto) {
+		for _, msg := range msgs {
+			if msg.GetOptions().GetMapEntry() {
+				continue
+			}
+			name := qualify(scope, msg.GetName())
+			sym := &typeSymbol{visible: true}
+			s.types[name] = sym
+			resolve = append(resolve,
+				pending{scope: name, fields: msg.Field, msg: sym},
+				pending{scope: name, fields: msg.Extension})
+			addMessages(name, msg.NestedType)
+			addEnums(name, msg.EnumType)
+		}
+	}
+	pkg := protoreflect.FullName(fd.GetPackage())
+	addMessages(pkg, fd.MessageType)
+	addEnums(pkg, fd.EnumType)
+	resolve = append(resolve, pending{scope: pkg, fields: fd.Extension})
+	for _, p := range resolve {
+		scopes := scopesFor(p.scope)
+		for _, fldProto := range p.fields {
+			fld := field{name: fldProto.GetName(), kind: protoreflect.Kind(fldProto.GetType())}
+			if fldProto.TypeName != nil {
+				fld.typeName = s.resolveType(fldProto.GetTypeName(), scopes)
+				if fldProto.Type == nil {
+					fld.kind = protoreflect.MessageKind
+					if sym := s.types[fld.typeName]; sym != nil && sym.isEnum {
+						fld.kind = protoreflect.EnumKind
+					}
+				}
+			}
+			if p.msg != nil {
+				p.msg.fields = append(p.msg.fields, fld)
+				continue
+			}
+			extendee := s.resolveType(fldProto.GetExtendee(), scopes)
+			if extendee == "" {
+				extendee = protoreflect.FullName(strings.TrimPrefix(fldProto.GetExtendee(), "."))
+			}
+			s.exts = append(s.exts, &extSymbol{field: fld, name: qualify(p.scope, fld.name), extendee: extendee})
+		}
+	}
+}
+
+// resolveType returns the fully-qualified name of the type with the given
+// name, which is resolved relative to the given scopes, from innermost to
+// outermost. It returns the empty string if the type isn't found.
+func (s *symbols) resolveType(name string, scopes []protoreflect.FullName) protoreflect.FullName {
+	for _, fqn := range candidateNames(name, scopes) {
+		if _, ok := s.types[fqn]; ok {
+			return fqn
+		}
+	}
+	return ""
+}
+
+// resolveExtension returns the extension with the given name, which is
+// resolved like a type name. It returns nil if the extension isn't found.
+func (s *symbols) resolveExtension(name string, scopes []protoreflect.FullName) *extSymbol {
+	for _, fqn := range candidateNames(name, scopes) {
+		for _, ext := range s.exts {
+			if ext.name == fqn {
+				return ext
+			}
+		}
+	}
+	return nil
+}
+
+// fields returns the fields of the message with the given name.
+func (s *symbols) fields(msg protoreflect.FullName) []field {
+	if sym := s.types[msg]; sym != nil {
+		return sym.fields
+	}
+	return nil
+}
+
+// field returns the field of the message with the given name, or nil if
+// there is no such field.
+func (s *symbols) field(msg protoreflect.FullName, name string) *field {
+	fields := s.fields(msg)
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// candidateNames returns the fully-qualified names that the given name may
+// refer to, in the order in which they are tried.
+func candidateNames(name string, scopes []protoreflect.FullName) []protoreflect.FullName {
+	if name == "" {
+		return nil
+	}
+	if strings.HasPrefix(name, ".") {
+		return []protoreflect.FullName{protoreflect.FullName(name[1:])}
+	}
+	names := make([]protoreflect.FullName, 0, len(scopes)+1)
+	for i := len(scopes) - 1; i >= 0; i-- {
+		names = append(names, qualify(scopes[i], name))
+	}
+	return append(names, protoreflect.FullName(name))
+}
+
+// scopesFor returns the names of the given scope and the scopes that enclose
+// it, from outermost to innermost.
+func scopesFor(scope protoreflect.FullName) []protoreflect.FullName {
+	var scopes []protoreflect.FullName
+	for ; scope != ""; scope = scope.Parent() {
+		scopes = append([]protoreflect.FullName{scope}, scopes...)
+	}
+	return scopes
+}
+
+func qualify(scope protoreflect.FullName, name string) protoreflect.FullName {
+	if scope == "" {
+		return protoreflect.FullName(name)
+	}
+	return scope + "." + protoreflect.FullName(name)
+}
@@ -0,0 +1,123 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package completion
+
+import (
+	"strings"
+
+	"github.com/bufbuild/protocompile/ast"
+)
+
+type tokenKind int
+
+const (
+	// an identifier or a qualified name, which may start or end with a dot
+	tokenName = tokenKind(iota)
+	tokenNumber
+	tokenString
+	tokenPunct
+)
+
+type token struct {
+	kind       tokenKind
+	text       string
+	start, end int
+}
+
+// tokensBefore returns the tokens of the given file, which was parsed from
+// the given source, that start before the given offset. Qualified names are
+// combined into a single token, and a token that continues after the offset
+// is truncated. It returns false if the offset is inside a comment or a string
+// literal, or after text that the lexer rejected, like an unterminated string,
+// where there is nothing to complete.
+func tokensBefore(file *ast.FileNode, source []byte, offset int) ([]token, bool) {
+	var tokens []token
+	lastEnd := 0
+	items := file.Items()
+	for item, ok := items.First(); ok; item, ok = items.Next(item) {
+		info := file.ItemInfo(item)
+		text := info.RawText()
+		start := info.Start().Offset
+		end := start + len(text)
+		if start >= offset || text == "" {
+			break
+		}
+		lastEnd = end
+		if _, comment := file.GetItem(item); comment.IsValid() {
+			if strings.HasPrefix(text, "//") {
+				// the cursor may be at the end of the line
+				end = start + len(strings.TrimSuffix(text, "\n"))
+				if offset <= end {
+					return nil, false
+				}
+			} else if offset < end {
+				return nil, false
+			}
+			continue
+		}
+		kind := tokenKindOf(text)
+		if end > offset {
+			if kind == tokenString {
+				return nil, false
+			}
+			text, end = text[:offset-start], offset
+		}
+		if n := len(tokens); n > 0 && kind == tokenName && tokens[n-1].kind == tokenName && tokens[n-1].end == start {
+			tokens[n-1].text += text
+			tokens[n-1].end = end
+			continue
+		}
+		tokens = append(tokens, token{kind: kind, text: text, start: start, end: end})
+	}
+	if lastEnd < offset && strings.TrimSpace(string(source[lastEnd:offset])) != "" {
+		return nil, false
+	}
+	return tokens, true
+}
+
+func tokenKindOf(text string) tokenKind {
+	switch c := text[0]; {
+	case c == '"' || c == '\'':
+		return tokenString
+	case isDigit(c) || (c == '.' && len(text) > 1):
+		return tokenNumber
+	case c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return tokenName
+	default:
+		return tokenPunct
+	}
+}
+
+// openBlocks returns the number of blocks that are still open at the end of
+// the given file.
+func openBlocks(file *ast.FileNode) int {
+	var depth int
+	tokens := file.Tokens()
+	for tok, ok := tokens.First(); ok; tok, ok = tokens.Next(tok) {
+		switch file.TokenInfo(tok).RawText() {
+		case "{":
+			depth++
+		case "}":
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	return depth
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,189 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocompile
+
+import (
+	"fmt"
+	"time"
+)
+
+// Observer receives events that describe the progress of a compilation. This
+// can be used to measure how long each phase of compilation takes for each
+// file, for example to identify slow files or to create tracing spans.
+//
+// Observer implementations must be thread-safe as files are compiled
+// concurrently, so a single compilation operation could invoke Observe from
+// multiple goroutines.
+type Observer interface {
+	// Observe is called for each event. Since it is called synchronously by
+	// the compiler, it should return quickly.
+	Observe(Event)
+}
+
+// ObserverFunc is a simple function type that implements Observer.
+type ObserverFunc func(Event)
+
+var _ Observer = ObserverFunc(nil)
+
+// Observe implements the Observer interface.
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// EventKind indicates the kind of an Event.
+type EventKind int
+
+const (
+	// EventResolved indicates that a file was resolved via the compiler's
+	// Resolver. The event's Duration is the time spent in the resolver and
+	// its Resolved field indicates what kind of result it returned.
+	EventResolved = EventKind(iota + 1)
+	// EventParseStarted indicates that the compiler started parsing a file.
+	// This is only emitted for files for which the resolver returned source
+	// code or an AST.
+	EventParseStarted
+	// EventParseFinished indicates that the compiler finished parsing a file
+	// and converting it to a descriptor proto. The event's Duration is the
+	// time spent doing so.
+	EventParseFinished
+	// EventLinked indicates that the compiler finished linking a file. The
+	// event's Duration is the time spent linking, which does not include
+	// time spent waiting for dependencies to be compiled.
+	EventLinked
+	// EventOptionsInterpreted indicates that the compiler finished
+	// interpreting and validating the options in a file. The event's Duration
+	// is the time spent doing so.
+	EventOptionsInterpreted
+	// EventSourceInfoGenerated indicates that the compiler finished
+	// generating source code info for a file. This is not emitted if source
+	// code info is not generated. The event's Duration is the time spent
+	// doing so.
+	EventSourceInfoGenerated
+	// EventDone indicates that the compiler is done with a file. The event's
+	// Duration is the total time spent on the file, including time spent
+	// waiting for dependencies. If compilation failed, the event's Err field
+	// will be non-nil.
+	EventDone
+)
+
+// String returns a string representation of the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventResolved:
+		return "resolved"
+	case EventParseStarted:
+		return "parse started"
+	case EventParseFinished:
+		return "parse finished"
+	case EventLinked:
+		return "linked"
+	case EventOptionsInterpreted:
+		return "options interpreted"
+	case EventSourceInfoGenerated:
+		return "source info generated"
+	case EventDone:
+		return "done"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+// SearchResultKind indicates which field of a SearchResult was used by the
+// compiler.
+type SearchResultKind int
+
+const (
+	// SearchResultSource indicates the SearchResult.Source field.
+	SearchResultSource = SearchResultKind(iota + 1)
+	// SearchResultAST indicates the SearchResult.AST field.
+	SearchResultAST
+	// SearchResultProto indicates the SearchResult.Proto field.
+	SearchResultProto
+	// SearchResultParseResult indicates the SearchResult.ParseResult field.
+	SearchResultParseResult
+	// SearchResultDesc indicates the SearchResult.Desc field.
+	SearchResultDesc
+)
+
+// String returns the name of the SearchResult field that k represents.
+func (k SearchResultKind) String() string {
+	switch k {
+	case SearchResultSource:
+		return "Source"
+	case SearchResultAST:
+		return "AST"
+	case SearchResultProto:
+		return "Proto"
+	case SearchResultParseResult:
+		return "ParseResult"
+	case SearchResultDesc:
+		return "Desc"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+// kindOf returns the kind of the field in r that will be used by the
+// compiler. If multiple fields are set, this matches the order of preference
+// described in the docs for SearchResult.
+func kindOf(r SearchResult) SearchResultKind {
+	switch {
+	case r.Desc != nil:
+		return SearchResultDesc
+	case r.ParseResult != nil:
+		return SearchResultParseResult
+	case r.Proto != nil:
+		return SearchResultProto
+	case r.AST != nil:
+		return SearchResultAST
+	default:
+		return SearchResultSource
+	}
+}
+
+// Event describes progress in the compilation of a single file.
+type Event struct {
+	// The kind of event.
+	Kind EventKind
+	// The name of the file being compiled.
+	File string
+	// The time spent in the phase of compilation that this event concludes.
+	// This is zero for EventParseStarted.
+	Duration time.Duration
+	// The imports of the file that the compiler waited on before linking.
+	// This will be empty for events that precede waiting on dependencies
+	// (e.g. EventResolved).
+	BlockedOn []string
+	// For EventResolved and EventDone events, this indicates the kind of
+	// result returned by the resolver. For other events, it is zero.
+	Resolved SearchResultKind
+	// For EventDone events, this indicates if the file was loaded from the
+	// compiler's cache instead of being compiled.
+	FromCache bool
+	// For EventDone events, this is the error, if any, that caused
+	// compilation of the file to fail.
+	Err error
+}
+
+// observe sends an event to the compiler's observer, if it has one. The
+// given event's File and BlockedOn fields are populated from the task.
+func (t *task) observe(e Event) {
+	if t.e.c.Observer == nil {
+		return
+	}
+	e.File = t.r.name
+	e.BlockedOn = t.blockedOn
+	t.e.c.Observer.Observe(e)
+}
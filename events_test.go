@@ -0,0 +1,105 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocompile
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserver(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	events := map[string][]Event{}
+	observer := ObserverFunc(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events[e.File] = append(events[e.File], e)
+	})
+	kinds := func(file string) []EventKind {
+		mu.Lock()
+		defer mu.Unlock()
+		var kinds []EventKind
+		for _, e := range events[file] {
+			kinds = append(kinds, e.Kind)
+		}
+		return kinds
+	}
+
+	compiler := &Compiler{
+		Resolver: WithStandardImports(&SourceResolver{Accessor: SourceAccessorFromMap(map[string]string{
+			"a.proto": `syntax = "proto3"; package test; import "google/protobuf/descriptor.proto"; message A { google.protobuf.FileOptions opts = 1; }`,
+			"b.proto": `syntax = "proto3"; package test; import "a.proto"; message B { A a = 1; }`,
+			"c.proto": `syntax = "proto3"; package test; message C { Unknown u = 1; }`,
+		})}),
+		SourceInfoMode: SourceInfoStandard,
+		Observer:       observer,
+	}
+	_, err := compiler.Compile(context.Background(), "b.proto")
+	require.NoError(t, err)
+
+	sourceKinds := []EventKind{
+		EventResolved, EventParseStarted, EventParseFinished, EventLinked,
+		EventOptionsInterpreted, EventSourceInfoGenerated, EventDone,
+	}
+	assert.Equal(t, sourceKinds, kinds("a.proto"))
+	assert.Equal(t, sourceKinds, kinds("b.proto"))
+	assert.Equal(t, []EventKind{EventResolved, EventDone}, kinds("google/protobuf/descriptor.proto"))
+
+	mu.Lock()
+	resolved := events["b.proto"][0]
+	assert.Equal(t, SearchResultSource, resolved.Resolved)
+	assert.Empty(t, resolved.BlockedOn)
+	done := events["b.proto"][len(events["b.proto"])-1]
+	assert.Equal(t, []string{"a.proto"}, done.BlockedOn)
+	assert.NoError(t, done.Err)
+	assert.Positive(t, done.Duration)
+	assert.Equal(t, SearchResultDesc, events["google/protobuf/descriptor.proto"][0].Resolved)
+	mu.Unlock()
+
+	_, err = compiler.Compile(context.Background(), "c.proto")
+	require.Error(t, err)
+	assert.Equal(t, []EventKind{EventResolved, EventParseStarted, EventParseFinished, EventDone}, kinds("c.proto"))
+	mu.Lock()
+	assert.Error(t, events["c.proto"][3].Err)
+	mu.Unlock()
+}
+
+func TestObserverPanic(t *testing.T) {
+	t.Parallel()
+	var events []Event
+	compiler := &Compiler{
+		Resolver: ResolverFunc(func(string) (SearchResult, error) {
+			panic(errors.New("mui mui bad"))
+		}),
+		Observer: ObserverFunc(func(e Event) {
+			events = append(events, e)
+		}),
+	}
+	_, err := compiler.Compile(context.Background(), "test.proto")
+	var panicErr PanicError
+	require.ErrorAs(t, err, &panicErr)
+	// a task that panics still ends with an EventDone
+	require.Len(t, events, 1)
+	assert.Equal(t, EventDone, events[0].Kind)
+	assert.Equal(t, "test.proto", events[0].File)
+	require.ErrorAs(t, events[0].Err, &panicErr)
+	assert.Equal(t, "test.proto", panicErr.File)
+}
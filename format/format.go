@@ -0,0 +1,100 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package format implements canonical formatting of protobuf source files.
+//
+// The formatter works on the AST produced by package parser, so it accepts
+// exactly the same language as the compiler. The output uses the following
+// conventions:
+//
+//   - Declarations are indented with two spaces per level.
+//   - Tokens are separated by a single space, except around punctuation
+//     like dots, parentheses, commas, and semicolons.
+//   - The syntax or edition declaration comes first, followed by the
+//     package declaration, then imports sorted by path (unless the
+//     KeepImportOrder option is used), then file options, and then all
+//     other declarations in their original order. These groups, and all
+//     other top-level declarations, are separated by a single blank line.
+//   - Inside of bodies (messages, enums, services, etc), a blank line
+//     between two elements is kept, but runs of blank lines are collapsed
+//     into one. There are no blank lines at the start or end of a body,
+//     except to keep a detached comment separate from the open brace.
+//   - Compact options are kept on the same line when there is only one;
+//     otherwise, each option goes on its own line.
+//   - Message literals always span multiple lines, with one field per line
+//     and no separators. Array literals are kept on one line unless they
+//     contain message literals.
+//   - Extra semicolons (empty declarations) are removed.
+//
+// All comments are retained. Each comment stays attached to the same token
+// it is attached to in the source (see ast.NodeInfo.LeadingComments and
+// ast.NodeInfo.TrailingComments). And blank lines next to comments are only
+// added or removed where that doesn't change which element a comment belongs
+// to, so reformatting does not change the comments in source code info.
+package format
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// Print writes the canonical formatting of the given file to w.
+func Print(w io.Writer, file *ast.FileNode, opts ...Option) error {
+	p := newPrinter(file)
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+	p.printFile()
+	_, err := w.Write(p.bytes())
+	return err
+}
+
+// Source parses the given source and returns its canonical formatting. The
+// given filename is used in the positions of errors. If the source cannot be
+// parsed, the first syntax error is returned.
+func Source(filename string, src []byte, opts ...Option) ([]byte, error) {
+	file, err := parser.Parse(filename, bytes.NewReader(src), reporter.NewHandler(nil))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := Print(&buf, file, opts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Option represents an option for how source is formatted.
+type Option interface {
+	apply(*printer)
+}
+
+// KeepImportOrder will result in imports being written in the order they
+// appear in the source, instead of sorted by path. The order of imports
+// determines the order of dependencies in the file's descriptor, so this
+// is useful for source that must compile to exactly the same descriptor,
+// like source generated from a descriptor.
+func KeepImportOrder() Option {
+	return keepImportOrderOption{}
+}
+
+type keepImportOrderOption struct{}
+
+func (keepImportOrderOption) apply(p *printer) {
+	p.keepImportOrder = true
+}
@@ -0,0 +1,533 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/internal"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+	"github.com/bufbuild/protocompile/sourceinfo"
+)
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"empty": {
+			input:    "",
+			expected: "",
+		},
+		"only comments": {
+			input:    "  // just a comment   \n",
+			expected: "// just a comment\n",
+		},
+		"file layout": {
+			input: `// License header.
+
+syntax="proto3" ;
+option java_package="foo";
+import "z.proto";   import public "a.proto" ;
+message Foo{}
+package   foo.bar ;
+enum Bar{BAR_UNSPECIFIED=0;}
+// The end.
+`,
+			expected: `// License header.
+
+syntax = "proto3";
+
+package foo.bar;
+
+import public "a.proto";
+import "z.proto";
+
+option java_package = "foo";
+
+message Foo {}
+
+enum Bar {
+  BAR_UNSPECIFIED = 0;
+}
+// The end.
+`,
+		},
+		"blank lines in bodies": {
+			input: `syntax = "proto3";
+message Foo {
+
+  // leading
+  int32 x = 1; // trailing
+
+
+
+  string y = 2;
+  reserved 5, 6 to 10;reserved "a","b";
+
+}
+`,
+			expected: `syntax = "proto3";
+
+message Foo {
+  // leading
+  int32 x = 1; // trailing
+
+  string y = 2;
+  reserved 5, 6 to 10;
+  reserved "a", "b";
+}
+`,
+		},
+		"compact options": {
+			input: `syntax = "proto2";
+message Foo {
+  repeated  string a = 1[deprecated=true];
+  map < string , Foo > b = 2 [ deprecated = true , json_name = "bb" ];
+  optional int32 c = 3 [default=-1, (foo) = { a: 1 }];
+  extensions 100 to max [(bar) = "x"];
+}
+enum E { E_ZERO = 0 [(x) = -inf, (y) = 1.5]; }
+`,
+			expected: `syntax = "proto2";
+
+message Foo {
+  repeated string a = 1 [deprecated = true];
+  map<string, Foo> b = 2 [
+    deprecated = true,
+    json_name = "bb"
+  ];
+  optional int32 c = 3 [
+    default = -1,
+    (foo) = {
+      a: 1
+    }
+  ];
+  extensions 100 to max [(bar) = "x"];
+}
+
+enum E {
+  E_ZERO = 0 [
+    (x) = -inf,
+    (y) = 1.5
+  ];
+}
+`,
+		},
+		"message and array literals": {
+			input: `syntax = "proto3";
+option (custom) = { a: 1, b : <c:2> list:[1,2] msgs: [{x:1},{x:2}] [foo.ext]: "x" "y"; empty {} };
+option (other).name = "abc";
+option (any) = { [type.googleapis.com/foo.Bar] { name: 'x' } };
+`,
+			expected: `syntax = "proto3";
+
+option (custom) = {
+  a: 1
+  b {
+    c: 2
+  }
+  list: [1, 2]
+  msgs: [
+    {
+      x: 1
+    },
+    {
+      x: 2
+    }
+  ]
+  [foo.ext]:
+    "x"
+    "y"
+  empty {}
+};
+option (other).name = "abc";
+option (any) = {
+  [type.googleapis.com/foo.Bar] {
+    name: 'x'
+  }
+};
+`,
+		},
+		"nested declarations": {
+			input: `syntax = "proto2";
+message Foo {
+  oneof o { string s = 4; group G = 5 { optional int32 x = 1; } }
+  message Empty {   }
+  extend Foo { optional int32 ext = 100; }
+  ;;
+}
+`,
+			expected: `syntax = "proto2";
+
+message Foo {
+  oneof o {
+    string s = 4;
+    group G = 5 {
+      optional int32 x = 1;
+    }
+  }
+  message Empty {}
+  extend Foo {
+    optional int32 ext = 100;
+  }
+}
+`,
+		},
+		"services": {
+			input: `syntax = "proto3";
+service S { rpc Do ( stream Foo ) returns ( .foo.bar.Foo ) ; rpc Two(Foo) returns (Foo) {} rpc Three(Foo) returns (stream Foo) { option deprecated = true; } }
+`,
+			expected: `syntax = "proto3";
+
+service S {
+  rpc Do(stream Foo) returns (.foo.bar.Foo);
+  rpc Two(Foo) returns (Foo) {}
+  rpc Three(Foo) returns (stream Foo) {
+    option deprecated = true;
+  }
+}
+`,
+		},
+		"comments": {
+			input: `syntax = "proto3"; // syntax trailer
+
+/* detached */
+
+// leading for Foo
+message Foo { // trailer for Foo
+  int32 x = /* inline */ 1;
+      /*
+       * block comment
+       */
+  string y = 2;
+  ; // comment on empty decl
+  // at the end of the body
+}
+message Bar {
+  // only a comment
+}
+`,
+			expected: `syntax = "proto3"; // syntax trailer
+
+/* detached */
+
+// leading for Foo
+message Foo { // trailer for Foo
+  int32 x = /* inline */ 1;
+  /*
+   * block comment
+   */
+  string y = 2; // comment on empty decl
+  // at the end of the body
+}
+
+message Bar {
+  // only a comment
+}
+`,
+		},
+		"comments before punctuation": {
+			input: `syntax = "proto2";
+message Request // trailer
+{
+  repeated int32 a = 1 [packed = true /* packed! */, deprecated = true];
+  optional int32 b = 2 [default /* inline */ = 1];
+  extensions 10 to 20 // trailer
+  [verification = UNVERIFIED, declaration = {number: 10}];
+}
+enum Kind // trailer
+{}
+`,
+			expected: `syntax = "proto2";
+
+message Request // trailer
+{
+  repeated int32 a = 1 [
+    packed = true /* packed! */,
+    deprecated = true
+  ];
+  optional int32 b = 2 [default /* inline */ = 1];
+  extensions 10 to 20 // trailer
+  [
+    verification = UNVERIFIED,
+    declaration = {
+      number: 10
+    }
+  ];
+}
+
+enum Kind // trailer
+{}
+`,
+		},
+		"detached comments": {
+			input: `syntax = "proto3";
+// Syntax trailer.
+
+// pkg
+package foo;
+message X {
+
+  // Note: detached
+
+  // Is deprecated
+  optional bool d = 33; }
+message Empty {
+
+  // detached
+}
+`,
+			expected: `syntax = "proto3";
+// Syntax trailer.
+
+// pkg
+package foo;
+
+message X {
+
+  // Note: detached
+
+  // Is deprecated
+  optional bool d = 33;
+}
+
+message Empty {
+
+  // detached
+}
+`,
+		},
+		"editions": {
+			input: `edition = "2024";
+import option "opts.proto";
+import "dep.proto";
+export message Foo { local enum Kind { KIND_UNSPECIFIED = 0; } }
+`,
+			expected: `edition = "2024";
+
+import "dep.proto";
+import option "opts.proto";
+
+export message Foo {
+  local enum Kind {
+    KIND_UNSPECIFIED = 0;
+  }
+}
+`,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			formatted, err := Source("test.proto", []byte(testCase.input))
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, string(formatted))
+			// formatting is idempotent
+			again, err := Source("test.proto", formatted)
+			require.NoError(t, err)
+			assert.Equal(t, string(formatted), string(again))
+		})
+	}
+}
+
+func TestSource_KeepImportOrder(t *testing.T) {
+	t.Parallel()
+	const input = `syntax = "proto3";
+import "z.proto";
+import public "a.proto";
+`
+	formatted, err := Source("test.proto", []byte(input), KeepImportOrder())
+	require.NoError(t, err)
+	assert.Equal(t, `syntax = "proto3";
+
+import "z.proto";
+import public "a.proto";
+`, string(formatted))
+}
+
+func TestSource_SyntaxError(t *testing.T) {
+	t.Parallel()
+	_, err := Source("test.proto", []byte(`syntax = "proto3"; message Foo {`))
+	require.ErrorContains(t, err, "test.proto:1:33: syntax error")
+}
+
+func TestSource_TestData(t *testing.T) {
+	t.Parallel()
+	// The pathological files in parser/testdata are nested so deeply that
+	// formatting them is very slow, so only the large file is used.
+	paths := []string{"../parser/testdata/largeproto.proto"}
+	err := filepath.WalkDir("../internal/testdata", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".proto" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	for _, path := range paths {
+		path := path
+		t.Run(path, func(t *testing.T) {
+			t.Parallel()
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			original, err := parser.Parse(path, bytes.NewReader(data), reporter.NewHandler(nil))
+			if err != nil {
+				t.Skip("file has syntax errors")
+			}
+			var buf bytes.Buffer
+			require.NoError(t, Print(&buf, original))
+			formatted, err := parser.Parse(path, bytes.NewReader(buf.Bytes()), reporter.NewHandler(nil))
+			require.NoError(t, err)
+
+			again, err := Source(path, buf.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, buf.String(), string(again))
+			assert.Equal(t, comments(original), comments(formatted))
+			assert.Equal(t, sourceInfoComments(t, original), sourceInfoComments(t, formatted))
+			assert.True(t, proto.Equal(descriptor(t, original), descriptor(t, formatted)))
+		})
+	}
+}
+
+// comments returns the text of all comments in the given file, sorted and
+// with whitespace normalized.
+func comments(file *ast.FileNode) []string {
+	var result []string
+	items := file.Items()
+	for item, ok := items.First(); ok; item, ok = items.Next(item) {
+		if _, comment := file.GetItem(item); comment.IsValid() {
+			result = append(result, strings.Join(strings.Fields(comment.RawText()), " "))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// sourceInfoComments returns the comments in the source code info for the
+// given file, keyed by path, with whitespace normalized. Since the formatter
+// sorts imports, the paths of imports use the imported file's name instead of
+// its index.
+func sourceInfoComments(t *testing.T, file *ast.FileNode) map[string][]string {
+	t.Helper()
+	res, err := parser.ResultFromAST(file, false, reporter.NewHandler(nil))
+	require.NoError(t, err)
+	fd := res.FileDescriptorProto()
+	normalize := func(comment string) string {
+		return strings.Join(strings.Fields(comment), " ")
+	}
+	result := map[string][]string{}
+	for _, loc := range sourceinfo.GenerateSourceInfo(file, nil).GetLocation() {
+		if loc.LeadingComments == nil && loc.TrailingComments == nil && len(loc.LeadingDetachedComments) == 0 {
+			continue
+		}
+		path := fmt.Sprint(loc.Path)
+		if len(loc.Path) == 2 {
+			switch loc.Path[0] {
+			case internal.FileDependencyTag:
+				path = fmt.Sprintf("[%d %s]", loc.Path[0], fd.Dependency[loc.Path[1]])
+			case internal.FileOptionDependencyTag:
+				path = fmt.Sprintf("[%d %s]", loc.Path[0], internal.OptionDependencies(fd)[loc.Path[1]])
+			}
+		}
+		detached := make([]string, len(loc.LeadingDetachedComments))
+		for i, comment := range loc.LeadingDetachedComments {
+			detached[i] = normalize(comment)
+		}
+		result[path] = append(result[path], fmt.Sprintf("leading=%q trailing=%q detached=%q",
+			normalize(loc.GetLeadingComments()), normalize(loc.GetTrailingComments()), detached))
+	}
+	return result
+}
+
+// descriptor returns the descriptor for the given file. Since the formatter
+// sorts imports, the dependencies are sorted, too. And since it changes the
+// layout of message literals, aggregate values of options are normalized.
+func descriptor(t *testing.T, file *ast.FileNode) *descriptorpb.FileDescriptorProto {
+	t.Helper()
+	res, err := parser.ResultFromAST(file, false, reporter.NewHandler(nil))
+	require.NoError(t, err)
+	fd := proto.Clone(res.FileDescriptorProto()).(*descriptorpb.FileDescriptorProto)
+	public := map[string]bool{}
+	for _, index := range fd.PublicDependency {
+		public[fd.Dependency[index]] = true
+	}
+	weak := map[string]bool{}
+	for _, index := range fd.WeakDependency {
+		weak[fd.Dependency[index]] = true
+	}
+	sort.Strings(fd.Dependency)
+	fd.PublicDependency, fd.WeakDependency = nil, nil
+	for i, dep := range fd.Dependency {
+		if public[dep] {
+			fd.PublicDependency = append(fd.PublicDependency, int32(i))
+		}
+		if weak[dep] {
+			fd.WeakDependency = append(fd.WeakDependency, int32(i))
+		}
+	}
+	normalizeAggregateValues(fd.ProtoReflect())
+	return fd
+}
+
+func normalizeAggregateValues(msg protoreflect.Message) {
+	if opt, ok := msg.Interface().(*descriptorpb.UninterpretedOption); ok && opt.AggregateValue != nil {
+		var tokens []string
+		for _, token := range strings.Fields(opt.GetAggregateValue()) {
+			switch token {
+			case ",", ";", ":":
+			case "<":
+				tokens = append(tokens, "{")
+			case ">":
+				tokens = append(tokens, "}")
+			default:
+				tokens = append(tokens, token)
+			}
+		}
+		opt.AggregateValue = proto.String(strings.Join(tokens, " "))
+	}
+	msg.Range(func(field protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+		switch {
+		case field.Message() == nil || field.IsMap():
+		case field.IsList():
+			list := val.List()
+			for i := 0; i < list.Len(); i++ {
+				normalizeAggregateValues(list.Get(i).Message())
+			}
+		default:
+			normalizeAggregateValues(val.Message())
+		}
+		return true
+	})
+}
@@ -0,0 +1,932 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bufbuild/protocompile/ast"
+)
+
+const indentUnit = "  "
+
+// printer writes the tokens of a file, along with their comments. Whitespace
+// is not written eagerly: the layout code requests a space, line break, or
+// blank line, and the pending whitespace is written just before the next
+// token or comment. That way, the layout of a declaration doesn't need to
+// know about comments, and comments don't need to know about layout.
+type printer struct {
+	file   *ast.FileNode
+	buf    bytes.Buffer
+	indent int
+
+	// Whitespace to write before the next token or comment. At most
+	// one of these is honored, with newlines taking precedence.
+	pendingNewlines int // 1 is a line break, 2 is a blank line
+	pendingSpace    bool
+	// Set after a line comment (or a trailing comment). The next token
+	// must go on a new line, even if the layout does not call for one.
+	// If it doesn't, the new line is indented one extra level, as a
+	// continuation of the current declaration.
+	forceNewline bool
+	// Set after an open brace. Bodies never start with a blank line.
+	noBlank bool
+	// The last token that was written.
+	last ast.Token
+
+	// If true, imports are not sorted.
+	keepImportOrder bool
+}
+
+func newPrinter(file *ast.FileNode) *printer {
+	return &printer{file: file}
+}
+
+func (p *printer) bytes() []byte {
+	return p.buf.Bytes()
+}
+
+func (p *printer) space() {
+	p.pendingSpace = true
+}
+
+func (p *printer) line() {
+	if p.pendingNewlines < 1 {
+		p.pendingNewlines = 1
+	}
+}
+
+func (p *printer) blankLine() {
+	p.pendingNewlines = 2
+}
+
+// atLineStart returns true if the next token or comment will be the first
+// one on a line, at the current indentation level.
+func (p *printer) atLineStart() bool {
+	return p.pendingNewlines > 0 || p.buf.Len() == 0
+}
+
+func (p *printer) flush() {
+	switch {
+	case p.buf.Len() == 0:
+		// no whitespace at the start of the file
+	case p.pendingNewlines > 0 || p.forceNewline:
+		newlines, indent := p.pendingNewlines, p.indent
+		if newlines == 0 {
+			newlines, indent = 1, indent+1
+		}
+		if newlines > 1 && p.noBlank {
+			newlines = 1
+		}
+		for i := 0; i < newlines; i++ {
+			p.buf.WriteByte('\n')
+		}
+		for i := 0; i < indent; i++ {
+			p.buf.WriteString(indentUnit)
+		}
+	case p.pendingSpace:
+		p.buf.WriteByte(' ')
+	}
+	p.pendingNewlines, p.pendingSpace, p.forceNewline, p.noBlank = 0, false, false, false
+}
+
+func (p *printer) write(text string) {
+	p.flush()
+	p.buf.WriteString(text)
+}
+
+// token writes the given token, using the given text in place of the token's
+// text in the source. Its leading and trailing comments are written, too.
+func (p *printer) token(n ast.Node, text string) {
+	info := p.file.NodeInfo(n)
+	if lineStart, ok := p.leadingComments(info); ok {
+		p.separate(info.LeadingWhitespace(), lineStart, true)
+	}
+	p.write(text)
+	p.last = n.Start()
+	p.trailingComments(info)
+}
+
+// terminal writes the given token with its original text.
+func (p *printer) terminal(n ast.TerminalNode) {
+	p.token(n, p.file.NodeInfo(n).RawText())
+}
+
+// punct writes the given punctuation. If n is nil, the punctuation was absent
+// in the source (which the parser allows in some cases when it is lenient),
+// and the given text is written anyway.
+func (p *printer) punct(n *ast.RuneNode, text string) {
+	if n == nil {
+		p.write(text)
+		return
+	}
+	info := p.file.NodeInfo(n)
+	p.punctLeadingComments(info)
+	p.write(text)
+	p.last = n.Token()
+	p.trailingComments(info)
+}
+
+// open writes the given punctuation, which opens a block whose contents are
+// on their own lines, like the open brace of a message. If a comment before
+// it means that it must go on a new line, it goes at the indentation of the
+// enclosing declaration, not on a continuation line, so that it lines up
+// with the close brace.
+func (p *printer) open(n *ast.RuneNode, text string) {
+	var info ast.NodeInfo
+	if n != nil {
+		info = p.file.NodeInfo(n)
+		p.punctLeadingComments(info)
+	}
+	if p.forceNewline {
+		p.line()
+	}
+	p.write(text)
+	if n != nil {
+		p.last = n.Token()
+		p.trailingComments(info)
+	}
+}
+
+// punctLeadingComments writes the leading comments of punctuation. Writing
+// them uses up the whitespace that the layout requested before the
+// punctuation. So if it requested none, like before a comma, the punctuation
+// directly follows a comment on the same line.
+func (p *printer) punctLeadingComments(info ast.NodeInfo) {
+	spaced := p.pendingSpace || p.pendingNewlines > 0
+	if lineStart, ok := p.leadingComments(info); ok {
+		p.separate(info.LeadingWhitespace(), lineStart, true)
+		if !spaced {
+			p.pendingSpace = false
+		}
+	}
+}
+
+// dropped writes only the comments of the given token. This is used for
+// tokens that are removed when formatting, like extra semicolons.
+func (p *printer) dropped(n ast.Node) {
+	info := p.file.NodeInfo(n)
+	p.leadingComments(info)
+	p.trailingComments(info)
+}
+
+// leadingComments writes the given token's leading comments. It returns true
+// if there were any, along with whether the first comment was written at the
+// start of a line.
+func (p *printer) leadingComments(info ast.NodeInfo) (lineStart bool, ok bool) {
+	comments := info.LeadingComments()
+	if comments.Len() == 0 {
+		return false, false
+	}
+	lineStart = p.atLineStart()
+	for i := 0; i < comments.Len(); i++ {
+		c := comments.Index(i)
+		p.separate(c.LeadingWhitespace(), lineStart, i > 0)
+		p.comment(c)
+	}
+	return lineStart, true
+}
+
+func (p *printer) trailingComments(info ast.NodeInfo) {
+	comments := info.TrailingComments()
+	for i := 0; i < comments.Len(); i++ {
+		p.space()
+		p.comment(comments.Index(i))
+		// Whatever comes next goes on the following line, so
+		// that this is still a trailing comment when re-parsed.
+		p.forceNewline = true
+	}
+}
+
+// separate requests whitespace before a comment or before the token that
+// follows comments, based on the whitespace before it in the source. If the
+// item was on its own line, it will also be on its own line in the output.
+// A blank line is kept only if keepBlank is true and the item is not in the
+// middle of a declaration.
+func (p *printer) separate(sourceWhitespace string, lineStart, keepBlank bool) {
+	newlines := strings.Count(sourceWhitespace, "\n")
+	switch {
+	case newlines == 0:
+		p.space()
+	case !lineStart:
+		p.forceNewline = true
+	case newlines > 1 && keepBlank:
+		p.blankLine()
+	default:
+		p.line()
+	}
+}
+
+func (p *printer) comment(c ast.Comment) {
+	text := c.RawText()
+	if strings.HasPrefix(text, "//") {
+		p.write(strings.TrimRightFunc(text, unicode.IsSpace))
+		p.forceNewline = true
+		return
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > 1 {
+		// Re-indent the subsequent lines of a block comment that is on
+		// its own line, so they stay aligned with the first line.
+		reindent := p.atLineStart() || p.forceNewline
+		origIndent := c.Start().Col - 1
+		indent := strings.Repeat(indentUnit, p.indent)
+		for i := range lines {
+			line := strings.TrimRightFunc(lines[i], unicode.IsSpace)
+			if i > 0 && reindent {
+				line = trimIndent(line, origIndent)
+				if line != "" {
+					line = indent + line
+				}
+			}
+			lines[i] = line
+		}
+		text = strings.Join(lines, "\n")
+	}
+	p.write(text)
+}
+
+// trimIndent removes up to width leading spaces and tabs from s.
+func trimIndent(s string, width int) string {
+	i := 0
+	for i < len(s) && i < width && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	return s[i:]
+}
+
+// blankBefore returns true if there is a blank line before the given node
+// (or before its leading comments) in the source.
+func (p *printer) blankBefore(n ast.Node) bool {
+	info := p.file.NodeInfo(n)
+	whitespace := info.LeadingWhitespace()
+	if comments := info.LeadingComments(); comments.Len() > 0 {
+		whitespace = comments.Index(0).LeadingWhitespace()
+	}
+	return strings.Count(whitespace, "\n") > 1
+}
+
+// separateLeading requests the whitespace before the given node's leading
+// comments, or before the node itself if it has none: a blank line if blank
+// is true, otherwise a line break.
+//
+// In source code info, the first of the node's leading comments may instead
+// be a trailing comment of the previous element. That depends on whether the
+// comment directly follows the previous token or is separated from it by a
+// blank line. So the requested blank line is dropped or added if that is
+// needed to keep the comment attached to the same element.
+func (p *printer) separateLeading(n ast.Node, blank bool) {
+	if trailing, ok := p.trailsPrevious(n); ok {
+		blank = !trailing || p.last != p.prevToken(n)
+		if blank {
+			// even at the start of a body
+			p.noBlank = false
+		}
+	}
+	if blank {
+		p.blankLine()
+	} else {
+		p.line()
+	}
+}
+
+// trailsPrevious returns true if, in source code info, the first of the given
+// node's leading comments is a trailing comment of the previous token. The
+// second return value is false if that doesn't depend on whether there is a
+// blank line before the comment.
+//
+// Like protoc, the comment is a trailing comment if it directly follows the
+// previous token and it is followed by other groups of comments, by a blank
+// line, or by the end of a scope. Otherwise, if the comments are a single
+// group that directly precedes the node, they are its leading comments either
+// way.
+func (p *printer) trailsPrevious(n ast.Node) (trailing bool, ok bool) {
+	info := p.file.NodeInfo(n)
+	comments := info.LeadingComments()
+	prev := p.prevToken(n)
+	if comments.Len() == 0 || prev == 0 {
+		return false, false
+	}
+	prevInfo := p.file.TokenInfo(prev)
+	if prevInfo.TrailingComments().Len() > 0 {
+		// then none of the leading comments are trailing comments
+		return false, false
+	}
+	first, last := comments.Index(0), comments.Index(comments.Len()-1)
+	text := p.file.TokenInfo(n.Start()).RawText()
+	closer := text == "" || (len(text) == 1 && strings.ContainsAny(text, "}]),;"))
+	if !closer && !multipleGroups(comments) && last.End().Line >= info.Start().Line-1 {
+		return false, false
+	}
+	if first.Start().Line > prevInfo.End().Line+1 {
+		return false, true
+	}
+	// Before the end of a scope, comments on the same line as both tokens
+	// belong to neither, since it's ambiguous which one they belong to.
+	ambiguous := closer && text != "" &&
+		first.Start().Line == prevInfo.End().Line && last.End().Line == info.Start().Line
+	return !ambiguous, true
+}
+
+// prevToken returns the token before the given node, or zero if it is the
+// first token in the file.
+func (p *printer) prevToken(n ast.Node) ast.Token {
+	prev, ok := p.file.Tokens().Previous(n.Start())
+	if !ok {
+		return 0
+	}
+	return prev
+}
+
+// multipleGroups returns true if the given comments form more than one group
+// in source code info. A block comment is a group by itself, and a group of
+// line comments ends at a blank line.
+func multipleGroups(comments ast.Comments) bool {
+	for i := 1; i < comments.Len(); i++ {
+		prev, c := comments.Index(i-1), comments.Index(i)
+		if !strings.HasPrefix(prev.RawText(), "//") || !strings.HasPrefix(c.RawText(), "//") ||
+			c.Start().Line > prev.End().Line+1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *printer) hasComments(n ast.Node) bool {
+	info := p.file.NodeInfo(n)
+	return info.LeadingComments().Len() > 0 || info.TrailingComments().Len() > 0
+}
+
+// fileEntry is a top-level declaration, along with any empty declarations
+// that follow it, whose comments are printed after it.
+type fileEntry struct {
+	decl    ast.FileElement
+	empties []*ast.EmptyDeclNode
+}
+
+func (p *printer) printFile() {
+	file := p.file
+	switch {
+	case file.Syntax != nil:
+		p.syntax(file.Syntax.Keyword, file.Syntax.Equals, file.Syntax.Syntax, file.Syntax.Semicolon)
+	case file.Edition != nil:
+		p.syntax(file.Edition.Keyword, file.Edition.Equals, file.Edition.Edition, file.Edition.Semicolon)
+	}
+
+	var leading []*ast.EmptyDeclNode
+	var packages, imports, options, others []*fileEntry
+	var last *fileEntry
+	for _, decl := range file.Decls {
+		if empty, ok := decl.(*ast.EmptyDeclNode); ok {
+			if last == nil {
+				leading = append(leading, empty)
+			} else {
+				last.empties = append(last.empties, empty)
+			}
+			continue
+		}
+		last = &fileEntry{decl: decl}
+		switch decl.(type) {
+		case *ast.PackageNode:
+			packages = append(packages, last)
+		case *ast.ImportNode:
+			imports = append(imports, last)
+		case *ast.OptionNode:
+			options = append(options, last)
+		default:
+			others = append(others, last)
+		}
+	}
+	if !p.keepImportOrder {
+		sort.SliceStable(imports, func(i, j int) bool {
+			return imports[i].decl.(*ast.ImportNode).Name.AsString() < imports[j].decl.(*ast.ImportNode).Name.AsString()
+		})
+	}
+
+	for _, empty := range leading {
+		p.emptyDecl(empty)
+	}
+	p.fileEntries(packages, false)
+	p.fileEntries(imports, false)
+	p.fileEntries(options, true)
+	for _, entry := range others {
+		p.fileEntries([]*fileEntry{entry}, false)
+	}
+
+	// Comments at the end of the file are attributed to the EOF token.
+	info := file.NodeInfo(file.EOF)
+	if info.LeadingComments().Len() > 0 {
+		p.separateLeading(file.EOF, p.blankBefore(file.EOF))
+		p.leadingComments(info)
+	}
+	if p.buf.Len() > 0 {
+		p.buf.WriteByte('\n')
+	}
+}
+
+// fileEntries prints a group of top-level declarations. The group is
+// separated from what precedes it by a blank line. If keepBlanks is true,
+// blank lines between the declarations in the group are kept.
+func (p *printer) fileEntries(entries []*fileEntry, keepBlanks bool) {
+	for i, entry := range entries {
+		p.separateLeading(entry.decl, i == 0 || (keepBlanks && p.blankBefore(entry.decl)))
+		p.element(entry.decl)
+		for _, empty := range entry.empties {
+			p.emptyDecl(empty)
+		}
+	}
+}
+
+func (p *printer) syntax(keyword *ast.KeywordNode, equals *ast.RuneNode, val ast.StringValueNode, semicolon *ast.RuneNode) {
+	p.terminal(keyword)
+	p.space()
+	p.punct(equals, "=")
+	p.space()
+	p.value(val)
+	p.punct(semicolon, ";")
+}
+
+func (p *printer) emptyDecl(n *ast.EmptyDeclNode) {
+	if p.file.NodeInfo(n.Semicolon).LeadingComments().Len() > 0 {
+		p.line()
+	}
+	p.dropped(n.Semicolon)
+}
+
+// element prints a declaration, which is an element of a file or of the body
+// of some other declaration.
+func (p *printer) element(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.PackageNode:
+		p.terminal(n.Keyword)
+		p.space()
+		p.compact(n.Name)
+		p.punct(n.Semicolon, ";")
+	case *ast.ImportNode:
+		p.terminal(n.Keyword)
+		for _, modifier := range []*ast.KeywordNode{n.Public, n.Weak, n.Option} {
+			if modifier != nil {
+				p.space()
+				p.terminal(modifier)
+			}
+		}
+		p.space()
+		p.value(n.Name)
+		p.punct(n.Semicolon, ";")
+	case *ast.OptionNode:
+		p.terminal(n.Keyword)
+		p.space()
+		p.option(n)
+		p.punct(n.Semicolon, ";")
+	case *ast.MessageNode:
+		p.visibility(n.Visibility)
+		p.terminal(n.Keyword)
+		p.space()
+		p.terminal(n.Name)
+		p.body(n.OpenBrace, messageElements(n.Decls), n.CloseBrace)
+	case *ast.EnumNode:
+		p.visibility(n.Visibility)
+		p.terminal(n.Keyword)
+		p.space()
+		p.terminal(n.Name)
+		decls := make([]ast.Node, len(n.Decls))
+		for i, decl := range n.Decls {
+			decls[i] = decl
+		}
+		p.body(n.OpenBrace, decls, n.CloseBrace)
+	case *ast.ExtendNode:
+		p.terminal(n.Keyword)
+		p.space()
+		p.compact(n.Extendee)
+		decls := make([]ast.Node, len(n.Decls))
+		for i, decl := range n.Decls {
+			decls[i] = decl
+		}
+		p.body(n.OpenBrace, decls, n.CloseBrace)
+	case *ast.ServiceNode:
+		p.terminal(n.Keyword)
+		p.space()
+		p.terminal(n.Name)
+		decls := make([]ast.Node, len(n.Decls))
+		for i, decl := range n.Decls {
+			decls[i] = decl
+		}
+		p.body(n.OpenBrace, decls, n.CloseBrace)
+	case *ast.FieldNode:
+		if n.Label.KeywordNode != nil {
+			p.terminal(n.Label.KeywordNode)
+			p.space()
+		}
+		p.compact(n.FldType)
+		p.space()
+		p.terminal(n.Name)
+		p.fieldTag(n.Equals, n.Tag)
+		p.compactOptions(n.Options)
+		p.punct(n.Semicolon, ";")
+	case *ast.MapFieldNode:
+		p.terminal(n.MapType.Keyword)
+		p.punct(n.MapType.OpenAngle, "<")
+		p.terminal(n.MapType.KeyType)
+		p.punct(n.MapType.Comma, ",")
+		p.space()
+		p.compact(n.MapType.ValueType)
+		p.punct(n.MapType.CloseAngle, ">")
+		p.space()
+		p.terminal(n.Name)
+		p.fieldTag(n.Equals, n.Tag)
+		p.compactOptions(n.Options)
+		p.punct(n.Semicolon, ";")
+	case *ast.GroupNode:
+		if n.Label.KeywordNode != nil {
+			p.terminal(n.Label.KeywordNode)
+			p.space()
+		}
+		p.terminal(n.Keyword)
+		p.space()
+		p.terminal(n.Name)
+		p.fieldTag(n.Equals, n.Tag)
+		p.compactOptions(n.Options)
+		p.body(n.OpenBrace, messageElements(n.Decls), n.CloseBrace)
+	case *ast.OneofNode:
+		p.terminal(n.Keyword)
+		p.space()
+		p.terminal(n.Name)
+		decls := make([]ast.Node, len(n.Decls))
+		for i, decl := range n.Decls {
+			decls[i] = decl
+		}
+		p.body(n.OpenBrace, decls, n.CloseBrace)
+	case *ast.ExtensionRangeNode:
+		p.terminal(n.Keyword)
+		p.space()
+		for i, r := range n.Ranges {
+			if i > 0 {
+				p.space()
+			}
+			p.tagRange(r)
+			if i < len(n.Commas) {
+				p.punct(n.Commas[i], ",")
+			}
+		}
+		p.compactOptions(n.Options)
+		p.punct(n.Semicolon, ";")
+	case *ast.ReservedNode:
+		p.terminal(n.Keyword)
+		p.space()
+		var items []ast.Node
+		for _, r := range n.Ranges {
+			items = append(items, r)
+		}
+		for _, name := range n.Names {
+			items = append(items, name)
+		}
+		for _, ident := range n.Identifiers {
+			items = append(items, ident)
+		}
+		for i, item := range items {
+			if i > 0 {
+				p.space()
+			}
+			if r, ok := item.(*ast.RangeNode); ok {
+				p.tagRange(r)
+			} else {
+				p.value(item.(ast.ValueNode))
+			}
+			if i < len(n.Commas) {
+				p.punct(n.Commas[i], ",")
+			}
+		}
+		p.punct(n.Semicolon, ";")
+	case *ast.EnumValueNode:
+		p.terminal(n.Name)
+		p.space()
+		p.punct(n.Equals, "=")
+		p.space()
+		p.compact(n.Number)
+		p.compactOptions(n.Options)
+		p.punct(n.Semicolon, ";")
+	case *ast.RPCNode:
+		p.terminal(n.Keyword)
+		p.space()
+		p.terminal(n.Name)
+		p.rpcType(n.Input)
+		p.space()
+		p.terminal(n.Returns)
+		p.space()
+		p.rpcType(n.Output)
+		if n.OpenBrace == nil {
+			p.punct(n.Semicolon, ";")
+			break
+		}
+		decls := make([]ast.Node, len(n.Decls))
+		for i, decl := range n.Decls {
+			decls[i] = decl
+		}
+		p.body(n.OpenBrace, decls, n.CloseBrace)
+	case *ast.EmptyDeclNode:
+		p.emptyDecl(n)
+	default:
+		p.tokens(n)
+	}
+}
+
+func messageElements(elements []ast.MessageElement) []ast.Node {
+	decls := make([]ast.Node, len(elements))
+	for i, decl := range elements {
+		decls[i] = decl
+	}
+	return decls
+}
+
+func (p *printer) visibility(n *ast.KeywordNode) {
+	if n != nil {
+		p.terminal(n)
+		p.space()
+	}
+}
+
+func (p *printer) fieldTag(equals *ast.RuneNode, tag *ast.UintLiteralNode) {
+	if tag == nil {
+		// The tag may be absent if the parser was lenient.
+		return
+	}
+	p.space()
+	p.punct(equals, "=")
+	p.space()
+	p.terminal(tag)
+}
+
+func (p *printer) tagRange(n *ast.RangeNode) {
+	p.compact(n.StartVal)
+	if n.To == nil {
+		return
+	}
+	p.space()
+	p.terminal(n.To)
+	p.space()
+	if n.Max != nil {
+		p.terminal(n.Max)
+	} else {
+		p.compact(n.EndVal)
+	}
+}
+
+func (p *printer) rpcType(n *ast.RPCTypeNode) {
+	p.punct(n.OpenParen, "(")
+	if n.Stream != nil {
+		p.terminal(n.Stream)
+		p.space()
+	}
+	p.compact(n.MessageType)
+	p.punct(n.CloseParen, ")")
+}
+
+// body prints a brace-enclosed list of declarations. Each declaration goes on
+// its own line. Empty bodies are printed as "{}".
+func (p *printer) body(open *ast.RuneNode, decls []ast.Node, closeBrace *ast.RuneNode) {
+	p.space()
+	if !p.hasContent(open, decls, closeBrace) {
+		p.open(open, "{")
+		for _, decl := range decls {
+			p.dropped(decl)
+		}
+		p.punct(closeBrace, "}")
+		return
+	}
+	p.open(open, "{")
+	p.noBlank = true
+	p.indent++
+	for _, decl := range decls {
+		if empty, ok := decl.(*ast.EmptyDeclNode); ok {
+			p.emptyDecl(empty)
+			continue
+		}
+		p.separateLeading(decl, p.blankBefore(decl))
+		p.element(decl)
+	}
+	p.indent--
+	p.closeBrace(closeBrace, "}")
+}
+
+// hasContent returns true if a body has any declarations or comments, other
+// than extra semicolons without comments.
+func (p *printer) hasContent(open *ast.RuneNode, decls []ast.Node, closeBrace *ast.RuneNode) bool {
+	if open != nil && p.file.NodeInfo(open).TrailingComments().Len() > 0 {
+		return true
+	}
+	for _, decl := range decls {
+		empty, ok := decl.(*ast.EmptyDeclNode)
+		if !ok || p.hasComments(empty.Semicolon) {
+			return true
+		}
+	}
+	return closeBrace != nil && p.file.NodeInfo(closeBrace).LeadingComments().Len() > 0
+}
+
+// closeBrace writes the closing brace (or bracket) of a multi-line construct
+// on its own line. Any comments before it are indented like the contents.
+func (p *printer) closeBrace(n *ast.RuneNode, text string) {
+	if n == nil {
+		p.line()
+		p.write(text)
+		return
+	}
+	info := p.file.NodeInfo(n)
+	if info.LeadingComments().Len() > 0 {
+		p.indent++
+		p.separateLeading(n, p.blankBefore(n))
+		p.leadingComments(info)
+		p.indent--
+	}
+	p.pendingNewlines = 1
+	p.write(text)
+	p.last = n.Token()
+	p.trailingComments(info)
+}
+
+func (p *printer) compactOptions(n *ast.CompactOptionsNode) {
+	if n == nil {
+		return
+	}
+	p.space()
+	multiline := len(n.Options) > 1
+	if multiline {
+		p.open(n.OpenBracket, "[")
+		p.indent++
+	} else {
+		p.punct(n.OpenBracket, "[")
+	}
+	for i, opt := range n.Options {
+		if multiline {
+			p.line()
+		}
+		p.option(opt)
+		if i < len(n.Commas) {
+			if i == len(n.Options)-1 {
+				// trailing comma, allowed only when parser is lenient
+				p.dropped(n.Commas[i])
+			} else {
+				p.punct(n.Commas[i], ",")
+			}
+		}
+	}
+	if multiline {
+		p.indent--
+		p.closeBrace(n.CloseBracket, "]")
+	} else {
+		p.punct(n.CloseBracket, "]")
+	}
+}
+
+// option prints the name and value of an option, without the "option"
+// keyword or trailing semicolon.
+func (p *printer) option(n *ast.OptionNode) {
+	p.optionName(n.Name)
+	if n.Val == nil {
+		return
+	}
+	p.space()
+	p.punct(n.Equals, "=")
+	p.space()
+	p.value(n.Val)
+}
+
+func (p *printer) optionName(n *ast.OptionNameNode) {
+	for i, part := range n.Parts {
+		p.compact(part)
+		if i < len(n.Dots) {
+			p.punct(n.Dots[i], ".")
+		}
+	}
+}
+
+func (p *printer) value(n ast.ValueNode) {
+	switch n := n.(type) {
+	case *ast.CompoundStringLiteralNode:
+		// Each part of the string goes on its own line.
+		p.indent++
+		for _, part := range n.Children() {
+			p.line()
+			p.compact(part)
+		}
+		p.indent--
+	case *ast.ArrayLiteralNode:
+		p.arrayLiteral(n)
+	case *ast.MessageLiteralNode:
+		p.messageLiteral(n)
+	default:
+		p.compact(n)
+	}
+}
+
+func (p *printer) arrayLiteral(n *ast.ArrayLiteralNode) {
+	p.punct(n.OpenBracket, "[")
+	var multiline bool
+	for _, elem := range n.Elements {
+		if _, ok := elem.(*ast.MessageLiteralNode); ok {
+			multiline = true
+			break
+		}
+	}
+	if multiline {
+		p.noBlank = true
+		p.indent++
+	}
+	for i, elem := range n.Elements {
+		switch {
+		case multiline:
+			p.line()
+		case i > 0:
+			p.space()
+		}
+		p.value(elem)
+		if i < len(n.Commas) {
+			p.punct(n.Commas[i], ",")
+		}
+	}
+	if multiline {
+		p.indent--
+		p.closeBrace(n.CloseBracket, "]")
+	} else {
+		p.punct(n.CloseBracket, "]")
+	}
+}
+
+func (p *printer) messageLiteral(n *ast.MessageLiteralNode) {
+	// Angle brackets are replaced with braces.
+	if len(n.Elements) == 0 && !p.hasComments(n.Close) && p.file.NodeInfo(n.Open).TrailingComments().Len() == 0 {
+		p.punct(n.Open, "{")
+		p.punct(n.Close, "}")
+		return
+	}
+	p.open(n.Open, "{")
+	p.noBlank = true
+	p.indent++
+	for i, field := range n.Elements {
+		if p.blankBefore(field) {
+			p.blankLine()
+		} else {
+			p.line()
+		}
+		p.compact(field.Name)
+		if _, ok := field.Val.(*ast.MessageLiteralNode); ok {
+			if field.Sep != nil {
+				p.dropped(field.Sep)
+			}
+		} else {
+			p.punct(field.Sep, ":")
+		}
+		p.space()
+		p.value(field.Val)
+		if i < len(n.Seps) && n.Seps[i] != nil {
+			p.dropped(n.Seps[i])
+		}
+	}
+	p.indent--
+	p.closeBrace(n.Close, "}")
+}
+
+// compact prints all tokens of the given node without any space between them.
+// This is used for things like qualified names and negative numbers.
+func (p *printer) compact(n ast.Node) {
+	if n == nil {
+		return
+	}
+	switch n := n.(type) {
+	case ast.TerminalNode:
+		p.terminal(n)
+	case ast.CompositeNode:
+		for _, child := range n.Children() {
+			p.compact(child)
+		}
+	}
+}
+
+// tokens prints all tokens of the given node, separated by spaces. This is a
+// fallback for nodes that have no specific layout.
+func (p *printer) tokens(n ast.Node) {
+	switch n := n.(type) {
+	case ast.TerminalNode:
+		p.space()
+		p.terminal(n)
+	case ast.CompositeNode:
+		for _, child := range n.Children() {
+			p.tokens(child)
+		}
+	}
+}
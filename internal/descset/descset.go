@@ -0,0 +1,80 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package descset contains helpers for producing descriptor protos from
+// compiled files in the same form that protoc does, such as when writing
+// descriptor sets or sending files to plugins.
+package descset
+
+import (
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/options"
+	"github.com/bufbuild/protocompile/protoutil"
+)
+
+// Transitive returns the given files and all of their transitive
+// dependencies in topological order: every file appears after all of the
+// files it imports. Each file appears only once.
+func Transitive(files linker.Files) linker.Files {
+	var result linker.Files
+	seen := map[string]struct{}{}
+	var add func(file linker.File)
+	add = func(file linker.File) {
+		if _, ok := seen[file.Path()]; ok {
+			return
+		}
+		seen[file.Path()] = struct{}{}
+		imports := file.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(file.FindImportByPath(imports.Get(i).Path()))
+		}
+		result = append(result, file)
+	}
+	for _, file := range files {
+		add(file)
+	}
+	return result
+}
+
+// FileProto returns the descriptor proto for the given file. If retainOptions
+// is false, options whose retention is source-only are removed.
+//
+// When possible, options are encoded the same way as protoc encodes them,
+// which preserves the order in which they appear in source. But if options
+// must be removed, the remaining options are encoded in field number order.
+//
+// The returned proto may share state with the file, so it must not be
+// mutated.
+func FileProto(file linker.File, retainOptions bool) (*descriptorpb.FileDescriptorProto, error) {
+	res, ok := file.(linker.Result)
+	if !ok {
+		fd := protoutil.ProtoFromFileDescriptor(file)
+		if retainOptions {
+			return fd, nil
+		}
+		return options.StripSourceRetentionOptionsFromFile(fd)
+	}
+	if !retainOptions {
+		stripped, err := options.StripSourceRetentionOptionsFromFile(res.FileDescriptorProto())
+		if err != nil {
+			return nil, err
+		}
+		if stripped != res.FileDescriptorProto() {
+			return stripped, nil
+		}
+	}
+	return res.CanonicalProto(), nil
+}
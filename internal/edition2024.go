@@ -0,0 +1,256 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Edition2024 is the value of google.protobuf.Edition for edition 2024. It is
+// newer than the version of descriptor.proto in the protobuf-go runtime, so
+// there is no named constant for it in descriptorpb.
+const Edition2024 = descriptorpb.Edition(1001)
+
+// EditionName returns the name of the given edition, as used in error
+// messages. Unlike the String method of descriptorpb.Edition, it knows the
+// name of Edition2024.
+func EditionName(edition descriptorpb.Edition) string {
+	if edition == Edition2024 {
+		return "EDITION_2024"
+	}
+	return edition.String()
+}
+
+// SymbolVisibility corresponds to google.protobuf.SymbolVisibility, which
+// controls whether a message or enum can be referenced from other files.
+type SymbolVisibility int32
+
+const (
+	// VisibilityUnset indicates that the default visibility applies.
+	VisibilityUnset = SymbolVisibility(0)
+	// VisibilityLocal indicates that the symbol may only be referenced from
+	// the file in which it is declared.
+	VisibilityLocal = SymbolVisibility(1)
+	// VisibilityExport indicates that the symbol may be referenced from other
+	// files.
+	VisibilityExport = SymbolVisibility(2)
+)
+
+// FeatureSetDefaultSymbolVisibilityTag is the tag number of the
+// default_symbol_visibility field in google.protobuf.FeatureSet.
+const FeatureSetDefaultSymbolVisibilityTag = 8
+
+// DefaultSymbolVisibility corresponds to
+// google.protobuf.FeatureSet.VisibilityFeature.DefaultSymbolVisibility, the
+// type of the default_symbol_visibility feature. It controls the visibility
+// of messages and enums that are declared without "export" or "local".
+type DefaultSymbolVisibility int32
+
+const (
+	// DefaultVisibilityUnknown indicates that the feature is not set.
+	DefaultVisibilityUnknown = DefaultSymbolVisibility(0)
+	// DefaultVisibilityExportAll indicates that all symbols are exported by
+	// default. This is the behavior before edition 2024.
+	DefaultVisibilityExportAll = DefaultSymbolVisibility(1)
+	// DefaultVisibilityExportTopLevel indicates that top-level symbols are
+	// exported by default and nested symbols are local by default. This is
+	// the default in edition 2024.
+	DefaultVisibilityExportTopLevel = DefaultSymbolVisibility(2)
+	// DefaultVisibilityLocalAll indicates that all symbols are local by
+	// default.
+	DefaultVisibilityLocalAll = DefaultSymbolVisibility(3)
+	// DefaultVisibilityStrict indicates that all symbols are local by
+	// default and that nested symbols may not be exported.
+	DefaultVisibilityStrict = DefaultSymbolVisibility(4)
+)
+
+// DefaultSymbolVisibilityField describes the default_symbol_visibility field
+// of google.protobuf.FeatureSet. Since the field is newer than the version of
+// descriptor.proto in the protobuf-go runtime, this is a field of a stand-in
+// FeatureSet message that has no other fields. Its values serialize the same
+// way as values of the real field.
+var DefaultSymbolVisibilityField = newDefaultSymbolVisibilityField()
+
+func newDefaultSymbolVisibilityField() protoreflect.FieldDescriptor {
+	// The field may only be used on files, starting in edition 2024.
+	var support []byte
+	support = protowire.AppendTag(support, featureSupportIntroducedTag, protowire.VarintType)
+	support = protowire.AppendVarint(support, uint64(Edition2024))
+	opts := &descriptorpb.FieldOptions{
+		Retention: descriptorpb.FieldOptions_RETENTION_SOURCE.Enum(),
+		Targets:   []descriptorpb.FieldOptions_OptionTargetType{descriptorpb.FieldOptions_TARGET_TYPE_FILE},
+	}
+	unknown := protowire.AppendTag(nil, FieldOptionsFeatureSupportTag, protowire.BytesType)
+	unknown = protowire.AppendBytes(unknown, support)
+	opts.ProtoReflect().SetUnknown(unknown)
+
+	enumValues := []string{
+		"DEFAULT_SYMBOL_VISIBILITY_UNKNOWN",
+		"EXPORT_ALL",
+		"EXPORT_TOP_LEVEL",
+		"LOCAL_ALL",
+		"STRICT",
+	}
+	enum := &descriptorpb.EnumDescriptorProto{Name: proto.String("DefaultSymbolVisibility")}
+	for i, name := range enumValues {
+		enum.Value = append(enum.Value, &descriptorpb.EnumValueDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(int32(i)),
+		})
+	}
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("google/protobuf/descriptor.proto"),
+		Package: proto.String("google.protobuf"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("FeatureSet"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("default_symbol_visibility"),
+				JsonName: proto.String("defaultSymbolVisibility"),
+				Number:   proto.Int32(FeatureSetDefaultSymbolVisibilityTag),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum(),
+				TypeName: proto.String(".google.protobuf.FeatureSet.VisibilityFeature.DefaultSymbolVisibility"),
+				Options:  opts,
+			}},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name:     proto.String("VisibilityFeature"),
+				EnumType: []*descriptorpb.EnumDescriptorProto{enum},
+			}},
+		}},
+	}, new(protoregistry.Files))
+	if err != nil {
+		panic(err)
+	}
+	return fd.Messages().Get(0).Fields().Get(0)
+}
+
+// DefaultSymbolVisibilityOf returns the value of the default_symbol_visibility
+// feature in the given feature set. It returns DefaultVisibilityUnknown if the
+// feature is not set.
+func DefaultSymbolVisibilityOf(features *descriptorpb.FeatureSet) DefaultSymbolVisibility {
+	if features == nil {
+		return DefaultVisibilityUnknown
+	}
+	var vis DefaultSymbolVisibility
+	rangeUnknownFields(features.ProtoReflect(), FeatureSetDefaultSymbolVisibilityTag, protowire.VarintType, func(data []byte) int {
+		val, n := protowire.ConsumeVarint(data)
+		if n >= 0 {
+			vis = DefaultSymbolVisibility(int32(val))
+		}
+		return n
+	})
+	return vis
+}
+
+// The fields used for edition 2024 declarations (option dependencies and
+// symbol visibility) are newer than the version of descriptor.proto in the
+// protobuf-go runtime. So they are stored as unrecognized fields, which
+// still serialize correctly.
+
+// OptionDependencies returns the option dependencies of the given file.
+// These are files imported with "import option".
+func OptionDependencies(fd *descriptorpb.FileDescriptorProto) []string {
+	var deps []string
+	rangeUnknownFields(fd.ProtoReflect(), FileOptionDependencyTag, protowire.BytesType, func(data []byte) int {
+		val, n := protowire.ConsumeBytes(data)
+		if n >= 0 {
+			deps = append(deps, string(val))
+		}
+		return n
+	})
+	return deps
+}
+
+// AddOptionDependency adds the given path to the option dependencies of the
+// given file.
+func AddOptionDependency(fd *descriptorpb.FileDescriptorProto, path string) {
+	msg := fd.ProtoReflect()
+	unknown := msg.GetUnknown()
+	unknown = protowire.AppendTag(unknown, FileOptionDependencyTag, protowire.BytesType)
+	unknown = protowire.AppendString(unknown, path)
+	msg.SetUnknown(unknown)
+}
+
+// VisibilityOf returns the visibility of the given message or enum
+// descriptor proto. It returns VisibilityUnset for other messages.
+func VisibilityOf(msg protoreflect.ProtoMessage) SymbolVisibility {
+	tag, ok := visibilityTag(msg)
+	if !ok {
+		return VisibilityUnset
+	}
+	var vis SymbolVisibility
+	rangeUnknownFields(msg.ProtoReflect(), tag, protowire.VarintType, func(data []byte) int {
+		val, n := protowire.ConsumeVarint(data)
+		if n >= 0 {
+			vis = SymbolVisibility(int32(val))
+		}
+		return n
+	})
+	return vis
+}
+
+// SetVisibility sets the visibility of the given message or enum descriptor
+// proto. It does nothing for other messages.
+func SetVisibility(msg protoreflect.ProtoMessage, vis SymbolVisibility) {
+	tag, ok := visibilityTag(msg)
+	if !ok {
+		return
+	}
+	m := msg.ProtoReflect()
+	unknown := m.GetUnknown()
+	unknown = protowire.AppendTag(unknown, tag, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, uint64(vis))
+	m.SetUnknown(unknown)
+}
+
+func visibilityTag(msg protoreflect.ProtoMessage) (protowire.Number, bool) {
+	switch msg.(type) {
+	case *descriptorpb.DescriptorProto:
+		return MessageVisibilityTag, true
+	case *descriptorpb.EnumDescriptorProto:
+		return EnumVisibilityTag, true
+	default:
+		return 0, false
+	}
+}
+
+// rangeUnknownFields calls fn for the value of every unrecognized field of
+// msg with the given number and type. The function must return the length
+// of the value, or a negative number if it is invalid, which stops the
+// iteration.
+func rangeUnknownFields(msg protoreflect.Message, num protowire.Number, typ protowire.Type, fn func([]byte) int) {
+	data := msg.GetUnknown()
+	for len(data) > 0 {
+		fieldNum, fieldType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return
+		}
+		data = data[n:]
+		if fieldNum == num && fieldType == typ {
+			n = fn(data)
+		} else {
+			n = protowire.ConsumeFieldValue(fieldNum, fieldType, data)
+		}
+		if n < 0 {
+			return
+		}
+		data = data[n:]
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	// FieldOptionsFeatureSupportTag is the tag number of the feature_support
+	// field in google.protobuf.FieldOptions.
+	FieldOptionsFeatureSupportTag = 22
+
+	featureSupportIntroducedTag         = 1
+	featureSupportDeprecatedTag         = 2
+	featureSupportDeprecationWarningTag = 3
+	featureSupportRemovedTag            = 4
+)
+
+// FeatureSupport describes the editions in which a feature may be used. It
+// corresponds to google.protobuf.FieldOptions.FeatureSupport, which is newer
+// than the version of descriptor.proto in the protobuf-go runtime. So it is
+// only present as unrecognized fields of a feature field's options.
+type FeatureSupport struct {
+	// The first edition in which the feature may be used.
+	EditionIntroduced descriptorpb.Edition
+	// The first edition in which the feature is deprecated, or zero if
+	// it is not deprecated.
+	EditionDeprecated descriptorpb.Edition
+	// A message that explains how to migrate away from a deprecated feature.
+	DeprecationWarning string
+	// The first edition in which the feature may no longer be used, or
+	// zero if it has not been removed.
+	EditionRemoved descriptorpb.Edition
+}
+
+// FeatureSupportOf returns the feature support of a feature field with the
+// given options. It returns nil if the options do not include feature support
+// or if they cannot be parsed.
+func FeatureSupportOf(opts *descriptorpb.FieldOptions) *FeatureSupport {
+	var support *FeatureSupport
+	data := opts.ProtoReflect().GetUnknown()
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil
+		}
+		data = data[n:]
+		if num != FieldOptionsFeatureSupportTag || typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil
+			}
+			data = data[n:]
+			continue
+		}
+		val, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return nil
+		}
+		data = data[n:]
+		if support == nil {
+			support = &FeatureSupport{}
+		}
+		// Multiple occurrences of a message field are merged.
+		if !support.merge(val) {
+			return nil
+		}
+	}
+	return support
+}
+
+func (s *FeatureSupport) merge(data []byte) bool {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return false
+		}
+		data = data[n:]
+		switch {
+		case typ == protowire.VarintType &&
+			(num == featureSupportIntroducedTag || num == featureSupportDeprecatedTag || num == featureSupportRemovedTag):
+			val, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return false
+			}
+			data = data[n:]
+			edition := descriptorpb.Edition(int32(val))
+			switch num {
+			case featureSupportIntroducedTag:
+				s.EditionIntroduced = edition
+			case featureSupportDeprecatedTag:
+				s.EditionDeprecated = edition
+			default:
+				s.EditionRemoved = edition
+			}
+		case typ == protowire.BytesType && num == featureSupportDeprecationWarningTag:
+			val, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return false
+			}
+			data = data[n:]
+			s.DeprecationWarning = string(val)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return false
+			}
+			data = data[n:]
+		}
+	}
+	return true
+}
@@ -143,6 +143,14 @@ type result struct {
 	prefix string
 	deps   Files
 
+	// The paths of files imported with "import option". These are included
+	// in deps, but their elements may only be used in options.
+	optionDeps []string
+	// The set of files whose types may be used in this file: its regular
+	// imports and their public imports. This is computed lazily and only
+	// when the file has option dependencies.
+	typeImports map[string]struct{}
+
 	// A map of all descriptors keyed by their fully-qualified name (without
 	// any leading dot).
 	descriptors map[string]protoreflect.Descriptor
@@ -161,6 +169,16 @@ type result struct {
 	// message literals (in option values) that are extension fields. These names
 	// are resolved during linking and stored here, to be used to interpret options.
 	optionQualifiedNames map[ast.IdentValueNode]string
+	// The options whose names refer to extensions that could not be resolved.
+	// These were already reported as errors, so they aren't interpreted.
+	unresolvedOptions map[*descriptorpb.UninterpretedOption]struct{}
+
+	// The references to symbols in this file, sorted by their location. See
+	// Result.References.
+	references []Reference
+	// Option names whose references are recorded after all other references
+	// are resolved.
+	optionNames []optionNameRefs
 
 	imports      fileImports
 	messages     msgDescriptors
@@ -177,6 +195,7 @@ var _ protoutil.DescriptorProtoWrapper = (*result)(nil)
 func (r *result) RemoveAST() {
 	r.Result = parser.ResultWithoutAST(r.FileDescriptorProto())
 	r.optionQualifiedNames = nil
+	r.removeReferenceNodes()
 }
 
 func (r *result) AsProto() proto.Message {
@@ -201,6 +220,8 @@ func (r *result) Syntax() protoreflect.Syntax {
 		return protoreflect.Proto2
 	case "proto3":
 		return protoreflect.Proto3
+	case "editions":
+		return protoreflect.Editions
 	default:
 		return 0 // ???
 	}
@@ -886,6 +907,12 @@ func (e *enumDescriptor) ReservedRanges() protoreflect.EnumRanges {
 	return e.rsvdRanges
 }
 
+// IsClosed returns true if the enum uses closed semantics, where a value
+// that is not declared in the enum is treated as an unknown field.
+func (e *enumDescriptor) IsClosed() bool {
+	return isClosedEnum(e)
+}
+
 type enumRanges struct {
 	protoreflect.EnumRanges
 	ranges [][2]protoreflect.EnumNumber
@@ -1224,6 +1251,13 @@ func (f *fldDescriptor) Cardinality() protoreflect.Cardinality {
 	case descriptorpb.FieldDescriptorProto_LABEL_REQUIRED:
 		return protoreflect.Required
 	case descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL:
+		if isEditions(f.file) {
+			// fields in editions files use a feature instead of a label
+			presence := resolveFeature(f, fieldPresenceField)
+			if descriptorpb.FeatureSet_FieldPresence(presence.Enum()) == descriptorpb.FeatureSet_LEGACY_REQUIRED {
+				return protoreflect.Required
+			}
+		}
 		return protoreflect.Optional
 	default:
 		return 0
@@ -1231,7 +1265,16 @@ func (f *fldDescriptor) Cardinality() protoreflect.Cardinality {
 }
 
 func (f *fldDescriptor) Kind() protoreflect.Kind {
-	return protoreflect.Kind(f.proto.GetType())
+	kind := protoreflect.Kind(f.proto.GetType())
+	if kind == protoreflect.MessageKind && isEditions(f.file) && !f.isMapEntry() {
+		// Editions has no groups. Instead, message fields can use the same
+		// delimited encoding, which is reported as a group kind.
+		val := resolveFeature(f, messageEncodingField)
+		if descriptorpb.FeatureSet_MessageEncoding(val.Enum()) == descriptorpb.FeatureSet_DELIMITED {
+			return protoreflect.GroupKind
+		}
+	}
+	return kind
 }
 
 func (f *fldDescriptor) HasJSONName() bool {
@@ -1253,12 +1296,22 @@ func (f *fldDescriptor) TextName() string {
 }
 
 func (f *fldDescriptor) HasPresence() bool {
-	if f.FieldDescriptor != noOpField {
-		return f.FieldDescriptor.HasPresence()
-	}
 	if f.proto.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
 		return false
 	}
+	if isEditions(f.file) {
+		// The protobuf-go runtime doesn't yet consider features when computing
+		// presence, so we always compute it from resolved features here.
+		if f.IsExtension() || f.Kind() == protoreflect.MessageKind || f.Kind() == protoreflect.GroupKind ||
+			f.proto.OneofIndex != nil {
+			return true
+		}
+		val := resolveFeature(f, fieldPresenceField)
+		return descriptorpb.FeatureSet_FieldPresence(val.Enum()) != descriptorpb.FeatureSet_IMPLICIT
+	}
+	if f.FieldDescriptor != noOpField {
+		return f.FieldDescriptor.HasPresence()
+	}
 	return f.IsExtension() ||
 		f.Syntax() == protoreflect.Proto2 ||
 		f.Kind() == protoreflect.MessageKind || f.Kind() == protoreflect.GroupKind ||
@@ -1292,6 +1345,13 @@ func (f *fldDescriptor) IsWeak() bool {
 }
 
 func (f *fldDescriptor) IsPacked() bool {
+	if isEditions(f.file) {
+		if f.proto.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED || !isPackable(f) {
+			return false
+		}
+		val := resolveFeature(f, repeatedFieldEncodingField)
+		return descriptorpb.FeatureSet_RepeatedFieldEncoding(val.Enum()) == descriptorpb.FeatureSet_PACKED
+	}
 	if f.FieldDescriptor != noOpField {
 		return f.FieldDescriptor.IsPacked()
 	}
@@ -0,0 +1,238 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linker
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ComputeFeatureSetDefaults computes the default values of all features for
+// each edition up to the given maximum, like protoc's --edition_defaults_out
+// flag. The result can be used by runtimes and code generators to resolve
+// features without having to interpret the edition_defaults option of every
+// feature field.
+//
+// The given files must include google/protobuf/descriptor.proto or a file that
+// imports it, since the google.protobuf.FeatureSet message defined there
+// provides the standard features. Any extensions of FeatureSet that are
+// defined in the given files or their transitive imports are included as
+// custom features.
+//
+// The result contains an entry for every edition that appears in the
+// edition_defaults of any feature field and is not newer than maxEdition. The
+// entries are sorted by edition. Entries older than minEdition are retained,
+// because they still describe the defaults for those editions.
+func ComputeFeatureSetDefaults(files Files, minEdition, maxEdition descriptorpb.Edition) (*descriptorpb.FeatureSetDefaults, error) {
+	if minEdition > maxEdition {
+		return nil, fmt.Errorf("invalid edition range, edition %v is newer than edition %v", minEdition, maxEdition)
+	}
+
+	var featureSet protoreflect.MessageDescriptor
+	var exts []protoreflect.ExtensionDescriptor
+	seen := map[protoreflect.FullName]struct{}{}
+	for _, file := range files {
+		if featureSet == nil {
+			featureSet = findFeatureSet(file, map[string]struct{}{})
+		}
+		for _, ext := range customFeatures(file) {
+			if _, ok := seen[ext.FullName()]; ok {
+				continue
+			}
+			seen[ext.FullName()] = struct{}{}
+			exts = append(exts, ext)
+		}
+	}
+	if featureSet == nil {
+		return nil, fmt.Errorf("could not find %s; make sure google/protobuf/descriptor.proto is included", featureSetName)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		return exts[i].Number() < exts[j].Number()
+	})
+
+	editions := map[descriptorpb.Edition]struct{}{}
+	if err := validateFeatureMessage(featureSet); err != nil {
+		return nil, err
+	}
+	collectEditions(featureSet, maxEdition, editions)
+	for _, ext := range exts {
+		if err := validateFeatureExtension(ext); err != nil {
+			return nil, err
+		}
+		if err := validateFeatureMessage(ext.Message()); err != nil {
+			return nil, err
+		}
+		collectEditions(ext.Message(), maxEdition, editions)
+	}
+	sortedEditions := make([]descriptorpb.Edition, 0, len(editions))
+	for edition := range editions {
+		sortedEditions = append(sortedEditions, edition)
+	}
+	sort.Slice(sortedEditions, func(i, j int) bool {
+		return sortedEditions[i] < sortedEditions[j]
+	})
+
+	result := &descriptorpb.FeatureSetDefaults{
+		MinimumEdition: minEdition.Enum(),
+		MaximumEdition: maxEdition.Enum(),
+	}
+	for _, edition := range sortedEditions {
+		msg := dynamicpb.NewMessage(featureSet)
+		if err := fillFeatureDefaults(edition, msg); err != nil {
+			return nil, err
+		}
+		for _, ext := range exts {
+			xt := dynamicpb.NewExtensionType(ext)
+			extMsg := dynamicpb.NewMessage(ext.Message())
+			if err := fillFeatureDefaults(edition, extMsg); err != nil {
+				return nil, err
+			}
+			msg.Set(xt.TypeDescriptor(), protoreflect.ValueOfMessage(extMsg))
+		}
+		// The given FeatureSet may not be the same version as the one
+		// linked into this program, so we convert via serialization.
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		features := &descriptorpb.FeatureSet{}
+		if err := proto.Unmarshal(data, features); err != nil {
+			return nil, err
+		}
+		result.Defaults = append(result.Defaults, &descriptorpb.FeatureSetDefaults_FeatureSetEditionDefault{
+			Edition:  edition.Enum(),
+			Features: features,
+		})
+	}
+	return result, nil
+}
+
+// findFeatureSet returns the google.protobuf.FeatureSet message defined in the
+// given file or in any of its transitive imports, or nil if there is none.
+func findFeatureSet(file protoreflect.FileDescriptor, seen map[string]struct{}) protoreflect.MessageDescriptor {
+	if _, ok := seen[file.Path()]; ok {
+		return nil
+	}
+	seen[file.Path()] = struct{}{}
+	if file.Package() == featureSetName.Parent() {
+		if md := file.Messages().ByName(featureSetName.Name()); md != nil {
+			return md
+		}
+	}
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if md := findFeatureSet(imports.Get(i).FileDescriptor, seen); md != nil {
+			return md
+		}
+	}
+	return nil
+}
+
+// validateFeatureMessage checks that the given message, which is either
+// google.protobuf.FeatureSet or the type of a custom feature extension, only
+// has fields that can be used as features.
+func validateFeatureMessage(md protoreflect.MessageDescriptor) error {
+	if md.Oneofs().Len() > 0 {
+		return fmt.Errorf("type %s contains unsupported oneof feature fields", md.FullName())
+	}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fld := fields.Get(i)
+		if fld.Cardinality() == protoreflect.Required {
+			return fmt.Errorf("feature field %s is an unsupported required field", fld.FullName())
+		}
+		if fld.IsList() {
+			return fmt.Errorf("feature field %s is an unsupported repeated field", fld.FullName())
+		}
+		opts, _ := fld.Options().(*descriptorpb.FieldOptions)
+		if len(opts.GetTargets()) == 0 {
+			return fmt.Errorf("feature field %s has no target specified", fld.FullName())
+		}
+	}
+	return nil
+}
+
+// validateFeatureExtension checks that the given extension of
+// google.protobuf.FeatureSet can be used as a custom feature.
+func validateFeatureExtension(ext protoreflect.ExtensionDescriptor) error {
+	if ext.Message() == nil {
+		return fmt.Errorf("extension %s of %s is not of message type; feature extensions should always use messages to allow for evolution", ext.FullName(), featureSetName)
+	}
+	if ext.IsList() {
+		return fmt.Errorf("only singular feature extensions are supported; found repeated extension %s", ext.FullName())
+	}
+	if ext.Message().Extensions().Len() > 0 || ext.Message().ExtensionRanges().Len() > 0 {
+		return fmt.Errorf("nested extensions in feature extension %s are not supported", ext.FullName())
+	}
+	return nil
+}
+
+// collectEditions adds to the given set all editions, up to maxEdition, for
+// which any field of the given message has an edition default.
+func collectEditions(md protoreflect.MessageDescriptor, maxEdition descriptorpb.Edition, editions map[descriptorpb.Edition]struct{}) {
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		opts, _ := fields.Get(i).Options().(*descriptorpb.FieldOptions)
+		for _, def := range opts.GetEditionDefaults() {
+			if def.GetEdition() <= maxEdition {
+				editions[def.GetEdition()] = struct{}{}
+			}
+		}
+	}
+}
+
+// fillFeatureDefaults sets every field of the given message to its default
+// for the given edition. For message fields, the defaults of all editions up
+// to the given one are merged; for other fields, the latest one applies.
+func fillFeatureDefaults(edition descriptorpb.Edition, msg protoreflect.Message) error {
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fld := fields.Get(i)
+		opts, _ := fld.Options().(*descriptorpb.FieldOptions)
+		var defaults []*descriptorpb.FieldOptions_EditionDefault
+		for _, def := range opts.GetEditionDefaults() {
+			if def.GetEdition() <= edition {
+				defaults = append(defaults, def)
+			}
+		}
+		if len(defaults) == 0 {
+			return fmt.Errorf("no valid default found for edition %v in feature field %s", edition, fld.FullName())
+		}
+		sort.SliceStable(defaults, func(i, j int) bool {
+			return defaults[i].GetEdition() < defaults[j].GetEdition()
+		})
+		if fld.Message() == nil {
+			// only the latest default applies
+			defaults = defaults[len(defaults)-1:]
+		}
+		for _, def := range defaults {
+			// Values use the text format, so we parse them as a field of a
+			// new message and then merge that into the result.
+			text := fmt.Sprintf("%s: %s", fld.Name(), def.GetValue())
+			parsed := msg.New()
+			if err := prototext.Unmarshal([]byte(text), parsed.Interface()); err != nil {
+				return fmt.Errorf("parsing error in edition_defaults for feature field %s; could not parse %q: %w", fld.FullName(), def.GetValue(), err)
+			}
+			proto.Merge(msg.Interface(), parsed.Interface())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linker
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/bufbuild/protocompile/walk"
+)
+
+var (
+	featureSetDescriptor = (*descriptorpb.FeatureSet)(nil).ProtoReflect().Descriptor()
+	featureSetName       = featureSetDescriptor.FullName()
+
+	fieldPresenceField         = featureSetDescriptor.Fields().ByName("field_presence")
+	enumTypeField              = featureSetDescriptor.Fields().ByName("enum_type")
+	repeatedFieldEncodingField = featureSetDescriptor.Fields().ByName("repeated_field_encoding")
+	messageEncodingField       = featureSetDescriptor.Fields().ByName("message_encoding")
+	jsonFormatField            = featureSetDescriptor.Fields().ByName("json_format")
+
+	// defaults for the fields of google.protobuf.FeatureSet, keyed by edition
+	builtinFeatureDefaults sync.Map
+)
+
+// ResolvedFeatures returns the features that apply to the given element, which
+// may be a file or any element in a file. The result merges the following, in
+// order of increasing precedence: the defaults for the file's edition, the
+// file's features, the features of each enclosing element, and finally the
+// element's own features. Fields in a oneof inherit the oneof's features.
+//
+// Custom features, which are extensions of google.protobuf.FeatureSet, are
+// included if they are defined in the element's file or in any of its
+// transitive imports. Their defaults are computed from the edition_defaults
+// option of each of their fields, the same way as for standard features.
+//
+// Files that use proto2 or proto3 syntax cannot set features. But their
+// elements still have resolved features: the defaults for the edition of the
+// same name, which describe the behavior of that syntax.
+//
+// Features are only known once options have been interpreted. An error is
+// returned if the edition defaults of a custom feature cannot be parsed.
+func ResolvedFeatures(d protoreflect.Descriptor) (*descriptorpb.FeatureSet, error) {
+	file := d.ParentFile()
+	edition := editionOf(file)
+	result := proto.Clone(builtinDefaults(edition)).(*descriptorpb.FeatureSet) //nolint:errcheck
+
+	var types protoregistry.Types
+	for _, ext := range customFeatures(file) {
+		xt := extensionType(ext)
+		if err := types.RegisterExtension(xt); err != nil {
+			// already registered by another file with the same name
+			continue
+		}
+		if ext.Message() == nil {
+			continue
+		}
+		defaults := dynamicpb.NewMessage(ext.Message())
+		fields := ext.Message().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			val, ok, err := featureDefault(edition, fields.Get(i))
+			if err != nil {
+				return nil, fmt.Errorf("feature %s: %w", ext.FullName(), err)
+			}
+			if ok {
+				defaults.Set(fields.Get(i), val)
+			}
+		}
+		result.ProtoReflect().Set(xt.TypeDescriptor(), protoreflect.ValueOfMessage(defaults))
+	}
+
+	var chain []*descriptorpb.FeatureSet
+	for ; d != nil; d = featureParent(d) {
+		if features := featuresOf(d); features != nil {
+			chain = append(chain, features)
+		}
+	}
+	// Merge via serialization, so that custom features are always
+	// represented using the same extension types as their defaults.
+	unmarshalOpts := proto.UnmarshalOptions{Merge: true, Resolver: &types}
+	for i := len(chain) - 1; i >= 0; i-- {
+		data, err := proto.Marshal(chain[i])
+		if err != nil {
+			return nil, err
+		}
+		if err := unmarshalOpts.Unmarshal(data, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// isEditions returns true if the given file uses editions syntax.
+func isEditions(fd protoreflect.FileDescriptor) bool {
+	if r, ok := fd.(*result); ok {
+		return r.FileDescriptorProto().GetSyntax() == "editions"
+	}
+	return fd.Syntax() == protoreflect.Editions
+}
+
+// editionOf returns the edition of the given file. For files that use proto2
+// or proto3 syntax, this is EDITION_PROTO2 or EDITION_PROTO3, respectively.
+func editionOf(fd protoreflect.FileDescriptor) descriptorpb.Edition {
+	if r, ok := fd.(*result); ok && r.FileDescriptorProto().GetSyntax() == "editions" {
+		return r.FileDescriptorProto().GetEdition()
+	}
+	switch fd.Syntax() {
+	case protoreflect.Proto2:
+		return descriptorpb.Edition_EDITION_PROTO2
+	case protoreflect.Proto3:
+		return descriptorpb.Edition_EDITION_PROTO3
+	default:
+		// The only edition currently supported by the protobuf-go runtime.
+		return descriptorpb.Edition_EDITION_2023
+	}
+}
+
+// isClosedEnum returns true if the given enum uses closed semantics.
+func isClosedEnum(ed protoreflect.EnumDescriptor) bool {
+	file := ed.ParentFile()
+	if !isEditions(file) {
+		return file.Syntax() != protoreflect.Proto3
+	}
+	val := resolveFeature(ed, enumTypeField)
+	return descriptorpb.FeatureSet_EnumType(val.Enum()) == descriptorpb.FeatureSet_CLOSED
+}
+
+// isLegacyJSONFormat returns true if the given message or enum only supports
+// JSON on a best-effort basis, so conflicting JSON names are not errors.
+func isLegacyJSONFormat(d protoreflect.Descriptor) bool {
+	file := d.ParentFile()
+	if !isEditions(file) {
+		return file.Syntax() != protoreflect.Proto3
+	}
+	val := resolveFeature(d, jsonFormatField)
+	return descriptorpb.FeatureSet_JsonFormat(val.Enum()) == descriptorpb.FeatureSet_LEGACY_BEST_EFFORT
+}
+
+// resolveFeature returns the value of the given feature, which must be a field
+// of google.protobuf.FeatureSet, for the given element. This is a cheaper way
+// to query a single standard feature than ResolvedFeatures.
+func resolveFeature(d protoreflect.Descriptor, field protoreflect.FieldDescriptor) protoreflect.Value {
+	file := d.ParentFile()
+	for ; d != nil; d = featureParent(d) {
+		features := featuresOf(d)
+		if features == nil {
+			continue
+		}
+		msg := features.ProtoReflect()
+		if msg.Has(field) {
+			return msg.Get(field)
+		}
+	}
+	return builtinDefaults(editionOf(file)).ProtoReflect().Get(field)
+}
+
+// featureParent returns the element from which the given element inherits
+// features. This is the element's parent, except for fields in a oneof,
+// which inherit from the oneof, and for map entries, which inherit from
+// the map field.
+func featureParent(d protoreflect.Descriptor) protoreflect.Descriptor {
+	switch d := d.(type) {
+	case protoreflect.FieldDescriptor:
+		if d.ContainingOneof() != nil {
+			return d.ContainingOneof()
+		}
+	case protoreflect.MessageDescriptor:
+		if parent, ok := d.Parent().(protoreflect.MessageDescriptor); ok && d.IsMapEntry() {
+			fields := parent.Fields()
+			for i := 0; i < fields.Len(); i++ {
+				if fld := fields.Get(i); fld.Message() != nil && fld.Message().FullName() == d.FullName() {
+					return fld
+				}
+			}
+		}
+	}
+	return d.Parent()
+}
+
+type hasFeatures interface {
+	GetFeatures() *descriptorpb.FeatureSet
+}
+
+func featuresOf(d protoreflect.Descriptor) *descriptorpb.FeatureSet {
+	opts, ok := d.Options().(hasFeatures)
+	if !ok {
+		return nil
+	}
+	return opts.GetFeatures()
+}
+
+// builtinDefaults returns the defaults for all fields of
+// google.protobuf.FeatureSet for the given edition. The returned
+// value must not be mutated.
+func builtinDefaults(edition descriptorpb.Edition) *descriptorpb.FeatureSet {
+	if defaults, ok := builtinFeatureDefaults.Load(edition); ok {
+		return defaults.(*descriptorpb.FeatureSet) //nolint:errcheck
+	}
+	defaults := &descriptorpb.FeatureSet{}
+	fields := featureSetDescriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		val, ok, err := featureDefault(edition, fields.Get(i))
+		if err == nil && ok {
+			defaults.ProtoReflect().Set(fields.Get(i), val)
+		}
+	}
+	actual, _ := builtinFeatureDefaults.LoadOrStore(edition, defaults)
+	return actual.(*descriptorpb.FeatureSet) //nolint:errcheck
+}
+
+// customFeatures returns all extensions of google.protobuf.FeatureSet that
+// are defined in the given file or in any of its transitive imports.
+func customFeatures(file protoreflect.FileDescriptor) []protoreflect.ExtensionDescriptor {
+	var exts []protoreflect.ExtensionDescriptor
+	seen := map[string]struct{}{}
+	var visit func(protoreflect.FileDescriptor)
+	visit = func(file protoreflect.FileDescriptor) {
+		if _, ok := seen[file.Path()]; ok {
+			return
+		}
+		seen[file.Path()] = struct{}{}
+		imports := file.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			visit(imports.Get(i).FileDescriptor)
+		}
+		_ = walk.Descriptors(file, func(d protoreflect.Descriptor) error {
+			if ext, ok := d.(protoreflect.ExtensionDescriptor); ok && ext.IsExtension() &&
+				ext.ContainingMessage().FullName() == featureSetName {
+				exts = append(exts, ext)
+			}
+			return nil
+		})
+	}
+	visit(file)
+	return exts
+}
+
+func extensionType(ext protoreflect.ExtensionDescriptor) protoreflect.ExtensionType {
+	if xtd, ok := ext.(protoreflect.ExtensionTypeDescriptor); ok {
+		return xtd.Type()
+	}
+	return dynamicpb.NewExtensionType(ext)
+}
+
+// featureDefault returns the default value of the given feature for the given
+// edition. Defaults are computed from the edition_defaults option on the
+// feature field: the value for the latest edition that is not newer than the
+// given one applies. If there is no such value, this returns false.
+func featureDefault(edition descriptorpb.Edition, field protoreflect.FieldDescriptor) (protoreflect.Value, bool, error) {
+	opts, _ := field.Options().(*descriptorpb.FieldOptions)
+	var best *descriptorpb.FieldOptions_EditionDefault
+	for _, def := range opts.GetEditionDefaults() {
+		if def.GetEdition() > edition {
+			continue
+		}
+		if best == nil || def.GetEdition() > best.GetEdition() {
+			best = def
+		}
+	}
+	if best == nil {
+		return protoreflect.Value{}, false, nil
+	}
+	val, err := parseFeatureValue(field, best.GetValue())
+	if err != nil {
+		return protoreflect.Value{}, false, fmt.Errorf("invalid default %q for field %s: %w", best.GetValue(), field.FullName(), err)
+	}
+	return val, true, nil
+}
+
+// parseFeatureValue parses the given value for the given feature field.
+// Values in edition defaults use the text format, just like values in
+// protoc's text-format representation of a FeatureSet.
+func parseFeatureValue(field protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	msg := dynamicpb.NewMessage(field.ContainingMessage())
+	text := fmt.Sprintf("%s: %s", field.Name(), value)
+	if err := prototext.Unmarshal([]byte(text), msg); err != nil {
+		return protoreflect.Value{}, err
+	}
+	return msg.Get(field), nil
+}
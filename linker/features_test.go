@@ -0,0 +1,430 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linker_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/bufbuild/protocompile/linker"
+)
+
+func TestResolvedFeatures(t *testing.T) {
+	t.Parallel()
+	files, err := compile(t, map[string]string{
+		"features.proto": `
+			edition = "2023";
+			package test;
+			import "google/protobuf/descriptor.proto";
+			extend google.protobuf.FeatureSet {
+				CustomFeatures custom = 9995;
+			}
+			message CustomFeatures {
+				enum Level {
+					LEVEL_UNKNOWN = 0;
+					LOW = 1;
+					HIGH = 2;
+				}
+				Level level = 1 [
+					targets = TARGET_TYPE_FILE,
+					targets = TARGET_TYPE_FIELD,
+					targets = TARGET_TYPE_ONEOF,
+					edition_defaults = { edition: EDITION_PROTO2, value: "LOW" },
+					edition_defaults = { edition: EDITION_2023, value: "HIGH" }
+				];
+				bool flag = 2 [
+					targets = TARGET_TYPE_FILE,
+					edition_defaults = { edition: EDITION_PROTO2, value: "false" }
+				];
+			}`,
+		"test.proto": `
+			edition = "2023";
+			package test;
+			import "features.proto";
+			option features.(test.custom).flag = true;
+			option features.field_presence = IMPLICIT;
+			option features.enum_type = CLOSED;
+			message Foo {
+				int32 a = 1;
+				int32 b = 2 [features.field_presence = EXPLICIT];
+				repeated int32 c = 3;
+				repeated int32 d = 4 [features.repeated_field_encoding = EXPANDED];
+				Foo e = 5 [features.message_encoding = DELIMITED, features.(test.custom).level = LOW];
+				Foo f = 6;
+				oneof o {
+					option features.(test.custom).level = LOW;
+					int32 g = 7;
+				}
+				map<string, string> h = 8 [features.utf8_validation = NONE];
+				int32 i = 9 [features.field_presence = LEGACY_REQUIRED];
+			}
+			enum Open {
+				option features.enum_type = OPEN;
+				OPEN_ZERO = 0;
+			}
+			enum Closed {
+				CLOSED_ONE = 1;
+			}`,
+		"proto2.proto": `
+			syntax = "proto2";
+			message Bar {
+				repeated int32 a = 1;
+			}`,
+	})
+	require.NoError(t, err)
+	file := files.FindFileByPath("test.proto")
+	require.NotNil(t, file)
+	custom := file.FindImportByPath("features.proto").Extensions().ByName("custom")
+	require.NotNil(t, custom)
+	level := custom.Message().Fields().ByName("level")
+	flag := custom.Message().Fields().ByName("flag")
+
+	features, err := linker.ResolvedFeatures(file)
+	require.NoError(t, err)
+	assert.Equal(t, descriptorpb.FeatureSet_IMPLICIT, features.GetFieldPresence())
+	assert.Equal(t, descriptorpb.FeatureSet_CLOSED, features.GetEnumType())
+	assert.Equal(t, descriptorpb.FeatureSet_PACKED, features.GetRepeatedFieldEncoding())
+	assert.Equal(t, descriptorpb.FeatureSet_LENGTH_PREFIXED, features.GetMessageEncoding())
+	customFeatures := features.ProtoReflect().Get(custom).Message()
+	assert.Equal(t, protoreflect.EnumNumber(2), customFeatures.Get(level).Enum())
+	assert.True(t, customFeatures.Get(flag).Bool())
+
+	msg := file.Messages().ByName("Foo")
+	fields := msg.Fields()
+	a, b, c, d := fields.ByName("a"), fields.ByName("b"), fields.ByName("c"), fields.ByName("d")
+	e, f, g := fields.ByName("e"), fields.ByName("f"), fields.ByName("g")
+
+	features, err = linker.ResolvedFeatures(b)
+	require.NoError(t, err)
+	assert.Equal(t, descriptorpb.FeatureSet_EXPLICIT, features.GetFieldPresence())
+	assert.False(t, a.HasPresence())
+	assert.True(t, b.HasPresence())
+	assert.True(t, f.HasPresence())
+	assert.True(t, g.HasPresence())
+
+	assert.True(t, c.IsPacked())
+	assert.False(t, d.IsPacked())
+	assert.Equal(t, protoreflect.Required, fields.ByName("i").Cardinality())
+	assert.Equal(t, protoreflect.Optional, a.Cardinality())
+
+	features, err = linker.ResolvedFeatures(e)
+	require.NoError(t, err)
+	assert.Equal(t, descriptorpb.FeatureSet_DELIMITED, features.GetMessageEncoding())
+	customFeatures = features.ProtoReflect().Get(custom).Message()
+	assert.Equal(t, protoreflect.EnumNumber(1), customFeatures.Get(level).Enum())
+	assert.True(t, customFeatures.Get(flag).Bool())
+	assert.Equal(t, protoreflect.GroupKind, e.Kind())
+	assert.Equal(t, protoreflect.MessageKind, f.Kind())
+
+	// fields in a oneof inherit the oneof's features
+	features, err = linker.ResolvedFeatures(g)
+	require.NoError(t, err)
+	customFeatures = features.ProtoReflect().Get(custom).Message()
+	assert.Equal(t, protoreflect.EnumNumber(1), customFeatures.Get(level).Enum())
+
+	// map entries inherit the map field's features
+	features, err = linker.ResolvedFeatures(fields.ByName("h").MapValue())
+	require.NoError(t, err)
+	assert.Equal(t, descriptorpb.FeatureSet_NONE, features.GetUtf8Validation())
+
+	type closer interface{ IsClosed() bool }
+	openEnum, ok := file.Enums().ByName("Open").(closer)
+	require.True(t, ok)
+	assert.False(t, openEnum.IsClosed())
+	closedEnum, ok := file.Enums().ByName("Closed").(closer)
+	require.True(t, ok)
+	assert.True(t, closedEnum.IsClosed())
+
+	// Files that use proto2 or proto3 syntax have the features of the
+	// corresponding edition.
+	proto2File := files.FindFileByPath("proto2.proto")
+	require.NotNil(t, proto2File)
+	features, err = linker.ResolvedFeatures(proto2File.Messages().Get(0).Fields().Get(0))
+	require.NoError(t, err)
+	assert.Equal(t, descriptorpb.FeatureSet_EXPLICIT, features.GetFieldPresence())
+	assert.Equal(t, descriptorpb.FeatureSet_CLOSED, features.GetEnumType())
+	assert.Equal(t, descriptorpb.FeatureSet_EXPANDED, features.GetRepeatedFieldEncoding())
+	assert.Equal(t, descriptorpb.FeatureSet_LEGACY_BEST_EFFORT, features.GetJsonFormat())
+	assert.False(t, proto2File.Messages().Get(0).Fields().Get(0).IsPacked())
+}
+
+func TestComputeFeatureSetDefaults(t *testing.T) {
+	t.Parallel()
+	files, err := compile(t, map[string]string{
+		"features.proto": `
+			edition = "2023";
+			package test;
+			import "google/protobuf/descriptor.proto";
+			extend google.protobuf.FeatureSet {
+				CustomFeatures custom = 9995;
+			}
+			message CustomFeatures {
+				enum Level {
+					LEVEL_UNKNOWN = 0;
+					LOW = 1;
+					HIGH = 2;
+				}
+				Level level = 1 [
+					targets = TARGET_TYPE_FIELD,
+					edition_defaults = { edition: EDITION_PROTO2, value: "LOW" },
+					edition_defaults = { edition: EDITION_99997_TEST_ONLY, value: "HIGH" }
+				];
+			}`,
+		"no_features.proto": `
+			syntax = "proto3";
+			message Foo {}`,
+	})
+	require.NoError(t, err)
+	custom := files.FindFileByPath("features.proto").Extensions().ByName("custom")
+	require.NotNil(t, custom)
+	level := custom.Message().Fields().ByName("level")
+	var types protoregistry.Types
+	require.NoError(t, types.RegisterExtension(dynamicpb.NewExtensionType(custom)))
+
+	defaults, err := linker.ComputeFeatureSetDefaults(files, descriptorpb.Edition_EDITION_PROTO2, descriptorpb.Edition_EDITION_2023)
+	require.NoError(t, err)
+	assert.Equal(t, descriptorpb.Edition_EDITION_PROTO2, defaults.GetMinimumEdition())
+	assert.Equal(t, descriptorpb.Edition_EDITION_2023, defaults.GetMaximumEdition())
+	var editions []descriptorpb.Edition
+	for _, def := range defaults.Defaults {
+		editions = append(editions, def.GetEdition())
+	}
+	// EDITION_99997_TEST_ONLY is newer than the maximum
+	assert.Equal(t, []descriptorpb.Edition{
+		descriptorpb.Edition_EDITION_PROTO2,
+		descriptorpb.Edition_EDITION_PROTO3,
+		descriptorpb.Edition_EDITION_2023,
+	}, editions)
+	for _, def := range defaults.Defaults {
+		features := def.GetFeatures()
+		// every standard feature has a default
+		assert.NotNil(t, features.FieldPresence, "edition %v", def.GetEdition())
+		assert.NotNil(t, features.EnumType, "edition %v", def.GetEdition())
+		assert.NotNil(t, features.RepeatedFieldEncoding, "edition %v", def.GetEdition())
+		assert.NotNil(t, features.Utf8Validation, "edition %v", def.GetEdition())
+		assert.NotNil(t, features.MessageEncoding, "edition %v", def.GetEdition())
+		assert.NotNil(t, features.JsonFormat, "edition %v", def.GetEdition())
+	}
+	assert.Equal(t, descriptorpb.FeatureSet_EXPLICIT, defaults.Defaults[0].GetFeatures().GetFieldPresence())
+	assert.Equal(t, descriptorpb.FeatureSet_CLOSED, defaults.Defaults[0].GetFeatures().GetEnumType())
+	assert.Equal(t, descriptorpb.FeatureSet_IMPLICIT, defaults.Defaults[1].GetFeatures().GetFieldPresence())
+	assert.Equal(t, descriptorpb.FeatureSet_PACKED, defaults.Defaults[1].GetFeatures().GetRepeatedFieldEncoding())
+	assert.Equal(t, descriptorpb.FeatureSet_EXPLICIT, defaults.Defaults[2].GetFeatures().GetFieldPresence())
+	assert.Equal(t, descriptorpb.FeatureSet_OPEN, defaults.Defaults[2].GetFeatures().GetEnumType())
+
+	// custom features are included as unrecognized fields
+	data, err := proto.Marshal(defaults.Defaults[2].GetFeatures())
+	require.NoError(t, err)
+	var features descriptorpb.FeatureSet
+	require.NoError(t, proto.UnmarshalOptions{Resolver: &types}.Unmarshal(data, &features))
+	customFeatures := features.ProtoReflect().Get(custom).Message()
+	assert.Equal(t, protoreflect.EnumNumber(1), customFeatures.Get(level).Enum())
+
+	defaults, err = linker.ComputeFeatureSetDefaults(files, descriptorpb.Edition_EDITION_2023, descriptorpb.Edition_EDITION_99997_TEST_ONLY)
+	require.NoError(t, err)
+	require.Len(t, defaults.Defaults, 4)
+	last := defaults.Defaults[3]
+	assert.Equal(t, descriptorpb.Edition_EDITION_99997_TEST_ONLY, last.GetEdition())
+	data, err = proto.Marshal(last.GetFeatures())
+	require.NoError(t, err)
+	require.NoError(t, proto.UnmarshalOptions{Resolver: &types}.Unmarshal(data, &features))
+	customFeatures = features.ProtoReflect().Get(custom).Message()
+	assert.Equal(t, protoreflect.EnumNumber(2), customFeatures.Get(level).Enum())
+
+	_, err = linker.ComputeFeatureSetDefaults(files, descriptorpb.Edition_EDITION_2023, descriptorpb.Edition_EDITION_PROTO3)
+	assert.EqualError(t, err, "invalid edition range, edition EDITION_2023 is newer than edition EDITION_PROTO3")
+
+	noFeatures := linker.Files{files.FindFileByPath("no_features.proto")}
+	_, err = linker.ComputeFeatureSetDefaults(noFeatures, descriptorpb.Edition_EDITION_PROTO2, descriptorpb.Edition_EDITION_2023)
+	assert.EqualError(t, err, "could not find google.protobuf.FeatureSet; make sure google/protobuf/descriptor.proto is included")
+}
+
+func TestCustomFeatureValidation(t *testing.T) {
+	t.Parallel()
+	const featuresPrefix = `
+		edition = "2023";
+		package test;
+		import "google/protobuf/descriptor.proto";
+		`
+	testCases := map[string]struct {
+		features    string
+		usage       string
+		expectedErr string
+	}{
+		"success": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					bool flag = 1 [
+						targets = TARGET_TYPE_FIELD,
+						edition_defaults = { edition: EDITION_PROTO2, value: "false" },
+						edition_defaults = { edition: EDITION_2023, value: "true" }
+					];
+				}`,
+			usage: `message Foo { int32 a = 1 [features.(custom).flag = false]; }`,
+		},
+		"failure_not_message": {
+			features:    `extend google.protobuf.FeatureSet { bool custom = 9995; }`,
+			expectedErr: `features.proto:4:37: extension test.custom of google.protobuf.FeatureSet is not of message type; feature extensions should always use messages to allow for evolution`,
+		},
+		"failure_source_retention": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					bool flag = 1 [
+						retention = RETENTION_SOURCE,
+						targets = TARGET_TYPE_FIELD,
+						edition_defaults = { edition: EDITION_PROTO2, value: "false" }
+					];
+				}`,
+			expectedErr: `features.proto:8:33: feature test.Features.flag: features cannot use source retention since they are needed at runtime`,
+		},
+		"failure_wrong_type": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					int32 num = 1 [
+						targets = TARGET_TYPE_FIELD,
+						edition_defaults = { edition: EDITION_PROTO2, value: "1" }
+					];
+				}`,
+			expectedErr: `features.proto:7:25: feature test.Features.num: feature fields must be enums or bools, not int32`,
+		},
+		"failure_repeated": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					repeated bool flag = 1 [
+						targets = TARGET_TYPE_FIELD,
+						edition_defaults = { edition: EDITION_PROTO2, value: "false" }
+					];
+				}`,
+			expectedErr: `features.proto:7:25: feature test.Features.flag: feature fields cannot be repeated`,
+		},
+		"failure_no_targets": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					bool flag = 1 [edition_defaults = { edition: EDITION_PROTO2, value: "false" }];
+				}`,
+			expectedErr: `features.proto:7:25: feature test.Features.flag: feature fields must specify at least one target`,
+		},
+		"failure_no_defaults": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					bool flag = 1 [targets = TARGET_TYPE_FIELD];
+				}`,
+			expectedErr: `features.proto:7:25: feature test.Features.flag: feature fields must specify edition defaults`,
+		},
+		"failure_no_proto2_default": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					bool flag = 1 [
+						targets = TARGET_TYPE_FIELD,
+						edition_defaults = { edition: EDITION_2023, value: "false" }
+					];
+				}`,
+			expectedErr: `features.proto:9:33: feature test.Features.flag: feature fields must specify a default for EDITION_PROTO2`,
+		},
+		"failure_invalid_default": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					enum Level { LEVEL_UNKNOWN = 0; LOW = 1; }
+					Level level = 1 [
+						targets = TARGET_TYPE_FIELD,
+						edition_defaults = { edition: EDITION_PROTO2, value: "HIGH" }
+					];
+				}`,
+			expectedErr: `features.proto:10:33: feature test.Features.level: invalid default "HIGH" for EDITION_PROTO2: `,
+		},
+		"failure_duplicate_default": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					bool flag = 1 [
+						targets = TARGET_TYPE_FIELD,
+						edition_defaults = { edition: EDITION_PROTO2, value: "false" },
+						edition_defaults = { edition: EDITION_PROTO2, value: "true" }
+					];
+				}`,
+			expectedErr: `features.proto:9:33: feature test.Features.flag: multiple edition defaults specified for EDITION_PROTO2`,
+		},
+		"failure_usage_wrong_target": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					bool flag = 1 [
+						targets = TARGET_TYPE_MESSAGE,
+						edition_defaults = { edition: EDITION_PROTO2, value: "false" }
+					];
+				}`,
+			usage:       `message Foo { int32 a = 1 [features.(custom).flag = true]; }`,
+			expectedErr: `test.proto:4:28: feature "flag" is allowed on [message], not on field`,
+		},
+		"failure_usage_unknown_value": {
+			features: `
+				extend google.protobuf.FeatureSet { Features custom = 9995; }
+				message Features {
+					enum Level { LEVEL_UNKNOWN = 0; LOW = 1; }
+					Level level = 1 [
+						targets = TARGET_TYPE_FILE,
+						edition_defaults = { edition: EDITION_PROTO2, value: "LOW" }
+					];
+				}`,
+			usage:       `option features.(custom).level = LEVEL_UNKNOWN;`,
+			expectedErr: `test.proto:4:1: feature "level" must be set to a known value, not LEVEL_UNKNOWN`,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			input := map[string]string{
+				"features.proto": featuresPrefix + tc.features,
+			}
+			if tc.usage != "" {
+				input["test.proto"] = `
+					edition = "2023";
+					package test;
+					import "features.proto";
+					` + tc.usage
+			}
+			for filename, data := range input {
+				input[filename] = removePrefixIndent(data)
+			}
+			_, err := compile(t, input)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			// Errors from parsing default values have details from the
+			// protobuf runtime that are not stable, so only the prefix
+			// of the message is checked.
+			assert.True(t, strings.HasPrefix(err.Error(), tc.expectedErr), "expecting error %q; instead got %q", tc.expectedErr, err)
+		})
+	}
+}
@@ -16,13 +16,16 @@ package linker
 
 import (
 	"fmt"
+	"strings"
 
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/descriptorpb"
 
 	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/internal"
 	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/protoutil"
 	"github.com/bufbuild/protocompile/reporter"
 )
 
@@ -39,6 +42,12 @@ import (
 // reported, this function returns a non-nil error. The Result value returned
 // also implements protoreflect.FileDescriptor.
 //
+// If the handler's reporter allows linking to continue after an error, a
+// non-nil Result is returned along with the error. In that Result, types
+// that could not be resolved are represented by placeholder messages, whose
+// IsPlaceholder method returns true. A dependency may also be a placeholder,
+// created with NewPlaceholderFile, for an import that could not be found.
+//
 // Note that linking does NOT interpret options. So options messages in the
 // returned value have all values stored in UninterpretedOptions fields.
 func Link(parsed, parsedWithResolvedOptions parser.Result, dependencies Files, symbols *Symbols, handler *reporter.Handler) (Result, error) {
@@ -50,7 +59,12 @@ func Link(parsed, parsedWithResolvedOptions parser.Result, dependencies Files, s
 		prefix += "."
 	}
 
-	for _, imp := range parsed.FileDescriptorProto().Dependency {
+	// option dependencies must be present, too, since their extensions
+	// may be used in options
+	optionDeps := internal.OptionDependencies(parsed.FileDescriptorProto())
+	imports := append([]string(nil), parsed.FileDescriptorProto().Dependency...)
+	imports = append(imports, optionDeps...)
+	for _, imp := range imports {
 		dep := dependencies.FindFileByPath(imp)
 		if dep == nil {
 			return nil, fmt.Errorf("dependencies is missing import %q", imp)
@@ -61,7 +75,7 @@ func Link(parsed, parsedWithResolvedOptions parser.Result, dependencies Files, s
 	}
 	files := []*descriptorpb.FileDescriptorProto{parsedWithResolvedOptions.FileDescriptorProto()}
 	for _, f := range dependencies {
-		files = append(files, protodesc.ToFileDescriptorProto(f))
+		files = append(files, protoutil.ProtoFromFileDescriptor(f))
 	}
 	fds := &descriptorpb.FileDescriptorSet{
 		File: files,
@@ -86,6 +100,7 @@ func Link(parsed, parsedWithResolvedOptions parser.Result, dependencies Files, s
 		FileDescriptor:       fd,
 		Result:               parsed,
 		deps:                 dependencies,
+		optionDeps:           optionDeps,
 		descriptors:          map[string]protoreflect.Descriptor{},
 		usedImports:          map[string]struct{}{},
 		prefix:               prefix,
@@ -134,6 +149,12 @@ type Result interface {
 	// ResolveMessageLiteralExtensionName returns the fully qualified name for
 	// an identifier for extension field names in message literals.
 	ResolveMessageLiteralExtensionName(ast.IdentValueNode) string
+	// References returns the references to other symbols that were resolved
+	// when the file was linked, in the order they appear in the source. This
+	// includes the types of fields and extensions, extendees, method request
+	// and response types, and the fields and extensions named in options,
+	// including extension names in message literals.
+	References() []Reference
 	// ValidateOptions runs some validation checks on the descriptor that can only
 	// be done after options are interpreted. Any errors or warnings encountered
 	// will be reported via the given handler. If any error is reported, this
@@ -197,3 +218,192 @@ func (e errUnusedImport) Error() string {
 func (e errUnusedImport) UnusedImport() string {
 	return string(e)
 }
+
+func (e errUnusedImport) Code() reporter.Code {
+	return reporter.CodeUnusedImport
+}
+
+// ErrorSymbolCollision may be passed to an error reporter when two elements
+// have the same fully-qualified name. The error the reporter receives will be
+// wrapped with source position that indicates the later definition. Its code
+// is reporter.CodeSymbolCollision.
+type ErrorSymbolCollision interface {
+	reporter.ErrorWithCode
+	// Symbol returns the fully-qualified name that is defined twice.
+	Symbol() protoreflect.FullName
+	// Span returns the location of the name in the later definition.
+	Span() ast.SourceSpan
+	// PreviousSpan returns the location of the name in the earlier
+	// definition.
+	PreviousSpan() ast.SourceSpan
+	// PreviousIsPackage returns true if the earlier definition is a package.
+	PreviousIsPackage() bool
+}
+
+type errSymbolCollision struct {
+	symbol             protoreflect.FullName
+	span, previousSpan ast.SourceSpan
+	previousIsPackage  bool
+	// true if either definition is an enum value
+	isEnumValue bool
+}
+
+func (e *errSymbolCollision) Error() string {
+	var isPkg, suffix string
+	if e.isEnumValue {
+		// because of weird scoping for enum values, provide more context in
+		// the error message
+		suffix = "; protobuf uses C++ scoping rules for enum values, so they exist in the scope enclosing the enum"
+	}
+	if e.previousIsPackage {
+		isPkg = " as a package"
+	}
+	return fmt.Sprintf("symbol %q already defined%s at %v%s", e.symbol, isPkg, e.previousSpan.Start(), suffix)
+}
+
+func (e *errSymbolCollision) Code() reporter.Code {
+	return reporter.CodeSymbolCollision
+}
+
+func (e *errSymbolCollision) Symbol() protoreflect.FullName {
+	return e.symbol
+}
+
+func (e *errSymbolCollision) Span() ast.SourceSpan {
+	return e.span
+}
+
+func (e *errSymbolCollision) PreviousSpan() ast.SourceSpan {
+	return e.previousSpan
+}
+
+func (e *errSymbolCollision) PreviousIsPackage() bool {
+	return e.previousIsPackage
+}
+
+// ErrorExtensionCollision may be passed to an error reporter when two
+// extensions of the same message use the same tag number. The error the
+// reporter receives will be wrapped with source position that indicates the
+// tag of the later extension. Its code is reporter.CodeTagCollision.
+type ErrorExtensionCollision interface {
+	reporter.ErrorWithCode
+	// Extendee returns the fully-qualified name of the extended message.
+	Extendee() protoreflect.FullName
+	// Tag returns the tag number that both extensions use.
+	Tag() protoreflect.FieldNumber
+	// Span returns the location of the later extension's tag.
+	Span() ast.SourceSpan
+	// PreviousSpan returns the location of the earlier extension's tag.
+	PreviousSpan() ast.SourceSpan
+}
+
+type errExtensionCollision struct {
+	extendee           protoreflect.FullName
+	tag                protoreflect.FieldNumber
+	span, previousSpan ast.SourceSpan
+}
+
+func (e *errExtensionCollision) Error() string {
+	return fmt.Sprintf("extension with tag %d for message %s already defined at %v", e.tag, e.extendee, e.previousSpan.Start())
+}
+
+func (e *errExtensionCollision) Code() reporter.Code {
+	return reporter.CodeTagCollision
+}
+
+func (e *errExtensionCollision) Extendee() protoreflect.FullName {
+	return e.extendee
+}
+
+func (e *errExtensionCollision) Tag() protoreflect.FieldNumber {
+	return e.tag
+}
+
+func (e *errExtensionCollision) Span() ast.SourceSpan {
+	return e.span
+}
+
+func (e *errExtensionCollision) PreviousSpan() ast.SourceSpan {
+	return e.previousSpan
+}
+
+// ErrorUnresolvedReference may be passed to an error reporter when a
+// reference to a message, an enum, or an extension can't be resolved. The
+// error the reporter receives will be wrapped with source position that
+// indicates the reference. Its code is reporter.CodeUnresolvedReference.
+type ErrorUnresolvedReference interface {
+	reporter.ErrorWithCode
+	// Kind returns the kind of the reference.
+	Kind() ReferenceKind
+	// Name returns the name in the reference, as it is written. It is
+	// fully-qualified if it starts with a dot. Otherwise, it is relative to
+	// the scopes in which it was searched.
+	Name() string
+	// Scopes returns the fully-qualified names of the packages and messages
+	// in which the name was searched, from innermost to outermost. The
+	// outermost is the empty string, for the root namespace. A
+	// fully-qualified name is only searched in the root namespace.
+	Scopes() []string
+	// ResolvedTo returns the fully-qualified name that the reference
+	// resolved to, but which isn't defined. This happens when the first
+	// component of a qualified name refers to a package or message in an
+	// inner scope, which hides the one that was meant. Otherwise, it returns
+	// the empty string.
+	ResolvedTo() protoreflect.FullName
+}
+
+type errUnresolvedReference struct {
+	kind ReferenceKind
+	// the element that has the reference, like "field foo.Bar.baz", or
+	// empty if the message shouldn't have a prefix
+	element    string
+	name       string
+	scopes     []string
+	resolvedTo protoreflect.FullName
+}
+
+func (e *errUnresolvedReference) Error() string {
+	var what string
+	switch e.kind {
+	case ReferenceExtendee:
+		what = "extendee type"
+	case ReferenceMethodInput:
+		what = "request type"
+	case ReferenceMethodOutput:
+		what = "response type"
+	case ReferenceOptionName, ReferenceMessageLiteralExtension:
+		what = "extension"
+	default:
+		what = "type"
+	}
+	var buf strings.Builder
+	if e.element != "" {
+		buf.WriteString(e.element)
+		buf.WriteString(": ")
+	}
+	_, _ = fmt.Fprintf(&buf, "unknown %s %s", what, e.name)
+	if e.resolvedTo != "" {
+		_, _ = fmt.Fprintf(&buf, "; resolved to %s which is not defined; consider using a leading dot", e.resolvedTo)
+	}
+	return buf.String()
+}
+
+func (e *errUnresolvedReference) Code() reporter.Code {
+	return reporter.CodeUnresolvedReference
+}
+
+func (e *errUnresolvedReference) Kind() ReferenceKind {
+	return e.kind
+}
+
+func (e *errUnresolvedReference) Name() string {
+	return e.name
+}
+
+func (e *errUnresolvedReference) Scopes() []string {
+	return e.scopes
+}
+
+func (e *errUnresolvedReference) ResolvedTo() protoreflect.FullName {
+	return e.resolvedTo
+}
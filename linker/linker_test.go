@@ -19,7 +19,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 	"strings"
 	"testing"
@@ -28,24 +27,17 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 	"google.golang.org/protobuf/types/descriptorpb"
 
 	"github.com/bufbuild/protocompile"
-	"github.com/bufbuild/protocompile/internal"
 	"github.com/bufbuild/protocompile/internal/protoc"
 	"github.com/bufbuild/protocompile/internal/prototest"
 	"github.com/bufbuild/protocompile/linker"
 	"github.com/bufbuild/protocompile/reporter"
 )
 
-func TestMain(m *testing.M) {
-	// Enable just for tests.
-	internal.AllowEditions = true
-	status := m.Run()
-	os.Exit(status)
-}
-
 func TestSimpleLink(t *testing.T) {
 	t.Parallel()
 	compiler := protocompile.Compiler{
@@ -2017,14 +2009,14 @@ func TestLinkerValidation(t *testing.T) {
 		"failure_unknown_edition_future": {
 			input: map[string]string{
 				"test.proto": `
-					edition = "2024";
+					edition = "2025";
 					message Foo {
 						string foo = 1 [features.field_presence = LEGACY_REQUIRED];
 						int32 bar = 2 [features.field_presence = IMPLICIT];
 					}
 				`,
 			},
-			expectedErr: `test.proto:1:11: edition value "2024" not recognized; should be one of ["2023"]`,
+			expectedErr: `test.proto:1:11: edition value "2025" not recognized; should be one of ["2023","2024"]`,
 		},
 		"failure_unknown_edition_past": {
 			input: map[string]string{
@@ -2036,7 +2028,255 @@ func TestLinkerValidation(t *testing.T) {
 					}
 				`,
 			},
-			expectedErr: `test.proto:1:11: edition value "2022" not recognized; should be one of ["2023"]`,
+			expectedErr: `test.proto:1:11: edition value "2022" not recognized; should be one of ["2023","2024"]`,
+		},
+		"success_option_import": {
+			input: map[string]string{
+				"options.proto": `
+					syntax = "proto2";
+					import "google/protobuf/descriptor.proto";
+					extend google.protobuf.MessageOptions {
+						optional string label = 50000;
+					}
+					message Bar {}
+				`,
+				"test.proto": `
+					edition = "2024";
+					import option "options.proto";
+					message Foo {
+						option (label) = "foo";
+					}
+				`,
+			},
+			// protoc does not yet support edition 2024
+			expectedDiffWithProtoc: true,
+		},
+		"failure_option_import_used_as_type": {
+			input: map[string]string{
+				"options.proto": `
+					syntax = "proto2";
+					import "google/protobuf/descriptor.proto";
+					extend google.protobuf.MessageOptions {
+						optional string label = 50000;
+					}
+					message Bar {}
+				`,
+				"test.proto": `
+					edition = "2024";
+					import option "options.proto";
+					message Foo {
+						Bar bar = 1;
+					}
+				`,
+			},
+			expectedErr: `test.proto:4:9: field Foo.bar: Bar cannot be used as a type because "options.proto" is an option import`,
+		},
+		"failure_option_import_before_edition_2024": {
+			input: map[string]string{
+				"options.proto": `
+					syntax = "proto2";
+					message Bar {}
+				`,
+				"test.proto": `
+					edition = "2023";
+					import option "options.proto";
+				`,
+			},
+			expectedErr: `test.proto:2:8: option imports are not allowed before edition 2024`,
+		},
+		"success_exported_types": {
+			input: map[string]string{
+				"dep.proto": `
+					edition = "2024";
+					package dep;
+					message Outer {
+						export message Inner {}
+						export enum Kind { KIND_UNSPECIFIED = 0; }
+					}
+					local message Private {}
+				`,
+				"test.proto": `
+					edition = "2024";
+					import "dep.proto";
+					message Foo {
+						dep.Outer outer = 1;
+						dep.Outer.Inner inner = 2;
+						dep.Outer.Kind kind = 3;
+					}
+				`,
+			},
+			// protoc does not yet support edition 2024
+			expectedDiffWithProtoc: true,
+		},
+		"success_local_type_in_same_file": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2024";
+					local message Foo {
+						Bar bar = 1;
+						local message Bar {}
+					}
+					message Baz {
+						Foo foo = 1;
+						Foo.Bar bar = 2;
+					}
+				`,
+			},
+			// protoc does not yet support edition 2024
+			expectedDiffWithProtoc: true,
+		},
+		"failure_local_type_from_other_file": {
+			input: map[string]string{
+				"dep.proto": `
+					edition = "2024";
+					local message Bar {}
+				`,
+				"test.proto": `
+					edition = "2024";
+					import "dep.proto";
+					message Foo {
+						Bar bar = 1;
+					}
+				`,
+			},
+			expectedErr: `test.proto:4:9: field Foo.bar: Bar is local to "dep.proto" and cannot be used in other files`,
+		},
+		"failure_nested_type_local_by_default": {
+			input: map[string]string{
+				"dep.proto": `
+					edition = "2024";
+					message Outer {
+						enum Kind { KIND_UNSPECIFIED = 0; }
+					}
+				`,
+				"test.proto": `
+					edition = "2024";
+					import "dep.proto";
+					service Svc {
+						rpc Do(Outer) returns (Outer);
+					}
+					message Foo {
+						Outer.Kind kind = 1;
+					}
+				`,
+			},
+			expectedErr: `test.proto:7:9: field Foo.kind: Outer.Kind is local to "dep.proto" and cannot be used in other files`,
+		},
+		"success_default_symbol_visibility_export_all": {
+			input: map[string]string{
+				"dep.proto": `
+					edition = "2024";
+					option features.default_symbol_visibility = EXPORT_ALL;
+					message Outer {
+						enum Kind { KIND_UNSPECIFIED = 0; }
+						local message Private {}
+					}
+				`,
+				"test.proto": `
+					edition = "2024";
+					import "dep.proto";
+					message Foo {
+						Outer.Kind kind = 1;
+					}
+				`,
+			},
+			// protoc does not yet support edition 2024
+			expectedDiffWithProtoc: true,
+		},
+		"failure_default_symbol_visibility_local_all": {
+			input: map[string]string{
+				"dep.proto": `
+					edition = "2024";
+					option features.default_symbol_visibility = LOCAL_ALL;
+					message Bar {}
+					export message Baz {}
+				`,
+				"test.proto": `
+					edition = "2024";
+					import "dep.proto";
+					message Foo {
+						Baz baz = 1;
+						Bar bar = 2;
+					}
+				`,
+			},
+			expectedErr: `test.proto:5:9: field Foo.bar: Bar is local to "dep.proto" and cannot be used in other files`,
+		},
+		"failure_default_symbol_visibility_on_message": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2024";
+					message Foo {
+						option features.default_symbol_visibility = EXPORT_ALL;
+					}
+				`,
+			},
+			expectedErr: `test.proto:3:9: feature "default_symbol_visibility" is allowed on [file], not on message`,
+		},
+		"failure_default_symbol_visibility_before_2024": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					option features.default_symbol_visibility = EXPORT_ALL;
+				`,
+			},
+			expectedErr: `test.proto:2:1: feature "default_symbol_visibility" wasn't introduced until EDITION_2024 and can't be used in EDITION_2023`,
+		},
+		"failure_local_type_as_extendee": {
+			input: map[string]string{
+				"dep.proto": `
+					edition = "2024";
+					local message Bar {
+						extensions 1 to 10;
+					}
+				`,
+				"test.proto": `
+					edition = "2024";
+					import "dep.proto";
+					extend Bar {
+						string name = 1;
+					}
+				`,
+			},
+			expectedErr: `test.proto:3:8: extension name: Bar is local to "dep.proto" and cannot be used in other files`,
+		},
+		"failure_local_type_as_method_input": {
+			input: map[string]string{
+				"dep.proto": `
+					edition = "2024";
+					local message Bar {}
+				`,
+				"test.proto": `
+					edition = "2024";
+					import "dep.proto";
+					service Svc {
+						rpc Do(Bar) returns (Bar);
+					}
+				`,
+			},
+			expectedErr: `test.proto:4:16: method Svc.Do: Bar is local to "dep.proto" and cannot be used in other files`,
+		},
+		"failure_visibility_before_edition_2024": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					export message Foo {}
+				`,
+			},
+			expectedErr: `test.proto:2:1: message Foo: "export" may not be used before edition 2024`,
+		},
+		"success_visibility_words_as_identifiers": {
+			input: map[string]string{
+				"test.proto": `
+					syntax = "proto3";
+					package local;
+					message export {
+						.local.export export = 1;
+						enum local { export_ = 0; }
+						local kind = 2;
+					}
+				`,
+			},
 		},
 		"success_proto2_packed": {
 			input: map[string]string{
@@ -2233,6 +2473,167 @@ func TestLinkerValidation(t *testing.T) {
 			},
 			expectedErr: `test.proto:4:5: feature "enum_type" is allowed on [enum,file], not on field`,
 		},
+		"failure_editions_feature_unknown_value": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  int32 i32 = 1 [features.field_presence=FIELD_PRESENCE_UNKNOWN];
+					}
+				`,
+			},
+			expectedErr: `test.proto:3:18: feature "field_presence" must be set to a known value, not FIELD_PRESENCE_UNKNOWN`,
+		},
+		"failure_editions_implicit_presence_default": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					option features.field_presence = IMPLICIT;
+					message Foo {
+					  int32 i32 = 1 [default=1];
+					}
+				`,
+			},
+			expectedErr: `test.proto:4:18: field Foo.i32: default values are not allowed on fields with implicit presence`,
+		},
+		"failure_editions_field_presence_on_repeated": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  repeated int32 i32 = 1 [features.field_presence=EXPLICIT];
+					}
+				`,
+			},
+			expectedErr: `test.proto:3:27: field Foo.i32: only singular fields can specify field presence`,
+		},
+		"failure_editions_implicit_presence_on_message": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  Foo foo = 1 [features.field_presence=IMPLICIT];
+					}
+				`,
+			},
+			expectedErr: `test.proto:3:16: field Foo.foo: message fields cannot specify implicit presence`,
+		},
+		"failure_editions_field_presence_on_extension": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  extensions 1 to 100;
+					}
+					extend Foo {
+					  int32 i32 = 1 [features.field_presence=EXPLICIT];
+					}
+				`,
+			},
+			expectedErr: `test.proto:6:18: field i32: extension fields cannot specify field presence`,
+		},
+		"failure_editions_packed_on_non_repeated": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  int32 i32 = 1 [features.repeated_field_encoding=EXPANDED];
+					}
+				`,
+			},
+			expectedErr: `test.proto:3:18: field Foo.i32: only repeated fields can specify repeated field encoding`,
+		},
+		"failure_editions_packed_on_string": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  repeated string s = 1 [features.repeated_field_encoding=PACKED];
+					}
+				`,
+			},
+			expectedErr: `test.proto:3:26: field Foo.s: only repeated primitive fields can specify packed repeated field encoding`,
+		},
+		"failure_editions_utf8_validation_on_int": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  int32 i32 = 1 [features.utf8_validation=NONE];
+					}
+				`,
+			},
+			expectedErr: `test.proto:3:18: field Foo.i32: only string fields can specify utf8 validation`,
+		},
+		"failure_editions_message_encoding_on_int": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  int32 i32 = 1 [features.message_encoding=DELIMITED];
+					}
+				`,
+			},
+			expectedErr: `test.proto:3:18: field Foo.i32: only message fields can specify message encoding`,
+		},
+		"success_editions_delimited_and_map_features": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  Foo foo = 1 [features.message_encoding=DELIMITED];
+					  map<string, string> m = 2 [features.utf8_validation=NONE];
+					  repeated int32 i32 = 3 [features.repeated_field_encoding=EXPANDED];
+					}
+				`,
+			},
+		},
+		"failure_editions_open_enum_first_value": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					enum Foo {
+					  FOO = 1;
+					}
+				`,
+			},
+			expectedErr: `test.proto:3:9: enum Foo: open enums require that first value in enum have numeric value of 0`,
+		},
+		"success_editions_closed_enum_first_value": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					enum Foo {
+					  option features.enum_type = CLOSED;
+					  FOO = 1;
+					}
+				`,
+			},
+		},
+		"failure_editions_json_name_conflict": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  string foo_bar = 1;
+					  string fooBar = 2;
+					}
+				`,
+			},
+			expectedErr: `test.proto:4:3: field Foo.fooBar: default JSON name "fooBar" conflicts with default JSON name of field foo_bar, defined at test.proto:3:3`,
+		},
+		"success_editions_json_name_conflict_legacy_json": {
+			input: map[string]string{
+				"test.proto": `
+					edition = "2023";
+					message Foo {
+					  option features.json_format = LEGACY_BEST_EFFORT;
+					  string foo_bar = 1;
+					  string fooBar = 2;
+					}
+				`,
+			},
+		},
 	}
 
 	for name, tc := range testCases {
@@ -2428,6 +2829,92 @@ func TestLinkerSymbolCollisionNoSource(t *testing.T) {
 	require.ErrorContains(t, err, `foo.proto: symbol "google.protobuf.DescriptorProto" already defined at google/protobuf/descriptor.proto`)
 }
 
+func TestLinkerErrorTypes(t *testing.T) {
+	t.Parallel()
+	sources := map[string]string{
+		"a.proto": `syntax = "proto2";
+package foo.bar;
+import "b.proto";
+message A {
+  extensions 10 to 20;
+  message B {
+    optional Missing m = 1;
+    optional .foo.Missing n = 2;
+  }
+}
+enum A { X = 0; }
+extend A {
+  optional int32 x = 10;
+  optional int32 y = 10;
+}`,
+		"b.proto": `syntax = "proto2";`,
+	}
+	var errs, warnings []reporter.ErrorWithPos
+	compile := func() error {
+		t.Helper()
+		errs, warnings = nil, nil
+		compiler := protocompile.Compiler{
+			Resolver: &protocompile.SourceResolver{
+				Accessor: protocompile.SourceAccessorFromMap(sources),
+			},
+			Reporter: reporter.NewReporter(
+				func(err reporter.ErrorWithPos) error {
+					errs = append(errs, err)
+					return nil
+				},
+				func(err reporter.ErrorWithPos) {
+					warnings = append(warnings, err)
+				},
+			),
+		}
+		_, err := compiler.Compile(context.Background(), "a.proto")
+		return err
+	}
+
+	// other errors are only found once the symbol collision is fixed
+	require.ErrorIs(t, compile(), reporter.ErrInvalidSource)
+	require.Len(t, errs, 1)
+	var collision linker.ErrorSymbolCollision
+	require.ErrorAs(t, errs[0], &collision)
+	assert.Equal(t, reporter.CodeSymbolCollision, reporter.CodeOf(errs[0]))
+	assert.Equal(t, `a.proto:11:6: symbol "foo.bar.A" already defined at a.proto:4:9`, errs[0].Error())
+	assert.Equal(t, "foo.bar.A", string(collision.Symbol()))
+	assert.Equal(t, "a.proto:11:6", collision.Span().Start().String())
+	assert.Equal(t, "a.proto:4:9", collision.PreviousSpan().Start().String())
+	assert.False(t, collision.PreviousIsPackage())
+
+	sources["a.proto"] = strings.Replace(sources["a.proto"], "enum A", "enum E", 1)
+	require.ErrorIs(t, compile(), reporter.ErrInvalidSource)
+	require.Len(t, errs, 3)
+	var unresolved linker.ErrorUnresolvedReference
+	require.ErrorAs(t, errs[0], &unresolved)
+	assert.Equal(t, reporter.CodeUnresolvedReference, reporter.CodeOf(errs[0]))
+	assert.Equal(t, "a.proto:7:14: field foo.bar.A.B.m: unknown type Missing", errs[0].Error())
+	assert.Equal(t, linker.ReferenceFieldType, unresolved.Kind())
+	assert.Equal(t, "Missing", unresolved.Name())
+	assert.Equal(t, []string{"foo.bar.A.B", "foo.bar.A", "foo.bar", "foo", ""}, unresolved.Scopes())
+	assert.Empty(t, unresolved.ResolvedTo())
+	require.ErrorAs(t, errs[1], &unresolved)
+	assert.Equal(t, ".foo.Missing", unresolved.Name())
+	assert.Equal(t, []string{""}, unresolved.Scopes())
+
+	var extCollision linker.ErrorExtensionCollision
+	require.ErrorAs(t, errs[2], &extCollision)
+	assert.Equal(t, reporter.CodeTagCollision, reporter.CodeOf(errs[2]))
+	assert.Equal(t, "a.proto:14:22: extension with tag 10 for message foo.bar.A already defined at a.proto:13:22", errs[2].Error())
+	assert.Equal(t, "foo.bar.A", string(extCollision.Extendee()))
+	assert.Equal(t, protoreflect.FieldNumber(10), extCollision.Tag())
+	assert.Equal(t, 14, extCollision.Span().Start().Line)
+	assert.Equal(t, 13, extCollision.PreviousSpan().Start().Line)
+
+	sources["a.proto"] = strings.NewReplacer("Missing", "bar.A", "y = 10", "y = 11").Replace(sources["a.proto"])
+	require.NoError(t, compile())
+	require.Len(t, warnings, 1)
+	var unused linker.ErrorUnusedImport
+	require.ErrorAs(t, warnings[0], &unused)
+	assert.Equal(t, reporter.CodeUnusedImport, reporter.CodeOf(warnings[0]))
+}
+
 func TestSyntheticMapEntryUsageNoSource(t *testing.T) {
 	t.Parallel()
 	baseFileDescProto := &descriptorpb.FileDescriptorProto{
@@ -0,0 +1,150 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linker
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// emptyMessage is a message with no elements, which provides the
+// implementation of placeholder messages.
+var emptyMessage = func() protoreflect.MessageDescriptor {
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("placeholder.proto"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Placeholder")}},
+	}, nil)
+	if err != nil {
+		panic(err)
+	}
+	return fd.Messages().Get(0)
+}()
+
+// NewPlaceholderFile returns a placeholder for the file with the given path,
+// which could not be found. The file has no package and no elements, and its
+// IsPlaceholder method returns true.
+//
+// A placeholder can be used as a dependency when linking, in place of an
+// import that can't be resolved. References to types that would have been
+// defined in the missing file then can't be resolved, so they are reported as
+// errors and refer to placeholder messages.
+func NewPlaceholderFile(path string) File {
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{Name: proto.String(path)}, nil)
+	if err != nil {
+		// can't happen: the file has no elements to be invalid
+		panic(err)
+	}
+	f, err := NewFile(placeholderFile{FileDescriptor: fd}, nil)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+type placeholderFile struct {
+	protoreflect.FileDescriptor
+}
+
+func (f placeholderFile) IsPlaceholder() bool {
+	return true
+}
+
+// placeholderMessage is used in place of a message that couldn't be resolved,
+// for the type of a field, the extendee of an extension, or the request or
+// response type of a method. This lets the linker continue after such an
+// error, if the reporter allows it, and produce a usable result, like
+// protobuf-go's protodesc.FileOptions.AllowUnresolvable.
+type placeholderMessage struct {
+	protoreflect.MessageDescriptor
+	name protoreflect.FullName
+}
+
+var _ protoreflect.MessageDescriptor = (*placeholderMessage)(nil)
+
+// newPlaceholderMessage returns a placeholder for the message with the given
+// name, which may have a leading dot.
+func newPlaceholderMessage(name string) *placeholderMessage {
+	if len(name) > 0 && name[0] == '.' {
+		name = name[1:]
+	}
+	return &placeholderMessage{MessageDescriptor: emptyMessage, name: protoreflect.FullName(name)}
+}
+
+func (m *placeholderMessage) ParentFile() protoreflect.FileDescriptor {
+	return nil
+}
+
+func (m *placeholderMessage) Parent() protoreflect.Descriptor {
+	return nil
+}
+
+func (m *placeholderMessage) Index() int {
+	return 0
+}
+
+func (m *placeholderMessage) Syntax() protoreflect.Syntax {
+	return 0
+}
+
+func (m *placeholderMessage) Name() protoreflect.Name {
+	return m.name.Name()
+}
+
+func (m *placeholderMessage) FullName() protoreflect.FullName {
+	return m.name
+}
+
+func (m *placeholderMessage) IsPlaceholder() bool {
+	return true
+}
+
+func (m *placeholderMessage) Options() protoreflect.ProtoMessage {
+	return (*descriptorpb.MessageOptions)(nil)
+}
+
+// usePlaceholderTypes makes the given field refer to placeholder messages for
+// its extendee and for its type, if they couldn't be resolved. A field whose
+// descriptor proto already indicates a type other than a message or group
+// doesn't get a placeholder type.
+func (f *fldDescriptor) usePlaceholderTypes() {
+	if f.proto.GetExtendee() != "" && f.extendee == nil {
+		f.extendee = newPlaceholderMessage(f.proto.GetExtendee())
+	}
+	if f.proto.GetTypeName() == "" || f.msgType != nil || f.enumType != nil {
+		return
+	}
+	switch {
+	case f.proto.Type == nil:
+		// like protobuf-go, guess that the type is a message
+		f.proto.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	case f.proto.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE &&
+		f.proto.GetType() != descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		return
+	}
+	f.msgType = newPlaceholderMessage(f.proto.GetTypeName())
+}
+
+// usePlaceholderTypes makes the given method refer to placeholder messages for
+// its request and response types, if they couldn't be resolved.
+func (m *mtdDescriptor) usePlaceholderTypes() {
+	if m.inputType == nil {
+		m.inputType = newPlaceholderMessage(m.proto.GetInputType())
+	}
+	if m.outputType == nil {
+		m.outputType = newPlaceholderMessage(m.proto.GetOutputType())
+	}
+}
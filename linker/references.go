@@ -0,0 +1,242 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linker
+
+import (
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/bufbuild/protocompile/ast"
+)
+
+// ReferenceKind indicates the kind of a reference, which is where in the
+// source the referenced symbol is used.
+type ReferenceKind int
+
+const (
+	// ReferenceFieldType is the type of a field or extension. For map
+	// fields, this is the type of the map's values.
+	ReferenceFieldType = ReferenceKind(iota + 1)
+	// ReferenceExtendee is the extendee of an extension.
+	ReferenceExtendee
+	// ReferenceMethodInput is the request type of a method.
+	ReferenceMethodInput
+	// ReferenceMethodOutput is the response type of a method.
+	ReferenceMethodOutput
+	// ReferenceOptionName is a component of an option name, which refers
+	// to a field of an options message or to an extension.
+	ReferenceOptionName
+	// ReferenceMessageLiteralExtension is the name of an extension in a
+	// message literal in an option value.
+	ReferenceMessageLiteralExtension
+)
+
+func (k ReferenceKind) String() string {
+	switch k {
+	case ReferenceFieldType:
+		return "field type"
+	case ReferenceExtendee:
+		return "extendee"
+	case ReferenceMethodInput:
+		return "method input"
+	case ReferenceMethodOutput:
+		return "method output"
+	case ReferenceOptionName:
+		return "option name"
+	case ReferenceMessageLiteralExtension:
+		return "message literal extension"
+	default:
+		return "unknown"
+	}
+}
+
+// Reference is a use of a symbol in a file. References are recorded during
+// linking, and they are available from Result.References.
+type Reference struct {
+	// Symbol is the fully-qualified name of the referenced element, without
+	// a leading dot.
+	Symbol protoreflect.FullName
+	// Kind is the kind of the reference.
+	Kind ReferenceKind
+	// Node is the AST node that contains the reference, which is usually an
+	// ast.IdentValueNode. It is nil if the AST has been removed from the
+	// result, and an ast.NoSourceNode if the result has no AST.
+	Node ast.Node
+	// Span is the location of the reference in the source. It remains valid
+	// after the AST has been removed.
+	Span ast.SourceSpan
+}
+
+func (r *result) References() []Reference {
+	return r.references
+}
+
+func (r *result) addReference(kind ReferenceKind, d protoreflect.Descriptor, node ast.Node) {
+	r.references = append(r.references, Reference{
+		Symbol: d.FullName(),
+		Kind:   kind,
+		Node:   node,
+		Span:   r.FileNode().NodeInfo(node),
+	})
+}
+
+// sortReferences sorts the references in the file by their location.
+func (r *result) sortReferences() {
+	sort.SliceStable(r.references, func(i, j int) bool {
+		return r.references[i].Span.Start().Offset < r.references[j].Span.Start().Offset
+	})
+}
+
+// removeReferenceNodes drops the AST nodes from the recorded references,
+// keeping only their spans.
+func (r *result) removeReferenceNodes() {
+	for i := range r.references {
+		ref := &r.references[i]
+		ref.Node = nil
+		ref.Span = ast.NewSourceSpan(ref.Span.Start(), ref.Span.End())
+	}
+}
+
+// optionsMessages are the options messages for each kind of element, keyed by
+// the element type used in error messages.
+var optionsMessages = map[string]protoreflect.MessageDescriptor{
+	"file":            (*descriptorpb.FileOptions)(nil).ProtoReflect().Descriptor(),
+	"message":         (*descriptorpb.MessageOptions)(nil).ProtoReflect().Descriptor(),
+	"field":           (*descriptorpb.FieldOptions)(nil).ProtoReflect().Descriptor(),
+	"extension":       (*descriptorpb.FieldOptions)(nil).ProtoReflect().Descriptor(),
+	"oneof":           (*descriptorpb.OneofOptions)(nil).ProtoReflect().Descriptor(),
+	"extension range": (*descriptorpb.ExtensionRangeOptions)(nil).ProtoReflect().Descriptor(),
+	"enum":            (*descriptorpb.EnumOptions)(nil).ProtoReflect().Descriptor(),
+	"enum value":      (*descriptorpb.EnumValueOptions)(nil).ProtoReflect().Descriptor(),
+	"service":         (*descriptorpb.ServiceOptions)(nil).ProtoReflect().Descriptor(),
+	"method":          (*descriptorpb.MethodOptions)(nil).ProtoReflect().Descriptor(),
+}
+
+// optionNameRefs is an option name whose references to fields of options
+// messages are recorded after all types in the file have been resolved.
+type optionNameRefs struct {
+	elemType string
+	name     []*descriptorpb.UninterpretedOption_NamePart
+	// the extensions that the extension parts of the name resolved to
+	exts map[int]protoreflect.FieldDescriptor
+}
+
+// addOptionNameReferences records references for the parts of the given
+// option name. Extension names are already resolved; the other parts are
+// resolved as fields of the message type of the part before them.
+func (r *result) addOptionNameReferences(opt optionNameRefs) {
+	msg := optionsMessages[opt.elemType]
+	for i, part := range opt.name {
+		if msg == nil {
+			return
+		}
+		var fld protoreflect.FieldDescriptor
+		if ext, ok := opt.exts[i]; ok {
+			fld = ext
+		} else {
+			fld = msg.Fields().ByName(protoreflect.Name(part.GetNamePart()))
+		}
+		if fld == nil {
+			return
+		}
+		node := r.OptionNamePartNode(part)
+		if ref, ok := node.(*ast.FieldReferenceNode); ok {
+			node = ref.Name
+		}
+		r.addReference(ReferenceOptionName, fld, node)
+		msg = fld.Message()
+	}
+}
+
+// ReferenceIndex is an index of the references in a set of files, which
+// maps symbols to the places where they are used and back.
+type ReferenceIndex struct {
+	bySymbol map[protoreflect.FullName][]Reference
+	byFile   map[string][]Reference
+}
+
+// NewReferenceIndex creates an index of the references in the given files,
+// such as all of the files returned from a single compile operation. Files
+// that are not a Result, which were not linked from source, are ignored.
+func NewReferenceIndex(files Files) *ReferenceIndex {
+	idx := &ReferenceIndex{
+		bySymbol: map[protoreflect.FullName][]Reference{},
+		byFile:   map[string][]Reference{},
+	}
+	for _, file := range files {
+		res, ok := file.(Result)
+		if !ok {
+			continue
+		}
+		if _, ok := idx.byFile[res.Path()]; ok {
+			continue
+		}
+		refs := res.References()
+		idx.byFile[res.Path()] = refs
+		for _, ref := range refs {
+			idx.bySymbol[ref.Symbol] = append(idx.bySymbol[ref.Symbol], ref)
+		}
+	}
+	return idx
+}
+
+// References returns all references to the symbol with the given
+// fully-qualified name. A leading dot is ignored. References within a file
+// are in the order they appear in the source.
+func (idx *ReferenceIndex) References(symbol protoreflect.FullName) []Reference {
+	return idx.bySymbol[protoreflect.FullName(strings.TrimPrefix(string(symbol), "."))]
+}
+
+// IsReferenced returns true if the symbol with the given fully-qualified
+// name is used anywhere in the indexed files.
+func (idx *ReferenceIndex) IsReferenced(symbol protoreflect.FullName) bool {
+	return len(idx.References(symbol)) > 0
+}
+
+// ReferencesInFile returns all references in the file with the given path,
+// in the order they appear in the source.
+func (idx *ReferenceIndex) ReferencesInFile(path string) []Reference {
+	return idx.byFile[path]
+}
+
+// ReferenceAt returns the reference in the file with the given path at the
+// given position, which is identified by its Offset field. It returns false
+// if there is no reference there.
+func (idx *ReferenceIndex) ReferenceAt(path string, pos ast.SourcePos) (Reference, bool) {
+	refs := idx.byFile[path]
+	// the references are sorted, so find the first one that ends after pos
+	i := sort.Search(len(refs), func(i int) bool {
+		return pos.Offset < endOffset(refs[i].Span)
+	})
+	if i < len(refs) && refs[i].Span.Start().Offset <= pos.Offset {
+		return refs[i], true
+	}
+	return Reference{}, false
+}
+
+// endOffset returns the offset just past the end of the given span.
+func endOffset(span ast.SourceSpan) int {
+	// The offset of the end position is that of the last character, unlike
+	// its line and column, which are after the last character.
+	end := span.End()
+	if end == span.Start() {
+		// empty span
+		return end.Offset
+	}
+	return end.Offset + 1
+}
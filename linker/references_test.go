@@ -0,0 +1,122 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linker_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/linker"
+)
+
+func TestReferences(t *testing.T) {
+	t.Parallel()
+	sources := map[string]string{
+		"a.proto": `syntax = "proto2";
+package a;
+import "google/protobuf/descriptor.proto";
+message Msg {
+  optional string name = 1;
+  extensions 10 to 20;
+}
+enum Kind {
+  KIND_A = 0;
+}
+message Unused {}
+extend google.protobuf.MessageOptions {
+  optional Msg msg_opt = 5000;
+}
+extend Msg {
+  optional Kind kind = 10;
+}
+`,
+		"b.proto": `syntax = "proto2";
+package b;
+import "a.proto";
+message Req {
+  option (a.msg_opt) = { [a.kind]: KIND_A };
+  option (a.msg_opt).name = "y";
+  optional a.Msg msg = 1 [deprecated = true];
+  map<string, a.Kind> kinds = 2;
+  optional group Grp = 3 {}
+}
+service Svc {
+  rpc Do(Req) returns (a.Msg);
+}
+`,
+	}
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), "a.proto", "b.proto")
+	require.NoError(t, err)
+
+	res, ok := files[1].(linker.Result)
+	require.True(t, ok)
+	var refs []string
+	for _, ref := range res.References() {
+		refs = append(refs, fmt.Sprintf("%s %s %s", ref.Kind, ref.Symbol, text(sources["b.proto"], ref.Span)))
+	}
+	assert.Equal(t, []string{
+		"option name a.msg_opt a.msg_opt",
+		"message literal extension a.kind a.kind",
+		"option name a.msg_opt a.msg_opt",
+		"option name a.Msg.name name",
+		"field type a.Msg a.Msg",
+		"option name google.protobuf.FieldOptions.deprecated deprecated",
+		"field type a.Kind a.Kind",
+		"method input b.Req Req",
+		"method output a.Msg a.Msg",
+	}, refs)
+
+	idx := linker.NewReferenceIndex(files)
+	msgRefs := idx.References("a.Msg")
+	require.Len(t, msgRefs, 4)
+	assert.Equal(t, linker.ReferenceFieldType, msgRefs[0].Kind)
+	assert.Equal(t, linker.ReferenceExtendee, msgRefs[1].Kind)
+	assert.Equal(t, "a.proto", msgRefs[1].Span.Start().Filename)
+	assert.Equal(t, msgRefs, idx.References(".a.Msg"))
+	assert.True(t, idx.IsReferenced("a.Kind"))
+	assert.True(t, idx.IsReferenced("google.protobuf.MessageOptions"))
+	assert.False(t, idx.IsReferenced("a.Unused"))
+	assert.False(t, idx.IsReferenced("b.Req.Grp"))
+	assert.Len(t, idx.ReferencesInFile("b.proto"), 9)
+
+	offset := strings.Index(sources["b.proto"], "Req) returns")
+	ref, ok := idx.ReferenceAt("b.proto", ast.SourcePos{Offset: offset + 2})
+	require.True(t, ok)
+	assert.Equal(t, "b.Req", string(ref.Symbol))
+	_, ok = idx.ReferenceAt("b.proto", ast.SourcePos{Offset: offset + 3})
+	assert.False(t, ok)
+
+	// spans remain after the AST is removed, which the compiler does unless
+	// it is configured to retain ASTs
+	ref = res.References()[4]
+	assert.Nil(t, ref.Node)
+	assert.Equal(t, "a.Msg", text(sources["b.proto"], ref.Span))
+}
+
+func text(source string, span ast.SourceSpan) string {
+	return source[span.Start().Offset : span.End().Offset+1]
+}
@@ -34,6 +34,15 @@ func (r *result) ResolveMessageLiteralExtensionName(node ast.IdentValueNode) str
 	return r.optionQualifiedNames[node]
 }
 
+// IsUnresolvedOption returns true if the given option's name refers to an
+// extension that could not be resolved during linking. This can only happen if
+// the reporter allowed linking to continue after reporting that error, and
+// the option interpreter uses it to skip such options.
+func (r *result) IsUnresolvedOption(opt *descriptorpb.UninterpretedOption) bool {
+	_, ok := r.unresolvedOptions[opt]
+	return ok
+}
+
 func (r *result) resolveElement(name protoreflect.FullName) protoreflect.Descriptor {
 	if len(name) > 0 && name[0] == '.' {
 		name = name[1:]
@@ -88,6 +97,21 @@ func resolveInFile[T any](f File, publicImportsOnly bool, checked []string, fn f
 		}
 		return res, nil
 	}
+	if r, ok := f.(*result); ok && !publicImportsOnly {
+		// Elements in option dependencies are visible too. But callers that
+		// resolve types must check that they aren't from such a dependency.
+		for _, path := range r.optionDeps {
+			dep := r.deps.FindFileByPath(path)
+			if dep == nil {
+				continue
+			}
+			res, err := resolveInFile(dep, true, checked, fn)
+			if errors.Is(err, protoregistry.NotFound) {
+				continue
+			}
+			return res, err
+		}
+	}
 	return zero, err
 }
 
@@ -100,6 +124,10 @@ func (r *result) CheckForUnusedImports(handler *reporter.Handler) {
 	file, _ := r.FileNode().(*ast.FileNode)
 	for i, dep := range fd.Dependency {
 		if _, ok := r.usedImports[dep]; !ok {
+			if imp := r.deps.FindFileByPath(dep); imp != nil && imp.IsPlaceholder() {
+				// the import is missing, which was already reported
+				continue
+			}
 			isPublic := false
 			// it's fine if it's a public import
 			for _, j := range fd.PublicDependency {
@@ -173,7 +201,7 @@ func (r *result) resolveReferences(handler *reporter.Handler, s *Symbols) error
 		}
 	}
 
-	return walk.DescriptorsEnterAndExit(r,
+	err := walk.DescriptorsEnterAndExit(r,
 		func(d protoreflect.Descriptor) error {
 			fqn := d.FullName()
 			switch d := d.(type) {
@@ -209,7 +237,7 @@ func (r *result) resolveReferences(handler *reporter.Handler, s *Symbols) error
 				if r.Syntax() == protoreflect.Proto3 && !allowedProto3Extendee(d.field.proto.GetExtendee()) {
 					file := r.FileNode()
 					node := r.FieldNode(d.field.proto).FieldExtendee()
-					if err := handler.HandleErrorf(file.NodeInfo(node), "extend blocks in proto3 can only be used to define custom options"); err != nil {
+					if err := handler.HandleErrorWithPos(file.NodeInfo(node), reporter.Codef(reporter.CodeNotAllowedInSyntax, "extend blocks in proto3 can only be used to define custom options")); err != nil {
 						return err
 					}
 				}
@@ -268,6 +296,18 @@ func (r *result) resolveReferences(handler *reporter.Handler, s *Symbols) error
 			}
 			return nil
 		})
+	if err != nil {
+		return err
+	}
+
+	// Option names can refer to fields of message types declared in this
+	// file, so they are recorded once all types are resolved.
+	for _, optName := range r.optionNames {
+		r.addOptionNameReferences(optName)
+	}
+	r.optionNames = nil
+	r.sortReferences()
+	return nil
 }
 
 var allowedProto3Extendees = map[string]struct{}{
@@ -297,20 +337,25 @@ func resolveFieldTypes(f *fldDescriptor, handler *reporter.Handler, s *Symbols,
 	file := r.FileNode()
 	node := r.FieldNode(fld)
 	scope := fmt.Sprintf("field %s", f.fqn)
+	// If the reporter lets linking continue after a type can't be resolved,
+	// the field refers to a placeholder instead.
+	defer f.usePlaceholderTypes()
 	if fld.GetExtendee() != "" {
 		scope := fmt.Sprintf("extension %s", f.fqn)
 		dsc := r.resolve(fld.GetExtendee(), false, scopes)
-		if dsc == nil {
-			return handler.HandleErrorf(file.NodeInfo(node.FieldExtendee()), "unknown extendee type %s", fld.GetExtendee())
-		}
-		if isSentinelDescriptor(dsc) {
-			return handler.HandleErrorf(file.NodeInfo(node.FieldExtendee()), "unknown extendee type %s; resolved to %s which is not defined; consider using a leading dot", fld.GetExtendee(), dsc.FullName())
+		if dsc == nil || isSentinelDescriptor(dsc) {
+			err := unresolvedReference(ReferenceExtendee, "", fld.GetExtendee(), dsc, scopes)
+			return handler.HandleErrorWithPos(file.NodeInfo(node.FieldExtendee()), err)
 		}
 		extd, ok := dsc.(protoreflect.MessageDescriptor)
 		if !ok {
-			return handler.HandleErrorf(file.NodeInfo(node.FieldExtendee()), "extendee is invalid: %s is %s, not a message", dsc.FullName(), descriptorTypeWithArticle(dsc))
+			return handler.HandleErrorWithPos(file.NodeInfo(node.FieldExtendee()), reporter.Codef(reporter.CodeInvalidReference, "extendee is invalid: %s is %s, not a message", dsc.FullName(), descriptorTypeWithArticle(dsc)))
+		}
+		if reason := r.checkTypeAccess(dsc); reason != "" {
+			return handler.HandleErrorWithPos(file.NodeInfo(node.FieldExtendee()), reporter.Codef(reporter.CodeInaccessibleType, "%s: %s", scope, reason))
 		}
 		f.extendee = extd
+		r.addReference(ReferenceExtendee, extd, node.FieldExtendee())
 		extendeeName := "." + string(dsc.FullName())
 		if fld.GetExtendee() != extendeeName {
 			fld.Extendee = proto.String(extendeeName)
@@ -326,7 +371,7 @@ func resolveFieldTypes(f *fldDescriptor, handler *reporter.Handler, s *Symbols,
 			}
 		}
 		if !found {
-			if err := handler.HandleErrorf(file.NodeInfo(node.FieldTag()), "%s: tag %d is not in valid range for extended type %s", scope, tag, dsc.FullName()); err != nil {
+			if err := handler.HandleErrorWithPos(file.NodeInfo(node.FieldTag()), reporter.Codef(reporter.CodeExtensionOutOfRange, "%s: tag %d is not in valid range for extended type %s", scope, tag, dsc.FullName())); err != nil {
 				return err
 			}
 		} else {
@@ -347,11 +392,12 @@ func resolveFieldTypes(f *fldDescriptor, handler *reporter.Handler, s *Symbols,
 	}
 
 	dsc := r.resolve(fld.GetTypeName(), true, scopes)
-	if dsc == nil {
-		return handler.HandleErrorf(file.NodeInfo(node.FieldType()), "%s: unknown type %s", scope, fld.GetTypeName())
+	if dsc == nil || isSentinelDescriptor(dsc) {
+		err := unresolvedReference(ReferenceFieldType, scope, fld.GetTypeName(), dsc, scopes)
+		return handler.HandleErrorWithPos(file.NodeInfo(node.FieldType()), err)
 	}
-	if isSentinelDescriptor(dsc) {
-		return handler.HandleErrorf(file.NodeInfo(node.FieldType()), "%s: unknown type %s; resolved to %s which is not defined; consider using a leading dot", scope, fld.GetTypeName(), dsc.FullName())
+	if reason := r.checkTypeAccess(dsc); reason != "" {
+		return handler.HandleErrorWithPos(file.NodeInfo(node.FieldType()), reporter.Codef(reporter.CodeInaccessibleType, "%s: %s", scope, reason))
 	}
 	switch dsc := dsc.(type) {
 	case protoreflect.MessageDescriptor:
@@ -379,7 +425,7 @@ func resolveFieldTypes(f *fldDescriptor, handler *reporter.Handler, s *Symbols,
 				}
 			}
 			if !isValid {
-				return handler.HandleErrorf(file.NodeInfo(node.FieldType()), "%s: %s is a synthetic map entry and may not be referenced explicitly", scope, dsc.FullName())
+				return handler.HandleErrorWithPos(file.NodeInfo(node.FieldType()), reporter.Codef(reporter.CodeInvalidReference, "%s: %s is a synthetic map entry and may not be referenced explicitly", scope, dsc.FullName()))
 			}
 		}
 		typeName := "." + string(dsc.FullName())
@@ -390,19 +436,14 @@ func resolveFieldTypes(f *fldDescriptor, handler *reporter.Handler, s *Symbols,
 			// if type was tentatively unset, we now know it's actually a message
 			fld.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
 		} else if fld.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE && fld.GetType() != descriptorpb.FieldDescriptorProto_TYPE_GROUP {
-			return handler.HandleErrorf(file.NodeInfo(node.FieldType()), "%s: descriptor proto indicates type %v but should be %v", scope, fld.GetType(), descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+			return handler.HandleErrorWithPos(file.NodeInfo(node.FieldType()), reporter.Codef(reporter.CodeInvalidDescriptor, "%s: descriptor proto indicates type %v but should be %v", scope, fld.GetType(), descriptorpb.FieldDescriptorProto_TYPE_MESSAGE))
 		}
 		f.msgType = dsc
 	case protoreflect.EnumDescriptor:
 		proto3 := r.Syntax() == protoreflect.Proto3
-		enumIsClosed := dsc.IsClosed()
-		if fld.GetExtendee() == "" && proto3 && enumIsClosed {
+		if fld.GetExtendee() == "" && proto3 && isClosedEnum(dsc) {
 			// fields in a proto3 message cannot refer to closed enums
-			return handler.HandleErrorf(file.NodeInfo(node.FieldType()), "%s: cannot use enum with closed semantics %s in a proto3 message", scope, fld.GetTypeName())
-		}
-		if f.Cardinality() == protoreflect.Optional && !f.HasPresence() && enumIsClosed {
-			// fields in a proto3 message cannot refer to closed enums
-			return handler.HandleErrorf(file.NodeInfo(node.FieldType()), "%s.%s: implicit fields may only used enums with open semantics", scope, fld.GetTypeName())
+			return handler.HandleErrorWithPos(file.NodeInfo(node.FieldType()), reporter.Codef(reporter.CodeClosedEnum, "%s: cannot use enum with closed semantics %s in a proto3 message", scope, fld.GetTypeName()))
 		}
 		typeName := "." + string(dsc.FullName())
 		if fld.GetTypeName() != typeName {
@@ -412,11 +453,21 @@ func resolveFieldTypes(f *fldDescriptor, handler *reporter.Handler, s *Symbols,
 			// the type was tentatively unset, but now we know it's actually an enum
 			fld.Type = descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum()
 		} else if fld.GetType() != descriptorpb.FieldDescriptorProto_TYPE_ENUM {
-			return handler.HandleErrorf(file.NodeInfo(node.FieldType()), "%s: descriptor proto indicates type %v but should be %v", scope, fld.GetType(), descriptorpb.FieldDescriptorProto_TYPE_ENUM)
+			return handler.HandleErrorWithPos(file.NodeInfo(node.FieldType()), reporter.Codef(reporter.CodeInvalidDescriptor, "%s: descriptor proto indicates type %v but should be %v", scope, fld.GetType(), descriptorpb.FieldDescriptorProto_TYPE_ENUM))
 		}
 		f.enumType = dsc
 	default:
-		return handler.HandleErrorf(file.NodeInfo(node.FieldType()), "%s: invalid type: %s is %s, not a message or enum", scope, dsc.FullName(), descriptorTypeWithArticle(dsc))
+		return handler.HandleErrorWithPos(file.NodeInfo(node.FieldType()), reporter.Codef(reporter.CodeInvalidReference, "%s: invalid type: %s is %s, not a message or enum", scope, dsc.FullName(), descriptorTypeWithArticle(dsc)))
+	}
+	switch typeNode := node.FieldType().(type) {
+	case ast.IdentValueNode:
+		r.addReference(ReferenceFieldType, dsc, typeNode)
+	case ast.NoSourceNode:
+		// Without an AST, we can't tell whether this is a map field, whose
+		// type is the synthetic map entry that isn't named in source.
+		if msg, ok := dsc.(protoreflect.MessageDescriptor); !ok || !msg.IsMapEntry() {
+			r.addReference(ReferenceFieldType, dsc, typeNode)
+		}
 	}
 	return nil
 }
@@ -439,20 +490,24 @@ func isValidMap(mapField protoreflect.FieldDescriptor, mapEntry protoreflect.Mes
 func resolveMethodTypes(m *mtdDescriptor, handler *reporter.Handler, scopes []scope) error {
 	scope := fmt.Sprintf("method %s", m.fqn)
 	r := m.file
+	// If the reporter lets linking continue after a type can't be resolved,
+	// the method refers to a placeholder instead.
+	defer m.usePlaceholderTypes()
 	mtd := m.proto
 	file := r.FileNode()
 	node := r.MethodNode(mtd)
 	dsc := r.resolve(mtd.GetInputType(), false, scopes)
-	if dsc == nil {
-		if err := handler.HandleErrorf(file.NodeInfo(node.GetInputType()), "%s: unknown request type %s", scope, mtd.GetInputType()); err != nil {
+	if dsc == nil || isSentinelDescriptor(dsc) {
+		err := unresolvedReference(ReferenceMethodInput, scope, mtd.GetInputType(), dsc, scopes)
+		if err := handler.HandleErrorWithPos(file.NodeInfo(node.GetInputType()), err); err != nil {
 			return err
 		}
-	} else if isSentinelDescriptor(dsc) {
-		if err := handler.HandleErrorf(file.NodeInfo(node.GetInputType()), "%s: unknown request type %s; resolved to %s which is not defined; consider using a leading dot", scope, mtd.GetInputType(), dsc.FullName()); err != nil {
+	} else if msg, ok := dsc.(protoreflect.MessageDescriptor); !ok {
+		if err := handler.HandleErrorWithPos(file.NodeInfo(node.GetInputType()), reporter.Codef(reporter.CodeInvalidReference, "%s: invalid request type: %s is %s, not a message", scope, dsc.FullName(), descriptorTypeWithArticle(dsc))); err != nil {
 			return err
 		}
-	} else if msg, ok := dsc.(protoreflect.MessageDescriptor); !ok {
-		if err := handler.HandleErrorf(file.NodeInfo(node.GetInputType()), "%s: invalid request type: %s is %s, not a message", scope, dsc.FullName(), descriptorTypeWithArticle(dsc)); err != nil {
+	} else if reason := r.checkTypeAccess(dsc); reason != "" {
+		if err := handler.HandleErrorWithPos(file.NodeInfo(node.GetInputType()), reporter.Codef(reporter.CodeInaccessibleType, "%s: %s", scope, reason)); err != nil {
 			return err
 		}
 	} else {
@@ -461,20 +516,22 @@ func resolveMethodTypes(m *mtdDescriptor, handler *reporter.Handler, scopes []sc
 			mtd.InputType = proto.String(typeName)
 		}
 		m.inputType = msg
+		r.addReference(ReferenceMethodInput, msg, node.GetInputType())
 	}
 
 	// TODO: make input and output type resolution more DRY
 	dsc = r.resolve(mtd.GetOutputType(), false, scopes)
-	if dsc == nil {
-		if err := handler.HandleErrorf(file.NodeInfo(node.GetOutputType()), "%s: unknown response type %s", scope, mtd.GetOutputType()); err != nil {
+	if dsc == nil || isSentinelDescriptor(dsc) {
+		err := unresolvedReference(ReferenceMethodOutput, scope, mtd.GetOutputType(), dsc, scopes)
+		if err := handler.HandleErrorWithPos(file.NodeInfo(node.GetOutputType()), err); err != nil {
 			return err
 		}
-	} else if isSentinelDescriptor(dsc) {
-		if err := handler.HandleErrorf(file.NodeInfo(node.GetOutputType()), "%s: unknown response type %s; resolved to %s which is not defined; consider using a leading dot", scope, mtd.GetOutputType(), dsc.FullName()); err != nil {
+	} else if msg, ok := dsc.(protoreflect.MessageDescriptor); !ok {
+		if err := handler.HandleErrorWithPos(file.NodeInfo(node.GetOutputType()), reporter.Codef(reporter.CodeInvalidReference, "%s: invalid response type: %s is %s, not a message", scope, dsc.FullName(), descriptorTypeWithArticle(dsc))); err != nil {
 			return err
 		}
-	} else if msg, ok := dsc.(protoreflect.MessageDescriptor); !ok {
-		if err := handler.HandleErrorf(file.NodeInfo(node.GetOutputType()), "%s: invalid response type: %s is %s, not a message", scope, dsc.FullName(), descriptorTypeWithArticle(dsc)); err != nil {
+	} else if reason := r.checkTypeAccess(dsc); reason != "" {
+		if err := handler.HandleErrorWithPos(file.NodeInfo(node.GetOutputType()), reporter.Codef(reporter.CodeInaccessibleType, "%s: %s", scope, reason)); err != nil {
 			return err
 		}
 	} else {
@@ -483,6 +540,7 @@ func resolveMethodTypes(m *mtdDescriptor, handler *reporter.Handler, scopes []sc
 			mtd.OutputType = proto.String(typeName)
 		}
 		m.outputType = msg
+		r.addReference(ReferenceMethodOutput, msg, node.GetOutputType())
 	}
 
 	return nil
@@ -498,19 +556,29 @@ func (r *result) resolveOptions(handler *reporter.Handler, elemType string, elem
 opts:
 	for _, opt := range opts {
 		// resolve any extension names found in option names
-		for _, nm := range opt.Name {
+		optName := optionNameRefs{elemType: elemType, name: opt.Name}
+		for i, nm := range opt.Name {
 			if nm.GetIsExtension() {
 				node := r.OptionNamePartNode(nm)
-				fqn, err := r.resolveExtensionName(nm.GetNamePart(), scopes)
+				ext, err := r.resolveExtensionName(ReferenceOptionName, nm.GetNamePart(), scopes)
 				if err != nil {
-					if err := handler.HandleErrorf(file.NodeInfo(node), "%v%v", mc, err); err != nil {
+					if err := handler.HandleErrorWithPos(file.NodeInfo(node), fmt.Errorf("%v%w", mc, err)); err != nil {
 						return err
 					}
+					if r.unresolvedOptions == nil {
+						r.unresolvedOptions = map[*descriptorpb.UninterpretedOption]struct{}{}
+					}
+					r.unresolvedOptions[opt] = struct{}{}
 					continue opts
 				}
-				nm.NamePart = proto.String(fqn)
+				nm.NamePart = proto.String("." + string(ext.FullName()))
+				if optName.exts == nil {
+					optName.exts = map[int]protoreflect.FieldDescriptor{}
+				}
+				optName.exts[i] = ext
 			}
 		}
+		r.optionNames = append(r.optionNames, optName)
 		// also resolve any extension names found inside message literals in option values
 		mc.Option = opt
 		optVal := r.OptionNode(opt).GetValue()
@@ -551,13 +619,14 @@ func (r *result) resolveOptionValue(handler *reporter.Handler, mc *internal.Mess
 				// likely due to how it re-uses C++ text format implementation, and normal text
 				// format doesn't expect that kind of relative reference.)
 				scopes := scopes[:1] // first scope is file, the rest are enclosing messages
-				fqn, err := r.resolveExtensionName(string(fld.Name.Name.AsIdentifier()), scopes)
+				ext, err := r.resolveExtensionName(ReferenceMessageLiteralExtension, string(fld.Name.Name.AsIdentifier()), scopes)
 				if err != nil {
-					if err := handler.HandleErrorf(r.FileNode().NodeInfo(fld.Name.Name), "%v%v", mc, err); err != nil {
+					if err := handler.HandleErrorWithPos(r.FileNode().NodeInfo(fld.Name.Name), fmt.Errorf("%v%w", mc, err)); err != nil {
 						return err
 					}
 				} else {
-					r.optionQualifiedNames[fld.Name.Name] = fqn
+					r.optionQualifiedNames[fld.Name.Name] = "." + string(ext.FullName())
+					r.addReference(ReferenceMessageLiteralExtension, ext, fld.Name.Name)
 				}
 			}
 
@@ -580,20 +649,36 @@ func (r *result) resolveOptionValue(handler *reporter.Handler, mc *internal.Mess
 	return nil
 }
 
-func (r *result) resolveExtensionName(name string, scopes []scope) (string, error) {
+func (r *result) resolveExtensionName(kind ReferenceKind, name string, scopes []scope) (protoreflect.FieldDescriptor, error) {
 	dsc := r.resolve(name, false, scopes)
-	if dsc == nil {
-		return "", fmt.Errorf("unknown extension %s", name)
-	}
-	if isSentinelDescriptor(dsc) {
-		return "", fmt.Errorf("unknown extension %s; resolved to %s which is not defined; consider using a leading dot", name, dsc.FullName())
+	if dsc == nil || isSentinelDescriptor(dsc) {
+		return nil, unresolvedReference(kind, "", name, dsc, scopes)
 	}
-	if ext, ok := dsc.(protoreflect.FieldDescriptor); !ok {
-		return "", fmt.Errorf("invalid extension: %s is %s, not an extension", name, descriptorTypeWithArticle(dsc))
+	ext, ok := dsc.(protoreflect.FieldDescriptor)
+	if !ok {
+		return nil, reporter.Codef(reporter.CodeInvalidReference, "invalid extension: %s is %s, not an extension", name, descriptorTypeWithArticle(dsc))
 	} else if !ext.IsExtension() {
-		return "", fmt.Errorf("invalid extension: %s is a field but not an extension", name)
+		return nil, reporter.Codef(reporter.CodeInvalidReference, "invalid extension: %s is a field but not an extension", name)
+	}
+	return ext, nil
+}
+
+// unresolvedReference returns the error for a reference to the given name,
+// which couldn't be resolved in the given scopes. The given descriptor is
+// nil, or a sentinel for the undefined name that the reference resolved to.
+func unresolvedReference(kind ReferenceKind, element, name string, dsc protoreflect.Descriptor, scopes []scope) error {
+	err := &errUnresolvedReference{kind: kind, element: element, name: name}
+	if dsc != nil {
+		err.resolvedTo = dsc.FullName()
+	}
+	if strings.HasPrefix(name, ".") {
+		err.scopes = []string{""}
+		return err
+	}
+	for i := len(scopes) - 1; i >= 0; i-- {
+		err.scopes = append(err.scopes, scopes[i].names...)
 	}
-	return string("." + dsc.FullName()), nil
+	return err
 }
 
 func (r *result) resolve(name string, onlyTypes bool, scopes []scope) protoreflect.Descriptor {
@@ -610,7 +695,7 @@ func (r *result) resolve(name string, onlyTypes bool, scopes []scope) protorefle
 	}
 	var bestGuess protoreflect.Descriptor
 	for i := len(scopes) - 1; i >= 0; i-- {
-		d := scopes[i](firstName, name)
+		d := scopes[i].find(firstName, name)
 		if d != nil {
 			// In `protoc`, it will skip a match of the wrong type and move on
 			// to the next scope, but only if the reference is unqualified. So
@@ -632,6 +717,102 @@ func (r *result) resolve(name string, onlyTypes bool, scopes []scope) protorefle
 	return bestGuess
 }
 
+// checkTypeAccess checks that the given message or enum, which a type
+// reference in this file resolved to, may be used as a type in this file.
+// If not, it returns the reason.
+func (r *result) checkTypeAccess(d protoreflect.Descriptor) string {
+	file := d.ParentFile()
+	if file == nil || file.Path() == r.Path() {
+		return ""
+	}
+	if len(r.optionDeps) > 0 {
+		if _, ok := r.importsForTypes()[file.Path()]; !ok {
+			return fmt.Sprintf("%s cannot be used as a type because %q is an option import", d.FullName(), file.Path())
+		}
+	}
+	if !isExported(d) {
+		return fmt.Sprintf("%s is local to %q and cannot be used in other files", d.FullName(), file.Path())
+	}
+	return ""
+}
+
+// importsForTypes returns the set of files whose types may be used in this
+// file, which excludes option dependencies.
+func (r *result) importsForTypes() map[string]struct{} {
+	if r.typeImports != nil {
+		return r.typeImports
+	}
+	r.typeImports = map[string]struct{}{}
+	var addPublic func(protoreflect.FileDescriptor)
+	addPublic = func(fd protoreflect.FileDescriptor) {
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			imp := imports.Get(i)
+			if _, ok := r.typeImports[imp.Path()]; !ok && imp.IsPublic {
+				r.typeImports[imp.Path()] = struct{}{}
+				addPublic(imp.FileDescriptor)
+			}
+		}
+	}
+	imports := r.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		imp := imports.Get(i)
+		r.typeImports[imp.Path()] = struct{}{}
+		addPublic(imp.FileDescriptor)
+	}
+	return r.typeImports
+}
+
+// isExported returns true if the given message or enum may be used as a type
+// in files other than the one that declares it. Types declared with the
+// "export" or "local" keyword are exported or local, respectively. Otherwise,
+// the default_symbol_visibility feature decides. In edition 2024 and later, it
+// defaults to EXPORT_TOP_LEVEL, so nested types are local by default.
+func isExported(d protoreflect.Descriptor) bool {
+	var vis internal.SymbolVisibility
+	switch d := d.(type) {
+	case *msgDescriptor:
+		vis = internal.VisibilityOf(d.proto)
+	case *enumDescriptor:
+		vis = internal.VisibilityOf(d.proto)
+	default:
+		// descriptors that weren't linked from protos don't record visibility
+		return true
+	}
+	switch vis {
+	case internal.VisibilityLocal:
+		return false
+	case internal.VisibilityExport:
+		return true
+	}
+	switch defaultSymbolVisibility(d) {
+	case internal.DefaultVisibilityExportAll:
+		return true
+	case internal.DefaultVisibilityExportTopLevel:
+		_, topLevel := d.Parent().(protoreflect.FileDescriptor)
+		return topLevel
+	default:
+		// LOCAL_ALL and STRICT
+		return false
+	}
+}
+
+// defaultSymbolVisibility returns the value of the default_symbol_visibility
+// feature for the given element. The feature isn't a known field of
+// google.protobuf.FeatureSet, so it can't be queried with resolveFeature.
+func defaultSymbolVisibility(d protoreflect.Descriptor) internal.DefaultSymbolVisibility {
+	file := d.ParentFile()
+	for ; d != nil; d = featureParent(d) {
+		if vis := internal.DefaultSymbolVisibilityOf(featuresOf(d)); vis != internal.DefaultVisibilityUnknown {
+			return vis
+		}
+	}
+	if editionOf(file) >= internal.Edition2024 {
+		return internal.DefaultVisibilityExportTopLevel
+	}
+	return internal.DefaultVisibilityExportAll
+}
+
 func isType(d protoreflect.Descriptor) bool {
 	switch d.(type) {
 	case protoreflect.MessageDescriptor, protoreflect.EnumDescriptor:
@@ -642,7 +823,12 @@ func isType(d protoreflect.Descriptor) bool {
 
 // scope represents a lexical scope in a proto file in which messages and enums
 // can be declared.
-type scope func(firstName, fullName string) protoreflect.Descriptor
+type scope struct {
+	// the fully-qualified names of the namespaces that the scope searches,
+	// in the order in which they are searched
+	names []string
+	find  func(firstName, fullName string) protoreflect.Descriptor
+}
 
 func fileScope(r *result) scope {
 	// we search symbols in this file, but also symbols in other files that have
@@ -652,7 +838,7 @@ func fileScope(r *result) scope {
 	querySymbol := func(n string) protoreflect.Descriptor {
 		return r.resolveElement(protoreflect.FullName(n))
 	}
-	return func(firstName, fullName string) protoreflect.Descriptor {
+	find := func(firstName, fullName string) protoreflect.Descriptor {
 		for _, prefix := range prefixes {
 			var n1, n string
 			if prefix == "" {
@@ -669,17 +855,19 @@ func fileScope(r *result) scope {
 		}
 		return nil
 	}
+	return scope{names: prefixes, find: find}
 }
 
 func messageScope(r *result, messageName protoreflect.FullName) scope {
 	querySymbol := func(n string) protoreflect.Descriptor {
 		return resolveElementInFile(protoreflect.FullName(n), r)
 	}
-	return func(firstName, fullName string) protoreflect.Descriptor {
+	find := func(firstName, fullName string) protoreflect.Descriptor {
 		n1 := string(messageName) + "." + firstName
 		n := string(messageName) + "." + fullName
 		return resolveElementRelative(n1, n, querySymbol)
 	}
+	return scope{names: []string{string(messageName)}, find: find}
 }
 
 func resolveElementRelative(firstName, fullName string, query func(name string) protoreflect.Descriptor) protoreflect.Descriptor {
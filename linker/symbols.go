@@ -45,7 +45,7 @@ type packageSymbols struct {
 	children map[protoreflect.FullName]*packageSymbols
 	files    map[protoreflect.FileDescriptor]struct{}
 	symbols  map[protoreflect.FullName]symbolEntry
-	exts     map[extNumber]ast.SourcePos
+	exts     map[extNumber]ast.SourceSpan
 }
 
 type extNumber struct {
@@ -106,6 +106,50 @@ func (s *Symbols) Import(fd protoreflect.FileDescriptor, handler *reporter.Handl
 	return s.importFileWithExtensions(pkg, fd, handler)
 }
 
+// Delete removes all symbols and extension tags that were contributed by the
+// file with the given path. Packages that no longer contain any files or
+// symbols are also removed. This allows the file to later be imported again,
+// such as after it has been changed and re-linked, without reporting spurious
+// collisions with its prior contents.
+//
+// This must not be called concurrently with a link operation that uses s.
+func (s *Symbols) Delete(path string) {
+	if s == nil {
+		return
+	}
+	s.pkgTrie.deleteFile(path)
+}
+
+// deleteFile removes everything from s (and its sub-packages) that was
+// contributed by the given path. It returns true if s is empty afterwards.
+func (s *packageSymbols) deleteFile(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for fd := range s.files {
+		if fd.Path() == path {
+			delete(s.files, fd)
+		}
+	}
+	for pkg, child := range s.children {
+		if child.deleteFile(path) {
+			delete(s.children, pkg)
+			delete(s.symbols, pkg)
+		}
+	}
+	for name, entry := range s.symbols {
+		if !entry.isPackage && entry.span.Start().Filename == path {
+			delete(s.symbols, name)
+		}
+	}
+	for extNum, span := range s.exts {
+		if span.Start().Filename == path {
+			delete(s.exts, extNum)
+		}
+	}
+	return len(s.files) == 0 && len(s.children) == 0 && len(s.symbols) == 0 && len(s.exts) == 0
+}
+
 func (s *Symbols) importFileWithExtensions(pkg *packageSymbols, fd protoreflect.FileDescriptor, handler *reporter.Handler) error {
 	imported, err := pkg.importFile(fd, handler)
 	if err != nil {
@@ -137,11 +181,13 @@ func (s *packageSymbols) importFile(fd protoreflect.FileDescriptor, handler *rep
 		return false, nil
 	}
 
-	// first pass: check for conflicts
-	if err := s.checkFileLocked(fd, handler); err != nil {
+	// first pass: check for conflicts, using a sub-handler so that errors
+	// reported earlier, for other reasons, don't prevent committing
+	checkHandler := handler.SubHandler()
+	if err := s.checkFileLocked(fd, checkHandler); err != nil {
 		return false, err
 	}
-	if err := handler.Error(); err != nil {
+	if err := checkHandler.Error(); err != nil {
 		return false, err
 	}
 
@@ -214,6 +260,36 @@ func (s *packageSymbols) importPackage(pkgSpan ast.SourceSpan, pkg protoreflect.
 	return child, nil
 }
 
+// Lookup returns the location of the element or package with the given
+// fully-qualified name, among the files that have been imported into s. It
+// returns false if none of them defines the name.
+func (s *Symbols) Lookup(name protoreflect.FullName) (ast.SourceSpan, bool) {
+	if s == nil {
+		return nil, false
+	}
+	cur := &s.pkgTrie
+	for cur != nil {
+		cur.mu.RLock()
+		entry, ok := cur.symbols[name]
+		var next *packageSymbols
+		if !ok {
+			// descend into the package that contains the name
+			for pkg, child := range cur.children {
+				if strings.HasPrefix(string(name), string(pkg)+".") {
+					next = child
+					break
+				}
+			}
+		}
+		cur.mu.RUnlock()
+		if ok {
+			return entry.span, true
+		}
+		cur = next
+	}
+	return nil, false
+}
+
 func (s *Symbols) getPackage(pkg protoreflect.FullName) *packageSymbols {
 	if pkg == "" {
 		return &s.pkgTrie
@@ -240,21 +316,18 @@ func (s *Symbols) getPackage(pkg protoreflect.FullName) *packageSymbols {
 }
 
 func reportSymbolCollision(span ast.SourceSpan, fqn protoreflect.FullName, additionIsEnumVal bool, existing symbolEntry, handler *reporter.Handler) error {
-	// because of weird scoping for enum values, provide more context in error message
-	// if this conflict is with an enum value
-	var isPkg, suffix string
-	if additionIsEnumVal || existing.isEnumValue {
-		suffix = "; protobuf uses C++ scoping rules for enum values, so they exist in the scope enclosing the enum"
-	}
-	if existing.isPackage {
-		isPkg = " as a package"
-	}
 	orig := existing.span
 	conflict := span
 	if posLess(conflict.Start(), orig.Start()) {
 		orig, conflict = conflict, orig
 	}
-	return handler.HandleErrorf(conflict, "symbol %q already defined%s at %v%s", fqn, isPkg, orig.Start(), suffix)
+	return handler.HandleErrorWithPos(conflict, &errSymbolCollision{
+		symbol:            fqn,
+		span:              conflict,
+		previousSpan:      orig,
+		previousIsPackage: existing.isPackage,
+		isEnumValue:       additionIsEnumVal || existing.isEnumValue,
+	})
 }
 
 func posLess(a, b ast.SourcePos) bool {
@@ -387,7 +460,7 @@ func (s *packageSymbols) commitFileLocked(f protoreflect.FileDescriptor) {
 		s.symbols = map[protoreflect.FullName]symbolEntry{}
 	}
 	if s.exts == nil {
-		s.exts = map[extNumber]ast.SourcePos{}
+		s.exts = map[extNumber]ast.SourceSpan{}
 	}
 	_ = walk.Descriptors(f, func(d protoreflect.Descriptor) error {
 		span := sourceSpanFor(d)
@@ -444,11 +517,13 @@ func (s *packageSymbols) importResult(r *result, handler *reporter.Handler) (boo
 		return false, nil
 	}
 
-	// first pass: check for conflicts
-	if err := s.checkResultLocked(r, handler); err != nil {
+	// first pass: check for conflicts, using a sub-handler so that errors
+	// reported earlier, for other reasons, don't prevent committing
+	checkHandler := handler.SubHandler()
+	if err := s.checkResultLocked(r, checkHandler); err != nil {
 		return false, err
 	}
-	if err := handler.Error(); err != nil {
+	if err := checkHandler.Error(); err != nil {
 		return false, err
 	}
 
@@ -525,7 +600,7 @@ func (s *packageSymbols) commitResultLocked(r *result) {
 		s.symbols = map[protoreflect.FullName]symbolEntry{}
 	}
 	if s.exts == nil {
-		s.exts = map[extNumber]ast.SourcePos{}
+		s.exts = map[extNumber]ast.SourceSpan{}
 	}
 	_ = walk.DescriptorProtos(r.FileDescriptorProto(), func(fqn protoreflect.FullName, d proto.Message) error {
 		span := nameSpan(r.FileNode(), r.Node(d))
@@ -543,13 +618,13 @@ func (s *packageSymbols) commitResultLocked(r *result) {
 func (s *Symbols) AddExtension(pkg, extendee protoreflect.FullName, tag protoreflect.FieldNumber, span ast.SourceSpan, handler *reporter.Handler) error {
 	if pkg != "" {
 		if !strings.HasPrefix(string(extendee), string(pkg)+".") {
-			return handler.HandleErrorf(span, "could not register extension: extendee %q does not match package %q", extendee, pkg)
+			return handler.HandleErrorWithPos(span, reporter.Codef(reporter.CodeInvalidDescriptor, "could not register extension: extendee %q does not match package %q", extendee, pkg))
 		}
 	}
 	pkgSyms := s.getPackage(pkg)
 	if pkgSyms == nil {
 		// should never happen
-		return handler.HandleErrorf(span, "could not register extension: missing package symbols for %q", pkg)
+		return handler.HandleErrorWithPos(span, reporter.Codef(reporter.CodeInvalidDescriptor, "could not register extension: missing package symbols for %q", pkg))
 	}
 	return pkgSyms.addExtension(extendee, tag, span, handler)
 }
@@ -559,16 +634,17 @@ func (s *packageSymbols) addExtension(extendee protoreflect.FullName, tag protor
 	defer s.mu.Unlock()
 
 	if s.exts == nil {
-		s.exts = map[extNumber]ast.SourcePos{}
+		s.exts = map[extNumber]ast.SourceSpan{}
 	}
 
 	extNum := extNumber{extendee: extendee, tag: tag}
 	if existing, ok := s.exts[extNum]; ok {
-		if err := handler.HandleErrorf(span, "extension with tag %d for message %s already defined at %v", tag, extendee, existing); err != nil {
+		err := &errExtensionCollision{extendee: extendee, tag: tag, span: span, previousSpan: existing}
+		if err := handler.HandleErrorWithPos(span, err); err != nil {
 			return err
 		}
 	} else {
-		s.exts[extNum] = span.Start()
+		s.exts[extNum] = span
 	}
 	return nil
 }
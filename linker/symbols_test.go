@@ -137,6 +137,77 @@ func TestSymbolsImport(t *testing.T) {
 	}
 }
 
+func TestSymbolsDelete(t *testing.T) {
+	t.Parallel()
+
+	fd := parseAndLink(t, `
+		syntax = "proto2";
+		import "google/protobuf/descriptor.proto";
+		package foo.bar;
+		message Foo {
+			optional string bar = 1;
+			extensions 10 to 20;
+		}
+		extend Foo {
+			optional float f = 10;
+		}
+		extend google.protobuf.FieldOptions {
+			optional bytes xtra = 20000;
+		}
+		`)
+
+	var s Symbols
+	h := reporter.NewHandler(nil)
+	require.NoError(t, s.Import(fd, h))
+	require.NotNil(t, s.getPackage("foo.bar"))
+
+	s.Delete(fd.Path())
+	// packages with nothing left in them are removed
+	assert.Nil(t, s.getPackage("foo"))
+	assert.NotContains(t, s.pkgTrie.symbols, protoreflect.FullName("foo"))
+	// but the contents of other files remain
+	pkg := s.getPackage("google.protobuf")
+	require.NotNil(t, pkg)
+	assert.Contains(t, pkg.symbols, protoreflect.FullName("google.protobuf.FieldOptions"))
+	assert.Empty(t, pkg.exts)
+
+	// now the same file can be imported again without collisions
+	require.NoError(t, s.Import(parseAndLink(t, `
+		syntax = "proto2";
+		package foo.bar;
+		message Foo {
+			optional string bar = 1;
+		}
+		`), h))
+}
+
+func TestSymbolsLookup(t *testing.T) {
+	t.Parallel()
+
+	fd := parseAndLink(t, `
+		syntax = "proto2";
+		package foo.bar;
+		message Foo {
+			optional string bar = 1;
+		}
+		enum Kind {
+			KIND_A = 0;
+		}
+		`)
+
+	var s Symbols
+	require.NoError(t, s.Import(fd, reporter.NewHandler(nil)))
+	for _, name := range []protoreflect.FullName{"foo", "foo.bar", "foo.bar.Foo", "foo.bar.Foo.bar", "foo.bar.Kind", "foo.bar.KIND_A"} {
+		span, ok := s.Lookup(name)
+		assert.True(t, ok, name)
+		assert.NotNil(t, span, name)
+	}
+	for _, name := range []protoreflect.FullName{"", "bar", "foo.Foo", "foo.bar.Foo.baz", "foo.bar.Kind.KIND_A"} {
+		_, ok := s.Lookup(name)
+		assert.False(t, ok, name)
+	}
+}
+
 func TestSymbolExtensions(t *testing.T) {
 	t.Parallel()
 
@@ -23,6 +23,7 @@ import (
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/descriptorpb"
 
+	"github.com/bufbuild/protocompile/ast"
 	"github.com/bufbuild/protocompile/internal"
 	"github.com/bufbuild/protocompile/reporter"
 	"github.com/bufbuild/protocompile/walk"
@@ -38,18 +39,34 @@ func (r *result) ValidateOptions(handler *reporter.Handler) error {
 				if err := r.validateExtension(d, handler); err != nil {
 					return err
 				}
+				if d.ContainingMessage().FullName() == featureSetName {
+					if err := r.validateCustomFeature(d, handler); err != nil {
+						return err
+					}
+				}
 			}
 			if err := r.validatePacked(d, handler); err != nil {
 				return err
 			}
+			if err := r.validateImplicitPresence(d, handler); err != nil {
+				return err
+			}
+			if isEditions(r) {
+				if err := r.validateFieldFeatures(d, handler); err != nil {
+					return err
+				}
+			}
 		case protoreflect.MessageDescriptor:
-			md := d.(*msgDescriptor) //nolint:errcheck
-			if err := r.validateJSONNamesInMessage(md.proto, handler); err != nil {
+			if err := r.validateJSONNamesInMessage(d, handler); err != nil {
 				return err
 			}
 		case protoreflect.EnumDescriptor:
-			ed := d.(*enumDescriptor) //nolint:errcheck
-			if err := r.validateJSONNamesInEnum(ed.proto, handler); err != nil {
+			if isEditions(r) {
+				if err := r.validateOpenEnum(d, handler); err != nil {
+					return err
+				}
+			}
+			if err := r.validateJSONNamesInEnum(d, handler); err != nil {
 				return err
 			}
 		}
@@ -71,12 +88,12 @@ func (r *result) validateExtension(fld protoreflect.FieldDescriptor, handler *re
 		if fld.Kind() != protoreflect.MessageKind {
 			file := r.FileNode()
 			info := file.NodeInfo(r.FieldNode(fd.proto).FieldType())
-			return handler.HandleErrorf(info, "messages with message-set wire format cannot contain scalar extensions, only messages")
+			return handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeInvalidMessageSet, "messages with message-set wire format cannot contain scalar extensions, only messages"))
 		}
 		if fld.Cardinality() == protoreflect.Repeated {
 			file := r.FileNode()
 			info := file.NodeInfo(r.FieldNode(fd.proto).FieldLabel())
-			return handler.HandleErrorf(info, "messages with message-set wire format cannot contain repeated extensions, only optional")
+			return handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeInvalidMessageSet, "messages with message-set wire format cannot contain repeated extensions, only optional"))
 		}
 	} else if fld.Number() > internal.MaxNormalTag {
 		// In validateBasic() we just made sure these were within bounds for any message. But
@@ -84,7 +101,7 @@ func (r *result) validateExtension(fld protoreflect.FieldDescriptor, handler *re
 		// and, if not, enforce tighter limit.
 		file := r.FileNode()
 		info := file.NodeInfo(r.FieldNode(fd.proto).FieldTag())
-		return handler.HandleErrorf(info, "tag number %d is higher than max allowed tag number (%d)", fld.Number(), internal.MaxNormalTag)
+		return handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeInvalidTag, "tag number %d is higher than max allowed tag number (%d)", fld.Number(), internal.MaxNormalTag))
 	}
 
 	return nil
@@ -103,7 +120,7 @@ func (r *result) validatePacked(fld protoreflect.FieldDescriptor, handler *repor
 	if fd.proto.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
 		file := r.FileNode()
 		info := file.NodeInfo(r.FieldNode(fd.proto).FieldLabel())
-		err := handler.HandleErrorf(info, "packed option is only allowed on repeated fields")
+		err := handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeInvalidOption, "packed option is only allowed on repeated fields"))
 		if err != nil {
 			return err
 		}
@@ -113,33 +130,256 @@ func (r *result) validatePacked(fld protoreflect.FieldDescriptor, handler *repor
 		descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
 		file := r.FileNode()
 		info := file.NodeInfo(r.FieldNode(fd.proto).FieldType())
-		return handler.HandleErrorf(info, "packed option is only allowed on numeric, boolean, and enum fields")
+		return handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeInvalidOption, "packed option is only allowed on numeric, boolean, and enum fields"))
 	}
 	return nil
 }
 
-func (r *result) validateJSONNamesInMessage(md *descriptorpb.DescriptorProto, handler *reporter.Handler) error {
-	if err := r.validateFieldJSONNames(md, false, handler); err != nil {
-		return err
+func (r *result) validateImplicitPresence(fld protoreflect.FieldDescriptor, handler *reporter.Handler) error {
+	if xtd, ok := fld.(protoreflect.ExtensionTypeDescriptor); ok {
+		fld = xtd.Descriptor()
 	}
-	if err := r.validateFieldJSONNames(md, true, handler); err != nil {
-		return err
+	if fld.Enum() == nil || fld.Cardinality() != protoreflect.Optional || fld.HasPresence() {
+		return nil
+	}
+	if !isClosedEnum(fld.Enum()) {
+		return nil
 	}
+	fd := fld.(*fldDescriptor) //nolint:errcheck
+	if r.Syntax() == protoreflect.Proto3 {
+		// already reported during linking
+		return nil
+	}
+	file := r.FileNode()
+	info := file.NodeInfo(r.FieldNode(fd.proto).FieldType())
+	return handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeClosedEnum, "field %s: cannot use closed enum %s in a field with implicit presence", fld.FullName(), fld.Enum().FullName()))
+}
 
-	for _, nmd := range md.GetNestedType() {
-		if err := r.validateJSONNamesInMessage(nmd, handler); err != nil {
+// validateFieldFeatures validates the features of a field in a file that uses
+// editions. This is done after options are interpreted, so that features that
+// are inherited from enclosing elements are known.
+func (r *result) validateFieldFeatures(fld protoreflect.FieldDescriptor, handler *reporter.Handler) error {
+	if xtd, ok := fld.(protoreflect.ExtensionTypeDescriptor); ok {
+		fld = xtd.Descriptor()
+	}
+	fd := fld.(*fldDescriptor) //nolint:errcheck
+	file := r.FileNode()
+	fieldNode := r.FieldNode(fd.proto)
+
+	presence := descriptorpb.FeatureSet_FieldPresence(resolveFeature(fld, fieldPresenceField).Enum())
+	if fd.proto.DefaultValue != nil && presence == descriptorpb.FeatureSet_IMPLICIT {
+		info := file.NodeInfo(findOptionNode(fieldNode, "default"))
+		if err := handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeInvalidOption, "field %s: default values are not allowed on fields with implicit presence", fld.FullName())); err != nil {
+			return err
+		}
+	}
+	if fld.IsExtension() && presence == descriptorpb.FeatureSet_LEGACY_REQUIRED {
+		info := file.NodeInfo(findOptionNode(fieldNode, "features", "field_presence"))
+		if err := handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeInvalidLabel, "field %s: extension fields cannot be required", fld.FullName())); err != nil {
 			return err
 		}
 	}
-	for _, ed := range md.GetEnumType() {
-		if err := r.validateJSONNamesInEnum(ed, handler); err != nil {
+
+	if fld.ContainingMessage().IsMapEntry() {
+		// Features of map entry fields are copied from the map field, where
+		// they have already been validated. Some of them may only apply to the
+		// map field itself, so they aren't validated again for its entry fields.
+		return nil
+	}
+	features := fd.proto.GetOptions().GetFeatures()
+	if features == nil {
+		// nothing set explicitly
+		return nil
+	}
+	var msg string
+	var featureName string
+	switch {
+	case features.FieldPresence != nil && fld.IsExtension():
+		msg, featureName = "extension fields cannot specify field presence", "field_presence"
+	case features.FieldPresence != nil && (fld.ContainingOneof() != nil || fld.Cardinality() == protoreflect.Repeated):
+		msg, featureName = "only singular fields can specify field presence", "field_presence"
+	case features.GetFieldPresence() == descriptorpb.FeatureSet_IMPLICIT && fld.Message() != nil:
+		msg, featureName = "message fields cannot specify implicit presence", "field_presence"
+	case features.RepeatedFieldEncoding != nil && fld.Cardinality() != protoreflect.Repeated:
+		msg, featureName = "only repeated fields can specify repeated field encoding", "repeated_field_encoding"
+	case features.GetRepeatedFieldEncoding() == descriptorpb.FeatureSet_PACKED && !isPackable(fld):
+		msg, featureName = "only repeated primitive fields can specify packed repeated field encoding", "repeated_field_encoding"
+	case features.Utf8Validation != nil && fld.Kind() != protoreflect.StringKind && !fld.IsMap():
+		msg, featureName = "only string fields can specify utf8 validation", "utf8_validation"
+	case features.MessageEncoding != nil && (fld.Message() == nil || fld.IsMap()):
+		msg, featureName = "only message fields can specify message encoding", "message_encoding"
+	default:
+		return nil
+	}
+	info := file.NodeInfo(findOptionNode(fieldNode, "features", featureName))
+	return handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeInvalidFeature, "field %s: %s", fld.FullName(), msg))
+}
+
+// isPackable returns true if the given field could use packed encoding if
+// it were repeated.
+func isPackable(fld protoreflect.FieldDescriptor) bool {
+	switch fld.Kind() {
+	case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.MessageKind, protoreflect.GroupKind:
+		return false
+	default:
+		return true
+	}
+}
+
+// findOptionNode returns the name of the option in the given field's compact
+// options whose name starts with the given names. If the option is a message
+// literal, the first name is all that needs to match. If no such option is
+// found, the field node itself is returned.
+func findOptionNode(fieldNode ast.FieldDeclNode, names ...string) ast.Node {
+	opts := fieldNode.GetOptions()
+	if opts == nil {
+		return fieldNode
+	}
+	for _, opt := range opts.Options {
+		parts := opt.Name.Parts
+		matches := len(parts) > 0
+		for i := 0; matches && i < len(parts) && i < len(names); i++ {
+			matches = !parts[i].IsExtension() && string(parts[i].Name.AsIdentifier()) == names[i]
+		}
+		if matches {
+			return opt.Name
+		}
+	}
+	return fieldNode
+}
+
+// validateCustomFeature validates an extension of google.protobuf.FeatureSet,
+// which defines custom features, and the fields of its message type, which
+// are the features themselves.
+func (r *result) validateCustomFeature(ext protoreflect.FieldDescriptor, handler *reporter.Handler) error {
+	if xtd, ok := ext.(protoreflect.ExtensionTypeDescriptor); ok {
+		ext = xtd.Descriptor()
+	}
+	fd := ext.(*fldDescriptor) //nolint:errcheck
+	file := r.FileNode()
+	extNode := r.FieldNode(fd.proto)
+	if err := validateFeatureExtension(ext); err != nil {
+		return handler.HandleErrorWithPos(file.NodeInfo(extNode.FieldType()), reporter.WithCode(reporter.CodeInvalidFeature, err))
+	}
+	if fd.proto.GetOptions().GetRetention() == descriptorpb.FieldOptions_RETENTION_SOURCE {
+		info := file.NodeInfo(findOptionNode(extNode, "retention"))
+		return handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeInvalidFeature, "feature extension %s: features cannot use source retention since they are needed at runtime", ext.FullName()))
+	}
+	fields := ext.Message().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if err := r.validateFeatureField(fields.Get(i), extNode, handler); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (r *result) validateJSONNamesInEnum(ed *descriptorpb.EnumDescriptorProto, handler *reporter.Handler) error {
+// validateFeatureField validates the definition of a single custom feature,
+// which is a field of the message type of a FeatureSet extension. If the
+// field is defined in another file, errors are reported at the given
+// extension node.
+func (r *result) validateFeatureField(fld protoreflect.FieldDescriptor, extNode ast.FieldDeclNode, handler *reporter.Handler) error {
+	file := r.FileNode()
+	fieldNode := extNode
+	if fd, ok := fld.(*fldDescriptor); ok && fld.ParentFile().Path() == r.Path() {
+		fieldNode = r.FieldNode(fd.proto)
+	}
+	report := func(node ast.Node, format string, args ...interface{}) error {
+		return handler.HandleErrorWithPos(file.NodeInfo(node), reporter.Codef(reporter.CodeInvalidFeature, "feature %s: %s", fld.FullName(), fmt.Sprintf(format, args...)))
+	}
+
+	switch {
+	case fld.ContainingOneof() != nil:
+		return report(fieldNode, "feature fields cannot be in a oneof")
+	case fld.Cardinality() == protoreflect.Required:
+		return report(fieldNode, "feature fields cannot be required")
+	case fld.IsList():
+		return report(fieldNode, "feature fields cannot be repeated")
+	case fld.Kind() != protoreflect.EnumKind && fld.Kind() != protoreflect.BoolKind:
+		return report(fieldNode, "feature fields must be enums or bools, not %s", fld.Kind())
+	}
+
+	opts, _ := fld.Options().(*descriptorpb.FieldOptions)
+	if opts.GetRetention() == descriptorpb.FieldOptions_RETENTION_SOURCE {
+		return report(findOptionNode(fieldNode, "retention"), "features cannot use source retention since they are needed at runtime")
+	}
+	if len(opts.GetTargets()) == 0 {
+		return report(fieldNode, "feature fields must specify at least one target")
+	}
+	if len(opts.GetEditionDefaults()) == 0 {
+		return report(fieldNode, "feature fields must specify edition defaults")
+	}
+	defaultsNode := findOptionNode(fieldNode, "edition_defaults")
+	seen := map[descriptorpb.Edition]struct{}{}
+	for _, def := range opts.GetEditionDefaults() {
+		edition := def.GetEdition()
+		if edition == descriptorpb.Edition_EDITION_UNKNOWN {
+			return report(defaultsNode, "edition defaults must specify an edition")
+		}
+		if _, ok := seen[edition]; ok {
+			return report(defaultsNode, "multiple edition defaults specified for %s", edition)
+		}
+		seen[edition] = struct{}{}
+		if _, err := parseFeatureValue(fld, def.GetValue()); err != nil {
+			return report(defaultsNode, "invalid default %q for %s: %v", def.GetValue(), edition, err)
+		}
+	}
+	if _, ok := seen[descriptorpb.Edition_EDITION_PROTO2]; !ok {
+		return report(defaultsNode, "feature fields must specify a default for %s", descriptorpb.Edition_EDITION_PROTO2)
+	}
+
+	support := internal.FeatureSupportOf(opts)
+	if support == nil {
+		return nil
+	}
+	supportNode := findOptionNode(fieldNode, "feature_support")
+	switch {
+	case support.EditionIntroduced == descriptorpb.Edition_EDITION_UNKNOWN:
+		return report(supportNode, "feature support must specify the edition in which the feature was introduced")
+	case support.EditionDeprecated != 0 && support.EditionDeprecated < support.EditionIntroduced:
+		return report(supportNode, "feature cannot be deprecated (in %s) before it is introduced (in %s)",
+			support.EditionDeprecated, support.EditionIntroduced)
+	case support.EditionDeprecated != 0 && support.DeprecationWarning == "":
+		return report(supportNode, "feature support must specify a deprecation warning for a deprecated feature")
+	case support.EditionRemoved != 0 && support.EditionRemoved <= support.EditionIntroduced:
+		return report(supportNode, "feature cannot be removed (in %s) before or when it is introduced (in %s)",
+			support.EditionRemoved, support.EditionIntroduced)
+	case support.EditionRemoved != 0 && support.EditionDeprecated != 0 && support.EditionRemoved <= support.EditionDeprecated:
+		return report(supportNode, "feature cannot be removed (in %s) before or when it is deprecated (in %s)",
+			support.EditionRemoved, support.EditionDeprecated)
+	}
+	for edition := range seen {
+		if edition != descriptorpb.Edition_EDITION_PROTO2 && edition < support.EditionIntroduced {
+			return report(defaultsNode, "edition default for %s is before the feature was introduced (in %s)",
+				edition, support.EditionIntroduced)
+		}
+	}
+	return nil
+}
+
+// validateOpenEnum checks that the first value of an open enum, in a file
+// that uses editions, is zero.
+func (r *result) validateOpenEnum(ed protoreflect.EnumDescriptor, handler *reporter.Handler) error {
+	if isClosedEnum(ed) || ed.Values().Len() == 0 || ed.Values().Get(0).Number() == 0 {
+		return nil
+	}
+	evd := ed.Values().Get(0).(*enValDescriptor) //nolint:errcheck
+	info := r.FileNode().NodeInfo(r.EnumValueNode(evd.proto).GetNumber())
+	return handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeInvalidEnumValue, "enum %s: open enums require that first value in enum have numeric value of 0", ed.FullName()))
+}
+
+func (r *result) validateJSONNamesInMessage(md protoreflect.MessageDescriptor, handler *reporter.Handler) error {
+	legacy := isLegacyJSONFormat(md)
+	mdProto := md.(*msgDescriptor).proto //nolint:errcheck
+	if err := r.validateFieldJSONNames(mdProto, false, legacy, handler); err != nil {
+		return err
+	}
+	return r.validateFieldJSONNames(mdProto, true, legacy, handler)
+}
+
+func (r *result) validateJSONNamesInEnum(enum protoreflect.EnumDescriptor, handler *reporter.Handler) error {
+	legacy := isLegacyJSONFormat(enum)
+	ed := enum.(*enumDescriptor).proto //nolint:errcheck
 	seen := map[string]*descriptorpb.EnumValueDescriptorProto{}
 	for _, evd := range ed.GetValue() {
 		scope := "enum value " + ed.GetName() + "." + evd.GetName()
@@ -148,13 +388,13 @@ func (r *result) validateJSONNamesInEnum(ed *descriptorpb.EnumDescriptorProto, h
 		if existing, ok := seen[name]; ok && evd.GetNumber() != existing.GetNumber() {
 			fldNode := r.EnumValueNode(evd)
 			existingNode := r.EnumValueNode(existing)
-			conflictErr := fmt.Errorf("%s: camel-case name (with optional enum name prefix removed) %q conflicts with camel-case name of enum value %s, defined at %v",
+			conflictErr := reporter.Codef(reporter.CodeJSONNameConflict, "%s: camel-case name (with optional enum name prefix removed) %q conflicts with camel-case name of enum value %s, defined at %v",
 				scope, name, existing.GetName(), r.FileNode().NodeInfo(existingNode).Start())
 
 			// Since proto2 did not originally have a JSON format, we report conflicts as just warnings
-			if r.Syntax() != protoreflect.Proto3 {
+			if legacy {
 				handler.HandleWarningWithPos(r.FileNode().NodeInfo(fldNode), conflictErr)
-			} else if err := handler.HandleErrorf(r.FileNode().NodeInfo(fldNode), conflictErr.Error()); err != nil {
+			} else if err := handler.HandleErrorWithPos(r.FileNode().NodeInfo(fldNode), conflictErr); err != nil {
 				return err
 			}
 		} else {
@@ -164,7 +404,7 @@ func (r *result) validateJSONNamesInEnum(ed *descriptorpb.EnumDescriptorProto, h
 	return nil
 }
 
-func (r *result) validateFieldJSONNames(md *descriptorpb.DescriptorProto, useCustom bool, handler *reporter.Handler) error {
+func (r *result) validateFieldJSONNames(md *descriptorpb.DescriptorProto, useCustom, legacy bool, handler *reporter.Handler) error {
 	type jsonName struct {
 		source *descriptorpb.FieldDescriptorProto
 		// true if orig is a custom JSON name (vs. the field's default JSON name)
@@ -198,12 +438,12 @@ func (r *result) validateFieldJSONNames(md *descriptorpb.DescriptorProto, useCus
 					srcCustomStr = "default"
 				}
 				info := r.FileNode().NodeInfo(fldNode)
-				conflictErr := reporter.Errorf(info, "%s: %s JSON name %q conflicts with %s JSON name of field %s, defined at %v",
-					scope, customStr, name, srcCustomStr, existing.source.GetName(), r.FileNode().NodeInfo(r.FieldNode(existing.source)).Start())
+				conflictErr := reporter.Error(info, reporter.Codef(reporter.CodeJSONNameConflict, "%s: %s JSON name %q conflicts with %s JSON name of field %s, defined at %v",
+					scope, customStr, name, srcCustomStr, existing.source.GetName(), r.FileNode().NodeInfo(r.FieldNode(existing.source)).Start()))
 
 				// Since proto2 did not originally have default JSON names, we report conflicts
 				// between default names (neither is a custom name) as just warnings.
-				if r.Syntax() != protoreflect.Proto3 && !custom && !existing.custom {
+				if legacy && !custom && !existing.custom {
 					handler.HandleWarning(conflictErr)
 				} else if err := handler.HandleError(conflictErr); err != nil {
 					return err
@@ -0,0 +1,580 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package migrate rewrites proto source files that use proto2 or proto3
+// syntax so that they use editions instead.
+//
+// The rewrite is done on the source text, using positions from the AST, so
+// that comments and formatting are preserved. Only the declarations whose
+// meaning would otherwise change are edited. Features are used to retain the
+// behavior of the original syntax. Where it results in fewer edits, a
+// feature is set once at the file level instead of on many elements.
+//
+// The result can be checked with Verify, which compiles the migrated source
+// and compares it to the original.
+package migrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/protoutil"
+	"github.com/bufbuild/protocompile/walk"
+)
+
+// Edition is the edition to which files are migrated.
+const Edition = "2023"
+
+// ToEditions returns the source of the given file, rewritten to use editions.
+// The given file must use proto2 or proto3 syntax. It must have been compiled
+// from source with the AST retained (see protocompile.Compiler.RetainASTs).
+//
+// The following changes are made:
+//   - The syntax declaration is replaced with an edition declaration.
+//   - The "optional" and "required" labels are removed. Required fields
+//     use the LEGACY_REQUIRED field presence instead.
+//   - Groups are replaced with a message and a field that uses the DELIMITED
+//     message encoding.
+//   - The "packed" option is replaced with the repeated_field_encoding
+//     feature, where needed.
+//   - Reserved names are changed from string literals to identifiers.
+//   - Features are added so that fields, enums, and strings behave the same
+//     as they did with the original syntax: implicit presence for proto3
+//     fields, and closed enums, expanded repeated fields, no UTF-8 validation,
+//     and best-effort JSON for proto2 files.
+func ToEditions(file linker.Result) ([]byte, error) {
+	fileNode := file.AST()
+	if fileNode == nil {
+		return nil, fmt.Errorf("%s: cannot migrate file without an AST", file.Path())
+	}
+	var proto2 bool
+	switch file.FileDescriptorProto().GetSyntax() {
+	case "", "proto2":
+		proto2 = true
+	case "proto3":
+	default:
+		return nil, fmt.Errorf("%s: file already uses editions", file.Path())
+	}
+	m := &migrator{
+		file:     file,
+		fileNode: fileNode,
+		src:      sourceText(fileNode),
+		proto2:   proto2,
+	}
+	if err := m.migrate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file.Path(), err)
+	}
+	return m.apply()
+}
+
+type migrator struct {
+	file     linker.Result
+	fileNode *ast.FileNode
+	src      []byte
+	proto2   bool
+
+	edits []edit
+	// groups that must be moved out of a oneof or extend block
+	moved []movedGroup
+
+	// features set at the file level
+	fileImplicit bool
+	fileExpanded bool
+	fileNoUTF8   bool
+	fileClosed   bool
+}
+
+// edit replaces the source text between start (inclusive) and end
+// (exclusive) with the given text. If start and end are the same, the
+// text is inserted.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// movedGroup is a group in a oneof or an extend block. Its message must be
+// declared outside of the enclosing block, at the given offset.
+type movedGroup struct {
+	start, end         int
+	bodyStart, bodyEnd int
+	name               string
+	fieldDecl          string
+	insertAt           int
+	indent             string
+}
+
+func (m *migrator) migrate() error {
+	var fields []protoreflect.FieldDescriptor
+	var enums []protoreflect.EnumDescriptor
+	var hasTypes bool
+	err := walk.Descriptors(m.file, func(d protoreflect.Descriptor) error {
+		switch d := d.(type) {
+		case protoreflect.FieldDescriptor:
+			if !d.IsExtension() && d.ContainingMessage().IsMapEntry() {
+				return nil
+			}
+			fields = append(fields, d)
+		case protoreflect.EnumDescriptor:
+			enums = append(enums, d)
+			hasTypes = true
+		case protoreflect.MessageDescriptor:
+			hasTypes = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.chooseFileFeatures(fields, enums)
+
+	var fileOptions []string
+	if m.fileImplicit {
+		fileOptions = append(fileOptions, "option features.field_presence = IMPLICIT;")
+	}
+	if m.fileClosed {
+		fileOptions = append(fileOptions, "option features.enum_type = CLOSED;")
+	}
+	if m.fileExpanded {
+		fileOptions = append(fileOptions, "option features.repeated_field_encoding = EXPANDED;")
+	}
+	if m.fileNoUTF8 {
+		fileOptions = append(fileOptions, "option features.utf8_validation = NONE;")
+	}
+	if m.proto2 && hasTypes {
+		fileOptions = append(fileOptions, "option features.json_format = LEGACY_BEST_EFFORT;")
+	}
+	m.rewriteHeader(fileOptions)
+
+	for _, fld := range fields {
+		if err := m.rewriteField(fld); err != nil {
+			return err
+		}
+	}
+	if err := m.rewriteReservedNames(); err != nil {
+		return err
+	}
+	if m.proto2 && !m.fileClosed {
+		for _, enum := range enums {
+			if err := m.closeEnum(enum); err != nil {
+				return err
+			}
+		}
+	}
+	return m.moveGroups()
+}
+
+// chooseFileFeatures decides which features to set at the file level. A
+// feature is set at the file level if that requires fewer edits than setting
+// it on every element that needs it.
+func (m *migrator) chooseFileFeatures(fields []protoreflect.FieldDescriptor, enums []protoreflect.EnumDescriptor) {
+	var implicit, explicit, packed, expanded, stringFields int
+	for _, fld := range fields {
+		if isPackable(fld) {
+			if fld.IsPacked() {
+				packed++
+			} else {
+				expanded++
+			}
+		}
+		if hasStrings(fld) {
+			stringFields++
+		}
+		if !m.proto2 && fld.Cardinality() != protoreflect.Repeated && fld.Message() == nil {
+			switch {
+			case !fld.HasPresence():
+				implicit++
+			case isProto3Optional(fld):
+				explicit++
+			}
+		}
+	}
+	// With a file-level default, the elements that need the other
+	// value must still be edited.
+	m.fileImplicit = 1+explicit < implicit
+	m.fileExpanded = 1+packed < expanded
+	if m.proto2 {
+		m.fileNoUTF8 = stringFields > 1
+		m.fileClosed = len(enums) > 1
+	}
+}
+
+// rewriteHeader replaces the syntax declaration and adds the given file
+// options after the package, imports, and any existing file options.
+func (m *migrator) rewriteHeader(fileOptions []string) {
+	editionDecl := fmt.Sprintf("edition = %q;", Edition)
+	var anchor ast.Node
+decls:
+	for _, decl := range m.fileNode.Decls {
+		switch decl.(type) {
+		case *ast.PackageNode, *ast.ImportNode, *ast.OptionNode:
+			anchor = decl
+		case *ast.EmptyDeclNode:
+		default:
+			break decls
+		}
+	}
+
+	if m.fileNode.Syntax != nil {
+		start, end := m.span(m.fileNode.Syntax)
+		m.replace(start, end, editionDecl)
+		if anchor == nil {
+			anchor = m.fileNode.Syntax
+		}
+	} else {
+		// no syntax declaration, so we add one before the first declaration
+		insertAt := len(m.src)
+		if len(m.fileNode.Decls) > 0 {
+			insertAt, _ = m.span(m.fileNode.Decls[0])
+		}
+		text := editionDecl
+		if anchor == nil && len(fileOptions) > 0 {
+			text += "\n\n" + strings.Join(fileOptions, "\n")
+			fileOptions = nil
+		}
+		m.replace(insertAt, insertAt, text+"\n\n")
+	}
+	if len(fileOptions) == 0 {
+		return
+	}
+	_, end := m.span(anchor)
+	sep := "\n\n"
+	if _, ok := anchor.(*ast.OptionNode); ok {
+		sep = "\n"
+	}
+	m.replace(end, end, sep+strings.Join(fileOptions, "\n"))
+}
+
+func (m *migrator) rewriteField(fld protoreflect.FieldDescriptor) error {
+	fdProto := protoutil.ProtoFromFieldDescriptor(fld)
+	node := m.file.FieldNode(fdProto)
+	var features []string
+	if group, ok := node.(*ast.GroupNode); ok {
+		features = append(features, "features.message_encoding = DELIMITED")
+		if fld.Cardinality() == protoreflect.Required {
+			features = append(features, "features.field_presence = LEGACY_REQUIRED")
+		}
+		return m.rewriteGroup(fld, group, features)
+	}
+
+	var label *ast.KeywordNode
+	var fieldType ast.Node
+	if fieldNode, ok := node.(*ast.FieldNode); ok && fieldNode.Label.IsPresent() {
+		label, fieldType = fieldNode.Label.KeywordNode, fieldNode.FldType
+	}
+	switch {
+	case label == nil || label.Val == "repeated":
+		if !m.proto2 && fld.Cardinality() != protoreflect.Repeated && !fld.HasPresence() && !m.fileImplicit {
+			features = append(features, "features.field_presence = IMPLICIT")
+		}
+	case label.Val == "required":
+		features = append(features, "features.field_presence = LEGACY_REQUIRED")
+		m.removeUntil(label, fieldType)
+	default: // optional
+		if !m.proto2 && fld.Message() == nil && !fld.IsExtension() && m.fileImplicit {
+			features = append(features, "features.field_presence = EXPLICIT")
+		}
+		m.removeUntil(label, fieldType)
+	}
+
+	if isPackable(fld) && fld.IsPacked() == m.fileExpanded {
+		if fld.IsPacked() {
+			features = append(features, "features.repeated_field_encoding = PACKED")
+		} else {
+			features = append(features, "features.repeated_field_encoding = EXPANDED")
+		}
+	}
+	if m.proto2 && hasStrings(fld) && !m.fileNoUTF8 {
+		features = append(features, "features.utf8_validation = NONE")
+	}
+
+	opts := node.GetOptions()
+	var packed *ast.OptionNode
+	if opts != nil {
+		for _, opt := range opts.Options {
+			if isOption(opt, "packed") {
+				packed = opt
+				break
+			}
+		}
+	}
+	return m.updateOptions(node, opts, packed, features)
+}
+
+// updateOptions adds the given options to the compact options of the given
+// field, removing the given option, if not nil.
+func (m *migrator) updateOptions(node ast.FieldDeclNode, opts *ast.CompactOptionsNode, remove *ast.OptionNode, add []string) error {
+	switch {
+	case remove != nil && len(add) > 0:
+		start, end := m.span(remove)
+		m.replace(start, end, strings.Join(add, ", "))
+	case remove != nil && len(opts.Options) == 1:
+		// remove the whole option list, including preceding whitespace
+		_, start := m.span(node.FieldTag())
+		_, end := m.span(opts)
+		m.replace(start, end, "")
+	case remove != nil:
+		for i, opt := range opts.Options {
+			if opt != remove {
+				continue
+			}
+			var start, end int
+			if i < len(opts.Options)-1 {
+				start, _ = m.span(opt)
+				end, _ = m.span(opts.Options[i+1])
+			} else {
+				_, start = m.span(opts.Options[i-1])
+				_, end = m.span(opt)
+			}
+			m.replace(start, end, "")
+		}
+	case len(add) == 0:
+	case opts != nil:
+		_, end := m.span(opts.Options[len(opts.Options)-1])
+		m.replace(end, end, ", "+strings.Join(add, ", "))
+	default:
+		_, end := m.span(node.FieldTag())
+		m.replace(end, end, " ["+strings.Join(add, ", ")+"]")
+	}
+	return nil
+}
+
+func (m *migrator) rewriteGroup(fld protoreflect.FieldDescriptor, group *ast.GroupNode, features []string) error {
+	if group.Options != nil {
+		for _, opt := range group.Options.Options {
+			features = append(features, m.text(opt))
+		}
+	}
+	var label string
+	if fld.Cardinality() == protoreflect.Repeated {
+		label = "repeated "
+	}
+	name := group.Name.Val
+	fieldDecl := fmt.Sprintf("%s%s %s = %d [%s];", label, name, fld.Name(), fld.Number(), strings.Join(features, ", "))
+
+	start, end := m.span(group)
+	_, bodyStart := m.span(group.OpenBrace)
+	bodyEnd, _ := m.span(group.CloseBrace)
+
+	var container ast.Node
+	if group.Extendee != nil {
+		container = group.Extendee
+	} else if oneof := fld.ContainingOneof(); oneof != nil {
+		container = m.file.OneofNode(protoutil.ProtoFromOneofDescriptor(oneof))
+	}
+	if container == nil {
+		// The message can be declared in place of the group, followed by the field.
+		m.replace(start, bodyStart, fmt.Sprintf("message %s {", name))
+		m.replace(end, end, "\n"+m.indent(start)+fieldDecl)
+		return nil
+	}
+	insertAt, _ := m.span(container)
+	m.moved = append(m.moved, movedGroup{
+		start:     start,
+		end:       end,
+		bodyStart: bodyStart,
+		bodyEnd:   bodyEnd,
+		name:      name,
+		fieldDecl: fieldDecl,
+		insertAt:  insertAt,
+		indent:    m.indent(insertAt),
+	})
+	return nil
+}
+
+// moveGroups declares the messages for groups in oneofs and extend blocks
+// before the enclosing block. Groups nested in other such groups are moved
+// first, so that their edits are included in the body of the outer group.
+func (m *migrator) moveGroups() error {
+	sort.SliceStable(m.moved, func(i, j int) bool {
+		return m.moved[i].end-m.moved[i].start < m.moved[j].end-m.moved[j].start
+	})
+	for _, group := range m.moved {
+		body, err := m.extract(group.bodyStart, group.bodyEnd)
+		if err != nil {
+			return err
+		}
+		m.replace(group.start, group.end, group.fieldDecl)
+		m.replace(group.insertAt, group.insertAt, fmt.Sprintf("message %s {%s}\n%s", group.name, body, group.indent))
+	}
+	return nil
+}
+
+// rewriteReservedNames replaces string literals in reserved names with
+// identifiers, which editions require.
+func (m *migrator) rewriteReservedNames() error {
+	return ast.Walk(m.fileNode, &ast.SimpleVisitor{
+		DoVisitReservedNode: func(node *ast.ReservedNode) error {
+			for _, name := range node.Names {
+				start, end := m.span(name)
+				m.replace(start, end, name.AsString())
+			}
+			return nil
+		},
+	})
+}
+
+func (m *migrator) closeEnum(enum protoreflect.EnumDescriptor) error {
+	node, ok := m.file.EnumNode(protoutil.ProtoFromEnumDescriptor(enum)).(*ast.EnumNode)
+	if !ok {
+		return fmt.Errorf("unexpected declaration for enum %s", enum.FullName())
+	}
+	_, insertAt := m.span(node.OpenBrace)
+	option := "option features.enum_type = CLOSED;"
+	if len(node.Decls) == 0 {
+		m.replace(insertAt, insertAt, " "+option+" ")
+		return nil
+	}
+	first, _ := m.span(node.Decls[0])
+	indent := m.indent(first)
+	if !bytes.Contains(m.src[insertAt:first], []byte{'\n'}) {
+		// the body is on the same line as the open brace
+		m.replace(insertAt, insertAt, " "+option)
+		return nil
+	}
+	m.replace(insertAt, insertAt, "\n"+indent+option)
+	return nil
+}
+
+// removeUntil removes the given node and the whitespace after it, up to the
+// start of the given next node.
+func (m *migrator) removeUntil(node, next ast.Node) {
+	start, _ := m.span(node)
+	end, _ := m.span(next)
+	m.replace(start, end, "")
+}
+
+func (m *migrator) replace(start, end int, text string) {
+	m.edits = append(m.edits, edit{start: start, end: end, text: text})
+}
+
+// span returns the offsets of the start (inclusive) and end (exclusive) of
+// the given node.
+func (m *migrator) span(node ast.Node) (int, int) {
+	info := m.fileNode.NodeInfo(node)
+	start := info.Start().Offset
+	return start, start + len(info.RawText())
+}
+
+func (m *migrator) text(node ast.Node) string {
+	return m.fileNode.NodeInfo(node).RawText()
+}
+
+// indent returns the whitespace at the start of the line that contains the
+// given offset, if there is nothing else before the offset on that line.
+func (m *migrator) indent(offset int) string {
+	start := offset
+	for start > 0 && (m.src[start-1] == ' ' || m.src[start-1] == '\t') {
+		start--
+	}
+	if start > 0 && m.src[start-1] != '\n' {
+		return ""
+	}
+	return string(m.src[start:offset])
+}
+
+// extract applies all edits between the given offsets and returns the
+// resulting text. The edits are removed, so they won't be applied again.
+func (m *migrator) extract(start, end int) (string, error) {
+	var inside, outside []edit
+	for _, e := range m.edits {
+		if e.start >= start && e.end <= end {
+			inside = append(inside, e)
+		} else {
+			outside = append(outside, e)
+		}
+	}
+	m.edits = outside
+	text, err := applyEdits(m.src[start:end], inside, start)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+func (m *migrator) apply() ([]byte, error) {
+	return applyEdits(m.src, m.edits, 0)
+}
+
+// applyEdits applies the given edits to the given source, which starts at
+// the given offset. Edits at the same offset are applied in the order given.
+func applyEdits(src []byte, edits []edit, offset int) ([]byte, error) {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+	var buf bytes.Buffer
+	pos := 0
+	for _, e := range edits {
+		start, end := e.start-offset, e.end-offset
+		if start < pos {
+			return nil, errors.New("internal error: overlapping edits")
+		}
+		buf.Write(src[pos:start])
+		buf.WriteString(e.text)
+		pos = end
+	}
+	buf.Write(src[pos:])
+	return buf.Bytes(), nil
+}
+
+// sourceText returns the full source text of the given file.
+func sourceText(file *ast.FileNode) []byte {
+	var buf bytes.Buffer
+	items := file.Items()
+	for item, ok := items.First(); ok; item, ok = items.Next(item) {
+		info := file.ItemInfo(item)
+		buf.WriteString(info.LeadingWhitespace())
+		buf.WriteString(info.RawText())
+	}
+	return buf.Bytes()
+}
+
+// isPackable returns true if the given field is repeated and could use packed
+// encoding.
+func isPackable(fld protoreflect.FieldDescriptor) bool {
+	if fld.Cardinality() != protoreflect.Repeated || fld.IsMap() {
+		return false
+	}
+	switch fld.Kind() {
+	case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.MessageKind, protoreflect.GroupKind:
+		return false
+	default:
+		return true
+	}
+}
+
+// hasStrings returns true if the given field is a string field or a map
+// field with string keys or values.
+func hasStrings(fld protoreflect.FieldDescriptor) bool {
+	if fld.IsMap() {
+		return fld.MapKey().Kind() == protoreflect.StringKind || fld.MapValue().Kind() == protoreflect.StringKind
+	}
+	return fld.Kind() == protoreflect.StringKind
+}
+
+func isProto3Optional(fld protoreflect.FieldDescriptor) bool {
+	return protoutil.ProtoFromFieldDescriptor(fld).GetProto3Optional()
+}
+
+// isOption returns true if the given option has the given simple name.
+func isOption(opt *ast.OptionNode, name string) bool {
+	parts := opt.Name.Parts
+	return len(parts) == 1 && !parts[0].IsExtension() && string(parts[0].Name.AsIdentifier()) == name
+}
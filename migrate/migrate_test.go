@@ -0,0 +1,329 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+)
+
+func TestToEditions(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"proto2": {
+			input: `// Leading comment
+syntax = "proto2";
+
+package foo;
+
+// A message.
+message Foo {
+  optional string name = 1; // trailing comment
+  required int32 id = 2 [deprecated = true];
+  repeated int32 values = 3;
+  repeated int32 packed_values = 4 [packed = true];
+  repeated int32 more_values = 5 [packed = true, deprecated = true];
+  repeated Kind kinds = 6;
+  repeated uint64 ids = 7;
+  repeated double weights = 8;
+  map<string, int32> counts = 9;
+  optional Kind kind = 10 [default = B];
+  reserved "foo", "bar";
+}
+
+enum Kind {
+  A = 1;
+  B = 2;
+}
+
+enum Other {
+  C = 1;
+}
+`,
+			expected: `// Leading comment
+edition = "2023";
+
+package foo;
+
+option features.enum_type = CLOSED;
+option features.repeated_field_encoding = EXPANDED;
+option features.utf8_validation = NONE;
+option features.json_format = LEGACY_BEST_EFFORT;
+
+// A message.
+message Foo {
+  string name = 1; // trailing comment
+  int32 id = 2 [deprecated = true, features.field_presence = LEGACY_REQUIRED];
+  repeated int32 values = 3;
+  repeated int32 packed_values = 4 [features.repeated_field_encoding = PACKED];
+  repeated int32 more_values = 5 [features.repeated_field_encoding = PACKED, deprecated = true];
+  repeated Kind kinds = 6;
+  repeated uint64 ids = 7;
+  repeated double weights = 8;
+  map<string, int32> counts = 9;
+  Kind kind = 10 [default = B];
+  reserved foo, bar;
+}
+
+enum Kind {
+  A = 1;
+  B = 2;
+}
+
+enum Other {
+  C = 1;
+}
+`,
+		},
+		"proto2 with few elements": {
+			input: `syntax = "proto2";
+package foo;
+import "google/protobuf/empty.proto";
+message Foo {
+  optional string name = 1;
+  repeated int32 values = 2;
+  repeated int32 packed_values = 3 [packed = true];
+  optional google.protobuf.Empty empty = 4;
+}
+enum Kind { A = 1; }
+`,
+			expected: `edition = "2023";
+package foo;
+import "google/protobuf/empty.proto";
+
+option features.json_format = LEGACY_BEST_EFFORT;
+message Foo {
+  string name = 1 [features.utf8_validation = NONE];
+  repeated int32 values = 2 [features.repeated_field_encoding = EXPANDED];
+  repeated int32 packed_values = 3;
+  google.protobuf.Empty empty = 4;
+}
+enum Kind { option features.enum_type = CLOSED; A = 1; }
+`,
+		},
+		"proto3": {
+			input: `syntax = "proto3";
+
+package foo;
+
+option go_package = "foo";
+
+message Foo {
+  string a = 1;
+  int32 b = 2;
+  bool b2 = 8;
+  optional int32 c = 3;
+  repeated int32 d = 4;
+  repeated int32 e = 5 [packed = false];
+  Foo f = 6;
+  oneof g {
+    string h = 7;
+  }
+}
+`,
+			expected: `edition = "2023";
+
+package foo;
+
+option go_package = "foo";
+option features.field_presence = IMPLICIT;
+
+message Foo {
+  string a = 1;
+  int32 b = 2;
+  bool b2 = 8;
+  int32 c = 3 [features.field_presence = EXPLICIT];
+  repeated int32 d = 4;
+  repeated int32 e = 5 [features.repeated_field_encoding = EXPANDED];
+  Foo f = 6;
+  oneof g {
+    string h = 7;
+  }
+}
+`,
+		},
+		"proto3 with few implicit fields": {
+			input: `syntax = "proto3";
+message Foo {
+  string a = 1;
+  optional int32 b = 2;
+  optional int32 c = 3;
+}
+`,
+			expected: `edition = "2023";
+message Foo {
+  string a = 1 [features.field_presence = IMPLICIT];
+  int32 b = 2;
+  int32 c = 3;
+}
+`,
+		},
+		"groups": {
+			input: `syntax = "proto2";
+package foo;
+message Foo {
+  optional group Bar = 1 {
+    optional int32 x = 1;
+  }
+  repeated group Baz = 2 [deprecated = true] {
+    required int32 y = 1;
+  }
+  oneof choice {
+    group Qux = 3 {
+      optional int32 z = 1;
+    }
+  }
+  extensions 100 to 200;
+}
+extend Foo {
+  optional group Ext = 100 {
+    optional int32 w = 1;
+  }
+}
+`,
+			expected: `edition = "2023";
+package foo;
+
+option features.json_format = LEGACY_BEST_EFFORT;
+message Foo {
+  message Bar {
+    int32 x = 1;
+  }
+  Bar bar = 1 [features.message_encoding = DELIMITED];
+  message Baz {
+    int32 y = 1 [features.field_presence = LEGACY_REQUIRED];
+  }
+  repeated Baz baz = 2 [features.message_encoding = DELIMITED, deprecated = true];
+  message Qux {
+      int32 z = 1;
+    }
+  oneof choice {
+    Qux qux = 3 [features.message_encoding = DELIMITED];
+  }
+  extensions 100 to 200;
+}
+message Ext {
+    int32 w = 1;
+  }
+extend Foo {
+  Ext ext = 100 [features.message_encoding = DELIMITED];
+}
+`,
+		},
+		"no syntax": {
+			input: `message Foo {
+  optional string name = 1;
+}
+`,
+			expected: `edition = "2023";
+
+option features.json_format = LEGACY_BEST_EFFORT;
+
+message Foo {
+  string name = 1 [features.utf8_validation = NONE];
+}
+`,
+		},
+	}
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			file := compile(t, testCase.input)
+			migrated, err := ToEditions(file.(linker.Result))
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, string(migrated))
+			require.NoError(t, Verify(context.Background(), file, migrated))
+		})
+	}
+}
+
+func TestToEditions_AlreadyEditions(t *testing.T) {
+	t.Parallel()
+	file := compile(t, `edition = "2023"; message Foo {}`)
+	_, err := ToEditions(file.(linker.Result))
+	require.ErrorContains(t, err, "file already uses editions")
+}
+
+func TestToEditions_TestData(t *testing.T) {
+	t.Parallel()
+	paths := []string{
+		"desc_test1.proto",
+		"desc_test2.proto",
+		"desc_test_comments.proto",
+		"desc_test_complex.proto",
+		"desc_test_defaults.proto",
+		"desc_test_field_types.proto",
+		"desc_test_options.proto",
+		"desc_test_proto3.proto",
+		"desc_test_proto3_optional.proto",
+		"desc_test_wellknowntypes.proto",
+	}
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{"../internal/testdata"},
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+		RetainASTs:     true,
+	}
+	files, err := compiler.Compile(context.Background(), paths...)
+	require.NoError(t, err)
+	for _, file := range files {
+		file := file
+		t.Run(file.Path(), func(t *testing.T) {
+			t.Parallel()
+			migrated, err := ToEditions(file.(linker.Result))
+			require.NoError(t, err)
+			require.NoError(t, Verify(context.Background(), file, migrated))
+		})
+	}
+}
+
+func TestVerify_Mismatch(t *testing.T) {
+	t.Parallel()
+	file := compile(t, `syntax = "proto2"; message Foo { repeated int32 a = 1; }`)
+	err := Verify(context.Background(), file, []byte(`edition = "2023"; message Foo { repeated int32 a = 1; }`))
+	require.EqualError(t, err, "Foo.a: packed is true after migration, instead of false")
+
+	err = Verify(context.Background(), file, []byte(`edition = "2023"; message Foo { repeated int32 a = 1; int32 b = 2; }`))
+	require.EqualError(t, err, "Foo: has 2 fields after migration, instead of 1")
+
+	err = Verify(context.Background(), file, []byte(`edition = "2023"; message Foo { repeated int32 a = 1 }`))
+	require.ErrorContains(t, err, "test.proto:1:54: syntax error")
+}
+
+func compile(t *testing.T, source string) linker.File {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"test.proto": source,
+			}),
+		}),
+		RetainASTs: true,
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	return files[0]
+}
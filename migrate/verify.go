@@ -0,0 +1,253 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// Verify compiles the given migrated source for the given original file and
+// checks that the result is equivalent to the original. The two must define
+// the same elements, and fields must have the same wire format and behavior:
+// the same numbers, types, cardinality, presence, encoding, defaults, and
+// UTF-8 validation. Enums must have the same values and be open or closed
+// in the same way.
+//
+// The migrated source is compiled using the original file's dependencies.
+// An error is returned if it cannot be compiled or if it is not equivalent.
+func Verify(ctx context.Context, original linker.File, migrated []byte) error {
+	deps := map[string]protoreflect.FileDescriptor{}
+	addDeps(original, deps)
+	compiler := &protocompile.Compiler{
+		Resolver: protocompile.ResolverFunc(func(path string) (protocompile.SearchResult, error) {
+			if path == original.Path() {
+				return protocompile.SearchResult{Source: bytes.NewReader(migrated)}, nil
+			}
+			if dep, ok := deps[path]; ok {
+				return protocompile.SearchResult{Desc: dep}, nil
+			}
+			return protocompile.SearchResult{}, protoregistry.NotFound
+		}),
+		Reporter: reporter.NewReporter(nil, func(reporter.ErrorWithPos) {}),
+	}
+	files, err := compiler.Compile(ctx, original.Path())
+	if err != nil {
+		return err
+	}
+	return compareContainers(original, files[0])
+}
+
+func addDeps(file protoreflect.FileDescriptor, deps map[string]protoreflect.FileDescriptor) {
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		dep := imports.Get(i).FileDescriptor
+		if _, ok := deps[dep.Path()]; ok {
+			continue
+		}
+		deps[dep.Path()] = dep
+		addDeps(dep, deps)
+	}
+}
+
+// container is a file or message, which can contain other elements.
+type container interface {
+	protoreflect.Descriptor
+	Messages() protoreflect.MessageDescriptors
+	Enums() protoreflect.EnumDescriptors
+	Extensions() protoreflect.ExtensionDescriptors
+}
+
+func compareContainers(original, migrated container) error {
+	if original.Messages().Len() != migrated.Messages().Len() {
+		return fmt.Errorf("%s: has %d messages after migration, instead of %d", original.FullName(), migrated.Messages().Len(), original.Messages().Len())
+	}
+	for i := 0; i < original.Messages().Len(); i++ {
+		md := original.Messages().Get(i)
+		other := migrated.Messages().ByName(md.Name())
+		if other == nil {
+			return fmt.Errorf("%s: missing after migration", md.FullName())
+		}
+		if err := compareMessages(md, other); err != nil {
+			return err
+		}
+	}
+	if original.Enums().Len() != migrated.Enums().Len() {
+		return fmt.Errorf("%s: has %d enums after migration, instead of %d", original.FullName(), migrated.Enums().Len(), original.Enums().Len())
+	}
+	for i := 0; i < original.Enums().Len(); i++ {
+		ed := original.Enums().Get(i)
+		other := migrated.Enums().ByName(ed.Name())
+		if other == nil {
+			return fmt.Errorf("%s: missing after migration", ed.FullName())
+		}
+		if err := compareEnums(ed, other); err != nil {
+			return err
+		}
+	}
+	if original.Extensions().Len() != migrated.Extensions().Len() {
+		return fmt.Errorf("%s: has %d extensions after migration, instead of %d", original.FullName(), migrated.Extensions().Len(), original.Extensions().Len())
+	}
+	for i := 0; i < original.Extensions().Len(); i++ {
+		fld := original.Extensions().Get(i)
+		other := migrated.Extensions().ByName(fld.Name())
+		if other == nil {
+			return fmt.Errorf("%s: missing after migration", fld.FullName())
+		}
+		if err := compareFields(fld, other); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compareMessages(original, migrated protoreflect.MessageDescriptor) error {
+	if original.Fields().Len() != migrated.Fields().Len() {
+		return fmt.Errorf("%s: has %d fields after migration, instead of %d", original.FullName(), migrated.Fields().Len(), original.Fields().Len())
+	}
+	for i := 0; i < original.Fields().Len(); i++ {
+		fld := original.Fields().Get(i)
+		other := migrated.Fields().ByNumber(fld.Number())
+		if other == nil {
+			return fmt.Errorf("%s: missing after migration", fld.FullName())
+		}
+		if err := compareFields(fld, other); err != nil {
+			return err
+		}
+	}
+	if original.IsMapEntry() != migrated.IsMapEntry() {
+		return fmt.Errorf("%s: map entry differs", original.FullName())
+	}
+	return compareContainers(original, migrated)
+}
+
+func compareFields(original, migrated protoreflect.FieldDescriptor) error {
+	differs := func(what string, before, after interface{}) error {
+		return fmt.Errorf("%s: %s is %v after migration, instead of %v", original.FullName(), what, after, before)
+	}
+	switch {
+	case original.Name() != migrated.Name():
+		return differs("name", original.Name(), migrated.Name())
+	case original.Number() != migrated.Number():
+		return differs("number", original.Number(), migrated.Number())
+	case original.JSONName() != migrated.JSONName():
+		return differs("JSON name", original.JSONName(), migrated.JSONName())
+	case original.Kind() != migrated.Kind():
+		return differs("kind", original.Kind(), migrated.Kind())
+	case original.Cardinality() != migrated.Cardinality():
+		return differs("cardinality", original.Cardinality(), migrated.Cardinality())
+	case original.HasPresence() != migrated.HasPresence() && !original.ContainingMessage().IsMapEntry():
+		// The key and value of a map entry are always serialized, so their
+		// presence doesn't matter.
+		return differs("presence", original.HasPresence(), migrated.HasPresence())
+	case original.IsPacked() != migrated.IsPacked():
+		return differs("packed", original.IsPacked(), migrated.IsPacked())
+	case original.IsExtension() != migrated.IsExtension():
+		return differs("extension", original.IsExtension(), migrated.IsExtension())
+	case original.HasDefault() != migrated.HasDefault():
+		return differs("has default", original.HasDefault(), migrated.HasDefault())
+	case original.HasDefault() && !valuesEqual(original.Default(), migrated.Default()):
+		return differs("default", original.Default(), migrated.Default())
+	case typeName(original) != typeName(migrated):
+		return differs("type", typeName(original), typeName(migrated))
+	case oneofName(original) != oneofName(migrated):
+		return differs("oneof", oneofName(original), oneofName(migrated))
+	case original.ContainingMessage().FullName() != migrated.ContainingMessage().FullName():
+		return differs("extendee", original.ContainingMessage().FullName(), migrated.ContainingMessage().FullName())
+	}
+	if original.Kind() == protoreflect.StringKind {
+		before, err := linker.ResolvedFeatures(original)
+		if err != nil {
+			return err
+		}
+		after, err := linker.ResolvedFeatures(migrated)
+		if err != nil {
+			return err
+		}
+		if before.GetUtf8Validation() != after.GetUtf8Validation() {
+			return differs("UTF-8 validation", before.GetUtf8Validation(), after.GetUtf8Validation())
+		}
+	}
+	return nil
+}
+
+func compareEnums(original, migrated protoreflect.EnumDescriptor) error {
+	if isClosed(original) != isClosed(migrated) {
+		return fmt.Errorf("%s: closed is %v after migration, instead of %v", original.FullName(), isClosed(migrated), isClosed(original))
+	}
+	if original.Values().Len() != migrated.Values().Len() {
+		return fmt.Errorf("%s: has %d values after migration, instead of %d", original.FullName(), migrated.Values().Len(), original.Values().Len())
+	}
+	for i := 0; i < original.Values().Len(); i++ {
+		val, other := original.Values().Get(i), migrated.Values().Get(i)
+		if val.Name() != other.Name() || val.Number() != other.Number() {
+			return fmt.Errorf("%s: value is %s = %d after migration, instead of %s = %d", original.FullName(), other.Name(), other.Number(), val.Name(), val.Number())
+		}
+	}
+	return nil
+}
+
+func isClosed(ed protoreflect.EnumDescriptor) bool {
+	if closer, ok := ed.(interface{ IsClosed() bool }); ok {
+		return closer.IsClosed()
+	}
+	return ed.Syntax() != protoreflect.Proto3
+}
+
+func typeName(fld protoreflect.FieldDescriptor) protoreflect.FullName {
+	switch {
+	case fld.Message() != nil:
+		return fld.Message().FullName()
+	case fld.Enum() != nil:
+		return fld.Enum().FullName()
+	default:
+		return ""
+	}
+}
+
+// oneofName returns the name of the oneof that contains the given field. This
+// is empty for synthetic oneofs, which are not used in editions.
+func oneofName(fld protoreflect.FieldDescriptor) protoreflect.Name {
+	oneof := fld.ContainingOneof()
+	if oneof == nil || oneof.IsSynthetic() {
+		return ""
+	}
+	return oneof.Name()
+}
+
+func valuesEqual(a, b protoreflect.Value) bool {
+	switch a := a.Interface().(type) {
+	case []byte:
+		b, ok := b.Interface().([]byte)
+		return ok && bytes.Equal(a, b)
+	case float32:
+		b, ok := b.Interface().(float32)
+		return ok && (a == b || math.IsNaN(float64(a)) && math.IsNaN(float64(b)))
+	case float64:
+		b, ok := b.Interface().(float64)
+		return ok && (a == b || math.IsNaN(a) && math.IsNaN(b))
+	default:
+		return a == b.Interface()
+	}
+}
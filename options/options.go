@@ -31,6 +31,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 
 	"google.golang.org/protobuf/encoding/prototext"
@@ -63,6 +64,7 @@ type interpreter struct {
 	file                    file
 	resolver                linker.Resolver
 	container               optionsContainer
+	unresolved              unresolvedOptions
 	overrideDescriptorProto linker.File
 	lenient                 bool
 	reporter                *reporter.Handler
@@ -145,6 +147,7 @@ func interpretOptions(lenient bool, file file, res linker.Resolver, handler *rep
 		index:    sourceinfo.OptionIndex{},
 	}
 	interp.container, _ = file.(optionsContainer)
+	interp.unresolved, _ = file.(unresolvedOptions)
 	for _, opt := range interpOpts {
 		opt(&interp)
 	}
@@ -367,13 +370,13 @@ func (interp *interpreter) interpretFieldPseudoOptions(fqn string, fld *descript
 		opt := uo[index]
 		optNode := interp.file.OptionNode(opt)
 		if opt.StringValue == nil {
-			return interp.reporter.HandleErrorf(interp.nodeInfo(optNode.GetValue()), "%s: expecting string value for json_name option", scope)
+			return interp.reporter.HandleErrorWithPos(interp.nodeInfo(optNode.GetValue()), reporter.Codef(reporter.CodeInvalidOptionValue, "%s: expecting string value for json_name option", scope))
 		}
 		jsonName := string(opt.StringValue)
 		// Extensions don't support custom json_name values.
 		// If the value is already set (via the descriptor) and doesn't match the default value, return an error.
 		if fld.GetExtendee() != "" && jsonName != "" && jsonName != internal.JSONName(fld.GetName()) {
-			return interp.reporter.HandleErrorf(interp.nodeInfo(optNode.GetName()), "%s: option json_name is not allowed on extensions", scope)
+			return interp.reporter.HandleErrorWithPos(interp.nodeInfo(optNode.GetName()), reporter.Codef(reporter.CodeInvalidOption, "%s: option json_name is not allowed on extensions", scope))
 		}
 		// attribute source code info
 		if on, ok := optNode.(*ast.OptionNode); ok {
@@ -381,7 +384,7 @@ func (interp *interpreter) interpretFieldPseudoOptions(fqn string, fld *descript
 		}
 		uo = internal.RemoveOption(uo, index)
 		if strings.HasPrefix(jsonName, "[") && strings.HasSuffix(jsonName, "]") {
-			return interp.reporter.HandleErrorf(interp.nodeInfo(optNode.GetValue()), "%s: option json_name value cannot start with '[' and end with ']'; that is reserved for representing extensions", scope)
+			return interp.reporter.HandleErrorWithPos(interp.nodeInfo(optNode.GetValue()), reporter.Codef(reporter.CodeInvalidOptionValue, "%s: option json_name value cannot start with '[' and end with ']'; that is reserved for representing extensions", scope))
 		}
 		fld.JsonName = proto.String(jsonName)
 	}
@@ -410,10 +413,10 @@ func (interp *interpreter) processDefaultOption(scope string, fqn string, fld *d
 	opt := uos[found]
 	optNode := interp.file.OptionNode(opt)
 	if fld.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
-		return -1, interp.reporter.HandleErrorf(interp.nodeInfo(optNode.GetName()), "%s: default value cannot be set because field is repeated", scope)
+		return -1, interp.reporter.HandleErrorWithPos(interp.nodeInfo(optNode.GetName()), reporter.Codef(reporter.CodeInvalidOption, "%s: default value cannot be set because field is repeated", scope))
 	}
 	if fld.GetType() == descriptorpb.FieldDescriptorProto_TYPE_GROUP || fld.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
-		return -1, interp.reporter.HandleErrorf(interp.nodeInfo(optNode.GetName()), "%s: default value cannot be set because field is a message", scope)
+		return -1, interp.reporter.HandleErrorWithPos(interp.nodeInfo(optNode.GetName()), reporter.Codef(reporter.CodeInvalidOption, "%s: default value cannot be set because field is a message", scope))
 	}
 	mc := &internal.MessageContext{
 		File:        interp.file,
@@ -468,12 +471,12 @@ func (interp *interpreter) processDefaultOption(scope string, fqn string, fld *d
 
 func (interp *interpreter) defaultValue(mc *internal.MessageContext, fld *descriptorpb.FieldDescriptorProto, val ast.ValueNode) (interface{}, error) {
 	if _, ok := val.(*ast.MessageLiteralNode); ok {
-		return -1, reporter.Errorf(interp.nodeInfo(val), "%vdefault value cannot be a message", mc)
+		return -1, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vdefault value cannot be a message", mc))
 	}
 	if fld.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
 		ed := resolveDescriptor[protoreflect.EnumDescriptor](interp.resolver, fld.GetTypeName())
 		if ed == nil {
-			return -1, reporter.Errorf(interp.nodeInfo(val), "%vunable to resolve enum type %q for field %q", mc, fld.GetTypeName(), fld.GetName())
+			return -1, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vunable to resolve enum type %q for field %q", mc, fld.GetTypeName(), fld.GetName()))
 		}
 		_, name, err := interp.enumFieldValue(mc, ed, val, false)
 		if err != nil {
@@ -486,12 +489,12 @@ func (interp *interpreter) defaultValue(mc *internal.MessageContext, fld *descri
 
 func (interp *interpreter) defaultValueFromProto(mc *internal.MessageContext, fld *descriptorpb.FieldDescriptorProto, opt *descriptorpb.UninterpretedOption, node ast.Node) (interface{}, error) {
 	if opt.AggregateValue != nil {
-		return -1, reporter.Errorf(interp.nodeInfo(node), "%vdefault value cannot be a message", mc)
+		return -1, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vdefault value cannot be a message", mc))
 	}
 	if fld.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
 		ed := resolveDescriptor[protoreflect.EnumDescriptor](interp.resolver, fld.GetTypeName())
 		if ed == nil {
-			return -1, reporter.Errorf(interp.nodeInfo(node), "%vunable to resolve enum type %q for field %q", mc, fld.GetTypeName(), fld.GetName())
+			return -1, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vunable to resolve enum type %q for field %q", mc, fld.GetTypeName(), fld.GetName()))
 		}
 		_, name, err := interp.enumFieldValueFromProto(mc, ed, opt, node)
 		if err != nil {
@@ -835,6 +838,14 @@ type optionsContainer interface {
 	AddOptionBytes(pm proto.Message, opts []byte)
 }
 
+// unresolvedOptions may be optionally implemented by a linker.Result. Like
+// optionsContainer, it is meant only for internal use. It allows the option
+// interpreter to skip options whose names could not be resolved by the linker,
+// which already reported them as errors.
+type unresolvedOptions interface {
+	IsUnresolvedOption(opt *descriptorpb.UninterpretedOption) bool
+}
+
 func interpretElementOptions[Elem elementType[OptsStruct, Opts], OptsStruct any, Opts optionsType[OptsStruct]](
 	interp *interpreter,
 	fqn string,
@@ -872,7 +883,7 @@ func (interp *interpreter) interpretOptions(
 		dm := dynamicpb.NewMessage(md)
 		if err := cloneInto(dm, opts, nil); err != nil {
 			node := interp.file.Node(element)
-			return nil, interp.reporter.HandleError(reporter.Error(interp.nodeInfo(node), err))
+			return nil, interp.reporter.HandleErrorWithPos(interp.nodeInfo(node), reporter.WithCode(reporter.CodeInvalidOptionValue, err))
 		}
 		msg = dm
 	} else {
@@ -895,10 +906,16 @@ func (interp *interpreter) interpretOptions(
 				continue
 			}
 			// uninterpreted_option might be found reflectively, but is not actually valid for use
-			if err := interp.reporter.HandleErrorf(interp.nodeInfo(node.GetName()), "%vinvalid option 'uninterpreted_option'", mc); err != nil {
+			if err := interp.reporter.HandleErrorWithPos(interp.nodeInfo(node.GetName()), reporter.Codef(reporter.CodeInvalidOption, "%vinvalid option 'uninterpreted_option'", mc)); err != nil {
 				return nil, err
 			}
 		}
+		if interp.unresolved != nil && interp.unresolved.IsUnresolvedOption(uo) {
+			if interp.lenient {
+				remain = append(remain, uo)
+			}
+			continue
+		}
 		mc.Option = uo
 		res, err := interp.interpretField(mc, msg, uo, 0, nil)
 		if err != nil {
@@ -908,6 +925,11 @@ func (interp *interpreter) interpretOptions(
 			}
 			return nil, err
 		}
+		if res == nil {
+			// an error was reported, but the reporter allowed the
+			// interpretation to continue
+			continue
+		}
 		res.unknown = !isKnownField(optsDesc, res)
 		results = append(results, res)
 		if !uo.Name[0].GetIsExtension() && uo.Name[0].GetNamePart() == featuresFieldName {
@@ -951,7 +973,7 @@ func (interp *interpreter) interpretOptions(
 
 	if err := validateRecursive(msg, ""); err != nil {
 		node := interp.file.Node(element)
-		if err := interp.reporter.HandleErrorf(interp.nodeInfo(node), "error in %s options: %v", descriptorType(element), err); err != nil {
+		if err := interp.reporter.HandleErrorWithPos(interp.nodeInfo(node), reporter.Codef(reporter.CodeMissingRequiredField, "error in %s options: %v", descriptorType(element), err)); err != nil {
 			return nil, err
 		}
 	}
@@ -959,7 +981,7 @@ func (interp *interpreter) interpretOptions(
 	// now try to convert into the passed in message and fail if not successful
 	if err := cloneInto(opts, msg.Interface(), interp.resolver); err != nil {
 		node := interp.file.Node(element)
-		return nil, interp.reporter.HandleError(reporter.Error(interp.nodeInfo(node), err))
+		return nil, interp.reporter.HandleErrorWithPos(interp.nodeInfo(node), reporter.WithCode(reporter.CodeInvalidOptionValue, err))
 	}
 
 	if interp.container != nil {
@@ -989,37 +1011,133 @@ func (interp *interpreter) validateFeatures(
 		return nil
 	}
 	features := opts.Get(fld).Message()
+	if err := interp.validateFeatureValues(targetType, features, featuresInfo, fld.Number()); err != nil {
+		return err
+	}
+	return interp.validateUnknownFeatures(targetType, features, featuresInfo, fld.Number())
+}
+
+// validateFeatureValues validates the features set in the given message, which
+// is either a google.protobuf.FeatureSet or the value of a custom feature
+// extension. The given path is the field numbers of the message, relative to
+// the options message, and is used to find the position of each feature.
+func (interp *interpreter) validateFeatureValues(
+	targetType descriptorpb.FieldOptions_OptionTargetType,
+	features protoreflect.Message,
+	featuresInfo []*interpretedOption,
+	path ...protoreflect.FieldNumber,
+) error {
 	var err error
-	features.Range(func(featureField protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
-		opts, ok := featureField.Options().(*descriptorpb.FieldOptions)
-		if !ok {
-			return true
-		}
-		targetTypes := opts.GetTargets()
-		var allowed bool
-		for _, allowedType := range targetTypes {
-			if allowedType == targetType {
-				allowed = true
-				break
-			}
-		}
-		if !allowed {
-			allowedTypes := make([]string, len(targetTypes))
-			for i, t := range opts.Targets {
-				allowedTypes[i] = targetTypeString(t)
-			}
-			pos := interp.positionOfFeature(featuresInfo, fld.Number(), featureField.Number())
-			if len(opts.Targets) == 1 && opts.Targets[0] == descriptorpb.FieldOptions_TARGET_TYPE_UNKNOWN {
-				err = interp.reporter.HandleErrorf(pos, "feature field %q may not be used explicitly", featureField.Name())
-			} else {
-				err = interp.reporter.HandleErrorf(pos, "feature %q is allowed on [%s], not on %s", featureField.Name(), strings.Join(allowedTypes, ","), targetTypeString(targetType))
+	features.Range(func(featureField protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+		featurePath := append(path[:len(path):len(path)], featureField.Number())
+		if featureField.IsExtension() {
+			// Custom features declare their targets on the fields of the
+			// extension's message type, not on the extension itself.
+			if featureField.Message() != nil {
+				err = interp.validateFeatureValues(targetType, val.Message(), featuresInfo, featurePath...)
 			}
+			return err == nil
 		}
+		pos := interp.positionOfFeature(featuresInfo, featurePath...)
+		err = interp.validateFeatureValue(targetType, featureField, val, pos)
 		return err == nil
 	})
 	return err
 }
 
+// validateUnknownFeatures validates the features in the given
+// google.protobuf.FeatureSet message that are newer than the message's
+// descriptor, and so are stored in its unrecognized fields.
+func (interp *interpreter) validateUnknownFeatures(
+	targetType descriptorpb.FieldOptions_OptionTargetType,
+	features protoreflect.Message,
+	featuresInfo []*interpretedOption,
+	path ...protoreflect.FieldNumber,
+) error {
+	featureField := unknownFeatureField(features.Descriptor(), string(internal.DefaultSymbolVisibilityField.Name()))
+	if featureField == nil || len(features.GetUnknown()) == 0 {
+		return nil
+	}
+	featurePath := append(path[:len(path):len(path)], featureField.Number())
+	pos := interp.positionOfFeature(featuresInfo, featurePath...)
+	msg, err := unknownFeatureMessage(features)
+	if err != nil {
+		return interp.reporter.HandleErrorWithPos(pos, reporter.WithCode(reporter.CodeInvalidFeature, err))
+	}
+	if !msg.Has(featureField) {
+		return nil
+	}
+	return interp.validateFeatureValue(targetType, featureField, msg.Get(featureField), pos)
+}
+
+// validateFeatureValue validates the value of a single feature, which must be
+// a field of google.protobuf.FeatureSet or of a custom feature's message type.
+func (interp *interpreter) validateFeatureValue(
+	targetType descriptorpb.FieldOptions_OptionTargetType,
+	featureField protoreflect.FieldDescriptor,
+	val protoreflect.Value,
+	pos ast.SourceSpan,
+) error {
+	if featureField.Enum() != nil && val.Enum() == 0 {
+		// Zero values of feature enums are placeholders for an unknown value.
+		// So they are never valid values.
+		valName := strconv.Itoa(int(val.Enum()))
+		if ev := featureField.Enum().Values().ByNumber(0); ev != nil {
+			valName = string(ev.Name())
+		}
+		return interp.reporter.HandleErrorWithPos(pos, reporter.Codef(reporter.CodeInvalidFeature, "feature %q must be set to a known value, not %s", featureField.Name(), valName))
+	}
+	opts, ok := featureField.Options().(*descriptorpb.FieldOptions)
+	if !ok {
+		return nil
+	}
+	targetTypes := opts.GetTargets()
+	var allowed bool
+	for _, allowedType := range targetTypes {
+		if allowedType == targetType {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		// Like protoc, this is an error, not a warning, for custom
+		// features as well as for those in google.protobuf.FeatureSet.
+		allowedTypes := make([]string, len(targetTypes))
+		for i, t := range opts.Targets {
+			allowedTypes[i] = targetTypeString(t)
+		}
+		if len(opts.Targets) == 1 && opts.Targets[0] == descriptorpb.FieldOptions_TARGET_TYPE_UNKNOWN {
+			return interp.reporter.HandleErrorWithPos(pos, reporter.Codef(reporter.CodeInvalidFeature, "feature field %q may not be used explicitly", featureField.Name()))
+		}
+		return interp.reporter.HandleErrorWithPos(pos, reporter.Codef(reporter.CodeInvalidFeature, "feature %q is allowed on [%s], not on %s", featureField.Name(), strings.Join(allowedTypes, ","), targetTypeString(targetType)))
+	}
+	return interp.validateFeatureSupport(featureField, opts, pos)
+}
+
+// validateFeatureSupport checks that the given feature can be used in the
+// edition of the file being interpreted. Features that are deprecated in that
+// edition result in a warning.
+func (interp *interpreter) validateFeatureSupport(featureField protoreflect.FieldDescriptor, opts *descriptorpb.FieldOptions, pos ast.SourceSpan) error {
+	support := internal.FeatureSupportOf(opts)
+	fd := interp.file.FileDescriptorProto()
+	if support == nil || fd.GetSyntax() != "editions" {
+		return nil
+	}
+	edition := fd.GetEdition()
+	switch {
+	case edition < support.EditionIntroduced:
+		return interp.reporter.HandleErrorWithPos(pos, reporter.Codef(reporter.CodeInvalidFeature, "feature %q wasn't introduced until %s and can't be used in %s",
+			featureField.Name(), internal.EditionName(support.EditionIntroduced), internal.EditionName(edition)))
+	case support.EditionRemoved != 0 && edition >= support.EditionRemoved:
+		return interp.reporter.HandleErrorWithPos(pos, reporter.Codef(reporter.CodeInvalidFeature, "feature %q has been removed in %s and can't be used in %s",
+			featureField.Name(), internal.EditionName(support.EditionRemoved), internal.EditionName(edition)))
+	case support.EditionDeprecated != 0 && edition >= support.EditionDeprecated:
+		interp.reporter.HandleWarningWithPos(pos, reporter.Codef(reporter.CodeDeprecatedFeature, "feature %q has been deprecated in %s: %s",
+			featureField.Name(), internal.EditionName(support.EditionDeprecated), support.DeprecationWarning))
+	}
+	return nil
+}
+
 func (interp *interpreter) positionOfFeature(featuresInfo []*interpretedOption, fieldNumbers ...protoreflect.FieldNumber) ast.SourceSpan {
 	if interp.file.AST() == nil {
 		return ast.UnknownSpan(interp.file.FileDescriptorProto().GetName())
@@ -1178,7 +1296,7 @@ func (interp *interpreter) toOptionBytes(mc *internal.MessageContext, results []
 		if err != nil {
 			if _, ok := err.(reporter.ErrorWithPos); !ok {
 				span := ast.UnknownSpan(interp.file.AST().Name())
-				err = reporter.Errorf(span, "%sfailed to encode options: %w", mc, err)
+				err = reporter.Error(span, reporter.Codef(reporter.CodeInvalidOptionValue, "%sfailed to encode options: %w", mc, err))
 			}
 			if err := interp.reporter.HandleError(err); err != nil {
 				return nil, err
@@ -1259,23 +1377,26 @@ func (interp *interpreter) interpretField(mc *internal.MessageContext, msg proto
 		var err error
 		fld, err = interp.resolveExtensionType(extName)
 		if errors.Is(err, protoregistry.NotFound) {
-			return nil, interp.reporter.HandleErrorf(interp.nodeInfo(node),
+			return nil, interp.reporter.HandleErrorWithPos(interp.nodeInfo(node), reporter.Codef(reporter.CodeUnknownOption,
 				"%vunrecognized extension %s of %s",
-				mc, extName, msg.Descriptor().FullName())
+				mc, extName, msg.Descriptor().FullName()))
 		} else if err != nil {
 			return nil, interp.reporter.HandleErrorWithPos(interp.nodeInfo(node), err)
 		}
 		if fld.ContainingMessage().FullName() != msg.Descriptor().FullName() {
-			return nil, interp.reporter.HandleErrorf(interp.nodeInfo(node),
+			return nil, interp.reporter.HandleErrorWithPos(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOption,
 				"%vextension %s should extend %s but instead extends %s",
-				mc, extName, msg.Descriptor().FullName(), fld.ContainingMessage().FullName())
+				mc, extName, msg.Descriptor().FullName(), fld.ContainingMessage().FullName()))
 		}
 	} else {
 		fld = msg.Descriptor().Fields().ByName(protoreflect.Name(nm.GetNamePart()))
 		if fld == nil {
-			return nil, interp.reporter.HandleErrorf(interp.nodeInfo(node),
+			fld = unknownFeatureField(msg.Descriptor(), nm.GetNamePart())
+		}
+		if fld == nil {
+			return nil, interp.reporter.HandleErrorWithPos(interp.nodeInfo(node), reporter.Codef(reporter.CodeUnknownOption,
 				"%vfield %s of %s does not exist",
-				mc, nm.GetNamePart(), msg.Descriptor().FullName())
+				mc, nm.GetNamePart(), msg.Descriptor().FullName()))
 		}
 	}
 
@@ -1284,14 +1405,14 @@ func (interp *interpreter) interpretField(mc *internal.MessageContext, msg proto
 		nextnode := interp.file.OptionNamePartNode(nextnm)
 		k := fld.Kind()
 		if k != protoreflect.MessageKind && k != protoreflect.GroupKind {
-			return nil, interp.reporter.HandleErrorf(interp.nodeInfo(nextnode),
+			return nil, interp.reporter.HandleErrorWithPos(interp.nodeInfo(nextnode), reporter.Codef(reporter.CodeInvalidOption,
 				"%vcannot set field %s because %s is not a message",
-				mc, nextnm.GetNamePart(), nm.GetNamePart())
+				mc, nextnm.GetNamePart(), nm.GetNamePart()))
 		}
 		if fld.Cardinality() == protoreflect.Repeated {
-			return nil, interp.reporter.HandleErrorf(interp.nodeInfo(nextnode),
+			return nil, interp.reporter.HandleErrorWithPos(interp.nodeInfo(nextnode), reporter.Codef(reporter.CodeInvalidOption,
 				"%vcannot set field %s because %s is repeated (must use an aggregate)",
-				mc, nextnm.GetNamePart(), nm.GetNamePart())
+				mc, nextnm.GetNamePart(), nm.GetNamePart()))
 		}
 		var fdm protoreflect.Message
 		if msg.Has(fld) {
@@ -1301,9 +1422,9 @@ func (interp *interpreter) interpretField(mc *internal.MessageContext, msg proto
 			if ood := fld.ContainingOneof(); ood != nil {
 				existingFld := msg.WhichOneof(ood)
 				if existingFld != nil && existingFld.Number() != fld.Number() {
-					return nil, interp.reporter.HandleErrorf(interp.nodeInfo(node),
+					return nil, interp.reporter.HandleErrorWithPos(interp.nodeInfo(node), reporter.Codef(reporter.CodeDuplicateOption,
 						"%voneof %q already has field %q set",
-						mc, ood.Name(), fieldName(existingFld))
+						mc, ood.Name(), fieldName(existingFld)))
 				}
 			}
 			fldVal := msg.NewField(fld)
@@ -1316,6 +1437,13 @@ func (interp *interpreter) interpretField(mc *internal.MessageContext, msg proto
 
 	optNode := interp.file.OptionNode(opt)
 	optValNode := optNode.GetValue()
+	target := msg
+	if fld == internal.DefaultSymbolVisibilityField {
+		var err error
+		if target, err = unknownFeatureMessage(msg); err != nil {
+			return nil, interp.reporter.HandleErrorWithPos(interp.nodeInfo(node), reporter.WithCode(reporter.CodeInvalidOptionValue, err))
+		}
+	}
 	var val interpretedFieldValue
 	var index int
 	var err error
@@ -1323,9 +1451,12 @@ func (interp *interpreter) interpretField(mc *internal.MessageContext, msg proto
 		// We don't have an AST, so we get the value from the uninterpreted option proto.
 		// It's okay that we don't populate index as it is used to populate source code info,
 		// which can't be done without an AST.
-		val, err = interp.setOptionFieldFromProto(mc, msg, fld, node, opt, optValNode)
+		val, err = interp.setOptionFieldFromProto(mc, target, fld, node, opt, optValNode)
 	} else {
-		val, index, err = interp.setOptionField(mc, msg, fld, node, optValNode, false)
+		val, index, err = interp.setOptionField(mc, target, fld, node, optValNode, false)
+	}
+	if err == nil && target != msg {
+		err = saveUnknownFeatures(msg, target)
 	}
 	if err != nil {
 		return nil, interp.reporter.HandleError(err)
@@ -1346,6 +1477,41 @@ func (interp *interpreter) interpretField(mc *internal.MessageContext, msg proto
 	}, nil
 }
 
+// unknownFeatureField returns the feature field with the given name if the
+// given message is google.protobuf.FeatureSet and the field is newer than the
+// version of descriptor.proto that defines the message. Otherwise, it returns
+// nil. The only such field is internal.DefaultSymbolVisibilityField.
+func unknownFeatureField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fld := internal.DefaultSymbolVisibilityField
+	if md.FullName() != featureSetName || name != string(fld.Name()) || md.Fields().ByNumber(fld.Number()) != nil {
+		return nil
+	}
+	return fld
+}
+
+// unknownFeatureMessage returns a message in which to set the value of a field
+// returned by unknownFeatureField. It is populated from the unrecognized fields
+// of the given google.protobuf.FeatureSet message. Once the value is set, it
+// is stored back into msg with saveUnknownFeatures.
+func unknownFeatureMessage(msg protoreflect.Message) (protoreflect.Message, error) {
+	features := dynamicpb.NewMessage(internal.DefaultSymbolVisibilityField.ContainingMessage())
+	if err := proto.Unmarshal(msg.GetUnknown(), features); err != nil {
+		return nil, err
+	}
+	return features, nil
+}
+
+// saveUnknownFeatures replaces the unrecognized fields of msg with the contents
+// of the given message, which was returned by unknownFeatureMessage.
+func saveUnknownFeatures(msg protoreflect.Message, features protoreflect.Message) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(features.Interface())
+	if err != nil {
+		return err
+	}
+	msg.SetUnknown(data)
+	return nil
+}
+
 // setOptionField sets the value for field fld in the given message msg to the value represented
 // by AST node val. The given name is the AST node that corresponds to the name of fld. On success,
 // it returns additional metadata about the field that was set.
@@ -1354,7 +1520,7 @@ func (interp *interpreter) setOptionField(mc *internal.MessageContext, msg proto
 	if sl, ok := v.([]ast.ValueNode); ok {
 		// handle slices a little differently than the others
 		if fld.Cardinality() != protoreflect.Repeated {
-			return interpretedFieldValue{}, 0, reporter.Errorf(interp.nodeInfo(val), "%vvalue is an array but field is not repeated", mc)
+			return interpretedFieldValue{}, 0, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue is an array but field is not repeated", mc))
 		}
 		origPath := mc.OptAggPath
 		defer func() {
@@ -1402,7 +1568,7 @@ func (interp *interpreter) setOptionField(mc *internal.MessageContext, msg proto
 	if ood := fld.ContainingOneof(); ood != nil {
 		existingFld := msg.WhichOneof(ood)
 		if existingFld != nil && existingFld.Number() != fld.Number() {
-			return interpretedFieldValue{}, 0, reporter.Errorf(interp.nodeInfo(name), "%voneof %q already has field %q set", mc, ood.Name(), fieldName(existingFld))
+			return interpretedFieldValue{}, 0, reporter.Error(interp.nodeInfo(name), reporter.Codef(reporter.CodeDuplicateOption, "%voneof %q already has field %q set", mc, ood.Name(), fieldName(existingFld)))
 		}
 	}
 
@@ -1418,7 +1584,7 @@ func (interp *interpreter) setOptionField(mc *internal.MessageContext, msg proto
 		lv.Append(value.val)
 	default:
 		if msg.Has(fld) {
-			return interpretedFieldValue{}, 0, reporter.Errorf(interp.nodeInfo(name), "%vnon-repeated option field %s already set", mc, fieldName(fld))
+			return interpretedFieldValue{}, 0, reporter.Error(interp.nodeInfo(name), reporter.Codef(reporter.CodeDuplicateOption, "%vnon-repeated option field %s already set", mc, fieldName(fld)))
 		}
 		msg.Set(fld, value.val)
 	}
@@ -1442,7 +1608,7 @@ func (interp *interpreter) setOptionFieldFromProto(mc *internal.MessageContext,
 
 	case protoreflect.MessageKind, protoreflect.GroupKind:
 		if opt.AggregateValue == nil {
-			return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(node), "%vexpecting message, got %s", mc, optionValueKind(opt))
+			return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting message, got %s", mc, optionValueKind(opt)))
 		}
 		// We must parse the text format from the aggregate value string
 		fmd := fld.Message()
@@ -1452,13 +1618,13 @@ func (interp *interpreter) setOptionFieldFromProto(mc *internal.MessageContext,
 			AllowPartial: true,
 		}.Unmarshal([]byte(opt.GetAggregateValue()), tmpMsg)
 		if err != nil {
-			return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(node), "%vfailed to parse message literal %w", mc, err)
+			return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vfailed to parse message literal %w", mc, err))
 		}
 		msgData, err := proto.MarshalOptions{
 			AllowPartial: true,
 		}.Marshal(tmpMsg)
 		if err != nil {
-			return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(node), "%vfailed to serialize data from message literal %w", mc, err)
+			return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vfailed to serialize data from message literal %w", mc, err))
 		}
 		var data []byte
 		if k == protoreflect.GroupKind {
@@ -1485,14 +1651,14 @@ func (interp *interpreter) setOptionFieldFromProto(mc *internal.MessageContext,
 	if ood := fld.ContainingOneof(); ood != nil {
 		existingFld := msg.WhichOneof(ood)
 		if existingFld != nil && existingFld.Number() != fld.Number() {
-			return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(name), "%voneof %q already has field %q set", mc, ood.Name(), fieldName(existingFld))
+			return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(name), reporter.Codef(reporter.CodeDuplicateOption, "%voneof %q already has field %q set", mc, ood.Name(), fieldName(existingFld)))
 		}
 	}
 
 	switch {
 	case value.preserialized != nil:
 		if !fld.IsList() && !fld.IsMap() && msg.Has(fld) {
-			return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(name), "%vnon-repeated option field %s already set", mc, fieldName(fld))
+			return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(name), reporter.Codef(reporter.CodeDuplicateOption, "%vnon-repeated option field %s already set", mc, fieldName(fld)))
 		}
 		// We have to merge the bytes for this field into the message.
 		// TODO: if a map field, error if key for this entry already set?
@@ -1502,13 +1668,13 @@ func (interp *interpreter) setOptionFieldFromProto(mc *internal.MessageContext,
 			Merge:        true,
 		}.Unmarshal(value.preserialized, msg.Interface())
 		if err != nil {
-			return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(name), "%v failed to set value for field %v: %w", mc, fieldName(fld), err)
+			return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(name), reporter.Codef(reporter.CodeInvalidOptionValue, "%v failed to set value for field %v: %w", mc, fieldName(fld), err))
 		}
 	case fld.IsList():
 		msg.Mutable(fld).List().Append(value.val)
 	default:
 		if msg.Has(fld) {
-			return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(name), "%vnon-repeated option field %s already set", mc, fieldName(fld))
+			return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(name), reporter.Codef(reporter.CodeDuplicateOption, "%vnon-repeated option field %s already set", mc, fieldName(fld)))
 		}
 		msg.Set(fld, value.val)
 	}
@@ -1764,7 +1930,7 @@ func (interp *interpreter) fieldValue(mc *internal.MessageContext, msg protorefl
 			}
 			return interp.messageLiteralValue(mc, aggs, childMsg)
 		}
-		return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(val), "%vexpecting message, got %s", mc, valueKind(v))
+		return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting message, got %s", mc, valueKind(v)))
 
 	default:
 		v, err := interp.scalarFieldValue(mc, descriptorpb.FieldDescriptorProto_Type(k), val, insideMsgLiteral)
@@ -1785,34 +1951,34 @@ func (interp *interpreter) enumFieldValue(mc *internal.MessageContext, ed protor
 		name := protoreflect.Name(v)
 		ev := ed.Values().ByName(name)
 		if ev == nil {
-			return 0, "", reporter.Errorf(interp.nodeInfo(val), "%venum %s has no value named %s", mc, ed.FullName(), v)
+			return 0, "", reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%venum %s has no value named %s", mc, ed.FullName(), v))
 		}
 		return ev.Number(), name, nil
 	case int64:
 		if !allowNumber {
-			return 0, "", reporter.Errorf(interp.nodeInfo(val), "%vexpecting enum name, got %s", mc, valueKind(v))
+			return 0, "", reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting enum name, got %s", mc, valueKind(v)))
 		}
 		if v > math.MaxInt32 || v < math.MinInt32 {
-			return 0, "", reporter.Errorf(interp.nodeInfo(val), "%vvalue %d is out of range for an enum", mc, v)
+			return 0, "", reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for an enum", mc, v))
 		}
 		num = protoreflect.EnumNumber(v)
 	case uint64:
 		if !allowNumber {
-			return 0, "", reporter.Errorf(interp.nodeInfo(val), "%vexpecting enum name, got %s", mc, valueKind(v))
+			return 0, "", reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting enum name, got %s", mc, valueKind(v)))
 		}
 		if v > math.MaxInt32 {
-			return 0, "", reporter.Errorf(interp.nodeInfo(val), "%vvalue %d is out of range for an enum", mc, v)
+			return 0, "", reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for an enum", mc, v))
 		}
 		num = protoreflect.EnumNumber(v)
 	default:
-		return 0, "", reporter.Errorf(interp.nodeInfo(val), "%vexpecting enum, got %s", mc, valueKind(v))
+		return 0, "", reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting enum, got %s", mc, valueKind(v)))
 	}
 	ev := ed.Values().ByNumber(num)
 	if ev != nil {
 		return num, ev.Name(), nil
 	}
 	if ed.Syntax() != protoreflect.Proto3 {
-		return 0, "", reporter.Errorf(interp.nodeInfo(val), "%vclosed enum %s has no value with number %d", mc, ed.FullName(), num)
+		return 0, "", reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vclosed enum %s has no value with number %d", mc, ed.FullName(), num))
 	}
 	// unknown value, but enum is open, so we allow it and return blank name
 	return num, "", nil
@@ -1829,11 +1995,11 @@ func (interp *interpreter) enumFieldValueFromProto(mc *internal.MessageContext,
 		name := protoreflect.Name(opt.GetIdentifierValue())
 		ev := ed.Values().ByName(name)
 		if ev == nil {
-			return 0, "", reporter.Errorf(interp.nodeInfo(node), "%venum %s has no value named %s", mc, ed.FullName(), name)
+			return 0, "", reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%venum %s has no value named %s", mc, ed.FullName(), name))
 		}
 		return ev.Number(), name, nil
 	default:
-		return 0, "", reporter.Errorf(interp.nodeInfo(node), "%vexpecting enum, got %s", mc, optionValueKind(opt))
+		return 0, "", reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting enum, got %s", mc, optionValueKind(opt)))
 	}
 }
 
@@ -1866,67 +2032,67 @@ func (interp *interpreter) scalarFieldValue(mc *internal.MessageContext, fldType
 				}
 			}
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(val), "%vexpecting bool, got %s", mc, valueKind(v))
+		return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting bool, got %s", mc, valueKind(v)))
 	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
 		if str, ok := v.(string); ok {
 			return []byte(str), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(val), "%vexpecting bytes, got %s", mc, valueKind(v))
+		return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting bytes, got %s", mc, valueKind(v)))
 	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
 		if str, ok := v.(string); ok {
 			return str, nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(val), "%vexpecting string, got %s", mc, valueKind(v))
+		return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting string, got %s", mc, valueKind(v)))
 	case descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_SINT32, descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
 		if i, ok := v.(int64); ok {
 			if i > math.MaxInt32 || i < math.MinInt32 {
-				return nil, reporter.Errorf(interp.nodeInfo(val), "%vvalue %d is out of range for int32", mc, i)
+				return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for int32", mc, i))
 			}
 			return int32(i), nil
 		}
 		if ui, ok := v.(uint64); ok {
 			if ui > math.MaxInt32 {
-				return nil, reporter.Errorf(interp.nodeInfo(val), "%vvalue %d is out of range for int32", mc, ui)
+				return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for int32", mc, ui))
 			}
 			return int32(ui), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(val), "%vexpecting int32, got %s", mc, valueKind(v))
+		return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting int32, got %s", mc, valueKind(v)))
 	case descriptorpb.FieldDescriptorProto_TYPE_UINT32, descriptorpb.FieldDescriptorProto_TYPE_FIXED32:
 		if i, ok := v.(int64); ok {
 			if i > math.MaxUint32 || i < 0 {
-				return nil, reporter.Errorf(interp.nodeInfo(val), "%vvalue %d is out of range for uint32", mc, i)
+				return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for uint32", mc, i))
 			}
 			return uint32(i), nil
 		}
 		if ui, ok := v.(uint64); ok {
 			if ui > math.MaxUint32 {
-				return nil, reporter.Errorf(interp.nodeInfo(val), "%vvalue %d is out of range for uint32", mc, ui)
+				return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for uint32", mc, ui))
 			}
 			return uint32(ui), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(val), "%vexpecting uint32, got %s", mc, valueKind(v))
+		return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting uint32, got %s", mc, valueKind(v)))
 	case descriptorpb.FieldDescriptorProto_TYPE_INT64, descriptorpb.FieldDescriptorProto_TYPE_SINT64, descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
 		if i, ok := v.(int64); ok {
 			return i, nil
 		}
 		if ui, ok := v.(uint64); ok {
 			if ui > math.MaxInt64 {
-				return nil, reporter.Errorf(interp.nodeInfo(val), "%vvalue %d is out of range for int64", mc, ui)
+				return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for int64", mc, ui))
 			}
 			return int64(ui), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(val), "%vexpecting int64, got %s", mc, valueKind(v))
+		return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting int64, got %s", mc, valueKind(v)))
 	case descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_FIXED64:
 		if i, ok := v.(int64); ok {
 			if i < 0 {
-				return nil, reporter.Errorf(interp.nodeInfo(val), "%vvalue %d is out of range for uint64", mc, i)
+				return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for uint64", mc, i))
 			}
 			return uint64(i), nil
 		}
 		if ui, ok := v.(uint64); ok {
 			return ui, nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(val), "%vexpecting uint64, got %s", mc, valueKind(v))
+		return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting uint64, got %s", mc, valueKind(v)))
 	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
 		if id, ok := v.(ast.Identifier); ok {
 			switch id {
@@ -1945,7 +2111,7 @@ func (interp *interpreter) scalarFieldValue(mc *internal.MessageContext, fldType
 		if u, ok := v.(uint64); ok {
 			return float64(u), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(val), "%vexpecting double, got %s", mc, valueKind(v))
+		return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting double, got %s", mc, valueKind(v)))
 	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
 		if id, ok := v.(ast.Identifier); ok {
 			switch id {
@@ -1964,9 +2130,9 @@ func (interp *interpreter) scalarFieldValue(mc *internal.MessageContext, fldType
 		if u, ok := v.(uint64); ok {
 			return float32(u), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(val), "%vexpecting float, got %s", mc, valueKind(v))
+		return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting float, got %s", mc, valueKind(v)))
 	default:
-		return nil, reporter.Errorf(interp.nodeInfo(val), "%vunrecognized field type: %s", mc, fldType)
+		return nil, reporter.Error(interp.nodeInfo(val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vunrecognized field type: %s", mc, fldType))
 	}
 }
 
@@ -1983,49 +2149,49 @@ func (interp *interpreter) scalarFieldValueFromProto(mc *internal.MessageContext
 				return false, nil
 			}
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(node), "%vexpecting bool, got %s", mc, optionValueKind(opt))
+		return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting bool, got %s", mc, optionValueKind(opt)))
 	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
 		if opt.StringValue != nil {
 			return opt.GetStringValue(), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(node), "%vexpecting bytes, got %s", mc, optionValueKind(opt))
+		return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting bytes, got %s", mc, optionValueKind(opt)))
 	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
 		if opt.StringValue != nil {
 			return string(opt.GetStringValue()), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(node), "%vexpecting string, got %s", mc, optionValueKind(opt))
+		return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting string, got %s", mc, optionValueKind(opt)))
 	case descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_SINT32, descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
 		if opt.NegativeIntValue != nil {
 			i := opt.GetNegativeIntValue()
 			if i > math.MaxInt32 || i < math.MinInt32 {
-				return nil, reporter.Errorf(interp.nodeInfo(node), "%vvalue %d is out of range for int32", mc, i)
+				return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for int32", mc, i))
 			}
 			return int32(i), nil
 		}
 		if opt.PositiveIntValue != nil {
 			ui := opt.GetPositiveIntValue()
 			if ui > math.MaxInt32 {
-				return nil, reporter.Errorf(interp.nodeInfo(node), "%vvalue %d is out of range for int32", mc, ui)
+				return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for int32", mc, ui))
 			}
 			return int32(ui), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(node), "%vexpecting int32, got %s", mc, optionValueKind(opt))
+		return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting int32, got %s", mc, optionValueKind(opt)))
 	case descriptorpb.FieldDescriptorProto_TYPE_UINT32, descriptorpb.FieldDescriptorProto_TYPE_FIXED32:
 		if opt.NegativeIntValue != nil {
 			i := opt.GetNegativeIntValue()
 			if i > math.MaxUint32 || i < 0 {
-				return nil, reporter.Errorf(interp.nodeInfo(node), "%vvalue %d is out of range for uint32", mc, i)
+				return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for uint32", mc, i))
 			}
 			return uint32(i), nil
 		}
 		if opt.PositiveIntValue != nil {
 			ui := opt.GetPositiveIntValue()
 			if ui > math.MaxUint32 {
-				return nil, reporter.Errorf(interp.nodeInfo(node), "%vvalue %d is out of range for uint32", mc, ui)
+				return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for uint32", mc, ui))
 			}
 			return uint32(ui), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(node), "%vexpecting uint32, got %s", mc, optionValueKind(opt))
+		return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting uint32, got %s", mc, optionValueKind(opt)))
 	case descriptorpb.FieldDescriptorProto_TYPE_INT64, descriptorpb.FieldDescriptorProto_TYPE_SINT64, descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
 		if opt.NegativeIntValue != nil {
 			return opt.GetNegativeIntValue(), nil
@@ -2033,16 +2199,16 @@ func (interp *interpreter) scalarFieldValueFromProto(mc *internal.MessageContext
 		if opt.PositiveIntValue != nil {
 			ui := opt.GetPositiveIntValue()
 			if ui > math.MaxInt64 {
-				return nil, reporter.Errorf(interp.nodeInfo(node), "%vvalue %d is out of range for int64", mc, ui)
+				return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for int64", mc, ui))
 			}
 			return int64(ui), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(node), "%vexpecting int64, got %s", mc, optionValueKind(opt))
+		return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting int64, got %s", mc, optionValueKind(opt)))
 	case descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_FIXED64:
 		if opt.NegativeIntValue != nil {
 			i := opt.GetNegativeIntValue()
 			if i < 0 {
-				return nil, reporter.Errorf(interp.nodeInfo(node), "%vvalue %d is out of range for uint64", mc, i)
+				return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vvalue %d is out of range for uint64", mc, i))
 			}
 			// should not be possible since i should always be negative...
 			return uint64(i), nil
@@ -2050,7 +2216,7 @@ func (interp *interpreter) scalarFieldValueFromProto(mc *internal.MessageContext
 		if opt.PositiveIntValue != nil {
 			return opt.GetPositiveIntValue(), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(node), "%vexpecting uint64, got %s", mc, optionValueKind(opt))
+		return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting uint64, got %s", mc, optionValueKind(opt)))
 	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
 		if opt.IdentifierValue != nil {
 			switch opt.GetIdentifierValue() {
@@ -2069,7 +2235,7 @@ func (interp *interpreter) scalarFieldValueFromProto(mc *internal.MessageContext
 		if opt.PositiveIntValue != nil {
 			return float64(opt.GetPositiveIntValue()), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(node), "%vexpecting double, got %s", mc, optionValueKind(opt))
+		return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting double, got %s", mc, optionValueKind(opt)))
 	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
 		if opt.IdentifierValue != nil {
 			switch opt.GetIdentifierValue() {
@@ -2088,9 +2254,9 @@ func (interp *interpreter) scalarFieldValueFromProto(mc *internal.MessageContext
 		if opt.PositiveIntValue != nil {
 			return float32(opt.GetPositiveIntValue()), nil
 		}
-		return nil, reporter.Errorf(interp.nodeInfo(node), "%vexpecting float, got %s", mc, optionValueKind(opt))
+		return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vexpecting float, got %s", mc, optionValueKind(opt)))
 	default:
-		return nil, reporter.Errorf(interp.nodeInfo(node), "%vunrecognized field type: %s", mc, fldType)
+		return nil, reporter.Error(interp.nodeInfo(node), reporter.Codef(reporter.CodeInvalidOptionValue, "%vunrecognized field type: %s", mc, fldType))
 	}
 }
 
@@ -2140,10 +2306,10 @@ func (interp *interpreter) messageLiteralValue(mc *internal.MessageContext, fiel
 		}
 		if fieldNode.Name.IsAnyTypeReference() {
 			if fmd.FullName() != "google.protobuf.Any" {
-				return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Name.URLPrefix), "%vtype references are only allowed for google.protobuf.Any, but this type is %s", mc, fmd.FullName())
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Name.URLPrefix), reporter.Codef(reporter.CodeInvalidOption, "%vtype references are only allowed for google.protobuf.Any, but this type is %s", mc, fmd.FullName()))
 			}
 			if foundAnyNode {
-				return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Name.URLPrefix), "%vmultiple any type references are not allowed", mc)
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Name.URLPrefix), reporter.Codef(reporter.CodeDuplicateOption, "%vmultiple any type references are not allowed", mc))
 			}
 			foundAnyNode = true
 			urlPrefix := fieldNode.Name.URLPrefix.AsIdentifier()
@@ -2156,15 +2322,15 @@ func (interp *interpreter) messageLiteralValue(mc *internal.MessageContext, fiel
 			// "type.googleprod.com" as hosts/prefixes and using the compiled
 			// file's transitive closure to find the named message.
 			if urlPrefix != "type.googleapis.com" && urlPrefix != "type.googleprod.com" {
-				return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Name.URLPrefix), "%vcould not resolve type reference %s", mc, fullURL)
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Name.URLPrefix), reporter.Codef(reporter.CodeUnresolvedReference, "%vcould not resolve type reference %s", mc, fullURL))
 			}
 			anyFields, ok := fieldNode.Val.Value().([]*ast.MessageFieldNode)
 			if !ok {
-				return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Val), "%vtype references for google.protobuf.Any must have message literal value", mc)
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vtype references for google.protobuf.Any must have message literal value", mc))
 			}
 			anyMd := resolveDescriptor[protoreflect.MessageDescriptor](interp.resolver, string(msgName))
 			if anyMd == nil {
-				return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Name.URLPrefix), "%vcould not resolve type reference %s", mc, fullURL)
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Name.URLPrefix), reporter.Codef(reporter.CodeUnresolvedReference, "%vcould not resolve type reference %s", mc, fullURL))
 			}
 			// parse the message value
 			msgVal, err := interp.messageLiteralValue(mc, anyFields, dynamicpb.NewMessage(anyMd))
@@ -2177,16 +2343,16 @@ func (interp *interpreter) messageLiteralValue(mc *internal.MessageContext, fiel
 			//   bytes value = 2
 			typeURLDescriptor := fmd.Fields().ByNumber(1)
 			if typeURLDescriptor == nil || typeURLDescriptor.Kind() != protoreflect.StringKind {
-				return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Name), "%vfailed to set type_url string field on Any: %w", mc, err)
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Name), reporter.Codef(reporter.CodeInvalidOptionValue, "%vfailed to set type_url string field on Any: %w", mc, err))
 			}
 			msg.Set(typeURLDescriptor, protoreflect.ValueOfString(fullURL))
 			valueDescriptor := fmd.Fields().ByNumber(2)
 			if valueDescriptor == nil || valueDescriptor.Kind() != protoreflect.BytesKind {
-				return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Name), "%vfailed to set value bytes field on Any: %w", mc, err)
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Name), reporter.Codef(reporter.CodeInvalidOptionValue, "%vfailed to set value bytes field on Any: %w", mc, err))
 			}
 			b, err := proto.MarshalOptions{Deterministic: true}.Marshal(msgVal.val.Message().Interface())
 			if err != nil {
-				return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Val), "%vfailed to serialize message value: %w", mc, err)
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Val), reporter.Codef(reporter.CodeInvalidOptionValue, "%vfailed to serialize message value: %w", mc, err))
 			}
 			msg.Set(valueDescriptor, protoreflect.ValueOfBytes(b))
 		} else {
@@ -2214,7 +2380,7 @@ func (interp *interpreter) messageLiteralValue(mc *internal.MessageContext, fiel
 				// ...but only regular fields, not extensions that are groups...
 				if ffld != nil && ffld.Kind() == protoreflect.GroupKind && ffld.Message().Name() != protoreflect.Name(fieldNode.Name.Value()) {
 					// this is kind of silly to fail here, but this mimics protoc behavior
-					return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Name), "%vfield %s not found (did you mean the group named %s?)", mc, fieldNode.Name.Value(), ffld.Message().Name())
+					return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Name), reporter.Codef(reporter.CodeUnknownOption, "%vfield %s not found (did you mean the group named %s?)", mc, fieldNode.Name.Value(), ffld.Message().Name()))
 				}
 				if ffld == nil {
 					err = protoregistry.NotFound
@@ -2231,15 +2397,21 @@ func (interp *interpreter) messageLiteralValue(mc *internal.MessageContext, fiel
 				}
 			}
 			if errors.Is(err, protoregistry.NotFound) {
-				return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Name),
-					"%vfield %s not found", mc, string(fieldNode.Name.Name.AsIdentifier()))
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Name), reporter.Codef(reporter.CodeUnknownOption,
+					"%vfield %s not found", mc, string(fieldNode.Name.Name.AsIdentifier())))
 			} else if err != nil {
 				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Name), err)
 			}
+			if ffld.IsExtension() && ffld.ContainingMessage().FullName() != fmd.FullName() {
+				// possible if the extendee couldn't be resolved during linking
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Name), reporter.Codef(reporter.CodeInvalidOption,
+					"%vextension %s should extend %s but instead extends %s",
+					mc, ffld.FullName(), fmd.FullName(), ffld.ContainingMessage().FullName()))
+			}
 			if fieldNode.Sep == nil && ffld.Message() == nil {
 				// If there is no separator, the field type should be a message.
 				// Otherwise it is an error in the text format.
-				return interpretedFieldValue{}, reporter.Errorf(interp.nodeInfo(fieldNode.Val), "syntax error: unexpected value, expecting ':'")
+				return interpretedFieldValue{}, reporter.Error(interp.nodeInfo(fieldNode.Val), reporter.Codef(reporter.CodeInvalidOptionValue, "syntax error: unexpected value, expecting ':'"))
 			}
 			res, index, err := interp.setOptionField(mc, msg, ffld, fieldNode.Name, fieldNode.Val, true)
 			if err != nil {
@@ -392,7 +392,7 @@ func TestOptionsEncoding(t *testing.T) {
 			err = uOpts.Unmarshal(protoData, canonicalProto)
 			require.NoError(t, err)
 			if diff := cmp.Diff(res.FileDescriptorProto(), canonicalProto, protocmp.Transform()); diff != "" {
-				t.Fatal("canonical proto != proto:\n%v", diff)
+				t.Fatalf("canonical proto != proto:\n%v", diff)
 			}
 
 			// drum roll... make sure the bytes match the protoc output
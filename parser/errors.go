@@ -14,9 +14,77 @@
 
 package parser
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/reporter"
+)
 
 // ErrNoSyntax is a sentinel error that may be passed to a warning reporter.
 // The error the reporter receives will be wrapped with source position that
-// indicates the file that had no syntax statement.
-var ErrNoSyntax = errors.New("no syntax specified; defaulting to proto2 syntax")
+// indicates the file that had no syntax statement. Its code is
+// reporter.CodeNoSyntax.
+var ErrNoSyntax error = reporter.WithCode(reporter.CodeNoSyntax, errors.New("no syntax specified; defaulting to proto2 syntax"))
+
+// ErrorTagCollision may be passed to an error reporter when two fields in
+// the same message use the same tag number. The error the reporter receives
+// will be wrapped with source position that indicates the tag of the later
+// field. Its code is reporter.CodeTagCollision.
+type ErrorTagCollision interface {
+	reporter.ErrorWithCode
+	// Message returns the fully-qualified name of the message.
+	Message() protoreflect.FullName
+	// Tag returns the tag number that both fields use.
+	Tag() int32
+	// Field returns the name of the later field.
+	Field() string
+	// PreviousField returns the name of the earlier field.
+	PreviousField() string
+	// Span returns the location of the later field's tag.
+	Span() ast.SourceSpan
+	// PreviousSpan returns the location of the earlier field's tag.
+	PreviousSpan() ast.SourceSpan
+}
+
+type errTagCollision struct {
+	message              protoreflect.FullName
+	tag                  int32
+	field, previousField string
+	span, previousSpan   ast.SourceSpan
+}
+
+func (e *errTagCollision) Error() string {
+	return fmt.Sprintf("message %s: fields %s and %s both have the same tag %d", e.message, e.previousField, e.field, e.tag)
+}
+
+func (e *errTagCollision) Code() reporter.Code {
+	return reporter.CodeTagCollision
+}
+
+func (e *errTagCollision) Message() protoreflect.FullName {
+	return e.message
+}
+
+func (e *errTagCollision) Tag() int32 {
+	return e.tag
+}
+
+func (e *errTagCollision) Field() string {
+	return e.field
+}
+
+func (e *errTagCollision) PreviousField() string {
+	return e.previousField
+}
+
+func (e *errTagCollision) Span() ast.SourceSpan {
+	return e.span
+}
+
+func (e *errTagCollision) PreviousSpan() ast.SourceSpan {
+	return e.previousSpan
+}
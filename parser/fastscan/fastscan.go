@@ -43,6 +43,9 @@ type Import struct {
 	Path string
 	// Indicate if public or weak keyword was used in import statement.
 	IsPublic, IsWeak bool
+	// Indicate if option keyword was used in import statement. Such option
+	// imports are only allowed in edition 2024 and later.
+	IsOption bool
 }
 
 // SyntaxError is returned from Scan when one or more syntax errors are observed.
@@ -96,9 +99,9 @@ func newSyntaxError(errs []reporter.ErrorWithPos) error {
 func Scan(filename string, r io.Reader) (Result, error) {
 	var res Result
 
-	var currentImport []string     // if non-nil, parsing an import statement
-	var isPublic, isWeak bool      // if public or weak keyword observed in current import statement
-	var packageComponents []string // if non-nil, parsing a package statement
+	var currentImport []string          // if non-nil, parsing an import statement
+	var isPublic, isWeak, isOption bool // if public, weak, or option keyword observed in current import statement
+	var packageComponents []string      // if non-nil, parsing a package statement
 	var syntaxErrs []reporter.ErrorWithPos
 
 	// current stack of open blocks -- those starting with {, [, (, or < for
@@ -139,9 +142,10 @@ func Scan(filename string, r io.Reader) (Result, error) {
 				currentImport = append(currentImport, text.(string))
 			case identifierToken:
 				ident := text.(string) //nolint:errcheck
-				if len(currentImport) == 0 && (ident == "public" || ident == "weak") {
+				if len(currentImport) == 0 && (ident == "public" || ident == "weak" || ident == "option") {
 					isPublic = ident == "public"
 					isWeak = ident == "weak"
+					isOption = ident == "option"
 					break
 				}
 				fallthrough
@@ -149,19 +153,20 @@ func Scan(filename string, r io.Reader) (Result, error) {
 				if len(currentImport) > 0 {
 					if token != semicolonToken {
 						syntaxErrs = append(syntaxErrs,
-							reporter.Errorf(getLatestSpan(),
-								"unexpected %s; expecting semicolon", token.describe()),
+							reporter.Error(getLatestSpan(), reporter.Codef(reporter.CodeSyntax,
+								"unexpected %s; expecting semicolon", token.describe())),
 						)
 					}
 					res.Imports = append(res.Imports, Import{
 						Path:     strings.Join(currentImport, ""),
 						IsPublic: isPublic,
 						IsWeak:   isWeak,
+						IsOption: isOption,
 					})
 				} else {
 					syntaxErrs = append(syntaxErrs,
-						reporter.Errorf(getLatestSpan(),
-							"unexpected %s; expecting import path string", token.describe()),
+						reporter.Error(getLatestSpan(), reporter.Codef(reporter.CodeSyntax,
+							"unexpected %s; expecting import path string", token.describe())),
 					)
 				}
 				currentImport = nil
@@ -173,21 +178,21 @@ func Scan(filename string, r io.Reader) (Result, error) {
 			case identifierToken:
 				if len(packageComponents) > 0 && packageComponents[len(packageComponents)-1] != "." {
 					syntaxErrs = append(syntaxErrs,
-						reporter.Errorf(getLatestSpan(),
-							"package name should have a period between name components"),
+						reporter.Error(getLatestSpan(), reporter.Codef(reporter.CodeSyntax,
+							"package name should have a period between name components")),
 					)
 				}
 				packageComponents = append(packageComponents, text.(string))
 			case periodToken:
 				if len(packageComponents) == 0 {
 					syntaxErrs = append(syntaxErrs,
-						reporter.Errorf(getLatestSpan(),
-							"package name should not begin with a period"),
+						reporter.Error(getLatestSpan(), reporter.Codef(reporter.CodeSyntax,
+							"package name should not begin with a period")),
 					)
 				} else if packageComponents[len(packageComponents)-1] == "." {
 					syntaxErrs = append(syntaxErrs,
-						reporter.Errorf(getLatestSpan(),
-							"package name should not have two periods in a row"),
+						reporter.Error(getLatestSpan(), reporter.Codef(reporter.CodeSyntax,
+							"package name should not have two periods in a row")),
 					)
 				}
 				packageComponents = append(packageComponents, ".")
@@ -195,21 +200,21 @@ func Scan(filename string, r io.Reader) (Result, error) {
 				if len(packageComponents) > 0 {
 					if token != semicolonToken {
 						syntaxErrs = append(syntaxErrs,
-							reporter.Errorf(getLatestSpan(),
-								"unexpected %s; expecting semicolon", token.describe()),
+							reporter.Error(getLatestSpan(), reporter.Codef(reporter.CodeSyntax,
+								"unexpected %s; expecting semicolon", token.describe())),
 						)
 					}
 					if packageComponents[len(packageComponents)-1] == "." {
 						syntaxErrs = append(syntaxErrs,
-							reporter.Errorf(getSpan(prevLine+1, prevCol+1),
-								"package name should not end with a period"),
+							reporter.Error(getSpan(prevLine+1, prevCol+1), reporter.Codef(reporter.CodeSyntax,
+								"package name should not end with a period")),
 						)
 					}
 					res.PackageName = strings.Join(packageComponents, "")
 				} else {
 					syntaxErrs = append(syntaxErrs,
-						reporter.Errorf(getLatestSpan(),
-							"unexpected %s; expecting package name", token.describe()),
+						reporter.Error(getLatestSpan(), reporter.Codef(reporter.CodeSyntax,
+							"unexpected %s; expecting package name", token.describe())),
 					)
 				}
 				packageComponents = nil
@@ -227,7 +232,7 @@ func Scan(filename string, r io.Reader) (Result, error) {
 			if declarationStart && len(contextStack) == 0 {
 				if text == "import" {
 					currentImport = []string{}
-					isPublic, isWeak = false, false
+					isPublic, isWeak, isOption = false, false, false
 				} else if text == "package" {
 					packageComponents = []string{}
 				}
@@ -159,6 +159,19 @@ func TestScan(t *testing.T) {
 			},
 			expectedPackage: "abc.xyz",
 		},
+		{
+			name: "option imports",
+			input: `edition = "2024";
+				package abc.xyz;
+				import option "foo/options.proto";
+				import "google/protobuf/descriptor.proto";
+			`,
+			expectedImports: []Import{
+				{Path: "foo/options.proto", IsOption: true},
+				{Path: "google/protobuf/descriptor.proto"},
+			},
+			expectedPackage: "abc.xyz",
+		},
 	}
 	for _, testCase := range testCases {
 		testCase := testCase
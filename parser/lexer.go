@@ -85,6 +85,9 @@ type protoLex struct {
 	prevSym    ast.TerminalNode
 	prevOffset int
 	eof        ast.Token
+	// set once the lexer reaches the end of the input, after which info
+	// contains all tokens and comments in the file
+	atEOF bool
 
 	comments []ast.Token
 }
@@ -157,6 +160,13 @@ var keywords = map[string]int{
 	"returns":    _RETURNS,
 }
 
+// visibilityKeywords are only keywords when they precede a message or enum
+// declaration. Everywhere else, they are just identifiers.
+var visibilityKeywords = map[string]int{
+	"export": _EXPORT,
+	"local":  _LOCAL,
+}
+
 func (l *protoLex) maybeNewLine(r rune) {
 	if r == '\n' {
 		l.info.AddLine(l.input.offset())
@@ -187,6 +197,7 @@ func (l *protoLex) Lex(lval *protoSymType) int {
 			// (if appropriate)
 			l.setRune(lval, 0)
 			l.eof = lval.b.Token()
+			l.atEOF = true
 			return 0
 		}
 		if err != nil {
@@ -231,6 +242,10 @@ func (l *protoLex) Lex(lval *protoSymType) int {
 				l.setIdent(lval, str)
 				return t
 			}
+			if t, ok := visibilityKeywords[str]; ok && l.atTypeDecl() {
+				l.setIdent(lval, str)
+				return t
+			}
 			l.setIdent(lval, str)
 			return _NAME
 		}
@@ -442,6 +457,51 @@ func (l *protoLex) setError(lval *protoSymType, err error) {
 	lval.err, _ = l.addSourceError(err)
 }
 
+// atTypeDecl returns true if the remaining input starts with the keyword
+// "message" or "enum", followed by a name. Whitespace and comments before
+// either word are skipped. The input is not consumed.
+func (l *protoLex) atTypeDecl() bool {
+	data := l.input.data[l.input.pos:]
+	data, word := nextWord(data)
+	if word != "message" && word != "enum" {
+		return false
+	}
+	_, word = nextWord(data)
+	return word != ""
+}
+
+// nextWord skips whitespace and comments at the start of data and then
+// returns the identifier that follows, along with the remaining data. If
+// there is no identifier, the returned word is empty.
+func nextWord(data []byte) ([]byte, string) {
+	for len(data) > 0 {
+		switch {
+		case strings.IndexByte("\n\r\t\f\v ", data[0]) >= 0:
+			data = data[1:]
+		case bytes.HasPrefix(data, []byte("//")):
+			end := bytes.IndexByte(data, '\n')
+			if end < 0 {
+				return nil, ""
+			}
+			data = data[end+1:]
+		case bytes.HasPrefix(data, []byte("/*")):
+			end := bytes.Index(data[2:], []byte("*/"))
+			if end < 0 {
+				return nil, ""
+			}
+			data = data[end+4:]
+		default:
+			i := 0
+			for i < len(data) && (data[i] == '_' || (data[i] >= 'a' && data[i] <= 'z') ||
+				(data[i] >= 'A' && data[i] <= 'Z') || (i > 0 && data[i] >= '0' && data[i] <= '9')) {
+				i++
+			}
+			return data[i:], string(data[:i])
+		}
+	}
+	return nil, ""
+}
+
 func (l *protoLex) readNumber() {
 	allowExpSign := false
 	for {
@@ -743,7 +803,7 @@ func (l *protoLex) addSourceError(err error) (reporter.ErrorWithPos, bool) {
 	ewp, ok := err.(reporter.ErrorWithPos)
 	if !ok {
 		// TODO: Store the previous span instead of just the position.
-		ewp = reporter.Error(ast.NewSourceSpan(l.prev(), l.prev()), err)
+		ewp = reporter.Error(ast.NewSourceSpan(l.prev(), l.prev()), syntaxError(err))
 	}
 	handlerErr := l.handler.HandleError(ewp)
 	return ewp, handlerErr == nil
@@ -759,7 +819,16 @@ func (l *protoLex) errWithCurrentPos(err error, offset int) reporter.ErrorWithPo
 		return ewp
 	}
 	pos := l.info.SourcePos(l.input.offset() + offset)
-	return reporter.Error(ast.NewSourceSpan(pos, pos), err)
+	return reporter.Error(ast.NewSourceSpan(pos, pos), syntaxError(err))
+}
+
+// syntaxError returns the given error with the code CodeSyntax, unless it
+// already has a code.
+func syntaxError(err error) error {
+	if reporter.CodeOf(err) != reporter.CodeUnknown {
+		return err
+	}
+	return reporter.WithCode(reporter.CodeSyntax, err)
 }
 
 func (l *protoLex) requireSemicolon(semicolons []*ast.RuneNode) (*ast.RuneNode, []*ast.RuneNode) {
@@ -41,6 +41,9 @@ func init() {
 	for str, i := range keywords {
 		setTokenName(i, fmt.Sprintf(`"%s"`, str))
 	}
+	for str, i := range visibilityKeywords {
+		setTokenName(i, fmt.Sprintf(`"%s"`, str))
+	}
 }
 
 func setTokenName(token int, text string) {
@@ -90,7 +93,9 @@ func setTokenName(token int, text string) {
 // The degree to which the parser can recover from errors and populate the AST
 // depends on the nature of the syntax error and if there are any tokens after the
 // syntax error that can help the parser recover. This error recovery and partial
-// AST production is best effort.
+// AST production is best effort. Even if the parser cannot recover at all, and
+// the AST has no declarations, it still includes all of the tokens and comments
+// in the file, which can be examined via its ast.FileInfo.
 func Parse(filename string, r io.Reader, handler *reporter.Handler) (*ast.FileNode, error) {
 	lx, err := newLexer(r, filename, handler)
 	if err != nil {
@@ -100,7 +105,12 @@ func Parse(filename string, r io.Reader, handler *reporter.Handler) (*ast.FileNo
 	if lx.res == nil {
 		// nil AST means there was an error that prevented any parsing
 		// or the file was empty; synthesize empty non-nil AST
-		lx.res = ast.NewEmptyFileNode(filename)
+		if lx.atEOF {
+			// keep the tokens and comments that were lexed
+			lx.res = ast.NewFileNode(lx.info, nil, nil, lx.eof)
+		} else {
+			lx.res = ast.NewEmptyFileNode(filename)
+		}
 	}
 	return lx.res, handler.Error()
 }
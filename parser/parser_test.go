@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/descriptorpb"
 
+	"github.com/bufbuild/protocompile/ast"
 	"github.com/bufbuild/protocompile/internal"
 	"github.com/bufbuild/protocompile/reporter"
 )
@@ -949,6 +950,47 @@ func TestBasicSuccess(t *testing.T) {
 	assert.Equal(t, "proto3", result.AST().Syntax.Syntax.AsString())
 }
 
+func TestEdition2024Declarations(t *testing.T) {
+	t.Parallel()
+	contents := `edition = "2024";
+		import "foo.proto";
+		import option "bar.proto";
+		export message Foo {
+			local enum Kind { KIND_UNSPECIFIED = 0; }
+			message Bar {}
+		}
+		local message Baz {
+			// not a visibility modifier
+			local.Foo local = 1;
+			Foo export = 2;
+		}
+		enum Qux { QUX_UNSPECIFIED = 0; }`
+	handler := reporter.NewHandler(nil)
+	fileNode, err := Parse("test.proto", strings.NewReader(contents), handler)
+	require.NoError(t, err)
+	result, err := ResultFromAST(fileNode, true, handler)
+	require.NoError(t, err)
+	require.NoError(t, handler.Error())
+
+	fd := result.FileDescriptorProto()
+	assert.Equal(t, []string{"foo.proto"}, fd.Dependency)
+	assert.Equal(t, []string{"bar.proto"}, internal.OptionDependencies(fd))
+	assert.Equal(t, internal.VisibilityExport, internal.VisibilityOf(fd.MessageType[0]))
+	assert.Equal(t, internal.VisibilityLocal, internal.VisibilityOf(fd.MessageType[0].EnumType[0]))
+	assert.Equal(t, internal.VisibilityUnset, internal.VisibilityOf(fd.MessageType[0].NestedType[0]))
+	assert.Equal(t, internal.VisibilityLocal, internal.VisibilityOf(fd.MessageType[1]))
+	assert.Equal(t, internal.VisibilityUnset, internal.VisibilityOf(fd.EnumType[0]))
+	assert.Equal(t, "local.Foo", fd.MessageType[1].Field[0].GetTypeName())
+	assert.Equal(t, "local", fd.MessageType[1].Field[0].GetName())
+	assert.Equal(t, "export", fd.MessageType[1].Field[1].GetName())
+
+	msgNode, ok := fileNode.Decls[2].(*ast.MessageNode)
+	require.True(t, ok)
+	require.NotNil(t, msgNode.Visibility)
+	assert.Equal(t, "export", msgNode.Visibility.Val)
+	assert.Same(t, msgNode.Visibility, msgNode.Children()[0])
+}
+
 func BenchmarkBasicSuccess(b *testing.B) {
 	r := readerForTestdata(b, "largeproto.proto")
 	bs, err := io.ReadAll(r)
@@ -90,7 +90,7 @@ import (
 %type <cmpctOpts>    compactOptions
 %type <v>            fieldValue optionValue scalarValue fieldScalarValue messageLiteralWithBraces messageLiteral numLit specialFloatLit listLiteral listElement listOfMessagesLiteral messageValue
 %type <il>           enumValueNumber
-%type <id>           identifier mapKeyType msgElementName extElementName oneofElementName notGroupElementName mtdElementName enumValueName fieldCardinality
+%type <id>           visibility identifier mapKeyType msgElementName extElementName oneofElementName notGroupElementName mtdElementName enumValueName fieldCardinality
 %type <cidPart>      qualifiedIdentifierEntry qualifiedIdentifierFinal mtdElementIdentEntry mtdElementIdentFinal
 %type <cid>          qualifiedIdentifier msgElementIdent extElementIdent oneofElementIdent notGroupElementIdent mtdElementIdent qualifiedIdentifierDot qualifiedIdentifierLeading mtdElementIdentLeading
 %type <tid>          typeName msgElementTypeIdent extElementTypeIdent oneofElementTypeIdent notGroupElementTypeIdent mtdElementTypeIdent
@@ -136,7 +136,7 @@ import (
 %token <id>  _SYNTAX _EDITION _IMPORT _WEAK _PUBLIC _PACKAGE _OPTION _TRUE _FALSE _INF _NAN _REPEATED _OPTIONAL _REQUIRED
 %token <id>  _DOUBLE _FLOAT _INT32 _INT64 _UINT32 _UINT64 _SINT32 _SINT64 _FIXED32 _FIXED64 _SFIXED32 _SFIXED64
 %token <id>  _BOOL _STRING _BYTES _GROUP _ONEOF _MAP _EXTENSIONS _TO _MAX _RESERVED _ENUM _MESSAGE _EXTEND
-%token <id>  _SERVICE _RPC _STREAM _RETURNS
+%token <id>  _SERVICE _RPC _STREAM _RETURNS _EXPORT _LOCAL
 %token <err> _ERROR
 // we define all of these, even ones that aren't used, to improve error messages
 // so it shows the unexpected symbol instead of showing "$unk"
@@ -253,6 +253,10 @@ importDecl : _IMPORT stringLit semicolons {
 	  semi, extra := protolex.(*protoLex).requireSemicolon($4)
 		$$ = newNodeWithRunes(ast.NewImportNode($1.ToKeyword(), $2.ToKeyword(), nil, toStringValueNode($3), semi), extra...)
 	}
+	| _IMPORT _OPTION stringLit semicolons {
+	  semi, extra := protolex.(*protoLex).requireSemicolon($4)
+		$$ = newNodeWithRunes(ast.NewOptionImportNode($1.ToKeyword(), $2.ToKeyword(), toStringValueNode($3), semi), extra...)
+	}
 
 packageDecl : _PACKAGE qualifiedIdentifier semicolons {
 		semi, extra := protolex.(*protoLex).requireSemicolon($3)
@@ -957,6 +961,9 @@ fieldNameIdents : identifier {
 enumDecl : _ENUM identifier '{' enumBody '}' semicolons {
 		$$ = newNodeWithRunes(ast.NewEnumNode($1.ToKeyword(), $2, $3, $4, $5), $6...)
 	}
+	| visibility _ENUM identifier '{' enumBody '}' semicolons {
+		$$ = newNodeWithRunes(ast.NewEnumNodeWithVisibility($1.ToKeyword(), $2.ToKeyword(), $3, $4, $5, $6), $7...)
+	}
 
 enumBody : semicolons {
 		$$ = prependRunes(toEnumElement, $1, nil)
@@ -997,6 +1004,14 @@ enumValueDecl : enumValueName '=' enumValueNumber semicolons {
 messageDecl : _MESSAGE identifier '{' messageBody '}' semicolons {
 		$$ = newNodeWithRunes(ast.NewMessageNode($1.ToKeyword(), $2, $3, $4, $5), $6...)
 	}
+	| visibility _MESSAGE identifier '{' messageBody '}' semicolons {
+		$$ = newNodeWithRunes(ast.NewMessageNodeWithVisibility($1.ToKeyword(), $2.ToKeyword(), $3, $4, $5, $6), $7...)
+	}
+
+// The lexer only produces these tokens when the word is followed by a
+// message or enum declaration, so they need not be allowed as identifiers.
+visibility : _EXPORT
+	| _LOCAL
 
 messageBody : semicolons {
 		$$ = prependRunes(toMessageElement, $1, nil)
@@ -133,7 +133,9 @@ const _SERVICE = 57389
 const _RPC = 57390
 const _STREAM = 57391
 const _RETURNS = 57392
-const _ERROR = 57393
+const _EXPORT = 57393
+const _LOCAL = 57394
+const _ERROR = 57395
 
 var protoToknames = [...]string{
 	"$end",
@@ -186,6 +188,8 @@ var protoToknames = [...]string{
 	"_RPC",
 	"_STREAM",
 	"_RETURNS",
+	"_EXPORT",
+	"_LOCAL",
 	"_ERROR",
 	"'='",
 	"';'",
@@ -239,481 +243,487 @@ var protoExca = [...]int16{
 	-1, 14,
 	1, 7,
 	-2, 0,
-	-1, 89,
-	52, 60,
-	61, 60,
-	69, 60,
-	-2, 61,
-	-1, 101,
-	55, 37,
-	58, 37,
-	62, 37,
-	67, 37,
-	69, 37,
-	-2, 34,
-	-1, 112,
-	52, 60,
-	61, 60,
-	69, 60,
+	-1, 93,
+	54, 61,
+	63, 61,
+	71, 61,
 	-2, 62,
-	-1, 118,
-	56, 249,
-	-2, 0,
-	-1, 121,
-	55, 37,
-	58, 37,
-	62, 37,
-	67, 37,
-	69, 37,
+	-1, 107,
+	57, 38,
+	60, 38,
+	64, 38,
+	69, 38,
+	71, 38,
 	-2, 35,
-	-1, 140,
-	56, 225,
+	-1, 119,
+	54, 61,
+	63, 61,
+	71, 61,
+	-2, 63,
+	-1, 127,
+	58, 254,
 	-2, 0,
-	-1, 142,
-	56, 214,
+	-1, 130,
+	57, 38,
+	60, 38,
+	64, 38,
+	69, 38,
+	71, 38,
+	-2, 36,
+	-1, 150,
+	58, 230,
 	-2, 0,
-	-1, 144,
-	56, 250,
+	-1, 154,
+	58, 216,
 	-2, 0,
-	-1, 198,
-	56, 262,
+	-1, 156,
+	58, 255,
 	-2, 0,
-	-1, 203,
-	56, 83,
-	62, 83,
+	-1, 210,
+	58, 267,
 	-2, 0,
-	-1, 214,
-	56, 226,
+	-1, 215,
+	58, 84,
+	64, 84,
 	-2, 0,
-	-1, 271,
-	56, 215,
+	-1, 226,
+	58, 231,
 	-2, 0,
-	-1, 377,
-	56, 263,
+	-1, 285,
+	58, 217,
 	-2, 0,
-	-1, 464,
-	56, 155,
+	-1, 391,
+	58, 268,
 	-2, 0,
-	-1, 523,
-	69, 144,
-	-2, 141,
-	-1, 531,
-	56, 156,
+	-1, 480,
+	58, 156,
 	-2, 0,
-	-1, 607,
-	67, 52,
-	-2, 49,
-	-1, 665,
-	69, 144,
+	-1, 541,
+	71, 145,
 	-2, 142,
-	-1, 690,
-	67, 52,
+	-1, 549,
+	58, 157,
+	-2, 0,
+	-1, 625,
+	69, 53,
 	-2, 50,
-	-1, 732,
-	56, 273,
+	-1, 683,
+	71, 145,
+	-2, 143,
+	-1, 708,
+	69, 53,
+	-2, 51,
+	-1, 750,
+	58, 278,
 	-2, 0,
-	-1, 745,
-	56, 274,
+	-1, 763,
+	58, 279,
 	-2, 0,
 }
 
 const protoPrivate = 57344
 
-const protoLast = 2053
+const protoLast = 2098
 
 var protoAct = [...]int16{
-	140, 7, 746, 7, 7, 100, 139, 18, 440, 394,
-	604, 436, 607, 439, 502, 39, 524, 596, 95, 532,
-	496, 127, 437, 422, 520, 200, 32, 34, 523, 233,
-	421, 40, 90, 93, 94, 405, 102, 106, 36, 96,
-	109, 435, 272, 85, 378, 458, 326, 404, 21, 20,
-	19, 107, 108, 149, 215, 202, 145, 98, 101, 86,
-	663, 89, 449, 390, 134, 706, 703, 598, 707, 513,
-	9, 652, 395, 510, 465, 9, 511, 396, 717, 651,
-	507, 459, 459, 460, 452, 459, 456, 9, 506, 459,
-	459, 462, 739, 90, 693, 451, 655, 598, 459, 9,
-	680, 653, 459, 687, 508, 459, 423, 459, 124, 125,
-	453, 115, 459, 459, 459, 134, 126, 133, 142, 138,
-	131, 129, 497, 395, 198, 130, 423, 134, 199, 448,
-	416, 388, 389, 711, 489, 395, 505, 119, 9, 387,
-	207, 666, 488, 593, 9, 468, 472, 113, 222, 112,
-	273, 386, 470, 462, 587, 9, 373, 120, 121, 385,
-	110, 40, 110, 691, 674, 428, 424, 414, 374, 122,
-	114, 375, 279, 760, 758, 754, 750, 104, 744, 743,
-	741, 733, 729, 721, 695, 9, 424, 716, 753, 219,
-	217, 218, 668, 383, 227, 376, 322, 270, 213, 728,
-	719, 323, 713, 658, 464, 123, 379, 118, 117, 207,
-	116, 5, 6, 104, 399, 9, 598, 104, 670, 324,
-	31, 702, 222, 667, 493, 490, 9, 492, 430, 392,
-	419, 111, 13, 12, 403, 599, 407, 408, 413, 528,
-	463, 40, 381, 748, 726, 8, 412, 724, 397, 659,
-	33, 415, 15, 656, 26, 26, 9, 37, 38, 384,
-	210, 209, 105, 219, 217, 218, 103, 35, 227, 400,
-	595, 417, 211, 212, 402, 23, 529, 594, 104, 273,
-	409, 582, 406, 24, 413, 516, 25, 26, 382, 495,
-	491, 4, 412, 33, 10, 11, 731, 745, 380, 197,
-	377, 279, 475, 476, 477, 478, 479, 480, 481, 482,
-	483, 484, 485, 486, 418, 22, 143, 28, 27, 29,
-	30, 144, 274, 425, 141, 271, 220, 420, 275, 225,
-	411, 426, 427, 410, 40, 530, 531, 214, 231, 224,
-	221, 535, 147, 223, 429, 146, 534, 216, 204, 203,
-	447, 499, 601, 538, 150, 228, 605, 99, 602, 327,
-	540, 154, 234, 277, 606, 329, 542, 156, 237, 474,
-	391, 393, 438, 132, 128, 87, 88, 432, 206, 91,
-	431, 521, 518, 533, 522, 379, 17, 16, 434, 14,
-	3, 2, 1, 0, 0, 442, 442, 0, 0, 0,
-	0, 207, 0, 0, 457, 0, 0, 454, 455, 466,
-	0, 469, 471, 0, 0, 0, 0, 0, 0, 450,
-	473, 445, 433, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 444, 0, 494, 0, 0, 0, 0, 0,
-	0, 0, 0, 487, 0, 0, 0, 498, 0, 442,
-	461, 0, 0, 0, 467, 503, 514, 0, 0, 517,
-	0, 525, 526, 0, 0, 90, 504, 0, 583, 584,
-	0, 0, 0, 0, 0, 0, 0, 0, 586, 0,
-	0, 0, 0, 0, 585, 0, 0, 0, 588, 0,
-	591, 0, 509, 0, 0, 0, 0, 0, 527, 0,
-	512, 515, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 592, 0, 660, 661, 657, 590,
-	0, 0, 0, 0, 0, 0, 0, 90, 0, 0,
-	654, 0, 0, 589, 0, 0, 0, 0, 0, 0,
-	0, 597, 0, 90, 672, 673, 664, 40, 0, 0,
-	665, 669, 0, 0, 671, 0, 0, 675, 0, 0,
-	0, 0, 662, 0, 0, 0, 0, 0, 0, 0,
+	150, 7, 764, 7, 7, 106, 149, 18, 452, 520,
+	622, 625, 514, 456, 102, 408, 455, 614, 43, 542,
+	550, 137, 101, 438, 538, 419, 541, 212, 392, 35,
+	37, 44, 94, 97, 437, 100, 451, 108, 474, 245,
+	112, 39, 286, 418, 116, 21, 89, 340, 20, 19,
+	161, 453, 227, 214, 157, 153, 90, 113, 114, 115,
+	104, 107, 93, 404, 144, 724, 670, 465, 725, 721,
+	616, 681, 409, 673, 669, 531, 9, 410, 476, 528,
+	525, 475, 529, 481, 9, 475, 478, 468, 524, 671,
+	757, 475, 616, 475, 9, 475, 515, 94, 467, 475,
+	711, 472, 9, 475, 124, 125, 526, 475, 698, 475,
+	735, 469, 439, 475, 133, 134, 135, 475, 128, 122,
+	439, 475, 144, 136, 143, 705, 148, 154, 430, 144,
+	409, 729, 141, 210, 139, 140, 464, 409, 211, 402,
+	403, 523, 684, 507, 605, 9, 771, 401, 120, 611,
+	219, 506, 154, 488, 119, 9, 484, 400, 234, 283,
+	117, 709, 287, 486, 478, 399, 129, 130, 387, 9,
+	692, 444, 428, 44, 440, 388, 117, 778, 131, 121,
+	23, 776, 440, 772, 768, 762, 389, 761, 24, 759,
+	751, 25, 26, 747, 293, 739, 231, 713, 686, 229,
+	230, 239, 9, 432, 734, 110, 431, 282, 397, 390,
+	336, 337, 284, 225, 746, 737, 731, 676, 393, 480,
+	152, 219, 29, 27, 30, 31, 413, 151, 132, 32,
+	33, 127, 126, 123, 234, 411, 5, 6, 110, 110,
+	9, 616, 688, 338, 34, 720, 417, 685, 421, 422,
+	427, 511, 510, 44, 446, 395, 9, 435, 118, 13,
+	12, 426, 617, 98, 99, 546, 429, 26, 508, 398,
+	406, 479, 231, 744, 36, 229, 230, 239, 742, 414,
+	766, 40, 41, 9, 42, 433, 677, 416, 423, 111,
+	109, 110, 26, 287, 222, 221, 674, 613, 427, 612,
+	600, 396, 547, 420, 534, 513, 223, 224, 15, 426,
+	491, 492, 493, 494, 495, 496, 497, 498, 499, 500,
+	501, 502, 509, 38, 36, 293, 4, 8, 434, 10,
+	11, 749, 763, 394, 209, 391, 22, 441, 155, 156,
+	288, 285, 232, 436, 289, 442, 443, 237, 44, 425,
+	424, 548, 549, 226, 243, 236, 233, 553, 159, 235,
+	158, 445, 552, 228, 216, 215, 463, 517, 619, 556,
+	162, 240, 623, 105, 620, 341, 558, 166, 246, 291,
+	624, 343, 560, 168, 249, 490, 28, 405, 407, 454,
+	142, 448, 138, 91, 447, 92, 218, 95, 539, 393,
+	536, 551, 450, 540, 17, 16, 14, 3, 2, 458,
+	458, 1, 0, 0, 0, 219, 0, 0, 473, 0,
+	449, 470, 471, 482, 0, 485, 487, 0, 0, 0,
+	0, 0, 503, 504, 489, 0, 0, 461, 0, 0,
+	0, 460, 0, 0, 0, 0, 0, 0, 0, 0,
+	512, 0, 0, 0, 0, 0, 0, 477, 0, 505,
+	0, 483, 466, 516, 0, 458, 0, 0, 521, 0,
+	0, 0, 532, 0, 0, 535, 0, 543, 544, 0,
+	0, 94, 0, 527, 601, 602, 0, 0, 0, 0,
+	0, 0, 0, 0, 604, 0, 0, 0, 0, 0,
+	0, 0, 0, 603, 545, 0, 606, 0, 609, 530,
+	533, 522, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 676, 0, 0, 0, 0, 0, 0,
-	679, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 678, 0, 0, 0,
-	0, 0, 0, 682, 0, 684, 689, 0, 690, 686,
-	685, 0, 0, 0, 0, 0, 0, 0, 677, 0,
+	0, 0, 610, 0, 678, 679, 675, 0, 0, 0,
+	0, 608, 0, 0, 607, 94, 0, 0, 0, 0,
+	0, 0, 615, 0, 0, 0, 0, 0, 0, 0,
+	0, 94, 690, 691, 682, 44, 683, 0, 0, 0,
+	687, 0, 0, 680, 0, 689, 0, 672, 693, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	681, 683, 0, 688, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 442, 0,
-	697, 0, 0, 699, 503, 696, 0, 692, 0, 701,
-	0, 0, 0, 133, 0, 504, 131, 129, 710, 0,
-	709, 130, 0, 0, 0, 0, 715, 712, 0, 700,
-	704, 0, 0, 0, 0, 0, 720, 0, 0, 722,
-	718, 714, 694, 0, 0, 698, 0, 0, 133, 0,
-	0, 131, 129, 0, 727, 0, 130, 732, 705, 708,
-	730, 0, 735, 725, 723, 0, 734, 0, 0, 0,
-	0, 0, 0, 0, 749, 742, 0, 0, 0, 0,
-	747, 736, 737, 0, 0, 755, 752, 0, 756, 0,
-	0, 757, 0, 747, 0, 0, 751, 0, 0, 0,
-	759, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	738, 501, 740, 33, 137, 135, 41, 42, 43, 44,
-	45, 46, 47, 48, 49, 50, 51, 52, 53, 54,
-	55, 56, 57, 58, 59, 60, 61, 62, 63, 64,
-	65, 66, 67, 68, 69, 70, 71, 72, 73, 74,
-	75, 76, 77, 78, 79, 80, 81, 82, 83, 84,
-	0, 0, 0, 0, 134, 0, 0, 0, 0, 0,
-	0, 0, 395, 0, 441, 0, 0, 0, 500, 33,
-	137, 135, 41, 42, 43, 44, 45, 46, 47, 48,
-	49, 50, 51, 52, 53, 54, 55, 56, 57, 58,
-	59, 60, 61, 62, 63, 64, 65, 66, 67, 68,
-	69, 70, 71, 72, 73, 74, 75, 76, 77, 78,
-	79, 80, 81, 82, 83, 84, 0, 0, 0, 0,
-	134, 0, 0, 0, 0, 0, 0, 0, 395, 0,
-	441, 0, 0, 443, 33, 137, 135, 41, 42, 43,
-	44, 45, 46, 47, 48, 49, 50, 51, 52, 53,
-	54, 55, 56, 57, 58, 59, 60, 61, 62, 63,
-	64, 65, 66, 67, 68, 69, 70, 71, 72, 73,
-	74, 75, 76, 77, 78, 79, 80, 81, 82, 83,
-	84, 0, 0, 0, 0, 134, 0, 0, 0, 0,
-	0, 0, 0, 395, 0, 441, 41, 42, 43, 44,
-	45, 46, 47, 48, 49, 50, 51, 52, 53, 54,
-	55, 56, 57, 58, 59, 60, 61, 62, 63, 64,
-	65, 66, 67, 68, 69, 70, 71, 72, 73, 74,
-	75, 76, 77, 78, 79, 80, 81, 82, 83, 84,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 205, 92, 0, 0, 519, 41,
-	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
-	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
-	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
-	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
-	82, 83, 84, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 446, 0, 205, 0, 0, 0,
-	208, 41, 42, 43, 44, 45, 46, 47, 48, 49,
+	0, 694, 0, 0, 0, 0, 0, 0, 697, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 696, 0,
+	703, 700, 0, 702, 707, 708, 706, 0, 0, 695,
+	0, 704, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 699, 701, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 458, 0, 715, 521,
+	710, 717, 0, 714, 0, 0, 0, 719, 0, 0,
+	0, 143, 0, 0, 0, 0, 728, 0, 727, 141,
+	0, 139, 140, 0, 733, 730, 0, 718, 722, 0,
+	0, 0, 0, 712, 738, 0, 716, 740, 736, 732,
+	0, 0, 522, 0, 0, 0, 143, 0, 0, 723,
+	726, 0, 745, 0, 141, 750, 139, 140, 748, 0,
+	753, 743, 741, 0, 752, 0, 0, 0, 0, 0,
+	0, 0, 767, 760, 0, 0, 0, 0, 765, 754,
+	755, 0, 0, 773, 770, 0, 774, 0, 0, 775,
+	0, 765, 0, 0, 769, 0, 0, 0, 777, 0,
+	0, 756, 519, 758, 36, 147, 145, 45, 46, 47,
+	48, 49, 50, 51, 52, 53, 54, 55, 56, 57,
+	58, 59, 60, 61, 62, 63, 64, 65, 66, 67,
+	68, 69, 70, 71, 72, 73, 74, 75, 76, 77,
+	78, 79, 80, 81, 82, 83, 84, 85, 86, 87,
+	88, 0, 0, 0, 0, 0, 0, 144, 0, 0,
+	0, 0, 0, 0, 0, 409, 0, 457, 0, 0,
+	0, 518, 36, 147, 145, 45, 46, 47, 48, 49,
 	50, 51, 52, 53, 54, 55, 56, 57, 58, 59,
 	60, 61, 62, 63, 64, 65, 66, 67, 68, 69,
 	70, 71, 72, 73, 74, 75, 76, 77, 78, 79,
-	80, 81, 82, 83, 84, 0, 0, 0, 0, 0,
-	201, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 208, 33, 137, 135, 41, 42, 43, 44,
-	45, 46, 47, 48, 49, 50, 51, 52, 53, 54,
-	55, 56, 57, 58, 59, 60, 61, 62, 63, 64,
-	65, 66, 67, 68, 69, 70, 71, 72, 73, 74,
-	75, 76, 77, 78, 79, 80, 81, 82, 83, 84,
-	0, 0, 0, 0, 134, 0, 0, 0, 0, 0,
-	205, 0, 0, 0, 136, 41, 42, 43, 44, 45,
+	80, 81, 82, 83, 84, 85, 86, 87, 88, 0,
+	0, 0, 0, 0, 0, 144, 0, 0, 0, 0,
+	0, 0, 0, 409, 0, 457, 0, 0, 459, 36,
+	147, 145, 45, 46, 47, 48, 49, 50, 51, 52,
+	53, 54, 55, 56, 57, 58, 59, 60, 61, 62,
+	63, 64, 65, 66, 67, 68, 69, 70, 71, 72,
+	73, 74, 75, 76, 77, 78, 79, 80, 81, 82,
+	83, 84, 85, 86, 87, 88, 0, 0, 0, 0,
+	0, 0, 144, 0, 0, 0, 0, 0, 0, 0,
+	409, 0, 457, 45, 46, 47, 48, 49, 50, 51,
+	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
+	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 217, 96, 0, 0, 537, 45, 46,
+	47, 48, 49, 50, 51, 52, 53, 54, 55, 56,
+	57, 58, 59, 60, 61, 62, 63, 64, 65, 66,
+	67, 68, 69, 70, 71, 72, 73, 74, 75, 76,
+	77, 78, 79, 80, 81, 82, 83, 84, 85, 86,
+	87, 88, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 462, 0, 217, 0, 0,
+	0, 220, 45, 46, 47, 48, 49, 50, 51, 52,
+	53, 54, 55, 56, 57, 58, 59, 60, 61, 62,
+	63, 64, 65, 66, 67, 68, 69, 70, 71, 72,
+	73, 74, 75, 76, 77, 78, 79, 80, 81, 82,
+	83, 84, 85, 86, 87, 88, 0, 0, 0, 0,
+	0, 0, 0, 213, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 220, 36, 147, 145, 45,
 	46, 47, 48, 49, 50, 51, 52, 53, 54, 55,
 	56, 57, 58, 59, 60, 61, 62, 63, 64, 65,
 	66, 67, 68, 69, 70, 71, 72, 73, 74, 75,
-	76, 77, 78, 79, 80, 81, 82, 83, 84, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 33, 423, 208, 41, 42, 43,
-	44, 45, 46, 47, 48, 49, 50, 51, 52, 53,
-	54, 55, 56, 57, 58, 59, 60, 61, 62, 63,
-	64, 65, 66, 67, 68, 69, 70, 71, 72, 73,
-	74, 75, 76, 77, 78, 79, 80, 81, 82, 83,
-	84, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 424, 41, 42, 43, 44,
+	76, 77, 78, 79, 80, 81, 82, 83, 84, 85,
+	86, 87, 88, 0, 0, 0, 0, 0, 0, 144,
+	0, 0, 0, 0, 0, 217, 0, 0, 0, 146,
 	45, 46, 47, 48, 49, 50, 51, 52, 53, 54,
 	55, 56, 57, 58, 59, 60, 61, 62, 63, 64,
 	65, 66, 67, 68, 69, 70, 71, 72, 73, 74,
 	75, 76, 77, 78, 79, 80, 81, 82, 83, 84,
+	85, 86, 87, 88, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 92, 41, 42, 43, 44,
-	45, 46, 47, 48, 49, 50, 51, 52, 53, 54,
-	55, 56, 57, 58, 59, 60, 61, 62, 63, 64,
-	65, 66, 67, 68, 69, 70, 71, 72, 73, 74,
-	75, 76, 77, 78, 79, 80, 81, 82, 83, 84,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 97,
-	608, 609, 610, 611, 612, 613, 614, 615, 616, 617,
-	618, 619, 620, 621, 622, 623, 624, 625, 626, 627,
-	628, 629, 630, 631, 632, 633, 634, 635, 636, 637,
-	638, 639, 640, 641, 642, 643, 644, 645, 646, 647,
-	648, 649, 600, 650, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 603, 330, 331, 332, 333, 334, 335,
-	336, 337, 338, 339, 340, 341, 342, 343, 344, 345,
-	346, 347, 348, 349, 350, 351, 352, 353, 354, 355,
-	356, 357, 358, 359, 401, 360, 361, 362, 363, 364,
-	365, 366, 367, 368, 369, 370, 371, 372, 0, 0,
-	0, 0, 0, 226, 0, 0, 0, 328, 238, 239,
-	240, 241, 242, 243, 244, 26, 245, 246, 247, 248,
-	153, 152, 151, 249, 250, 251, 252, 253, 254, 255,
-	256, 257, 258, 259, 260, 261, 262, 263, 0, 230,
-	236, 229, 264, 265, 232, 28, 27, 29, 266, 267,
-	268, 269, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 235, 330, 331, 332, 333, 334, 335, 336, 337,
-	338, 339, 340, 341, 342, 343, 344, 345, 346, 347,
-	348, 349, 350, 351, 352, 353, 354, 355, 356, 357,
-	358, 359, 325, 360, 361, 362, 363, 364, 365, 366,
-	367, 368, 369, 370, 371, 372, 0, 0, 0, 0,
-	0, 148, 0, 0, 0, 328, 157, 158, 159, 160,
-	161, 162, 163, 164, 165, 166, 167, 168, 153, 152,
-	151, 169, 170, 171, 172, 173, 174, 175, 176, 177,
-	178, 179, 180, 181, 182, 183, 0, 184, 185, 186,
-	187, 188, 189, 190, 191, 192, 193, 194, 195, 196,
-	0, 0, 0, 0, 0, 536, 0, 0, 0, 155,
-	543, 544, 545, 546, 547, 548, 549, 537, 550, 551,
-	552, 553, 0, 0, 0, 554, 555, 556, 557, 558,
-	559, 560, 561, 562, 563, 564, 565, 566, 567, 568,
-	539, 569, 570, 571, 572, 573, 574, 575, 576, 577,
-	578, 579, 580, 581, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 541, 210, 209, 41, 42, 43, 44,
+	0, 36, 439, 220, 45, 46, 47, 48, 49, 50,
+	51, 52, 53, 54, 55, 56, 57, 58, 59, 60,
+	61, 62, 63, 64, 65, 66, 67, 68, 69, 70,
+	71, 72, 73, 74, 75, 76, 77, 78, 79, 80,
+	81, 82, 83, 84, 85, 86, 87, 88, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 440, 45, 46, 47, 48, 49,
+	50, 51, 52, 53, 54, 55, 56, 57, 58, 59,
+	60, 61, 62, 63, 64, 65, 66, 67, 68, 69,
+	70, 71, 72, 73, 74, 75, 76, 77, 78, 79,
+	80, 81, 82, 83, 84, 85, 86, 87, 88, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 238, 0, 0, 0, 96, 250, 251, 252,
+	253, 254, 255, 256, 26, 257, 258, 259, 260, 165,
+	164, 163, 261, 262, 263, 264, 265, 266, 267, 268,
+	269, 270, 271, 272, 273, 274, 275, 0, 242, 248,
+	241, 276, 277, 244, 29, 27, 30, 278, 279, 280,
+	281, 32, 33, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 247, 45, 46, 47, 48, 49, 50, 51,
+	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
+	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 103, 626,
+	627, 628, 629, 630, 631, 632, 633, 634, 635, 636,
+	637, 638, 639, 640, 641, 642, 643, 644, 645, 646,
+	647, 648, 649, 650, 651, 652, 653, 654, 655, 656,
+	657, 658, 659, 660, 661, 662, 663, 664, 665, 666,
+	667, 618, 668, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 621, 344, 345, 346, 347, 348,
+	349, 350, 351, 352, 353, 354, 355, 356, 357, 358,
+	359, 360, 361, 362, 363, 364, 365, 366, 367, 368,
+	369, 370, 371, 372, 373, 415, 374, 375, 376, 377,
+	378, 379, 380, 381, 382, 383, 384, 385, 386, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	342, 344, 345, 346, 347, 348, 349, 350, 351, 352,
+	353, 354, 355, 356, 357, 358, 359, 360, 361, 362,
+	363, 364, 365, 366, 367, 368, 369, 370, 371, 372,
+	373, 339, 374, 375, 376, 377, 378, 379, 380, 381,
+	382, 383, 384, 385, 386, 0, 0, 0, 0, 0,
+	0, 0, 160, 0, 0, 0, 342, 169, 170, 171,
+	172, 173, 174, 175, 176, 177, 178, 179, 180, 165,
+	164, 163, 181, 182, 183, 184, 185, 186, 187, 188,
+	189, 190, 191, 192, 193, 194, 195, 0, 196, 197,
+	198, 199, 200, 201, 202, 203, 204, 205, 206, 207,
+	208, 0, 0, 0, 0, 0, 0, 0, 554, 0,
+	0, 0, 167, 561, 562, 563, 564, 565, 566, 567,
+	555, 568, 569, 570, 571, 0, 0, 0, 572, 573,
+	574, 575, 576, 577, 578, 579, 580, 581, 582, 583,
+	584, 585, 586, 557, 587, 588, 589, 590, 591, 592,
+	593, 594, 595, 596, 597, 598, 599, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 559, 222,
+	221, 45, 46, 47, 48, 49, 50, 51, 52, 53,
+	54, 55, 56, 57, 58, 59, 60, 61, 62, 63,
+	64, 65, 66, 67, 68, 69, 70, 71, 72, 73,
+	74, 75, 76, 77, 78, 79, 80, 81, 82, 83,
+	84, 85, 86, 87, 88, 36, 420, 0, 45, 46,
+	47, 48, 49, 50, 51, 52, 53, 54, 55, 56,
+	57, 58, 59, 60, 61, 62, 63, 64, 65, 66,
+	67, 68, 69, 70, 71, 72, 73, 74, 75, 76,
+	77, 78, 79, 80, 81, 82, 83, 84, 85, 86,
+	87, 88, 290, 0, 0, 0, 0, 294, 295, 296,
+	297, 298, 299, 300, 26, 301, 302, 303, 304, 305,
+	306, 307, 308, 309, 310, 311, 312, 313, 314, 315,
+	316, 317, 318, 319, 320, 321, 322, 323, 324, 325,
+	326, 327, 328, 292, 329, 330, 331, 332, 333, 334,
+	335, 412, 0, 0, 0, 0, 45, 46, 47, 48,
+	49, 50, 51, 52, 53, 54, 55, 56, 57, 58,
+	59, 60, 61, 62, 63, 64, 65, 66, 67, 68,
+	69, 70, 71, 72, 73, 74, 75, 76, 77, 78,
+	79, 80, 81, 82, 83, 84, 85, 86, 87, 88,
 	45, 46, 47, 48, 49, 50, 51, 52, 53, 54,
 	55, 56, 57, 58, 59, 60, 61, 62, 63, 64,
 	65, 66, 67, 68, 69, 70, 71, 72, 73, 74,
 	75, 76, 77, 78, 79, 80, 81, 82, 83, 84,
-	33, 406, 0, 41, 42, 43, 44, 45, 46, 47,
-	48, 49, 50, 51, 52, 53, 54, 55, 56, 57,
-	58, 59, 60, 61, 62, 63, 64, 65, 66, 67,
-	68, 69, 70, 71, 72, 73, 74, 75, 76, 77,
-	78, 79, 80, 81, 82, 83, 84, 276, 0, 0,
-	0, 0, 280, 281, 282, 283, 284, 285, 286, 26,
-	287, 288, 289, 290, 291, 292, 293, 294, 295, 296,
-	297, 298, 299, 300, 301, 302, 303, 304, 305, 306,
-	307, 308, 309, 310, 311, 312, 313, 314, 278, 315,
-	316, 317, 318, 319, 320, 321, 398, 0, 0, 0,
-	0, 41, 42, 43, 44, 45, 46, 47, 48, 49,
-	50, 51, 52, 53, 54, 55, 56, 57, 58, 59,
-	60, 61, 62, 63, 64, 65, 66, 67, 68, 69,
-	70, 71, 72, 73, 74, 75, 76, 77, 78, 79,
-	80, 81, 82, 83, 84, 41, 42, 43, 44, 45,
-	46, 47, 48, 49, 50, 51, 52, 53, 54, 55,
-	56, 57, 58, 59, 60, 61, 62, 63, 64, 65,
-	66, 67, 68, 69, 70, 71, 72, 73, 74, 75,
-	76, 77, 78, 79, 80, 81, 82, 83, 84, 608,
-	609, 610, 611, 612, 613, 614, 615, 616, 617, 618,
-	619, 620, 621, 622, 623, 624, 625, 626, 627, 628,
-	629, 630, 631, 632, 633, 634, 635, 636, 637, 638,
-	639, 640, 641, 642, 643, 644, 645, 646, 647, 648,
-	649, 0, 650,
+	85, 86, 87, 88, 626, 627, 628, 629, 630, 631,
+	632, 633, 634, 635, 636, 637, 638, 639, 640, 641,
+	642, 643, 644, 645, 646, 647, 648, 649, 650, 651,
+	652, 653, 654, 655, 656, 657, 658, 659, 660, 661,
+	662, 663, 664, 665, 666, 667, 0, 668,
 }
 
 var protoPact = [...]int16{
-	203, -1000, 162, 162, -1000, 181, 180, 273, 167, -1000,
-	-1000, -1000, 289, 289, 273, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, 246, 1958, 1329, 1958, 1958, 1389,
-	1958, -1000, 213, -1000, 209, -1000, 173, 289, 289, 102,
+	228, -1000, 185, 185, -1000, 206, 205, 178, 189, -1000,
+	-1000, -1000, 320, 320, 178, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, 270, 2003, 1358, 2003, 219, 2003,
+	1476, 2003, -1000, -1000, -1000, 235, -1000, 234, -1000, 201,
+	320, 320, 320, 114, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 204,
+	-1000, 1358, 117, -1000, -1000, -1000, 1476, 176, 2003, 2003,
+	175, 174, -1000, 2003, -1000, 2003, 116, -1000, 171, -1000,
+	-1000, -1000, -1000, 201, 201, 201, -1000, 2003, 1172, -1000,
+	-1000, -1000, 57, 185, 170, 163, 185, 1700, -1000, -1000,
+	-1000, -1000, 185, -1000, -1000, -1000, -1000, 185, -1000, -1000,
+	287, -1000, -1000, -1000, 1105, -1000, 289, -1000, -1000, 155,
+	1420, 185, 185, 154, 1910, 152, 1700, -1000, -1000, -1000,
+	188, 1644, 2003, -1000, -1000, -1000, 113, 2003, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, 179, -1000, 1329, 110, -1000,
-	-1000, -1000, 1389, 155, 153, 152, -1000, 1958, -1000, 1958,
-	109, -1000, 150, -1000, -1000, -1000, -1000, 173, 173, -1000,
-	1958, 1149, -1000, -1000, -1000, 52, 162, 162, 1659, -1000,
-	-1000, -1000, -1000, 162, -1000, -1000, -1000, 162, -1000, -1000,
-	274, -1000, -1000, -1000, 1084, -1000, 255, -1000, -1000, 142,
-	1551, 141, 1865, 140, 1659, -1000, -1000, -1000, 166, 1605,
-	1958, -1000, -1000, -1000, 108, 1958, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 151,
+	253, -1000, 150, -1000, -1000, 1233, 102, 84, 7, -1000,
+	1959, -1000, -1000, -1000, -1000, 185, 1420, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 1588,
+	2003, 298, 2003, 2003, 1861, -1000, 110, 2003, 63, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, 139, 240, -1000,
-	137, -1000, -1000, 1208, 98, 78, 9, -1000, 1914, -1000,
-	-1000, -1000, -1000, 162, 1551, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, 1497, 1958, 277,
-	1958, 1958, 1816, -1000, 107, 1958, 67, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, 148, 145, 185, 1910, -1000, -1000, -1000, -1000,
+	-1000, 203, 1297, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	162, 1865, -1000, -1000, -1000, -1000, -1000, 178, 1270, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, 185, -1000, -1000, 2003,
+	2003, 109, 2003, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, 162, -1000, -1000, 1958, 1958, 105, 1958, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, 200, 2003, 98,
+	185, 253, -1000, -1000, -1000, -1000, 2003, -1000, -1000, -1000,
+	-1000, -1000, -1000, 848, 848, -1000, -1000, -1000, -1000, 1041,
+	65, 27, 40, -1000, -1000, 2003, 2003, 47, 23, -1000,
+	230, 162, 29, 101, 100, 90, 287, -1000, 2003, 98,
+	286, 185, 185, -1000, -1000, 115, 88, -1000, 227, -1000,
+	317, -1000, 198, 197, 2003, 98, 300, -1000, -1000, -1000,
+	28, -1000, -1000, -1000, -1000, 287, -1000, 1814, -1000, 780,
+	-1000, 77, -1000, 17, -1000, 35, -1000, -1000, 2003, -1000,
+	25, 21, 299, -1000, 185, 976, 185, 185, 298, 260,
+	1756, 295, -1000, 185, 185, -1000, 320, -1000, 2003, -1000,
+	81, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, 39, 115, 185, 107, -1000,
+	294, 292, -1000, 37, 212, 1532, -1000, 3, -1000, 18,
+	-1000, -1000, -1000, -1000, -1000, 72, -1000, 2, 291, 185,
+	160, 281, -1000, 185, 39, -1000, 0, -1000, -1000, 1358,
+	79, -1000, 193, -1000, -1000, -1000, -1000, -1000, 140, 1756,
+	-1000, -1000, -1000, -1000, 187, 1358, 2003, 2003, 108, 2003,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, 176, 1958, 100, 162, 240, -1000, -1000,
-	-1000, -1000, 1958, -1000, -1000, -1000, -1000, -1000, -1000, 835,
-	835, -1000, -1000, -1000, -1000, 1022, 60, 26, 41, -1000,
-	-1000, 1958, 1958, 34, 30, -1000, 199, 149, 22, 92,
-	91, 85, 274, -1000, 1958, 100, 278, -1000, -1000, 121,
-	81, -1000, 184, -1000, 285, -1000, 175, 172, 1958, 100,
-	284, -1000, -1000, -1000, 56, -1000, -1000, -1000, -1000, 274,
-	-1000, 1769, -1000, 769, -1000, 74, -1000, 19, -1000, 35,
-	-1000, -1000, 1958, -1000, 21, 17, 280, -1000, 162, 959,
-	162, 162, 277, 234, 1713, 276, -1000, 162, 162, -1000,
-	289, -1000, 1958, -1000, 93, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, 46, 121, 162,
-	101, -1000, 272, 265, -1000, 44, 185, 1443, -1000, 10,
-	-1000, 32, -1000, -1000, -1000, -1000, -1000, 72, -1000, 27,
-	248, 162, 148, 244, -1000, 162, 46, -1000, -9, -1000,
-	-1000, 1329, 80, -1000, 171, -1000, -1000, -1000, -1000, -1000,
-	136, 1713, -1000, -1000, -1000, -1000, 165, 1329, 1958, 1958,
-	104, 1958, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	39, -1000, -1000, 287, -1000, 1476, -1000, 185, -1000, -1000,
+	-1000, -1000, 51, 37, -1000, 186, -1000, 28, 1476, 56,
+	-1000, 2003, -1000, 2047, 99, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, 46, -1000, -1000, 274, -1000, 1389, -1000, 162,
-	-1000, -1000, -1000, -1000, 45, 44, -1000, 163, -1000, 56,
-	1389, 36, -1000, 1958, -1000, 2002, 103, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, 900, -1000, -1000, -1000, 39, 128, 162, 46,
-	-1000, -1000, 162, -1000, -1000, -1000, -1000, 1149, 162, -1000,
-	-1000, 169, 14, 13, 1958, 100, -1000, 162, 71, -1000,
-	162, 147, -1000, 163, -1000, 132, 11, -1000, -1000, -1000,
-	-1000, -1000, -1000, 162, 145, 162, 127, -1000, 162, -1000,
-	-1000, -1000, 1149, 242, -1000, 163, 239, 162, 144, -1000,
-	-1000, -1000, 126, 162, -1000, -1000, 162, -1000, 125, 162,
-	-1000, 162, -1000, 163, 44, -1000, 37, 124, 162, -1000,
-	123, 122, 241, 162, 120, -1000, -1000, -1000, 163, 162,
-	133, -1000, 119, -1000, 162, 241, -1000, -1000, -1000, -1000,
-	162, -1000, 118, 162, -1000, -1000, -1000, -1000, -1000, 117,
-	-1000,
+	915, -1000, -1000, -1000, 43, 139, 185, 39, -1000, -1000,
+	185, -1000, -1000, -1000, -1000, 1172, 185, -1000, -1000, 191,
+	15, 11, 2003, 98, -1000, 185, 67, -1000, 185, 159,
+	-1000, 186, -1000, 147, 41, -1000, -1000, -1000, -1000, -1000,
+	-1000, 185, 158, 185, 137, -1000, 185, -1000, -1000, -1000,
+	1172, 273, -1000, 186, 268, 185, 157, -1000, -1000, -1000,
+	135, 185, -1000, -1000, 185, -1000, 132, 185, -1000, 185,
+	-1000, 186, 37, -1000, 33, 131, 185, -1000, 129, 127,
+	278, 185, 126, -1000, -1000, -1000, 186, 185, 89, -1000,
+	125, -1000, 185, 278, -1000, -1000, -1000, -1000, 185, -1000,
+	123, 185, -1000, -1000, -1000, -1000, -1000, 119, -1000,
 }
 
 var protoPgo = [...]int16{
-	0, 392, 391, 390, 291, 252, 389, 387, 386, 384,
-	383, 7, 28, 24, 382, 381, 379, 378, 376, 61,
-	59, 16, 375, 45, 41, 21, 374, 11, 9, 22,
-	8, 373, 372, 14, 371, 370, 23, 5, 369, 368,
-	367, 366, 365, 364, 363, 53, 58, 57, 12, 10,
-	15, 362, 361, 360, 359, 358, 39, 357, 356, 18,
-	355, 354, 353, 46, 352, 351, 350, 349, 55, 25,
-	348, 347, 346, 345, 343, 342, 341, 340, 339, 338,
-	50, 54, 337, 6, 19, 336, 335, 333, 330, 329,
-	328, 29, 35, 30, 47, 327, 326, 49, 42, 325,
-	324, 322, 48, 56, 321, 316, 13, 315, 44, 300,
-	299, 298, 2, 297, 296, 20, 17, 0, 245,
+	0, 411, 408, 407, 326, 308, 406, 405, 404, 403,
+	401, 7, 26, 24, 400, 398, 397, 396, 395, 62,
+	56, 19, 393, 38, 36, 21, 392, 8, 15, 51,
+	13, 390, 389, 9, 388, 387, 23, 386, 5, 385,
+	384, 383, 382, 381, 380, 379, 50, 61, 60, 11,
+	10, 18, 378, 377, 376, 375, 374, 14, 373, 372,
+	22, 371, 370, 369, 47, 368, 367, 366, 365, 53,
+	27, 364, 363, 362, 360, 359, 358, 357, 356, 355,
+	354, 49, 52, 353, 6, 20, 352, 351, 350, 349,
+	347, 344, 39, 25, 34, 43, 343, 342, 48, 42,
+	341, 55, 340, 45, 54, 339, 338, 16, 336, 28,
+	335, 334, 333, 2, 332, 331, 12, 17, 0, 327,
 }
 
 var protoR1 = [...]int8{
 	0, 1, 1, 1, 1, 1, 1, 4, 6, 6,
-	5, 5, 5, 5, 5, 5, 5, 5, 118, 118,
-	117, 117, 116, 116, 2, 3, 7, 7, 7, 8,
-	50, 50, 56, 56, 57, 57, 47, 47, 46, 51,
-	51, 52, 52, 53, 53, 54, 54, 55, 55, 58,
-	58, 49, 49, 48, 10, 11, 18, 18, 19, 20,
-	20, 22, 22, 21, 21, 16, 25, 25, 26, 26,
-	26, 26, 30, 30, 30, 30, 31, 31, 106, 106,
-	28, 28, 69, 68, 68, 67, 67, 67, 67, 67,
-	67, 70, 70, 70, 17, 17, 17, 17, 24, 24,
-	24, 27, 27, 27, 27, 35, 35, 29, 29, 29,
-	32, 32, 32, 65, 65, 33, 33, 34, 34, 34,
-	66, 66, 59, 59, 60, 60, 61, 61, 62, 62,
-	63, 63, 64, 64, 45, 45, 45, 23, 23, 14,
-	14, 15, 15, 13, 13, 12, 9, 9, 75, 75,
-	77, 77, 77, 77, 74, 86, 86, 85, 85, 84,
-	84, 84, 84, 84, 72, 72, 72, 72, 76, 76,
-	76, 76, 78, 78, 78, 78, 79, 38, 38, 38,
-	38, 38, 38, 38, 38, 38, 38, 38, 38, 96,
-	96, 94, 94, 92, 92, 92, 95, 95, 93, 93,
-	93, 36, 36, 89, 89, 90, 90, 91, 91, 87,
-	87, 88, 88, 97, 100, 100, 99, 99, 98, 98,
-	98, 98, 101, 101, 80, 83, 83, 82, 82, 81,
-	81, 81, 81, 81, 81, 81, 81, 81, 81, 81,
-	71, 71, 71, 71, 71, 71, 71, 71, 102, 105,
-	105, 104, 104, 103, 103, 103, 103, 73, 73, 73,
-	73, 107, 110, 110, 109, 109, 108, 108, 108, 111,
-	111, 115, 115, 114, 114, 113, 113, 112, 112, 39,
-	39, 39, 39, 39, 39, 39, 39, 39, 39, 39,
-	39, 39, 39, 39, 39, 39, 39, 39, 39, 39,
+	5, 5, 5, 5, 5, 5, 5, 5, 119, 119,
+	118, 118, 117, 117, 2, 3, 7, 7, 7, 7,
+	8, 51, 51, 57, 57, 58, 58, 48, 48, 47,
+	52, 52, 53, 53, 54, 54, 55, 55, 56, 56,
+	59, 59, 50, 50, 49, 10, 11, 18, 18, 19,
+	20, 20, 22, 22, 21, 21, 16, 25, 25, 26,
+	26, 26, 26, 30, 30, 30, 30, 31, 31, 107,
+	107, 28, 28, 70, 69, 69, 68, 68, 68, 68,
+	68, 68, 71, 71, 71, 17, 17, 17, 17, 24,
+	24, 24, 27, 27, 27, 27, 35, 35, 29, 29,
+	29, 32, 32, 32, 66, 66, 33, 33, 34, 34,
+	34, 67, 67, 60, 60, 61, 61, 62, 62, 63,
+	63, 64, 64, 65, 65, 46, 46, 46, 23, 23,
+	14, 14, 15, 15, 13, 13, 12, 9, 9, 76,
+	76, 78, 78, 78, 78, 75, 87, 87, 86, 86,
+	85, 85, 85, 85, 85, 73, 73, 73, 73, 77,
+	77, 77, 77, 79, 79, 79, 79, 80, 39, 39,
 	39, 39, 39, 39, 39, 39, 39, 39, 39, 39,
-	39, 39, 40, 40, 40, 40, 40, 40, 40, 40,
+	97, 97, 95, 95, 93, 93, 93, 96, 96, 94,
+	94, 94, 36, 36, 90, 90, 91, 91, 92, 92,
+	88, 88, 89, 89, 98, 98, 101, 101, 100, 100,
+	99, 99, 99, 99, 102, 102, 81, 81, 37, 37,
+	84, 84, 83, 83, 82, 82, 82, 82, 82, 82,
+	82, 82, 82, 82, 82, 72, 72, 72, 72, 72,
+	72, 72, 72, 103, 106, 106, 105, 105, 104, 104,
+	104, 104, 74, 74, 74, 74, 108, 111, 111, 110,
+	110, 109, 109, 109, 112, 112, 116, 116, 115, 115,
+	114, 114, 113, 113, 40, 40, 40, 40, 40, 40,
 	40, 40, 40, 40, 40, 40, 40, 40, 40, 40,
 	40, 40, 40, 40, 40, 40, 40, 40, 40, 40,
-	40, 40, 40, 40, 40, 40, 40, 40, 40, 40,
-	40, 40, 44, 44, 44, 44, 44, 44, 44, 44,
-	44, 44, 44, 44, 44, 44, 44, 44, 44, 44,
-	44, 44, 44, 44, 44, 44, 44, 44, 44, 44,
-	44, 44, 44, 44, 44, 44, 44, 44, 44, 44,
-	44, 44, 44, 44, 41, 41, 41, 41, 41, 41,
+	40, 40, 40, 40, 40, 40, 40, 41, 41, 41,
 	41, 41, 41, 41, 41, 41, 41, 41, 41, 41,
 	41, 41, 41, 41, 41, 41, 41, 41, 41, 41,
 	41, 41, 41, 41, 41, 41, 41, 41, 41, 41,
-	41, 41, 41, 42, 42, 42, 42, 42, 42, 42,
+	41, 41, 41, 41, 41, 41, 41, 45, 45, 45,
+	45, 45, 45, 45, 45, 45, 45, 45, 45, 45,
+	45, 45, 45, 45, 45, 45, 45, 45, 45, 45,
+	45, 45, 45, 45, 45, 45, 45, 45, 45, 45,
+	45, 45, 45, 45, 45, 45, 45, 45, 45, 42,
 	42, 42, 42, 42, 42, 42, 42, 42, 42, 42,
 	42, 42, 42, 42, 42, 42, 42, 42, 42, 42,
 	42, 42, 42, 42, 42, 42, 42, 42, 42, 42,
-	42, 42, 42, 42, 42, 42, 43, 43, 43, 43,
+	42, 42, 42, 42, 42, 42, 42, 42, 43, 43,
 	43, 43, 43, 43, 43, 43, 43, 43, 43, 43,
 	43, 43, 43, 43, 43, 43, 43, 43, 43, 43,
 	43, 43, 43, 43, 43, 43, 43, 43, 43, 43,
-	43, 43, 43, 43, 43, 43, 43, 43, 43, 37,
-	37, 37, 37, 37, 37, 37, 37, 37, 37, 37,
-	37, 37, 37, 37, 37, 37, 37, 37, 37, 37,
-	37, 37, 37, 37, 37, 37, 37, 37, 37, 37,
-	37, 37, 37, 37, 37, 37, 37, 37, 37, 37,
-	37, 37, 37,
+	43, 43, 43, 43, 43, 43, 43, 43, 43, 43,
+	43, 44, 44, 44, 44, 44, 44, 44, 44, 44,
+	44, 44, 44, 44, 44, 44, 44, 44, 44, 44,
+	44, 44, 44, 44, 44, 44, 44, 44, 44, 44,
+	44, 44, 44, 44, 44, 44, 44, 44, 44, 44,
+	44, 44, 44, 44, 38, 38, 38, 38, 38, 38,
+	38, 38, 38, 38, 38, 38, 38, 38, 38, 38,
+	38, 38, 38, 38, 38, 38, 38, 38, 38, 38,
+	38, 38, 38, 38, 38, 38, 38, 38, 38, 38,
+	38, 38, 38, 38, 38, 38, 38, 38,
 }
 
 var protoR2 = [...]int8{
 	0, 1, 1, 1, 2, 2, 0, 2, 2, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 2,
-	1, 0, 1, 0, 4, 4, 3, 4, 4, 3,
-	1, 3, 1, 2, 1, 2, 1, 1, 2, 1,
-	3, 1, 3, 1, 3, 1, 3, 1, 2, 1,
-	2, 1, 1, 2, 5, 5, 1, 1, 2, 1,
-	1, 1, 2, 1, 2, 3, 1, 1, 1, 1,
-	1, 1, 1, 2, 1, 2, 2, 2, 1, 2,
-	3, 2, 1, 1, 2, 1, 2, 2, 2, 2,
-	1, 3, 2, 3, 1, 3, 5, 3, 1, 1,
-	1, 1, 1, 2, 1, 1, 1, 1, 3, 2,
-	3, 2, 3, 1, 3, 1, 1, 3, 2, 3,
-	1, 3, 1, 2, 1, 2, 1, 2, 1, 2,
-	1, 2, 1, 2, 1, 1, 1, 3, 2, 1,
-	2, 1, 2, 1, 1, 2, 3, 1, 8, 9,
-	9, 10, 7, 8, 6, 0, 1, 2, 1, 1,
-	1, 1, 2, 1, 5, 6, 3, 4, 7, 8,
-	5, 6, 5, 6, 3, 4, 6, 1, 1, 1,
-	1, 1, 1, 1, 1, 1, 1, 1, 1, 4,
-	4, 1, 3, 1, 3, 3, 1, 3, 1, 3,
-	3, 1, 2, 4, 1, 4, 1, 3, 3, 1,
-	3, 1, 3, 6, 1, 2, 2, 1, 1, 1,
-	1, 1, 4, 5, 6, 1, 2, 2, 1, 1,
+	1, 0, 1, 0, 4, 4, 3, 4, 4, 4,
+	3, 1, 3, 1, 2, 1, 2, 1, 1, 2,
+	1, 3, 1, 3, 1, 3, 1, 3, 1, 2,
+	1, 2, 1, 1, 2, 5, 5, 1, 1, 2,
+	1, 1, 1, 2, 1, 2, 3, 1, 1, 1,
+	1, 1, 1, 1, 2, 1, 2, 2, 2, 1,
+	2, 3, 2, 1, 1, 2, 1, 2, 2, 2,
+	2, 1, 3, 2, 3, 1, 3, 5, 3, 1,
+	1, 1, 1, 1, 2, 1, 1, 1, 1, 3,
+	2, 3, 2, 3, 1, 3, 1, 1, 3, 2,
+	3, 1, 3, 1, 2, 1, 2, 1, 2, 1,
+	2, 1, 2, 1, 2, 1, 1, 1, 3, 2,
+	1, 2, 1, 2, 1, 1, 2, 3, 1, 8,
+	9, 9, 10, 7, 8, 6, 0, 1, 2, 1,
+	1, 1, 1, 2, 1, 5, 6, 3, 4, 7,
+	8, 5, 6, 5, 6, 3, 4, 6, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	6, 7, 5, 6, 4, 5, 3, 4, 6, 0,
-	1, 2, 1, 1, 1, 2, 1, 6, 7, 5,
-	6, 6, 1, 2, 2, 1, 1, 1, 1, 6,
-	9, 4, 3, 1, 2, 2, 1, 1, 1, 1,
+	4, 4, 1, 3, 1, 3, 3, 1, 3, 1,
+	3, 3, 1, 2, 4, 1, 4, 1, 3, 3,
+	1, 3, 1, 3, 6, 7, 1, 2, 2, 1,
+	1, 1, 1, 1, 4, 5, 6, 7, 1, 1,
+	1, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 6, 7, 5, 6, 4,
+	5, 3, 4, 6, 0, 1, 2, 1, 1, 1,
+	2, 1, 6, 7, 5, 6, 6, 1, 2, 2,
+	1, 1, 1, 1, 6, 9, 4, 3, 1, 2,
+	2, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
@@ -741,184 +751,185 @@ var protoR2 = [...]int8{
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1,
 }
 
 var protoChk = [...]int16{
-	-1000, -1, -2, -3, -4, 8, 9, -117, -118, 53,
-	-4, -4, 52, 52, -6, -5, -7, -8, -11, -80,
-	-97, -102, -107, 2, 10, 13, 14, 45, 44, 46,
-	47, 53, -106, 4, -106, -5, -106, 11, 12, -50,
-	-37, 7, 8, 9, 10, 11, 12, 13, 14, 15,
-	16, 17, 18, 19, 20, 21, 22, 23, 24, 25,
-	26, 27, 28, 29, 30, 31, 32, 33, 34, 35,
-	36, 37, 38, 39, 40, 41, 42, 43, 44, 45,
-	46, 47, 48, 49, 50, -21, -20, -22, -18, -19,
-	-37, -16, 66, -37, -37, -59, -56, 60, -47, -57,
-	-37, -46, -37, 53, 4, 53, -117, -106, -106, -117,
-	60, 52, -19, -20, 60, -59, 55, 55, 55, -56,
-	-47, -46, 60, 55, -117, -117, -37, -25, -26, -28,
-	-106, -30, -31, -37, 55, 6, 65, 5, 67, -83,
-	-117, -100, -117, -105, -104, -103, -73, -75, 2, -45,
-	-61, 21, 20, 19, -52, 60, -40, 7, 8, 9,
-	10, 11, 12, 13, 14, 15, 16, 17, 18, 22,
+	-1000, -1, -2, -3, -4, 8, 9, -118, -119, 55,
+	-4, -4, 54, 54, -6, -5, -7, -8, -11, -81,
+	-98, -103, -108, 2, 10, 13, 14, 45, -37, 44,
+	46, 47, 51, 52, 55, -107, 4, -107, -5, -107,
+	11, 12, 14, -51, -38, 7, 8, 9, 10, 11,
+	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
+	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
+	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, -21,
+	-20, -22, -18, -19, -38, -16, 68, -38, 44, 45,
+	-38, -60, -57, 62, -48, -58, -38, -47, -38, 55,
+	4, 55, -118, -107, -107, -107, -118, 62, 54, -19,
+	-20, 62, -60, 57, -38, -38, 57, 57, -57, -48,
+	-47, 62, 57, -118, -118, -118, -38, -25, -26, -28,
+	-107, -30, -31, -38, 57, 6, 67, 5, 69, -84,
+	-118, 57, 57, -101, -118, -106, -105, -104, -74, -76,
+	2, -46, -62, 21, 20, 19, -53, 62, -41, 7,
+	8, 9, 10, 11, 12, 13, 14, 15, 16, 17,
+	18, 22, 23, 24, 25, 26, 27, 28, 29, 30,
+	31, 32, 33, 34, 35, 36, 38, 39, 40, 41,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, -111,
+	-118, -118, -70, 58, -69, -68, -71, 2, -17, -38,
+	70, 6, 5, 17, 18, 58, -83, -82, -72, -98,
+	-81, -103, -97, -78, -11, -75, -79, -90, 2, -46,
+	-61, 40, 38, -80, 43, -92, -52, 62, 39, -40,
+	7, 8, 9, 10, 11, 12, 13, 15, 16, 17,
+	18, 22, 23, 24, 25, 26, 27, 28, 29, 30,
+	31, 32, 33, 34, 35, 36, 41, 42, 47, 48,
+	49, 50, -101, -84, 58, -100, -99, -11, -102, -91,
+	2, -45, 43, -92, 7, 8, 9, 10, 11, 12,
+	13, 15, 16, 17, 18, 19, 20, 21, 22, 23,
+	24, 25, 26, 27, 28, 29, 30, 31, 32, 33,
+	34, 35, 36, 37, 38, 39, 40, 41, 42, 44,
+	45, 46, 47, 48, 49, 50, 58, -104, 55, 37,
+	-64, -55, 62, -43, 7, 8, 9, 10, 11, 12,
+	13, 14, 15, 16, 17, 18, 19, 20, 21, 22,
 	23, 24, 25, 26, 27, 28, 29, 30, 31, 32,
 	33, 34, 35, 36, 38, 39, 40, 41, 42, 43,
-	44, 45, 46, 47, 48, 49, 50, -110, -117, -117,
-	-69, 56, -68, -67, -70, 2, -17, -37, 68, 6,
-	5, 17, 18, 56, -82, -81, -71, -97, -80, -102,
-	-96, -77, -11, -74, -78, -89, 2, -45, -60, 40,
-	38, -79, 43, -91, -51, 60, 39, -39, 7, 8,
-	9, 10, 11, 12, 13, 15, 16, 17, 18, 22,
-	23, 24, 25, 26, 27, 28, 29, 30, 31, 32,
-	33, 34, 35, 36, 41, 42, 47, 48, 49, 50,
-	56, -99, -98, -11, -101, -90, 2, -44, 43, -91,
-	7, 8, 9, 10, 11, 12, 13, 15, 16, 17,
-	18, 19, 20, 21, 22, 23, 24, 25, 26, 27,
-	28, 29, 30, 31, 32, 33, 34, 35, 36, 37,
-	38, 39, 40, 41, 42, 44, 45, 46, 47, 48,
-	49, 50, 56, -103, 53, 37, -63, -54, 60, -42,
-	7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
-	17, 18, 19, 20, 21, 22, 23, 24, 25, 26,
-	27, 28, 29, 30, 31, 32, 33, 34, 35, 36,
-	38, 39, 40, 41, 42, 43, 44, 45, 46, 47,
-	48, 49, 50, -37, 60, -50, 56, -109, -108, -11,
-	-111, 2, 48, 56, -68, 61, 53, 61, 53, 54,
-	54, -35, -29, -34, -28, 63, 68, -56, 2, -117,
-	-81, 37, -63, -37, -94, -92, 5, -37, -37, -94,
-	-87, -88, -106, -37, 60, -50, 63, -117, -98, 52,
-	-95, -93, -36, 5, 65, -117, -37, -37, 60, -50,
-	52, -37, -117, -108, -37, -24, -27, -29, -32, -106,
-	-30, 65, -37, 68, -24, -69, 62, -66, 69, 2,
-	-29, 69, 58, 69, -37, -37, 52, -117, -23, 68,
-	53, -23, 61, 41, 55, 52, -117, -23, 53, -117,
-	61, -117, 61, -37, -38, 24, 25, 26, 27, 28,
-	29, 30, 31, 32, 33, 34, 35, -36, 61, 53,
-	41, 5, 52, 52, -37, 5, -115, 66, -37, -65,
-	69, 2, -33, -27, -29, 62, 69, 61, 69, -56,
-	52, 55, -23, 52, -117, -23, 5, -117, -14, 69,
-	-13, -15, -9, -12, -21, -117, -117, -92, 5, 42,
-	-86, -85, -84, -10, -72, -76, 2, 14, -62, 37,
-	-53, 60, -41, 7, 8, 9, 10, 11, 12, 13,
-	15, 16, 17, 18, 22, 23, 24, 25, 26, 27,
-	28, 29, 30, 31, 32, 33, 34, 35, 36, 38,
-	39, 40, 41, 42, 43, 44, 45, 46, 47, 48,
-	49, 50, 5, -117, -117, -106, -37, 61, -117, -23,
-	-93, -117, -36, 42, 5, 5, -116, -23, 53, 50,
-	49, -64, -55, 60, -49, -58, -43, -48, 7, 8,
-	9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
-	19, 20, 21, 22, 23, 24, 25, 26, 27, 28,
-	29, 30, 31, 32, 33, 34, 35, 36, 37, 38,
-	39, 40, 41, 42, 43, 44, 45, 46, 47, 48,
-	50, 69, 61, 69, -29, 69, 5, -83, 55, 5,
-	-117, -117, -23, 69, -13, -12, 61, 52, 56, -84,
-	53, -21, -37, -37, 60, -50, -117, -23, -59, -117,
-	55, -23, -116, -23, -116, -115, -59, 67, -56, -49,
-	-48, 60, -33, 55, -23, 56, -83, -117, -23, -117,
-	-25, -117, 52, 52, -116, -23, 52, 55, -23, -37,
-	-117, 62, -83, 55, -116, -117, 55, 67, -83, 55,
-	-117, 56, -117, -25, 5, -116, 5, -83, 55, 56,
-	-83, -114, -117, 56, -83, -117, -116, -116, -23, 55,
-	-23, 56, -83, 56, 56, -113, -112, -11, 2, -117,
-	56, -116, -83, 55, 56, -117, -112, -117, 56, -83,
-	56,
+	44, 45, 46, 47, 48, 49, 50, -38, 62, -51,
+	58, -110, -109, -11, -112, 2, 48, 58, -69, 63,
+	55, 63, 55, 56, 56, -35, -29, -34, -28, 65,
+	70, -57, 2, -118, -82, 37, -64, -38, -95, -93,
+	5, -38, -38, -95, -88, -89, -107, -38, 62, -51,
+	65, 58, 58, -118, -99, 54, -96, -94, -36, 5,
+	67, -118, -38, -38, 62, -51, 54, -38, -118, -109,
+	-38, -24, -27, -29, -32, -107, -30, 67, -38, 70,
+	-24, -70, 64, -67, 71, 2, -29, 71, 60, 71,
+	-38, -38, 54, -118, -23, 70, 55, -23, 63, 41,
+	57, 54, -118, -23, 55, -118, 63, -118, 63, -38,
+	-39, 24, 25, 26, 27, 28, 29, 30, 31, 32,
+	33, 34, 35, -118, -118, -36, 63, 55, 41, 5,
+	54, 54, -38, 5, -116, 68, -38, -66, 71, 2,
+	-33, -27, -29, 64, 71, 63, 71, -57, 54, 57,
+	-23, 54, -118, -23, 5, -118, -14, 71, -13, -15,
+	-9, -12, -21, -118, -118, -93, 5, 42, -87, -86,
+	-85, -10, -73, -77, 2, 14, -63, 37, -54, 62,
+	-42, 7, 8, 9, 10, 11, 12, 13, 15, 16,
+	17, 18, 22, 23, 24, 25, 26, 27, 28, 29,
+	30, 31, 32, 33, 34, 35, 36, 38, 39, 40,
+	41, 42, 43, 44, 45, 46, 47, 48, 49, 50,
+	5, -118, -118, -107, -38, 63, -118, -23, -94, -118,
+	-36, 42, 5, 5, -117, -23, 55, 50, 49, -65,
+	-56, 62, -50, -59, -44, -49, 7, 8, 9, 10,
+	11, 12, 13, 14, 15, 16, 17, 18, 19, 20,
+	21, 22, 23, 24, 25, 26, 27, 28, 29, 30,
+	31, 32, 33, 34, 35, 36, 37, 38, 39, 40,
+	41, 42, 43, 44, 45, 46, 47, 48, 50, 71,
+	63, 71, -29, 71, 5, -84, 57, 5, -118, -118,
+	-23, 71, -13, -12, 63, 54, 58, -85, 55, -21,
+	-38, -38, 62, -51, -118, -23, -60, -118, 57, -23,
+	-117, -23, -117, -116, -60, 69, -57, -50, -49, 62,
+	-33, 57, -23, 58, -84, -118, -23, -118, -25, -118,
+	54, 54, -117, -23, 54, 57, -23, -38, -118, 64,
+	-84, 57, -117, -118, 57, 69, -84, 57, -118, 58,
+	-118, -25, 5, -117, 5, -84, 57, 58, -84, -115,
+	-118, 58, -84, -118, -117, -117, -23, 57, -23, 58,
+	-84, 58, 58, -114, -113, -11, 2, -118, 58, -117,
+	-84, 57, 58, -118, -113, -118, 58, -84, 58,
 }
 
 var protoDef = [...]int16{
 	-2, -2, -2, -2, 3, 0, 0, 0, 20, 18,
 	4, 5, 0, 0, -2, 9, 10, 11, 12, 13,
 	14, 15, 16, 17, 0, 0, 0, 0, 0, 0,
-	0, 19, 0, 78, 0, 8, 21, 0, 0, 21,
-	30, 519, 520, 521, 522, 523, 524, 525, 526, 527,
-	528, 529, 530, 531, 532, 533, 534, 535, 536, 537,
-	538, 539, 540, 541, 542, 543, 544, 545, 546, 547,
-	548, 549, 550, 551, 552, 553, 554, 555, 556, 557,
-	558, 559, 560, 561, 562, 0, 63, 0, 59, -2,
-	56, 57, 0, 0, 0, 0, 122, 0, 32, 0,
-	36, -2, 0, 24, 79, 25, 26, 21, 21, 29,
-	0, 0, -2, 64, 58, 0, 21, 21, -2, 123,
-	33, -2, 38, 21, 27, 28, 31, 21, 66, 67,
-	68, 69, 70, 71, 0, 72, 0, 74, 65, 0,
-	-2, 0, -2, 0, -2, 252, 253, 254, 256, 0,
-	0, 134, 135, 136, 126, 0, 41, 312, 313, 314,
-	315, 316, 317, 318, 319, 320, 321, 322, 323, 324,
-	325, 326, 327, 328, 329, 330, 331, 332, 333, 334,
-	335, 336, 337, 338, 339, 340, 341, 342, 343, 344,
-	345, 346, 347, 348, 349, 350, 351, 0, -2, 55,
-	0, 81, 82, -2, 85, 90, 0, 94, 0, 73,
-	75, 76, 77, 21, -2, 228, 229, 230, 231, 232,
-	233, 234, 235, 236, 237, 238, 239, 0, 0, 0,
-	0, 0, 0, 204, 124, 0, 305, 39, 279, 280,
-	281, 282, 283, 284, 285, 286, 287, 288, 289, 290,
-	291, 292, 293, 294, 295, 296, 297, 298, 299, 300,
-	301, 302, 303, 304, 306, 307, 308, 309, 310, 311,
-	21, -2, 217, 218, 219, 220, 221, 0, 0, 206,
-	352, 353, 354, 355, 356, 357, 358, 359, 360, 361,
-	362, 363, 364, 365, 366, 367, 368, 369, 370, 371,
-	372, 373, 374, 375, 376, 377, 378, 379, 380, 381,
-	382, 383, 384, 385, 386, 387, 388, 389, 390, 391,
-	392, 393, 21, 251, 255, 0, 0, 130, 0, 45,
-	433, 434, 435, 436, 437, 438, 439, 440, 441, 442,
-	443, 444, 445, 446, 447, 448, 449, 450, 451, 452,
-	453, 454, 455, 456, 457, 458, 459, 460, 461, 462,
-	463, 464, 465, 466, 467, 468, 469, 470, 471, 472,
-	473, 474, 475, 0, 0, 127, 21, -2, 265, 266,
-	267, 268, 0, 80, 84, 86, 87, 88, 89, 0,
-	0, 92, 105, 106, 107, 0, 0, 0, 0, 224,
-	227, 0, 0, 21, 0, 191, 193, 0, 21, 0,
-	21, 21, 209, 211, 0, 125, 0, 213, 216, 0,
-	0, 196, 198, 201, 0, 248, 0, 0, 0, 131,
-	0, 42, 261, 264, 0, 93, 98, 99, 100, 101,
-	102, 0, 104, 0, 91, 0, 109, 0, 118, 0,
-	120, 95, 0, 97, 0, 21, 0, 246, 21, 0,
-	21, 21, 0, 0, -2, 0, 174, 21, 21, 207,
-	0, 208, 0, 40, 0, 177, 178, 179, 180, 181,
-	182, 183, 184, 185, 186, 187, 188, 21, 0, 21,
-	0, 202, 0, 0, 46, 23, 0, 0, 103, 0,
-	111, 0, 113, 115, 116, 108, 117, 0, 119, 0,
-	0, 21, 0, 0, 244, 21, 21, 247, 0, 138,
-	139, 0, 143, -2, 147, 189, 190, 192, 194, 195,
-	0, -2, 158, 159, 160, 161, 163, 0, 0, 0,
-	128, 0, 43, 394, 395, 396, 397, 398, 399, 400,
-	401, 402, 403, 404, 405, 406, 407, 408, 409, 410,
-	411, 412, 413, 414, 415, 416, 417, 418, 419, 420,
-	421, 422, 423, 424, 425, 426, 427, 428, 429, 430,
-	431, 432, 21, 175, 203, 210, 212, 0, 222, 21,
-	197, 205, 199, 200, 0, 23, 259, 23, 22, 0,
-	0, 0, 132, 0, 47, 0, 51, -2, 476, 477,
-	478, 479, 480, 481, 482, 483, 484, 485, 486, 487,
-	488, 489, 490, 491, 492, 493, 494, 495, 496, 497,
-	498, 499, 500, 501, 502, 503, 504, 505, 506, 507,
-	508, 509, 510, 511, 512, 513, 514, 515, 516, 517,
-	518, 110, 0, 112, 121, 96, 0, 0, 21, 21,
-	245, 242, 21, 137, 140, -2, 145, 0, 21, 157,
-	162, 0, 23, 0, 0, 129, 172, 21, 0, 223,
-	21, 0, 257, 23, 260, 21, 0, 272, 133, 48,
-	-2, 53, 114, 21, 0, 21, 0, 240, 21, 243,
-	146, 154, 0, 0, 166, 23, 0, 21, 0, 44,
-	173, 176, 0, 21, 258, 269, 21, 271, 0, 21,
-	152, 21, 241, 23, 23, 167, 0, 0, 21, 148,
-	0, 0, -2, 21, 0, 153, 54, 164, 23, 21,
-	0, 170, 0, 149, 21, -2, 276, 277, 278, 150,
-	21, 165, 0, 21, 171, 270, 275, 151, 168, 0,
-	169,
+	0, 0, 228, 229, 19, 0, 79, 0, 8, 21,
+	0, 0, 0, 21, 31, 524, 525, 526, 527, 528,
+	529, 530, 531, 532, 533, 534, 535, 536, 537, 538,
+	539, 540, 541, 542, 543, 544, 545, 546, 547, 548,
+	549, 550, 551, 552, 553, 554, 555, 556, 557, 558,
+	559, 560, 561, 562, 563, 564, 565, 566, 567, 0,
+	64, 0, 60, -2, 57, 58, 0, 0, 0, 0,
+	0, 0, 123, 0, 33, 0, 37, -2, 0, 24,
+	80, 25, 26, 21, 21, 21, 30, 0, 0, -2,
+	65, 59, 0, 21, 0, 0, 21, -2, 124, 34,
+	-2, 39, 21, 27, 28, 29, 32, 21, 67, 68,
+	69, 70, 71, 72, 0, 73, 0, 75, 66, 0,
+	-2, 21, 21, 0, -2, 0, -2, 257, 258, 259,
+	261, 0, 0, 135, 136, 137, 127, 0, 42, 317,
+	318, 319, 320, 321, 322, 323, 324, 325, 326, 327,
+	328, 329, 330, 331, 332, 333, 334, 335, 336, 337,
+	338, 339, 340, 341, 342, 343, 344, 345, 346, 347,
+	348, 349, 350, 351, 352, 353, 354, 355, 356, 0,
+	-2, 56, 0, 82, 83, -2, 86, 91, 0, 95,
+	0, 74, 76, 77, 78, 21, -2, 233, 234, 235,
+	236, 237, 238, 239, 240, 241, 242, 243, 244, 0,
+	0, 0, 0, 0, 0, 205, 125, 0, 310, 40,
+	284, 285, 286, 287, 288, 289, 290, 291, 292, 293,
+	294, 295, 296, 297, 298, 299, 300, 301, 302, 303,
+	304, 305, 306, 307, 308, 309, 311, 312, 313, 314,
+	315, 316, 0, 0, 21, -2, 219, 220, 221, 222,
+	223, 0, 0, 207, 357, 358, 359, 360, 361, 362,
+	363, 364, 365, 366, 367, 368, 369, 370, 371, 372,
+	373, 374, 375, 376, 377, 378, 379, 380, 381, 382,
+	383, 384, 385, 386, 387, 388, 389, 390, 391, 392,
+	393, 394, 395, 396, 397, 398, 21, 256, 260, 0,
+	0, 131, 0, 46, 438, 439, 440, 441, 442, 443,
+	444, 445, 446, 447, 448, 449, 450, 451, 452, 453,
+	454, 455, 456, 457, 458, 459, 460, 461, 462, 463,
+	464, 465, 466, 467, 468, 469, 470, 471, 472, 473,
+	474, 475, 476, 477, 478, 479, 480, 0, 0, 128,
+	21, -2, 270, 271, 272, 273, 0, 81, 85, 87,
+	88, 89, 90, 0, 0, 93, 106, 107, 108, 0,
+	0, 0, 0, 226, 232, 0, 0, 21, 0, 192,
+	194, 0, 21, 0, 21, 21, 210, 212, 0, 126,
+	0, 21, 21, 214, 218, 0, 0, 197, 199, 202,
+	0, 253, 0, 0, 0, 132, 0, 43, 266, 269,
+	0, 94, 99, 100, 101, 102, 103, 0, 105, 0,
+	92, 0, 110, 0, 119, 0, 121, 96, 0, 98,
+	0, 21, 0, 251, 21, 0, 21, 21, 0, 0,
+	-2, 0, 175, 21, 21, 208, 0, 209, 0, 41,
+	0, 178, 179, 180, 181, 182, 183, 184, 185, 186,
+	187, 188, 189, 215, 227, 21, 0, 21, 0, 203,
+	0, 0, 47, 23, 0, 0, 104, 0, 112, 0,
+	114, 116, 117, 109, 118, 0, 120, 0, 0, 21,
+	0, 0, 249, 21, 21, 252, 0, 139, 140, 0,
+	144, -2, 148, 190, 191, 193, 195, 196, 0, -2,
+	159, 160, 161, 162, 164, 0, 0, 0, 129, 0,
+	44, 399, 400, 401, 402, 403, 404, 405, 406, 407,
+	408, 409, 410, 411, 412, 413, 414, 415, 416, 417,
+	418, 419, 420, 421, 422, 423, 424, 425, 426, 427,
+	428, 429, 430, 431, 432, 433, 434, 435, 436, 437,
+	21, 176, 204, 211, 213, 0, 224, 21, 198, 206,
+	200, 201, 0, 23, 264, 23, 22, 0, 0, 0,
+	133, 0, 48, 0, 52, -2, 481, 482, 483, 484,
+	485, 486, 487, 488, 489, 490, 491, 492, 493, 494,
+	495, 496, 497, 498, 499, 500, 501, 502, 503, 504,
+	505, 506, 507, 508, 509, 510, 511, 512, 513, 514,
+	515, 516, 517, 518, 519, 520, 521, 522, 523, 111,
+	0, 113, 122, 97, 0, 0, 21, 21, 250, 247,
+	21, 138, 141, -2, 146, 0, 21, 158, 163, 0,
+	23, 0, 0, 130, 173, 21, 0, 225, 21, 0,
+	262, 23, 265, 21, 0, 277, 134, 49, -2, 54,
+	115, 21, 0, 21, 0, 245, 21, 248, 147, 155,
+	0, 0, 167, 23, 0, 21, 0, 45, 174, 177,
+	0, 21, 263, 274, 21, 276, 0, 21, 153, 21,
+	246, 23, 23, 168, 0, 0, 21, 149, 0, 0,
+	-2, 21, 0, 154, 55, 165, 23, 21, 0, 171,
+	0, 150, 21, -2, 281, 282, 283, 151, 21, 166,
+	0, 21, 172, 275, 280, 152, 169, 0, 170,
 }
 
 var protoTok1 = [...]int8{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 77, 3, 75, 74, 73, 71, 3,
-	66, 67, 70, 64, 61, 65, 60, 58, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 54, 53,
-	63, 52, 62, 59, 76, 3, 3, 3, 3, 3,
+	3, 3, 3, 79, 3, 77, 76, 75, 73, 3,
+	68, 69, 72, 66, 63, 67, 62, 60, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 56, 55,
+	65, 54, 64, 61, 78, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 68, 57, 69, 72, 3, 79, 3, 3, 3,
+	3, 70, 59, 71, 74, 3, 81, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 55, 3, 56, 78,
+	3, 3, 3, 57, 3, 58, 80,
 }
 
 var protoTok2 = [...]int8{
@@ -927,6 +938,7 @@ var protoTok2 = [...]int8{
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
 	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
+	52, 53,
 }
 
 var protoTok3 = [...]int8{
@@ -1425,245 +1437,251 @@ protodefault:
 			protoVAL.imprt = newNodeWithRunes(ast.NewImportNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id.ToKeyword(), nil, toStringValueNode(protoDollar[3].str), semi), extra...)
 		}
 	case 29:
+		protoDollar = protoS[protopt-4 : protopt+1]
+		{
+			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[4].bs)
+			protoVAL.imprt = newNodeWithRunes(ast.NewOptionImportNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id.ToKeyword(), toStringValueNode(protoDollar[3].str), semi), extra...)
+		}
+	case 30:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[3].bs)
 			protoVAL.pkg = newNodeWithRunes(ast.NewPackageNode(protoDollar[1].id.ToKeyword(), protoDollar[2].cid.toIdentValueNode(nil), semi), extra...)
 		}
-	case 30:
+	case 31:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cid = &identSlices{idents: []*ast.IdentNode{protoDollar[1].id}}
 		}
-	case 31:
+	case 32:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].cid.idents = append(protoDollar[1].cid.idents, protoDollar[3].id)
 			protoDollar[1].cid.dots = append(protoDollar[1].cid.dots, protoDollar[2].b)
 			protoVAL.cid = protoDollar[1].cid
 		}
-	case 32:
+	case 33:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cid = &identSlices{idents: []*ast.IdentNode{protoDollar[1].cidPart.Node}, dots: protoDollar[1].cidPart.Runes}
 		}
-	case 33:
+	case 34:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoDollar[1].cid.idents = append(protoDollar[1].cid.idents, protoDollar[2].cidPart.Node)
 			protoDollar[1].cid.dots = append(protoDollar[1].cid.dots, protoDollar[2].cidPart.Runes...)
 			protoVAL.cid = protoDollar[1].cid
 		}
-	case 34:
+	case 35:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cid = &identSlices{idents: []*ast.IdentNode{protoDollar[1].cidPart.Node}, dots: protoDollar[1].cidPart.Runes}
 		}
-	case 35:
+	case 36:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoDollar[1].cid.idents = append(protoDollar[1].cid.idents, protoDollar[2].cidPart.Node)
 			protoDollar[1].cid.dots = append(protoDollar[1].cid.dots, protoDollar[2].cidPart.Runes...)
 			protoVAL.cid = protoDollar[1].cid
 		}
-	case 36:
+	case 37:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cidPart = newNodeWithRunes(protoDollar[1].id)
 		}
-	case 37:
+	case 38:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protolex.(*protoLex).Error("syntax error: unexpected '.'")
 			protoVAL.cidPart = protoDollar[1].cidPart
 		}
-	case 38:
+	case 39:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.cidPart = newNodeWithRunes(protoDollar[1].id, protoDollar[2].b)
 		}
-	case 39:
+	case 40:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cid = &identSlices{idents: []*ast.IdentNode{protoDollar[1].id}}
 		}
-	case 40:
+	case 41:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].cid.idents = append(protoDollar[1].cid.idents, protoDollar[3].id)
 			protoDollar[1].cid.dots = append(protoDollar[1].cid.dots, protoDollar[2].b)
 			protoVAL.cid = protoDollar[1].cid
 		}
-	case 41:
+	case 42:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cid = &identSlices{idents: []*ast.IdentNode{protoDollar[1].id}}
 		}
-	case 42:
+	case 43:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].cid.idents = append(protoDollar[1].cid.idents, protoDollar[3].id)
 			protoDollar[1].cid.dots = append(protoDollar[1].cid.dots, protoDollar[2].b)
 			protoVAL.cid = protoDollar[1].cid
 		}
-	case 43:
+	case 44:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cid = &identSlices{idents: []*ast.IdentNode{protoDollar[1].id}}
 		}
-	case 44:
+	case 45:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].cid.idents = append(protoDollar[1].cid.idents, protoDollar[3].id)
 			protoDollar[1].cid.dots = append(protoDollar[1].cid.dots, protoDollar[2].b)
 			protoVAL.cid = protoDollar[1].cid
 		}
-	case 45:
+	case 46:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cid = &identSlices{idents: []*ast.IdentNode{protoDollar[1].id}}
 		}
-	case 46:
+	case 47:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].cid.idents = append(protoDollar[1].cid.idents, protoDollar[3].id)
 			protoDollar[1].cid.dots = append(protoDollar[1].cid.dots, protoDollar[2].b)
 			protoVAL.cid = protoDollar[1].cid
 		}
-	case 47:
+	case 48:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cid = &identSlices{idents: []*ast.IdentNode{protoDollar[1].cidPart.Node}, dots: protoDollar[1].cidPart.Runes}
 		}
-	case 48:
+	case 49:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoDollar[1].cid.idents = append(protoDollar[1].cid.idents, protoDollar[2].cidPart.Node)
 			protoDollar[1].cid.dots = append(protoDollar[1].cid.dots, protoDollar[2].cidPart.Runes...)
 			protoVAL.cid = protoDollar[1].cid
 		}
-	case 49:
+	case 50:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cid = &identSlices{idents: []*ast.IdentNode{protoDollar[1].cidPart.Node}, dots: protoDollar[1].cidPart.Runes}
 		}
-	case 50:
+	case 51:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoDollar[1].cid.idents = append(protoDollar[1].cid.idents, protoDollar[2].cidPart.Node)
 			protoDollar[1].cid.dots = append(protoDollar[1].cid.dots, protoDollar[2].cidPart.Runes...)
 			protoVAL.cid = protoDollar[1].cid
 		}
-	case 51:
+	case 52:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.cidPart = newNodeWithRunes(protoDollar[1].id)
 		}
-	case 52:
+	case 53:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protolex.(*protoLex).Error("syntax error: unexpected '.'")
 			protoVAL.cidPart = protoDollar[1].cidPart
 		}
-	case 53:
+	case 54:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.cidPart = newNodeWithRunes(protoDollar[1].id, protoDollar[2].b)
 		}
-	case 54:
+	case 55:
 		protoDollar = protoS[protopt-5 : protopt+1]
 		{
 			optName := ast.NewOptionNameNode(protoDollar[2].optNms.refs, protoDollar[2].optNms.dots)
 			protoVAL.optRaw = ast.NewOptionNode(protoDollar[1].id.ToKeyword(), optName, protoDollar[3].b, protoDollar[4].v, protoDollar[5].b)
 		}
-	case 55:
+	case 56:
 		protoDollar = protoS[protopt-5 : protopt+1]
 		{
 			optName := ast.NewOptionNameNode(protoDollar[2].optNms.refs, protoDollar[2].optNms.dots)
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[5].bs)
 			protoVAL.opt = newNodeWithRunes(ast.NewOptionNode(protoDollar[1].id.ToKeyword(), optName, protoDollar[3].b, protoDollar[4].v, semi), extra...)
 		}
-	case 56:
+	case 57:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.refRaw = ast.NewFieldReferenceNode(protoDollar[1].id)
 		}
-	case 57:
+	case 58:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.refRaw = protoDollar[1].refRaw
 		}
-	case 58:
+	case 59:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.ref = newNodeWithRunes(protoDollar[1].refRaw, protoDollar[2].b)
 		}
-	case 59:
+	case 60:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.ref = newNodeWithRunes(protoDollar[1].refRaw)
 		}
-	case 60:
+	case 61:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protolex.(*protoLex).Error("syntax error: unexpected '.'")
 			protoVAL.ref = protoDollar[1].ref
 		}
-	case 61:
+	case 62:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.optNms = &fieldRefSlices{refs: []*ast.FieldReferenceNode{protoDollar[1].ref.Node}, dots: protoDollar[1].ref.Runes}
 		}
-	case 62:
+	case 63:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoDollar[1].optNms.refs = append(protoDollar[1].optNms.refs, protoDollar[2].ref.Node)
 			protoDollar[1].optNms.dots = append(protoDollar[1].optNms.dots, protoDollar[2].ref.Runes...)
 			protoVAL.optNms = protoDollar[1].optNms
 		}
-	case 63:
+	case 64:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.optNms = &fieldRefSlices{refs: []*ast.FieldReferenceNode{protoDollar[1].ref.Node}, dots: protoDollar[1].ref.Runes}
 		}
-	case 64:
+	case 65:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoDollar[1].optNms.refs = append(protoDollar[1].optNms.refs, protoDollar[2].ref.Node)
 			protoDollar[1].optNms.dots = append(protoDollar[1].optNms.dots, protoDollar[2].ref.Runes...)
 			protoVAL.optNms = protoDollar[1].optNms
 		}
-	case 65:
+	case 66:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.refRaw = ast.NewExtensionFieldReferenceNode(protoDollar[1].b, protoDollar[2].tid, protoDollar[3].b)
 		}
-	case 68:
+	case 69:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.v = toStringValueNode(protoDollar[1].str)
 		}
-	case 71:
+	case 72:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.v = protoDollar[1].id
 		}
-	case 72:
+	case 73:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.v = protoDollar[1].f
 		}
-	case 73:
+	case 74:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.v = ast.NewSignedFloatLiteralNode(protoDollar[1].b, protoDollar[2].f)
 		}
-	case 74:
+	case 75:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.v = protoDollar[1].i
 		}
-	case 75:
+	case 76:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			if protoDollar[2].i.Val > math.MaxInt64+1 {
@@ -1673,29 +1691,29 @@ protodefault:
 				protoVAL.v = ast.NewNegativeIntLiteralNode(protoDollar[1].b, protoDollar[2].i)
 			}
 		}
-	case 76:
+	case 77:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			f := ast.NewSpecialFloatLiteralNode(protoDollar[2].id.ToKeyword())
 			protoVAL.v = ast.NewSignedFloatLiteralNode(protoDollar[1].b, f)
 		}
-	case 77:
+	case 78:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			f := ast.NewSpecialFloatLiteralNode(protoDollar[2].id.ToKeyword())
 			protoVAL.v = ast.NewSignedFloatLiteralNode(protoDollar[1].b, f)
 		}
-	case 78:
+	case 79:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.str = []*ast.StringLiteralNode{protoDollar[1].s}
 		}
-	case 79:
+	case 80:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.str = append(protoDollar[1].str, protoDollar[2].s)
 		}
-	case 80:
+	case 81:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			if protoDollar[2].msgLitFlds == nil {
@@ -1705,12 +1723,12 @@ protodefault:
 				protoVAL.v = ast.NewMessageLiteralNode(protoDollar[1].b, fields, delimiters, protoDollar[3].b)
 			}
 		}
-	case 81:
+	case 82:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.v = ast.NewMessageLiteralNode(protoDollar[1].b, nil, nil, protoDollar[2].b)
 		}
-	case 84:
+	case 85:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			if protoDollar[1].msgLitFlds != nil {
@@ -1720,7 +1738,7 @@ protodefault:
 				protoVAL.msgLitFlds = protoDollar[2].msgLitFlds
 			}
 		}
-	case 85:
+	case 86:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			if protoDollar[1].msgLitFld != nil {
@@ -1729,7 +1747,7 @@ protodefault:
 				protoVAL.msgLitFlds = nil
 			}
 		}
-	case 86:
+	case 87:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			if protoDollar[1].msgLitFld != nil {
@@ -1738,7 +1756,7 @@ protodefault:
 				protoVAL.msgLitFlds = nil
 			}
 		}
-	case 87:
+	case 88:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			if protoDollar[1].msgLitFld != nil {
@@ -1747,22 +1765,22 @@ protodefault:
 				protoVAL.msgLitFlds = nil
 			}
 		}
-	case 88:
+	case 89:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.msgLitFlds = nil
 		}
-	case 89:
+	case 90:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.msgLitFlds = nil
 		}
-	case 90:
+	case 91:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgLitFlds = nil
 		}
-	case 91:
+	case 92:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			if protoDollar[1].refRaw != nil && protoDollar[2].b != nil {
@@ -1771,7 +1789,7 @@ protodefault:
 				protoVAL.msgLitFld = nil
 			}
 		}
-	case 92:
+	case 93:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			if protoDollar[1].refRaw != nil && protoDollar[2].v != nil {
@@ -1780,37 +1798,37 @@ protodefault:
 				protoVAL.msgLitFld = nil
 			}
 		}
-	case 93:
+	case 94:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.msgLitFld = nil
 		}
-	case 94:
+	case 95:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.refRaw = ast.NewFieldReferenceNode(protoDollar[1].id)
 		}
-	case 95:
+	case 96:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.refRaw = ast.NewExtensionFieldReferenceNode(protoDollar[1].b, protoDollar[2].cid.toIdentValueNode(nil), protoDollar[3].b)
 		}
-	case 96:
+	case 97:
 		protoDollar = protoS[protopt-5 : protopt+1]
 		{
 			protoVAL.refRaw = ast.NewAnyTypeReferenceNode(protoDollar[1].b, protoDollar[2].cid.toIdentValueNode(nil), protoDollar[3].b, protoDollar[4].cid.toIdentValueNode(nil), protoDollar[5].b)
 		}
-	case 97:
+	case 98:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.refRaw = nil
 		}
-	case 101:
+	case 102:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.v = toStringValueNode(protoDollar[1].str)
 		}
-	case 103:
+	case 104:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			kw := protoDollar[2].id.ToKeyword()
@@ -1825,12 +1843,12 @@ protodefault:
 			f := ast.NewSpecialFloatLiteralNode(kw)
 			protoVAL.v = ast.NewSignedFloatLiteralNode(protoDollar[1].b, f)
 		}
-	case 104:
+	case 105:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.v = protoDollar[1].id
 		}
-	case 108:
+	case 109:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			if protoDollar[2].msgLitFlds == nil {
@@ -1840,12 +1858,12 @@ protodefault:
 				protoVAL.v = ast.NewMessageLiteralNode(protoDollar[1].b, fields, delimiters, protoDollar[3].b)
 			}
 		}
-	case 109:
+	case 110:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.v = ast.NewMessageLiteralNode(protoDollar[1].b, nil, nil, protoDollar[2].b)
 		}
-	case 110:
+	case 111:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			if protoDollar[2].sl == nil {
@@ -1854,29 +1872,29 @@ protodefault:
 				protoVAL.v = ast.NewArrayLiteralNode(protoDollar[1].b, protoDollar[2].sl.vals, protoDollar[2].sl.commas, protoDollar[3].b)
 			}
 		}
-	case 111:
+	case 112:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.v = ast.NewArrayLiteralNode(protoDollar[1].b, nil, nil, protoDollar[2].b)
 		}
-	case 112:
+	case 113:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.v = ast.NewArrayLiteralNode(protoDollar[1].b, nil, nil, protoDollar[3].b)
 		}
-	case 113:
+	case 114:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.sl = &valueSlices{vals: []ast.ValueNode{protoDollar[1].v}}
 		}
-	case 114:
+	case 115:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].sl.vals = append(protoDollar[1].sl.vals, protoDollar[3].v)
 			protoDollar[1].sl.commas = append(protoDollar[1].sl.commas, protoDollar[2].b)
 			protoVAL.sl = protoDollar[1].sl
 		}
-	case 117:
+	case 118:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			if protoDollar[2].sl == nil {
@@ -1885,193 +1903,193 @@ protodefault:
 				protoVAL.v = ast.NewArrayLiteralNode(protoDollar[1].b, protoDollar[2].sl.vals, protoDollar[2].sl.commas, protoDollar[3].b)
 			}
 		}
-	case 118:
+	case 119:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.v = ast.NewArrayLiteralNode(protoDollar[1].b, nil, nil, protoDollar[2].b)
 		}
-	case 119:
+	case 120:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.v = ast.NewArrayLiteralNode(protoDollar[1].b, nil, nil, protoDollar[3].b)
 		}
-	case 120:
+	case 121:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.sl = &valueSlices{vals: []ast.ValueNode{protoDollar[1].v}}
 		}
-	case 121:
+	case 122:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].sl.vals = append(protoDollar[1].sl.vals, protoDollar[3].v)
 			protoDollar[1].sl.commas = append(protoDollar[1].sl.commas, protoDollar[2].b)
 			protoVAL.sl = protoDollar[1].sl
 		}
-	case 122:
+	case 123:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[1].cid.toIdentValueNode(nil)
 		}
-	case 123:
+	case 124:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[2].cid.toIdentValueNode(protoDollar[1].b)
 		}
-	case 124:
+	case 125:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[1].cid.toIdentValueNode(nil)
 		}
-	case 125:
+	case 126:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[2].cid.toIdentValueNode(protoDollar[1].b)
 		}
-	case 126:
+	case 127:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[1].cid.toIdentValueNode(nil)
 		}
-	case 127:
+	case 128:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[2].cid.toIdentValueNode(protoDollar[1].b)
 		}
-	case 128:
+	case 129:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[1].cid.toIdentValueNode(nil)
 		}
-	case 129:
+	case 130:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[2].cid.toIdentValueNode(protoDollar[1].b)
 		}
-	case 130:
+	case 131:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[1].cid.toIdentValueNode(nil)
 		}
-	case 131:
+	case 132:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[2].cid.toIdentValueNode(protoDollar[1].b)
 		}
-	case 132:
+	case 133:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[1].cid.toIdentValueNode(nil)
 		}
-	case 133:
+	case 134:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.tid = protoDollar[2].cid.toIdentValueNode(protoDollar[1].b)
 		}
-	case 137:
+	case 138:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.cmpctOpts = ast.NewCompactOptionsNode(protoDollar[1].b, protoDollar[2].opts.options, protoDollar[2].opts.commas, protoDollar[3].b)
 		}
-	case 138:
+	case 139:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protolex.(*protoLex).Error("compact options must have at least one option")
 			protoVAL.cmpctOpts = ast.NewCompactOptionsNode(protoDollar[1].b, nil, nil, protoDollar[2].b)
 		}
-	case 139:
+	case 140:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.opts = &compactOptionSlices{options: []*ast.OptionNode{protoDollar[1].opt.Node}, commas: protoDollar[1].opt.Runes}
 		}
-	case 140:
+	case 141:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoDollar[1].opts.options = append(protoDollar[1].opts.options, protoDollar[2].opt.Node)
 			protoDollar[1].opts.commas = append(protoDollar[1].opts.commas, protoDollar[2].opt.Runes...)
 			protoVAL.opts = protoDollar[1].opts
 		}
-	case 141:
+	case 142:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.opts = &compactOptionSlices{options: []*ast.OptionNode{protoDollar[1].opt.Node}, commas: protoDollar[1].opt.Runes}
 		}
-	case 142:
+	case 143:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoDollar[1].opts.options = append(protoDollar[1].opts.options, protoDollar[2].opt.Node)
 			protoDollar[1].opts.commas = append(protoDollar[1].opts.commas, protoDollar[2].opt.Runes...)
 			protoVAL.opts = protoDollar[1].opts
 		}
-	case 143:
+	case 144:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.opt = newNodeWithRunes(protoDollar[1].optRaw)
 		}
-	case 144:
+	case 145:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protolex.(*protoLex).Error("syntax error: unexpected ','")
 			protoVAL.opt = protoDollar[1].opt
 		}
-	case 145:
+	case 146:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.opt = newNodeWithRunes(protoDollar[1].optRaw, protoDollar[2].b)
 		}
-	case 146:
+	case 147:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			optName := ast.NewOptionNameNode(protoDollar[1].optNms.refs, protoDollar[1].optNms.dots)
 			protoVAL.optRaw = ast.NewCompactOptionNode(optName, protoDollar[2].b, protoDollar[3].v)
 		}
-	case 147:
+	case 148:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			optName := ast.NewOptionNameNode(protoDollar[1].optNms.refs, protoDollar[1].optNms.dots)
 			protolex.(*protoLex).Error("compact option must have a value")
 			protoVAL.optRaw = ast.NewCompactOptionNode(optName, nil, nil)
 		}
-	case 148:
+	case 149:
 		protoDollar = protoS[protopt-8 : protopt+1]
 		{
 			protoVAL.grp = ast.NewGroupNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id.ToKeyword(), protoDollar[3].id, protoDollar[4].b, protoDollar[5].i, nil, protoDollar[6].b, protoDollar[7].msgElements, protoDollar[8].b)
 		}
-	case 149:
+	case 150:
 		protoDollar = protoS[protopt-9 : protopt+1]
 		{
 			protoVAL.grp = ast.NewGroupNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id.ToKeyword(), protoDollar[3].id, protoDollar[4].b, protoDollar[5].i, protoDollar[6].cmpctOpts, protoDollar[7].b, protoDollar[8].msgElements, protoDollar[9].b)
 		}
-	case 150:
+	case 151:
 		protoDollar = protoS[protopt-9 : protopt+1]
 		{
 			protoVAL.msgGrp = newNodeWithRunes(ast.NewGroupNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id.ToKeyword(), protoDollar[3].id, protoDollar[4].b, protoDollar[5].i, nil, protoDollar[6].b, protoDollar[7].msgElements, protoDollar[8].b), protoDollar[9].bs...)
 		}
-	case 151:
+	case 152:
 		protoDollar = protoS[protopt-10 : protopt+1]
 		{
 			protoVAL.msgGrp = newNodeWithRunes(ast.NewGroupNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id.ToKeyword(), protoDollar[3].id, protoDollar[4].b, protoDollar[5].i, protoDollar[6].cmpctOpts, protoDollar[7].b, protoDollar[8].msgElements, protoDollar[9].b), protoDollar[10].bs...)
 		}
-	case 152:
+	case 153:
 		protoDollar = protoS[protopt-7 : protopt+1]
 		{
 			protoVAL.msgGrp = newNodeWithRunes(ast.NewGroupNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id.ToKeyword(), protoDollar[3].id, nil, nil, nil, protoDollar[4].b, protoDollar[5].msgElements, protoDollar[6].b), protoDollar[7].bs...)
 		}
-	case 153:
+	case 154:
 		protoDollar = protoS[protopt-8 : protopt+1]
 		{
 			protoVAL.msgGrp = newNodeWithRunes(ast.NewGroupNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id.ToKeyword(), protoDollar[3].id, nil, nil, protoDollar[4].cmpctOpts, protoDollar[5].b, protoDollar[6].msgElements, protoDollar[7].b), protoDollar[8].bs...)
 		}
-	case 154:
+	case 155:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			protoVAL.oo = newNodeWithRunes(ast.NewOneofNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id, protoDollar[3].b, protoDollar[4].ooElements, protoDollar[5].b), protoDollar[6].bs...)
 		}
-	case 155:
+	case 156:
 		protoDollar = protoS[protopt-0 : protopt+1]
 		{
 			protoVAL.ooElements = nil
 		}
-	case 157:
+	case 158:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			if protoDollar[2].ooElement != nil {
@@ -2080,7 +2098,7 @@ protodefault:
 				protoVAL.ooElements = protoDollar[1].ooElements
 			}
 		}
-	case 158:
+	case 159:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			if protoDollar[1].ooElement != nil {
@@ -2089,423 +2107,433 @@ protodefault:
 				protoVAL.ooElements = nil
 			}
 		}
-	case 159:
+	case 160:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.ooElement = protoDollar[1].optRaw
 		}
-	case 160:
+	case 161:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.ooElement = protoDollar[1].fld
 		}
-	case 161:
+	case 162:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.ooElement = protoDollar[1].grp
 		}
-	case 162:
+	case 163:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.ooElement = nil
 		}
-	case 163:
+	case 164:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.ooElement = nil
 		}
-	case 164:
+	case 165:
 		protoDollar = protoS[protopt-5 : protopt+1]
 		{
 			protoVAL.fld = ast.NewFieldNode(nil, protoDollar[1].tid, protoDollar[2].id, protoDollar[3].b, protoDollar[4].i, nil, protoDollar[5].b)
 		}
-	case 165:
+	case 166:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			protoVAL.fld = ast.NewFieldNode(nil, protoDollar[1].tid, protoDollar[2].id, protoDollar[3].b, protoDollar[4].i, protoDollar[5].cmpctOpts, protoDollar[6].b)
 		}
-	case 166:
+	case 167:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.fld = ast.NewFieldNode(nil, protoDollar[1].tid, protoDollar[2].id, nil, nil, nil, protoDollar[3].b)
 		}
-	case 167:
+	case 168:
 		protoDollar = protoS[protopt-4 : protopt+1]
 		{
 			protoVAL.fld = ast.NewFieldNode(nil, protoDollar[1].tid, protoDollar[2].id, nil, nil, protoDollar[3].cmpctOpts, protoDollar[4].b)
 		}
-	case 168:
+	case 169:
 		protoDollar = protoS[protopt-7 : protopt+1]
 		{
 			protoVAL.grp = ast.NewGroupNode(nil, protoDollar[1].id.ToKeyword(), protoDollar[2].id, protoDollar[3].b, protoDollar[4].i, nil, protoDollar[5].b, protoDollar[6].msgElements, protoDollar[7].b)
 		}
-	case 169:
+	case 170:
 		protoDollar = protoS[protopt-8 : protopt+1]
 		{
 			protoVAL.grp = ast.NewGroupNode(nil, protoDollar[1].id.ToKeyword(), protoDollar[2].id, protoDollar[3].b, protoDollar[4].i, protoDollar[5].cmpctOpts, protoDollar[6].b, protoDollar[7].msgElements, protoDollar[8].b)
 		}
-	case 170:
+	case 171:
 		protoDollar = protoS[protopt-5 : protopt+1]
 		{
 			protoVAL.grp = ast.NewGroupNode(nil, protoDollar[1].id.ToKeyword(), protoDollar[2].id, nil, nil, nil, protoDollar[3].b, protoDollar[4].msgElements, protoDollar[5].b)
 		}
-	case 171:
+	case 172:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			protoVAL.grp = ast.NewGroupNode(nil, protoDollar[1].id.ToKeyword(), protoDollar[2].id, nil, nil, protoDollar[3].cmpctOpts, protoDollar[4].b, protoDollar[5].msgElements, protoDollar[6].b)
 		}
-	case 172:
+	case 173:
 		protoDollar = protoS[protopt-5 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[5].bs)
 			protoVAL.mapFld = newNodeWithRunes(ast.NewMapFieldNode(protoDollar[1].mapType, protoDollar[2].id, protoDollar[3].b, protoDollar[4].i, nil, semi), extra...)
 		}
-	case 173:
+	case 174:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[6].bs)
 			protoVAL.mapFld = newNodeWithRunes(ast.NewMapFieldNode(protoDollar[1].mapType, protoDollar[2].id, protoDollar[3].b, protoDollar[4].i, protoDollar[5].cmpctOpts, semi), extra...)
 		}
-	case 174:
+	case 175:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[3].bs)
 			protoVAL.mapFld = newNodeWithRunes(ast.NewMapFieldNode(protoDollar[1].mapType, protoDollar[2].id, nil, nil, nil, semi), extra...)
 		}
-	case 175:
+	case 176:
 		protoDollar = protoS[protopt-4 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[4].bs)
 			protoVAL.mapFld = newNodeWithRunes(ast.NewMapFieldNode(protoDollar[1].mapType, protoDollar[2].id, nil, nil, protoDollar[3].cmpctOpts, semi), extra...)
 		}
-	case 176:
+	case 177:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			protoVAL.mapType = ast.NewMapTypeNode(protoDollar[1].id.ToKeyword(), protoDollar[2].b, protoDollar[3].id, protoDollar[4].b, protoDollar[5].tid, protoDollar[6].b)
 		}
-	case 189:
+	case 190:
 		protoDollar = protoS[protopt-4 : protopt+1]
 		{
 			// TODO: Tolerate a missing semicolon here. This currnelty creates a shift/reduce conflict
 			// between `extensions 1 to 10` and `extensions 1` followed by `to = 10`.
 			protoVAL.ext = newNodeWithRunes(ast.NewExtensionRangeNode(protoDollar[1].id.ToKeyword(), protoDollar[2].rngs.ranges, protoDollar[2].rngs.commas, nil, protoDollar[3].b), protoDollar[4].bs...)
 		}
-	case 190:
+	case 191:
 		protoDollar = protoS[protopt-4 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[4].bs)
 			protoVAL.ext = newNodeWithRunes(ast.NewExtensionRangeNode(protoDollar[1].id.ToKeyword(), protoDollar[2].rngs.ranges, protoDollar[2].rngs.commas, protoDollar[3].cmpctOpts, semi), extra...)
 		}
-	case 191:
+	case 192:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.rngs = &rangeSlices{ranges: []*ast.RangeNode{protoDollar[1].rng}}
 		}
-	case 192:
+	case 193:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].rngs.ranges = append(protoDollar[1].rngs.ranges, protoDollar[3].rng)
 			protoDollar[1].rngs.commas = append(protoDollar[1].rngs.commas, protoDollar[2].b)
 			protoVAL.rngs = protoDollar[1].rngs
 		}
-	case 193:
+	case 194:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.rng = ast.NewRangeNode(protoDollar[1].i, nil, nil, nil)
 		}
-	case 194:
+	case 195:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.rng = ast.NewRangeNode(protoDollar[1].i, protoDollar[2].id.ToKeyword(), protoDollar[3].i, nil)
 		}
-	case 195:
+	case 196:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.rng = ast.NewRangeNode(protoDollar[1].i, protoDollar[2].id.ToKeyword(), nil, protoDollar[3].id.ToKeyword())
 		}
-	case 196:
+	case 197:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.rngs = &rangeSlices{ranges: []*ast.RangeNode{protoDollar[1].rng}}
 		}
-	case 197:
+	case 198:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].rngs.ranges = append(protoDollar[1].rngs.ranges, protoDollar[3].rng)
 			protoDollar[1].rngs.commas = append(protoDollar[1].rngs.commas, protoDollar[2].b)
 			protoVAL.rngs = protoDollar[1].rngs
 		}
-	case 198:
+	case 199:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.rng = ast.NewRangeNode(protoDollar[1].il, nil, nil, nil)
 		}
-	case 199:
+	case 200:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.rng = ast.NewRangeNode(protoDollar[1].il, protoDollar[2].id.ToKeyword(), protoDollar[3].il, nil)
 		}
-	case 200:
+	case 201:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.rng = ast.NewRangeNode(protoDollar[1].il, protoDollar[2].id.ToKeyword(), nil, protoDollar[3].id.ToKeyword())
 		}
-	case 201:
+	case 202:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.il = protoDollar[1].i
 		}
-	case 202:
+	case 203:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.il = ast.NewNegativeIntLiteralNode(protoDollar[1].b, protoDollar[2].i)
 		}
-	case 203:
+	case 204:
 		protoDollar = protoS[protopt-4 : protopt+1]
 		{
 			// TODO: Tolerate a missing semicolon here. This currnelty creates a shift/reduce conflict
 			// between `reserved 1 to 10` and `reserved 1` followed by `to = 10`.
 			protoVAL.resvd = newNodeWithRunes(ast.NewReservedRangesNode(protoDollar[1].id.ToKeyword(), protoDollar[2].rngs.ranges, protoDollar[2].rngs.commas, protoDollar[3].b), protoDollar[4].bs...)
 		}
-	case 205:
+	case 206:
 		protoDollar = protoS[protopt-4 : protopt+1]
 		{
 			// TODO: Tolerate a missing semicolon here. This currnelty creates a shift/reduce conflict
 			// between `reserved 1 to 10` and `reserved 1` followed by `to = 10`.
 			protoVAL.resvd = newNodeWithRunes(ast.NewReservedRangesNode(protoDollar[1].id.ToKeyword(), protoDollar[2].rngs.ranges, protoDollar[2].rngs.commas, protoDollar[3].b), protoDollar[4].bs...)
 		}
-	case 207:
+	case 208:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[3].bs)
 			protoVAL.resvd = newNodeWithRunes(ast.NewReservedNamesNode(protoDollar[1].id.ToKeyword(), protoDollar[2].names.names, protoDollar[2].names.commas, semi), extra...)
 		}
-	case 208:
+	case 209:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[3].bs)
 			protoVAL.resvd = newNodeWithRunes(ast.NewReservedIdentifiersNode(protoDollar[1].id.ToKeyword(), protoDollar[2].names.idents, protoDollar[2].names.commas, semi), extra...)
 		}
-	case 209:
+	case 210:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.names = &nameSlices{names: []ast.StringValueNode{toStringValueNode(protoDollar[1].str)}}
 		}
-	case 210:
+	case 211:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].names.names = append(protoDollar[1].names.names, toStringValueNode(protoDollar[3].str))
 			protoDollar[1].names.commas = append(protoDollar[1].names.commas, protoDollar[2].b)
 			protoVAL.names = protoDollar[1].names
 		}
-	case 211:
+	case 212:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.names = &nameSlices{idents: []*ast.IdentNode{protoDollar[1].id}}
 		}
-	case 212:
+	case 213:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoDollar[1].names.idents = append(protoDollar[1].names.idents, protoDollar[3].id)
 			protoDollar[1].names.commas = append(protoDollar[1].names.commas, protoDollar[2].b)
 			protoVAL.names = protoDollar[1].names
 		}
-	case 213:
+	case 214:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			protoVAL.en = newNodeWithRunes(ast.NewEnumNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id, protoDollar[3].b, protoDollar[4].enElements, protoDollar[5].b), protoDollar[6].bs...)
 		}
-	case 214:
+	case 215:
+		protoDollar = protoS[protopt-7 : protopt+1]
+		{
+			protoVAL.en = newNodeWithRunes(ast.NewEnumNodeWithVisibility(protoDollar[1].id.ToKeyword(), protoDollar[2].id.ToKeyword(), protoDollar[3].id, protoDollar[4].b, protoDollar[5].enElements, protoDollar[6].b), protoDollar[7].bs...)
+		}
+	case 216:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.enElements = prependRunes(toEnumElement, protoDollar[1].bs, nil)
 		}
-	case 215:
+	case 217:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.enElements = prependRunes(toEnumElement, protoDollar[1].bs, protoDollar[2].enElements)
 		}
-	case 216:
+	case 218:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.enElements = append(protoDollar[1].enElements, protoDollar[2].enElements...)
 		}
-	case 217:
+	case 219:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.enElements = protoDollar[1].enElements
 		}
-	case 218:
+	case 220:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.enElements = toElements[ast.EnumElement](toEnumElement, protoDollar[1].opt.Node, protoDollar[1].opt.Runes)
 		}
-	case 219:
+	case 221:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.enElements = toElements[ast.EnumElement](toEnumElement, protoDollar[1].env.Node, protoDollar[1].env.Runes)
 		}
-	case 220:
+	case 222:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.enElements = toElements[ast.EnumElement](toEnumElement, protoDollar[1].resvd.Node, protoDollar[1].resvd.Runes)
 		}
-	case 221:
+	case 223:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.enElements = nil
 		}
-	case 222:
+	case 224:
 		protoDollar = protoS[protopt-4 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[4].bs)
 			protoVAL.env = newNodeWithRunes(ast.NewEnumValueNode(protoDollar[1].id, protoDollar[2].b, protoDollar[3].il, nil, semi), extra...)
 		}
-	case 223:
+	case 225:
 		protoDollar = protoS[protopt-5 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[5].bs)
 			protoVAL.env = newNodeWithRunes(ast.NewEnumValueNode(protoDollar[1].id, protoDollar[2].b, protoDollar[3].il, protoDollar[4].cmpctOpts, semi), extra...)
 		}
-	case 224:
+	case 226:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			protoVAL.msg = newNodeWithRunes(ast.NewMessageNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id, protoDollar[3].b, protoDollar[4].msgElements, protoDollar[5].b), protoDollar[6].bs...)
 		}
-	case 225:
+	case 227:
+		protoDollar = protoS[protopt-7 : protopt+1]
+		{
+			protoVAL.msg = newNodeWithRunes(ast.NewMessageNodeWithVisibility(protoDollar[1].id.ToKeyword(), protoDollar[2].id.ToKeyword(), protoDollar[3].id, protoDollar[4].b, protoDollar[5].msgElements, protoDollar[6].b), protoDollar[7].bs...)
+		}
+	case 230:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = prependRunes(toMessageElement, protoDollar[1].bs, nil)
 		}
-	case 226:
+	case 231:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.msgElements = prependRunes(toMessageElement, protoDollar[1].bs, protoDollar[2].msgElements)
 		}
-	case 227:
+	case 232:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.msgElements = append(protoDollar[1].msgElements, protoDollar[2].msgElements...)
 		}
-	case 228:
+	case 233:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = protoDollar[1].msgElements
 		}
-	case 229:
+	case 234:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = toElements[ast.MessageElement](toMessageElement, protoDollar[1].msgFld.Node, protoDollar[1].msgFld.Runes)
 		}
-	case 230:
+	case 235:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = toElements[ast.MessageElement](toMessageElement, protoDollar[1].en.Node, protoDollar[1].en.Runes)
 		}
-	case 231:
+	case 236:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = toElements[ast.MessageElement](toMessageElement, protoDollar[1].msg.Node, protoDollar[1].msg.Runes)
 		}
-	case 232:
+	case 237:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = toElements[ast.MessageElement](toMessageElement, protoDollar[1].extend.Node, protoDollar[1].extend.Runes)
 		}
-	case 233:
+	case 238:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = toElements[ast.MessageElement](toMessageElement, protoDollar[1].ext.Node, protoDollar[1].ext.Runes)
 		}
-	case 234:
+	case 239:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = toElements[ast.MessageElement](toMessageElement, protoDollar[1].msgGrp.Node, protoDollar[1].msgGrp.Runes)
 		}
-	case 235:
+	case 240:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = toElements[ast.MessageElement](toMessageElement, protoDollar[1].opt.Node, protoDollar[1].opt.Runes)
 		}
-	case 236:
+	case 241:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = toElements[ast.MessageElement](toMessageElement, protoDollar[1].oo.Node, protoDollar[1].oo.Runes)
 		}
-	case 237:
+	case 242:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = toElements[ast.MessageElement](toMessageElement, protoDollar[1].mapFld.Node, protoDollar[1].mapFld.Runes)
 		}
-	case 238:
+	case 243:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = toElements[ast.MessageElement](toMessageElement, protoDollar[1].resvd.Node, protoDollar[1].resvd.Runes)
 		}
-	case 239:
+	case 244:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.msgElements = nil
 		}
-	case 240:
+	case 245:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			semis, extra := protolex.(*protoLex).requireSemicolon(protoDollar[6].bs)
 			protoVAL.msgFld = newNodeWithRunes(ast.NewFieldNode(protoDollar[1].id.ToKeyword(), protoDollar[2].tid, protoDollar[3].id, protoDollar[4].b, protoDollar[5].i, nil, semis), extra...)
 		}
-	case 241:
+	case 246:
 		protoDollar = protoS[protopt-7 : protopt+1]
 		{
 			semis, extra := protolex.(*protoLex).requireSemicolon(protoDollar[7].bs)
 			protoVAL.msgFld = newNodeWithRunes(ast.NewFieldNode(protoDollar[1].id.ToKeyword(), protoDollar[2].tid, protoDollar[3].id, protoDollar[4].b, protoDollar[5].i, protoDollar[6].cmpctOpts, semis), extra...)
 		}
-	case 242:
+	case 247:
 		protoDollar = protoS[protopt-5 : protopt+1]
 		{
 			semis, extra := protolex.(*protoLex).requireSemicolon(protoDollar[5].bs)
 			protoVAL.msgFld = newNodeWithRunes(ast.NewFieldNode(nil, protoDollar[1].tid, protoDollar[2].id, protoDollar[3].b, protoDollar[4].i, nil, semis), extra...)
 		}
-	case 243:
+	case 248:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			semis, extra := protolex.(*protoLex).requireSemicolon(protoDollar[6].bs)
 			protoVAL.msgFld = newNodeWithRunes(ast.NewFieldNode(nil, protoDollar[1].tid, protoDollar[2].id, protoDollar[3].b, protoDollar[4].i, protoDollar[5].cmpctOpts, semis), extra...)
 		}
-	case 244:
+	case 249:
 		protoDollar = protoS[protopt-4 : protopt+1]
 		{
 			semis, extra := protolex.(*protoLex).requireSemicolon(protoDollar[4].bs)
 			protoVAL.msgFld = newNodeWithRunes(ast.NewFieldNode(protoDollar[1].id.ToKeyword(), protoDollar[2].tid, protoDollar[3].id, nil, nil, nil, semis), extra...)
 		}
-	case 245:
+	case 250:
 		protoDollar = protoS[protopt-5 : protopt+1]
 		{
 			semis, extra := protolex.(*protoLex).requireSemicolon(protoDollar[5].bs)
 			protoVAL.msgFld = newNodeWithRunes(ast.NewFieldNode(protoDollar[1].id.ToKeyword(), protoDollar[2].tid, protoDollar[3].id, nil, nil, protoDollar[4].cmpctOpts, semis), extra...)
 		}
-	case 246:
+	case 251:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			semis, extra := protolex.(*protoLex).requireSemicolon(protoDollar[3].bs)
 			protoVAL.msgFld = newNodeWithRunes(ast.NewFieldNode(nil, protoDollar[1].tid, protoDollar[2].id, nil, nil, nil, semis), extra...)
 		}
-	case 247:
+	case 252:
 		protoDollar = protoS[protopt-4 : protopt+1]
 		{
 			semis, extra := protolex.(*protoLex).requireSemicolon(protoDollar[4].bs)
 			protoVAL.msgFld = newNodeWithRunes(ast.NewFieldNode(nil, protoDollar[1].tid, protoDollar[2].id, nil, nil, protoDollar[3].cmpctOpts, semis), extra...)
 		}
-	case 248:
+	case 253:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			protoVAL.extend = newNodeWithRunes(ast.NewExtendNode(protoDollar[1].id.ToKeyword(), protoDollar[2].tid, protoDollar[3].b, protoDollar[4].extElements, protoDollar[5].b), protoDollar[6].bs...)
 		}
-	case 249:
+	case 254:
 		protoDollar = protoS[protopt-0 : protopt+1]
 		{
 			protoVAL.extElements = nil
 		}
-	case 251:
+	case 256:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			if protoDollar[2].extElement != nil {
@@ -2514,7 +2542,7 @@ protodefault:
 				protoVAL.extElements = protoDollar[1].extElements
 			}
 		}
-	case 252:
+	case 257:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			if protoDollar[1].extElement != nil {
@@ -2523,133 +2551,133 @@ protodefault:
 				protoVAL.extElements = nil
 			}
 		}
-	case 253:
+	case 258:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.extElement = protoDollar[1].fld
 		}
-	case 254:
+	case 259:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.extElement = protoDollar[1].grp
 		}
-	case 255:
+	case 260:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.extElement = nil
 		}
-	case 256:
+	case 261:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.extElement = nil
 		}
-	case 257:
+	case 262:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			protoVAL.fld = ast.NewFieldNode(protoDollar[1].id.ToKeyword(), protoDollar[2].tid, protoDollar[3].id, protoDollar[4].b, protoDollar[5].i, nil, protoDollar[6].b)
 		}
-	case 258:
+	case 263:
 		protoDollar = protoS[protopt-7 : protopt+1]
 		{
 			protoVAL.fld = ast.NewFieldNode(protoDollar[1].id.ToKeyword(), protoDollar[2].tid, protoDollar[3].id, protoDollar[4].b, protoDollar[5].i, protoDollar[6].cmpctOpts, protoDollar[7].b)
 		}
-	case 259:
+	case 264:
 		protoDollar = protoS[protopt-5 : protopt+1]
 		{
 			protoVAL.fld = ast.NewFieldNode(nil, protoDollar[1].tid, protoDollar[2].id, protoDollar[3].b, protoDollar[4].i, nil, protoDollar[5].b)
 		}
-	case 260:
+	case 265:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			protoVAL.fld = ast.NewFieldNode(nil, protoDollar[1].tid, protoDollar[2].id, protoDollar[3].b, protoDollar[4].i, protoDollar[5].cmpctOpts, protoDollar[6].b)
 		}
-	case 261:
+	case 266:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			protoVAL.svc = newNodeWithRunes(ast.NewServiceNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id, protoDollar[3].b, protoDollar[4].svcElements, protoDollar[5].b), protoDollar[6].bs...)
 		}
-	case 262:
+	case 267:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.svcElements = prependRunes(toServiceElement, protoDollar[1].bs, nil)
 		}
-	case 263:
+	case 268:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.svcElements = prependRunes(toServiceElement, protoDollar[1].bs, protoDollar[2].svcElements)
 		}
-	case 264:
+	case 269:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.svcElements = append(protoDollar[1].svcElements, protoDollar[2].svcElements...)
 		}
-	case 265:
+	case 270:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.svcElements = protoDollar[1].svcElements
 		}
-	case 266:
+	case 271:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.svcElements = toElements[ast.ServiceElement](toServiceElement, protoDollar[1].opt.Node, protoDollar[1].opt.Runes)
 		}
-	case 267:
+	case 272:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.svcElements = toElements[ast.ServiceElement](toServiceElement, protoDollar[1].mtd.Node, protoDollar[1].mtd.Runes)
 		}
-	case 268:
+	case 273:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.svcElements = nil
 		}
-	case 269:
+	case 274:
 		protoDollar = protoS[protopt-6 : protopt+1]
 		{
 			semi, extra := protolex.(*protoLex).requireSemicolon(protoDollar[6].bs)
 			protoVAL.mtd = newNodeWithRunes(ast.NewRPCNode(protoDollar[1].id.ToKeyword(), protoDollar[2].id, protoDollar[3].mtdMsgType, protoDollar[4].id.ToKeyword(), protoDollar[5].mtdMsgType, semi), extra...)
 		}
-	case 270:
+	case 275:
 		protoDollar = protoS[protopt-9 : protopt+1]
 		{
 			protoVAL.mtd = newNodeWithRunes(ast.NewRPCNodeWithBody(protoDollar[1].id.ToKeyword(), protoDollar[2].id, protoDollar[3].mtdMsgType, protoDollar[4].id.ToKeyword(), protoDollar[5].mtdMsgType, protoDollar[6].b, protoDollar[7].mtdElements, protoDollar[8].b), protoDollar[9].bs...)
 		}
-	case 271:
+	case 276:
 		protoDollar = protoS[protopt-4 : protopt+1]
 		{
 			protoVAL.mtdMsgType = ast.NewRPCTypeNode(protoDollar[1].b, protoDollar[2].id.ToKeyword(), protoDollar[3].tid, protoDollar[4].b)
 		}
-	case 272:
+	case 277:
 		protoDollar = protoS[protopt-3 : protopt+1]
 		{
 			protoVAL.mtdMsgType = ast.NewRPCTypeNode(protoDollar[1].b, nil, protoDollar[2].tid, protoDollar[3].b)
 		}
-	case 273:
+	case 278:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.mtdElements = prependRunes(toMethodElement, protoDollar[1].bs, nil)
 		}
-	case 274:
+	case 279:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.mtdElements = prependRunes(toMethodElement, protoDollar[1].bs, protoDollar[2].mtdElements)
 		}
-	case 275:
+	case 280:
 		protoDollar = protoS[protopt-2 : protopt+1]
 		{
 			protoVAL.mtdElements = append(protoDollar[1].mtdElements, protoDollar[2].mtdElements...)
 		}
-	case 276:
+	case 281:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.mtdElements = protoDollar[1].mtdElements
 		}
-	case 277:
+	case 282:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.mtdElements = toElements[ast.RPCElement](toMethodElement, protoDollar[1].opt.Node, protoDollar[1].opt.Runes)
 		}
-	case 278:
+	case 283:
 		protoDollar = protoS[protopt-1 : protopt+1]
 		{
 			protoVAL.mtdElements = nil
@@ -32,6 +32,7 @@ import (
 
 var supportedEditions = map[string]descriptorpb.Edition{
 	"2023": descriptorpb.Edition_EDITION_2023,
+	"2024": internal.Edition2024,
 }
 
 // NB: protoreflect.Syntax doesn't yet know about editions, so we have to use our own type.
@@ -122,7 +123,7 @@ func (r *result) createFileDescriptor(filename string, file *ast.FileNode, handl
 			syntax = syntaxProto2
 		default:
 			nodeInfo := file.NodeInfo(file.Syntax.Syntax)
-			if handler.HandleErrorf(nodeInfo, `syntax value must be "proto2" or "proto3"`) != nil {
+			if handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeUnknownSyntax, `syntax value must be "proto2" or "proto3"`)) != nil {
 				return
 			}
 		}
@@ -132,12 +133,6 @@ func (r *result) createFileDescriptor(filename string, file *ast.FileNode, handl
 			fd.Syntax = proto.String(file.Syntax.Syntax.AsString())
 		}
 	case file.Edition != nil:
-		if !internal.AllowEditions {
-			nodeInfo := file.NodeInfo(file.Edition.Edition)
-			if handler.HandleErrorf(nodeInfo, `editions are not yet supported; use syntax proto2 or proto3 instead`) != nil {
-				return
-			}
-		}
 		edition := file.Edition.Edition.AsString()
 		syntax = syntaxEditions
 
@@ -150,7 +145,7 @@ func (r *result) createFileDescriptor(filename string, file *ast.FileNode, handl
 				editionStrs = append(editionStrs, fmt.Sprintf("%q", supportedEdition))
 			}
 			sort.Strings(editionStrs)
-			if handler.HandleErrorf(nodeInfo, `edition value %q not recognized; should be one of [%s]`, edition, strings.Join(editionStrs, ",")) != nil {
+			if handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeUnknownEdition, `edition value %q not recognized; should be one of [%s]`, edition, strings.Join(editionStrs, ","))) != nil {
 				return
 			}
 		}
@@ -170,6 +165,10 @@ func (r *result) createFileDescriptor(filename string, file *ast.FileNode, handl
 		case *ast.ExtendNode:
 			r.addExtensions(decl, &fd.Extension, &fd.MessageType, syntax, handler, 0)
 		case *ast.ImportNode:
+			if decl.Option != nil {
+				internal.AddOptionDependency(fd, decl.Name.AsString())
+				continue
+			}
 			index := len(fd.Dependency)
 			fd.Dependency = append(fd.Dependency, decl.Name.AsString())
 			if decl.Public != nil {
@@ -189,20 +188,20 @@ func (r *result) createFileDescriptor(filename string, file *ast.FileNode, handl
 		case *ast.PackageNode:
 			if fd.Package != nil {
 				nodeInfo := file.NodeInfo(decl)
-				if handler.HandleErrorf(nodeInfo, "files should have only one package declaration") != nil {
+				if handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeDuplicatePackage, "files should have only one package declaration")) != nil {
 					return
 				}
 			}
 			pkgName := string(decl.Name.AsIdentifier())
 			if len(pkgName) >= 512 {
 				nodeInfo := file.NodeInfo(decl.Name)
-				if handler.HandleErrorf(nodeInfo, "package name (with whitespace removed) must be less than 512 characters long") != nil {
+				if handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeLimitExceeded, "package name (with whitespace removed) must be less than 512 characters long")) != nil {
 					return
 				}
 			}
 			if strings.Count(pkgName, ".") > 100 {
 				nodeInfo := file.NodeInfo(decl.Name)
-				if handler.HandleErrorf(nodeInfo, "package name may not contain more than 100 periods") != nil {
+				if handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeLimitExceeded, "package name may not contain more than 100 periods")) != nil {
 					return
 				}
 			}
@@ -313,7 +312,7 @@ func (r *result) addExtensions(ext *ast.ExtendNode, flds *[]*descriptorpb.FieldD
 	}
 	if count == 0 {
 		nodeInfo := r.file.NodeInfo(ext)
-		_ = handler.HandleErrorf(nodeInfo, "extend sections must define at least one extension")
+		_ = handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeEmptyDeclaration, "extend sections must define at least one extension"))
 	}
 }
 
@@ -397,7 +396,7 @@ func (r *result) asGroupDescriptors(group *ast.GroupNode, syntax syntaxType, max
 	}
 	if !unicode.IsUpper(rune(group.Name.Val[0])) {
 		nameNodeInfo := r.file.NodeInfo(group.Name)
-		_ = handler.HandleErrorf(nameNodeInfo, "group %s should have a name that starts with a capital letter", group.Name.Val)
+		_ = handler.HandleErrorWithPos(nameNodeInfo, reporter.Codef(reporter.CodeInvalidName, "group %s should have a name that starts with a capital letter", group.Name.Val))
 	}
 	fieldName := strings.ToLower(group.Name.Val)
 	fd := &descriptorpb.FieldDescriptorProto{
@@ -475,7 +474,7 @@ func (r *result) asEnumValue(ev *ast.EnumValueNode, handler *reporter.Handler) *
 	num, ok := ast.AsInt32(ev.Number, math.MinInt32, math.MaxInt32)
 	if !ok {
 		numberNodeInfo := r.file.NodeInfo(ev.Number)
-		_ = handler.HandleErrorf(numberNodeInfo, "value %d is out of range: should be between %d and %d", ev.Number.Value(), math.MinInt32, math.MaxInt32)
+		_ = handler.HandleErrorWithPos(numberNodeInfo, reporter.Codef(reporter.CodeInvalidEnumValue, "value %d is out of range: should be between %d and %d", ev.Number.Value(), math.MinInt32, math.MaxInt32))
 	}
 	evd := &descriptorpb.EnumValueDescriptorProto{Name: proto.String(ev.Name.Val), Number: proto.Int32(num)}
 	r.putEnumValueNode(evd, ev)
@@ -515,6 +514,9 @@ func (r *result) asMethodDescriptor(node *ast.RPCNode) *descriptorpb.MethodDescr
 func (r *result) asEnumDescriptor(en *ast.EnumNode, syntax syntaxType, handler *reporter.Handler) *descriptorpb.EnumDescriptorProto {
 	ed := &descriptorpb.EnumDescriptorProto{Name: proto.String(en.Name.Val)}
 	r.putEnumNode(ed, en)
+	if en.Visibility != nil {
+		internal.SetVisibility(ed, asVisibility(en.Visibility))
+	}
 	rsvdNames := map[string]ast.SourcePos{}
 	for _, decl := range en.Decls {
 		switch decl := decl.(type) {
@@ -548,6 +550,9 @@ func (r *result) asEnumReservedRange(rng *ast.RangeNode, handler *reporter.Handl
 func (r *result) asMessageDescriptor(node *ast.MessageNode, syntax syntaxType, handler *reporter.Handler, depth int) *descriptorpb.DescriptorProto {
 	msgd := &descriptorpb.DescriptorProto{Name: proto.String(node.Name.Val)}
 	r.putMessageNode(msgd, node)
+	if node.Visibility != nil {
+		internal.SetVisibility(msgd, asVisibility(node.Visibility))
+	}
 	// don't bother processing body if we've exceeded depth
 	if r.checkDepth(depth, node, handler) {
 		r.addMessageBody(msgd, &node.MessageBody, syntax, handler, depth)
@@ -555,17 +560,24 @@ func (r *result) asMessageDescriptor(node *ast.MessageNode, syntax syntaxType, h
 	return msgd
 }
 
+func asVisibility(keyword *ast.KeywordNode) internal.SymbolVisibility {
+	if keyword.Val == "local" {
+		return internal.VisibilityLocal
+	}
+	return internal.VisibilityExport
+}
+
 func (r *result) addReservedNames(names *[]string, node *ast.ReservedNode, syntax syntaxType, handler *reporter.Handler, alreadyReserved map[string]ast.SourcePos) {
 	if syntax == syntaxEditions {
 		if len(node.Names) > 0 {
 			nameNodeInfo := r.file.NodeInfo(node.Names[0])
-			_ = handler.HandleErrorf(nameNodeInfo, `must use identifiers, not string literals, to reserved names with editions`)
+			_ = handler.HandleErrorWithPos(nameNodeInfo, reporter.Codef(reporter.CodeNotAllowedInSyntax, `must use identifiers, not string literals, to reserved names with editions`))
 		}
 		for _, n := range node.Identifiers {
 			name := string(n.AsIdentifier())
 			nameNodeInfo := r.file.NodeInfo(n)
 			if existing, ok := alreadyReserved[name]; ok {
-				_ = handler.HandleErrorf(nameNodeInfo, "name %q is already reserved at %s", name, existing)
+				_ = handler.HandleErrorWithPos(nameNodeInfo, reporter.Codef(reporter.CodeDuplicateReservedName, "name %q is already reserved at %s", name, existing))
 				continue
 			}
 			alreadyReserved[name] = nameNodeInfo.Start()
@@ -576,13 +588,13 @@ func (r *result) addReservedNames(names *[]string, node *ast.ReservedNode, synta
 
 	if len(node.Identifiers) > 0 {
 		nameNodeInfo := r.file.NodeInfo(node.Identifiers[0])
-		_ = handler.HandleErrorf(nameNodeInfo, `must use string literals, not identifiers, to reserved names with proto2 and proto3`)
+		_ = handler.HandleErrorWithPos(nameNodeInfo, reporter.Codef(reporter.CodeNotAllowedInSyntax, `must use string literals, not identifiers, to reserved names with proto2 and proto3`))
 	}
 	for _, n := range node.Names {
 		name := n.AsString()
 		nameNodeInfo := r.file.NodeInfo(n)
 		if existing, ok := alreadyReserved[name]; ok {
-			_ = handler.HandleErrorf(nameNodeInfo, "name %q is already reserved at %s", name, existing)
+			_ = handler.HandleErrorWithPos(nameNodeInfo, reporter.Codef(reporter.CodeDuplicateReservedName, "name %q is already reserved at %s", name, existing))
 			continue
 		}
 		alreadyReserved[name] = nameNodeInfo.Start()
@@ -599,7 +611,7 @@ func (r *result) checkDepth(depth int, node ast.MessageDeclNode, handler *report
 		// pinpoint the group keyword if the source is a group
 		n = grp.Keyword
 	}
-	_ = handler.HandleErrorf(r.file.NodeInfo(n), "message nesting depth must be less than 32")
+	_ = handler.HandleErrorWithPos(r.file.NodeInfo(n), reporter.Codef(reporter.CodeLimitExceeded, "message nesting depth must be less than 32"))
 	return false
 }
 
@@ -624,7 +636,7 @@ func (r *result) addMessageBody(msgd *descriptorpb.DescriptorProto, body *ast.Me
 		if syntax == syntaxProto3 {
 			node := r.OptionNode(messageSetOpt)
 			nodeInfo := r.file.NodeInfo(node)
-			_ = handler.HandleErrorf(nodeInfo, "messages with message-set wire format are not allowed with proto3 syntax")
+			_ = handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeNotAllowedInSyntax, "messages with message-set wire format are not allowed with proto3 syntax"))
 		}
 		maxTag = internal.MaxTag // higher limit for messageset wire format
 	}
@@ -679,7 +691,7 @@ func (r *result) addMessageBody(msgd *descriptorpb.DescriptorProto, body *ast.Me
 			}
 			if ooFields == 0 {
 				declNodeInfo := r.file.NodeInfo(decl)
-				_ = handler.HandleErrorf(declNodeInfo, "oneof must contain at least one field")
+				_ = handler.HandleErrorWithPos(declNodeInfo, reporter.Codef(reporter.CodeEmptyDeclaration, "oneof must contain at least one field"))
 			}
 		case *ast.MessageNode:
 			msgd.NestedType = append(msgd.NestedType, r.asMessageDescriptor(decl, syntax, handler, depth+1))
@@ -695,12 +707,12 @@ func (r *result) addMessageBody(msgd *descriptorpb.DescriptorProto, body *ast.Me
 		if len(msgd.Field) > 0 {
 			node := r.FieldNode(msgd.Field[0])
 			nodeInfo := r.file.NodeInfo(node)
-			_ = handler.HandleErrorf(nodeInfo, "messages with message-set wire format cannot contain non-extension fields")
+			_ = handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeInvalidMessageSet, "messages with message-set wire format cannot contain non-extension fields"))
 		}
 		if len(msgd.ExtensionRange) == 0 {
 			node := r.OptionNode(messageSetOpt)
 			nodeInfo := r.file.NodeInfo(node)
-			_ = handler.HandleErrorf(nodeInfo, "messages with message-set wire format must contain at least one extension range")
+			_ = handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeInvalidMessageSet, "messages with message-set wire format must contain at least one extension range"))
 		}
 	}
 
@@ -731,7 +743,7 @@ func (r *result) isMessageSetWireFormat(scope string, md *descriptorpb.Descripto
 	default:
 		optNode := r.OptionNode(opt)
 		optNodeInfo := r.file.NodeInfo(optNode.GetValue())
-		return nil, handler.HandleErrorf(optNodeInfo, "%s: expecting bool value for message_set_wire_format option", scope)
+		return nil, handler.HandleErrorWithPos(optNodeInfo, reporter.Codef(reporter.CodeInvalidOptionValue, "%s: expecting bool value for message_set_wire_format option", scope))
 	}
 }
 
@@ -751,7 +763,7 @@ func (r *result) getRangeBounds(rng *ast.RangeNode, minVal, maxVal int32, handle
 	if !ok {
 		checkOrder = false
 		startValNodeInfo := r.file.NodeInfo(rng.StartVal)
-		_ = handler.HandleErrorf(startValNodeInfo, "range start %d is out of range: should be between %d and %d", rng.StartValue(), minVal, maxVal)
+		_ = handler.HandleErrorWithPos(startValNodeInfo, reporter.Codef(reporter.CodeInvalidRange, "range start %d is out of range: should be between %d and %d", rng.StartValue(), minVal, maxVal))
 	}
 
 	end, ok := rng.EndValueAsInt32(minVal, maxVal)
@@ -759,13 +771,13 @@ func (r *result) getRangeBounds(rng *ast.RangeNode, minVal, maxVal int32, handle
 		checkOrder = false
 		if rng.EndVal != nil {
 			endValNodeInfo := r.file.NodeInfo(rng.EndVal)
-			_ = handler.HandleErrorf(endValNodeInfo, "range end %d is out of range: should be between %d and %d", rng.EndValue(), minVal, maxVal)
+			_ = handler.HandleErrorWithPos(endValNodeInfo, reporter.Codef(reporter.CodeInvalidRange, "range end %d is out of range: should be between %d and %d", rng.EndValue(), minVal, maxVal))
 		}
 	}
 
 	if checkOrder && start > end {
 		rangeStartNodeInfo := r.file.NodeInfo(rng.RangeStart())
-		_ = handler.HandleErrorf(rangeStartNodeInfo, "range, %d to %d, is invalid: start must be <= end", start, end)
+		_ = handler.HandleErrorWithPos(rangeStartNodeInfo, reporter.Codef(reporter.CodeInvalidRange, "range, %d to %d, is invalid: start must be <= end", start, end))
 	}
 
 	return start, end
@@ -791,11 +803,11 @@ func (r *result) asServiceDescriptor(svc *ast.ServiceNode) *descriptorpb.Service
 func (r *result) checkTag(n ast.Node, v uint64, maxTag int32) error {
 	switch {
 	case v < 1:
-		return reporter.Errorf(r.file.NodeInfo(n), "tag number %d must be greater than zero", v)
+		return reporter.Error(r.file.NodeInfo(n), reporter.Codef(reporter.CodeInvalidTag, "tag number %d must be greater than zero", v))
 	case v > uint64(maxTag):
-		return reporter.Errorf(r.file.NodeInfo(n), "tag number %d is higher than max allowed tag number (%d)", v, maxTag)
+		return reporter.Error(r.file.NodeInfo(n), reporter.Codef(reporter.CodeInvalidTag, "tag number %d is higher than max allowed tag number (%d)", v, maxTag))
 	case v >= internal.SpecialReservedStart && v <= internal.SpecialReservedEnd:
-		return reporter.Errorf(r.file.NodeInfo(n), "tag number %d is in disallowed reserved range %d-%d", v, internal.SpecialReservedStart, internal.SpecialReservedEnd)
+		return reporter.Error(r.file.NodeInfo(n), reporter.Codef(reporter.CodeInvalidTag, "tag number %d is in disallowed reserved range %d-%d", v, internal.SpecialReservedStart, internal.SpecialReservedEnd))
 	default:
 		return nil
 	}
@@ -100,13 +100,25 @@ func validateImports(res *result, handler *reporter.Handler) error {
 		info := fileNode.NodeInfo(decl)
 		name := imp.Name.AsString()
 		if prev, ok := imports[name]; ok {
-			return handler.HandleErrorf(info, "%q was already imported at %v", name, prev)
+			return handler.HandleErrorWithPos(info, reporter.Codef(reporter.CodeDuplicateImport, "%q was already imported at %v", name, prev))
 		}
 		imports[name] = info.Start()
+		if imp.Option != nil && res.proto.GetEdition() < internal.Edition2024 {
+			if err := handler.HandleErrorWithPos(fileNode.NodeInfo(imp.Option), reporter.Codef(reporter.CodeNotAllowedInSyntax, "option imports are not allowed before edition 2024")); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
+func validateVisibility(res *result, scope string, visibility *ast.KeywordNode, handler *reporter.Handler) error {
+	if visibility == nil || res.proto.GetEdition() >= internal.Edition2024 {
+		return nil
+	}
+	return handler.HandleErrorWithPos(res.file.NodeInfo(visibility), reporter.Codef(reporter.CodeNotAllowedInSyntax, "%s: %q may not be used before edition 2024", scope, visibility.Val))
+}
+
 func validateNoFeatures(res *result, syntax syntaxType, scope string, opts []*descriptorpb.UninterpretedOption, handler *reporter.Handler) error {
 	if syntax == syntaxEditions {
 		// Editions is allowed to use features
@@ -117,7 +129,7 @@ func validateNoFeatures(res *result, syntax syntaxType, scope string, opts []*de
 	} else if index >= 0 {
 		optNode := res.OptionNode(opts[index])
 		optNameNodeInfo := res.file.NodeInfo(optNode.GetName())
-		if err := handler.HandleErrorf(optNameNodeInfo, "%s: option 'features' may only be used with editions but file uses %s syntax", scope, syntax); err != nil {
+		if err := handler.HandleErrorWithPos(optNameNodeInfo, reporter.Codef(reporter.CodeNotAllowedInSyntax, "%s: option 'features' may only be used with editions but file uses %s syntax", scope, syntax)); err != nil {
 			return err
 		}
 	}
@@ -127,10 +139,16 @@ func validateNoFeatures(res *result, syntax syntaxType, scope string, opts []*de
 func validateMessage(res *result, syntax syntaxType, name protoreflect.FullName, md *descriptorpb.DescriptorProto, handler *reporter.Handler) error {
 	scope := fmt.Sprintf("message %s", name)
 
+	if node, ok := res.MessageNode(md).(*ast.MessageNode); ok {
+		if err := validateVisibility(res, scope, node.Visibility, handler); err != nil {
+			return err
+		}
+	}
+
 	if syntax == syntaxProto3 && len(md.ExtensionRange) > 0 {
 		n := res.ExtensionRangeNode(md.ExtensionRange[0])
 		nInfo := res.file.NodeInfo(n)
-		if err := handler.HandleErrorf(nInfo, "%s: extension ranges are not allowed in proto3", scope); err != nil {
+		if err := handler.HandleErrorWithPos(nInfo, reporter.Codef(reporter.CodeNotAllowedInSyntax, "%s: extension ranges are not allowed in proto3", scope)); err != nil {
 			return err
 		}
 	}
@@ -140,7 +158,7 @@ func validateMessage(res *result, syntax syntaxType, name protoreflect.FullName,
 	} else if index >= 0 {
 		optNode := res.OptionNode(md.Options.GetUninterpretedOption()[index])
 		optNameNodeInfo := res.file.NodeInfo(optNode.GetName())
-		if err := handler.HandleErrorf(optNameNodeInfo, "%s: map_entry option should not be set explicitly; use map type instead", scope); err != nil {
+		if err := handler.HandleErrorWithPos(optNameNodeInfo, reporter.Codef(reporter.CodeInvalidOption, "%s: map_entry option should not be set explicitly; use map type instead", scope)); err != nil {
 			return err
 		}
 	}
@@ -159,7 +177,7 @@ func validateMessage(res *result, syntax syntaxType, name protoreflect.FullName,
 	for i := 1; i < len(rsvd); i++ {
 		if rsvd[i].start < rsvd[i-1].end {
 			rangeNodeInfo := res.file.NodeInfo(rsvd[i].node)
-			if err := handler.HandleErrorf(rangeNodeInfo, "%s: reserved ranges overlap: %d to %d and %d to %d", scope, rsvd[i-1].start, rsvd[i-1].end-1, rsvd[i].start, rsvd[i].end-1); err != nil {
+			if err := handler.HandleErrorWithPos(rangeNodeInfo, reporter.Codef(reporter.CodeOverlappingRanges, "%s: reserved ranges overlap: %d to %d and %d to %d", scope, rsvd[i-1].start, rsvd[i-1].end-1, rsvd[i].start, rsvd[i].end-1)); err != nil {
 				return err
 			}
 		}
@@ -178,7 +196,7 @@ func validateMessage(res *result, syntax syntaxType, name protoreflect.FullName,
 	for i := 1; i < len(exts); i++ {
 		if exts[i].start < exts[i-1].end {
 			rangeNodeInfo := res.file.NodeInfo(exts[i].node)
-			if err := handler.HandleErrorf(rangeNodeInfo, "%s: extension ranges overlap: %d to %d and %d to %d", scope, exts[i-1].start, exts[i-1].end-1, exts[i].start, exts[i].end-1); err != nil {
+			if err := handler.HandleErrorWithPos(rangeNodeInfo, reporter.Codef(reporter.CodeOverlappingRanges, "%s: extension ranges overlap: %d to %d and %d to %d", scope, exts[i-1].start, exts[i-1].end-1, exts[i].start, exts[i].end-1)); err != nil {
 				return err
 			}
 		}
@@ -198,7 +216,7 @@ func validateMessage(res *result, syntax syntaxType, name protoreflect.FullName,
 				span = rangeNodeInfo
 			}
 			// ranges overlap
-			if err := handler.HandleErrorf(span, "%s: extension range %d to %d overlaps reserved range %d to %d", scope, exts[j].start, exts[j].end-1, rsvd[i].start, rsvd[i].end-1); err != nil {
+			if err := handler.HandleErrorWithPos(span, reporter.Codef(reporter.CodeOverlappingRanges, "%s: extension range %d to %d overlaps reserved range %d to %d", scope, exts[j].start, exts[j].end-1, rsvd[i].start, rsvd[i].end-1)); err != nil {
 				return err
 			}
 		}
@@ -217,33 +235,41 @@ func validateMessage(res *result, syntax syntaxType, name protoreflect.FullName,
 		if !isIdentifier(n) {
 			node := findMessageReservedNameNode(res.MessageNode(md), n)
 			nodeInfo := res.file.NodeInfo(node)
-			if err := handler.HandleErrorf(nodeInfo, "%s: reserved name %q is not a valid identifier", scope, n); err != nil {
+			if err := handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeInvalidName, "%s: reserved name %q is not a valid identifier", scope, n)); err != nil {
 				return err
 			}
 		}
 		rsvdNames[n] = struct{}{}
 	}
-	fieldTags := map[int32]string{}
+	fieldTags := map[int32]*descriptorpb.FieldDescriptorProto{}
 	for _, fld := range md.Field {
 		fn := res.FieldNode(fld)
 		if _, ok := rsvdNames[fld.GetName()]; ok {
 			fieldNameNodeInfo := res.file.NodeInfo(fn.FieldName())
-			if err := handler.HandleErrorf(fieldNameNodeInfo, "%s: field %s is using a reserved name", scope, fld.GetName()); err != nil {
+			if err := handler.HandleErrorWithPos(fieldNameNodeInfo, reporter.Codef(reporter.CodeReservedName, "%s: field %s is using a reserved name", scope, fld.GetName())); err != nil {
 				return err
 			}
 		}
-		if existing := fieldTags[fld.GetNumber()]; existing != "" {
+		if existing := fieldTags[fld.GetNumber()]; existing != nil {
 			fieldTagNodeInfo := res.file.NodeInfo(fn.FieldTag())
-			if err := handler.HandleErrorf(fieldTagNodeInfo, "%s: fields %s and %s both have the same tag %d", scope, existing, fld.GetName(), fld.GetNumber()); err != nil {
+			err := &errTagCollision{
+				message:       name,
+				tag:           fld.GetNumber(),
+				field:         fld.GetName(),
+				previousField: existing.GetName(),
+				span:          fieldTagNodeInfo,
+				previousSpan:  res.file.NodeInfo(res.FieldNode(existing).FieldTag()),
+			}
+			if err := handler.HandleErrorWithPos(fieldTagNodeInfo, err); err != nil {
 				return err
 			}
 		}
-		fieldTags[fld.GetNumber()] = fld.GetName()
+		fieldTags[fld.GetNumber()] = fld
 		// check reserved ranges
 		r := sort.Search(len(rsvd), func(index int) bool { return rsvd[index].end > fld.GetNumber() })
 		if r < len(rsvd) && rsvd[r].start <= fld.GetNumber() {
 			fieldTagNodeInfo := res.file.NodeInfo(fn.FieldTag())
-			if err := handler.HandleErrorf(fieldTagNodeInfo, "%s: field %s is using tag %d which is in reserved range %d to %d", scope, fld.GetName(), fld.GetNumber(), rsvd[r].start, rsvd[r].end-1); err != nil {
+			if err := handler.HandleErrorWithPos(fieldTagNodeInfo, reporter.Codef(reporter.CodeReservedNumber, "%s: field %s is using tag %d which is in reserved range %d to %d", scope, fld.GetName(), fld.GetNumber(), rsvd[r].start, rsvd[r].end-1)); err != nil {
 				return err
 			}
 		}
@@ -251,7 +277,7 @@ func validateMessage(res *result, syntax syntaxType, name protoreflect.FullName,
 		e := sort.Search(len(exts), func(index int) bool { return exts[index].end > fld.GetNumber() })
 		if e < len(exts) && exts[e].start <= fld.GetNumber() {
 			fieldTagNodeInfo := res.file.NodeInfo(fn.FieldTag())
-			if err := handler.HandleErrorf(fieldTagNodeInfo, "%s: field %s is using tag %d which is in extension range %d to %d", scope, fld.GetName(), fld.GetNumber(), exts[e].start, exts[e].end-1); err != nil {
+			if err := handler.HandleErrorWithPos(fieldTagNodeInfo, reporter.Codef(reporter.CodeReservedNumber, "%s: field %s is using tag %d which is in extension range %d to %d", scope, fld.GetName(), fld.GetNumber(), exts[e].start, exts[e].end-1)); err != nil {
 				return err
 			}
 		}
@@ -317,10 +343,16 @@ func findReservedNameNode[T ast.Node](parent ast.Node, decls []T, name string) a
 func validateEnum(res *result, syntax syntaxType, name protoreflect.FullName, ed *descriptorpb.EnumDescriptorProto, handler *reporter.Handler) error {
 	scope := fmt.Sprintf("enum %s", name)
 
+	if node, ok := res.EnumNode(ed).(*ast.EnumNode); ok {
+		if err := validateVisibility(res, scope, node.Visibility, handler); err != nil {
+			return err
+		}
+	}
+
 	if len(ed.Value) == 0 {
 		enNode := res.EnumNode(ed)
 		enNodeInfo := res.file.NodeInfo(enNode)
-		if err := handler.HandleErrorf(enNodeInfo, "%s: enums must define at least one value", scope); err != nil {
+		if err := handler.HandleErrorWithPos(enNodeInfo, reporter.Codef(reporter.CodeEmptyDeclaration, "%s: enums must define at least one value", scope)); err != nil {
 			return err
 		}
 	}
@@ -347,7 +379,7 @@ func validateEnum(res *result, syntax syntaxType, name protoreflect.FullName, ed
 		if !valid {
 			optNode := res.OptionNode(allowAliasOpt)
 			optNodeInfo := res.file.NodeInfo(optNode.GetValue())
-			if err := handler.HandleErrorf(optNodeInfo, "%s: expecting bool value for allow_alias option", scope); err != nil {
+			if err := handler.HandleErrorWithPos(optNodeInfo, reporter.Codef(reporter.CodeInvalidOptionValue, "%s: expecting bool value for allow_alias option", scope)); err != nil {
 				return err
 			}
 		}
@@ -356,7 +388,7 @@ func validateEnum(res *result, syntax syntaxType, name protoreflect.FullName, ed
 	if syntax == syntaxProto3 && len(ed.Value) > 0 && ed.Value[0].GetNumber() != 0 {
 		evNode := res.EnumValueNode(ed.Value[0])
 		evNodeInfo := res.file.NodeInfo(evNode.GetNumber())
-		if err := handler.HandleErrorf(evNodeInfo, "%s: proto3 requires that first value in enum have numeric value of 0", scope); err != nil {
+		if err := handler.HandleErrorWithPos(evNodeInfo, reporter.Codef(reporter.CodeInvalidEnumValue, "%s: proto3 requires that first value in enum have numeric value of 0", scope)); err != nil {
 			return err
 		}
 	}
@@ -372,7 +404,7 @@ func validateEnum(res *result, syntax syntaxType, name protoreflect.FullName, ed
 			} else {
 				evNode := res.EnumValueNode(evd)
 				evNodeInfo := res.file.NodeInfo(evNode.GetNumber())
-				if err := handler.HandleErrorf(evNodeInfo, "%s: values %s and %s both have the same numeric value %d; use allow_alias option if intentional", scope, existing, evd.GetName(), evd.GetNumber()); err != nil {
+				if err := handler.HandleErrorWithPos(evNodeInfo, reporter.Codef(reporter.CodeEnumValueCollision, "%s: values %s and %s both have the same numeric value %d; use allow_alias option if intentional", scope, existing, evd.GetName(), evd.GetNumber())); err != nil {
 					return err
 				}
 			}
@@ -382,7 +414,7 @@ func validateEnum(res *result, syntax syntaxType, name protoreflect.FullName, ed
 	if allowAlias && !hasAlias {
 		optNode := res.OptionNode(allowAliasOpt)
 		optNodeInfo := res.file.NodeInfo(optNode.GetValue())
-		if err := handler.HandleErrorf(optNodeInfo, "%s: allow_alias is true but no values are aliases", scope); err != nil {
+		if err := handler.HandleErrorWithPos(optNodeInfo, reporter.Codef(reporter.CodeInvalidOption, "%s: allow_alias is true but no values are aliases", scope)); err != nil {
 			return err
 		}
 	}
@@ -397,7 +429,7 @@ func validateEnum(res *result, syntax syntaxType, name protoreflect.FullName, ed
 	for i := 1; i < len(rsvd); i++ {
 		if rsvd[i].start <= rsvd[i-1].end {
 			rangeNodeInfo := res.file.NodeInfo(rsvd[i].node)
-			if err := handler.HandleErrorf(rangeNodeInfo, "%s: reserved ranges overlap: %d to %d and %d to %d", scope, rsvd[i-1].start, rsvd[i-1].end, rsvd[i].start, rsvd[i].end); err != nil {
+			if err := handler.HandleErrorWithPos(rangeNodeInfo, reporter.Codef(reporter.CodeOverlappingRanges, "%s: reserved ranges overlap: %d to %d and %d to %d", scope, rsvd[i-1].start, rsvd[i-1].end, rsvd[i].start, rsvd[i].end)); err != nil {
 				return err
 			}
 		}
@@ -411,7 +443,7 @@ func validateEnum(res *result, syntax syntaxType, name protoreflect.FullName, ed
 		if !isIdentifier(n) {
 			node := findEnumReservedNameNode(res.EnumNode(ed), n)
 			nodeInfo := res.file.NodeInfo(node)
-			if err := handler.HandleErrorf(nodeInfo, "%s: reserved name %q is not a valid identifier", scope, n); err != nil {
+			if err := handler.HandleErrorWithPos(nodeInfo, reporter.Codef(reporter.CodeInvalidName, "%s: reserved name %q is not a valid identifier", scope, n)); err != nil {
 				return err
 			}
 		}
@@ -421,7 +453,7 @@ func validateEnum(res *result, syntax syntaxType, name protoreflect.FullName, ed
 		evn := res.EnumValueNode(ev)
 		if _, ok := rsvdNames[ev.GetName()]; ok {
 			enumValNodeInfo := res.file.NodeInfo(evn.GetName())
-			if err := handler.HandleErrorf(enumValNodeInfo, "%s: value %s is using a reserved name", scope, ev.GetName()); err != nil {
+			if err := handler.HandleErrorWithPos(enumValNodeInfo, reporter.Codef(reporter.CodeReservedName, "%s: value %s is using a reserved name", scope, ev.GetName())); err != nil {
 				return err
 			}
 		}
@@ -429,7 +461,7 @@ func validateEnum(res *result, syntax syntaxType, name protoreflect.FullName, ed
 		r := sort.Search(len(rsvd), func(index int) bool { return rsvd[index].end >= ev.GetNumber() })
 		if r < len(rsvd) && rsvd[r].start <= ev.GetNumber() {
 			enumValNodeInfo := res.file.NodeInfo(evn.GetNumber())
-			if err := handler.HandleErrorf(enumValNodeInfo, "%s: value %s is using number %d which is in reserved range %d to %d", scope, ev.GetName(), ev.GetNumber(), rsvd[r].start, rsvd[r].end); err != nil {
+			if err := handler.HandleErrorWithPos(enumValNodeInfo, reporter.Codef(reporter.CodeReservedNumber, "%s: value %s is using number %d which is in reserved range %d to %d", scope, ev.GetName(), ev.GetNumber(), rsvd[r].start, rsvd[r].end)); err != nil {
 				return err
 			}
 		}
@@ -458,26 +490,26 @@ func validateField(res *result, syntax syntaxType, name protoreflect.FullName, f
 	node := res.FieldNode(fld)
 	if fld.Number == nil {
 		fieldTagNodeInfo := res.file.NodeInfo(node)
-		if err := handler.HandleErrorf(fieldTagNodeInfo, "%s: missing field tag number", scope); err != nil {
+		if err := handler.HandleErrorWithPos(fieldTagNodeInfo, reporter.Codef(reporter.CodeInvalidTag, "%s: missing field tag number", scope)); err != nil {
 			return err
 		}
 	}
 	if syntax != syntaxProto2 {
 		if fld.GetType() == descriptorpb.FieldDescriptorProto_TYPE_GROUP {
 			groupNodeInfo := res.file.NodeInfo(node.GetGroupKeyword())
-			if err := handler.HandleErrorf(groupNodeInfo, "%s: groups are not allowed in proto3 or editions", scope); err != nil {
+			if err := handler.HandleErrorWithPos(groupNodeInfo, reporter.Codef(reporter.CodeNotAllowedInSyntax, "%s: groups are not allowed in proto3 or editions", scope)); err != nil {
 				return err
 			}
 		} else if fld.Label != nil && fld.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REQUIRED {
 			fieldLabelNodeInfo := res.file.NodeInfo(node.FieldLabel())
-			if err := handler.HandleErrorf(fieldLabelNodeInfo, "%s: label 'required' is not allowed in proto3 or editions", scope); err != nil {
+			if err := handler.HandleErrorWithPos(fieldLabelNodeInfo, reporter.Codef(reporter.CodeNotAllowedInSyntax, "%s: label 'required' is not allowed in proto3 or editions", scope)); err != nil {
 				return err
 			}
 		}
 		if syntax == syntaxEditions {
 			if fld.Label != nil && fld.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL {
 				fieldLabelNodeInfo := res.file.NodeInfo(node.FieldLabel())
-				if err := handler.HandleErrorf(fieldLabelNodeInfo, "%s: label 'optional' is not allowed in editions; use option features.field_presence instead", scope); err != nil {
+				if err := handler.HandleErrorWithPos(fieldLabelNodeInfo, reporter.Codef(reporter.CodeNotAllowedInSyntax, "%s: label 'optional' is not allowed in editions; use option features.field_presence instead", scope)); err != nil {
 					return err
 				}
 			}
@@ -486,7 +518,7 @@ func validateField(res *result, syntax syntaxType, name protoreflect.FullName, f
 			} else if index >= 0 {
 				optNode := res.OptionNode(fld.Options.GetUninterpretedOption()[index])
 				optNameNodeInfo := res.file.NodeInfo(optNode.GetName())
-				if err := handler.HandleErrorf(optNameNodeInfo, "%s: packed option is not allowed in editions; use option features.repeated_field_encoding instead", scope); err != nil {
+				if err := handler.HandleErrorWithPos(optNameNodeInfo, reporter.Codef(reporter.CodeNotAllowedInSyntax, "%s: packed option is not allowed in editions; use option features.repeated_field_encoding instead", scope)); err != nil {
 					return err
 				}
 			}
@@ -496,7 +528,7 @@ func validateField(res *result, syntax syntaxType, name protoreflect.FullName, f
 			} else if index >= 0 {
 				optNode := res.OptionNode(fld.Options.GetUninterpretedOption()[index])
 				optNameNodeInfo := res.file.NodeInfo(optNode.GetName())
-				if err := handler.HandleErrorf(optNameNodeInfo, "%s: default values are not allowed in proto3", scope); err != nil {
+				if err := handler.HandleErrorWithPos(optNameNodeInfo, reporter.Codef(reporter.CodeNotAllowedInSyntax, "%s: default values are not allowed in proto3", scope)); err != nil {
 					return err
 				}
 			}
@@ -504,13 +536,13 @@ func validateField(res *result, syntax syntaxType, name protoreflect.FullName, f
 	} else {
 		if fld.Label == nil && fld.OneofIndex == nil {
 			fieldNameNodeInfo := res.file.NodeInfo(node.FieldName())
-			if err := handler.HandleErrorf(fieldNameNodeInfo, "%s: field has no label; proto2 requires explicit 'optional' label", scope); err != nil {
+			if err := handler.HandleErrorWithPos(fieldNameNodeInfo, reporter.Codef(reporter.CodeInvalidLabel, "%s: field has no label; proto2 requires explicit 'optional' label", scope)); err != nil {
 				return err
 			}
 		}
 		if fld.GetExtendee() != "" && fld.Label != nil && fld.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REQUIRED {
 			fieldLabelNodeInfo := res.file.NodeInfo(node.FieldLabel())
-			if err := handler.HandleErrorf(fieldLabelNodeInfo, "%s: extension fields cannot be 'required'", scope); err != nil {
+			if err := handler.HandleErrorWithPos(fieldLabelNodeInfo, reporter.Codef(reporter.CodeInvalidLabel, "%s: extension fields cannot be 'required'", scope)); err != nil {
 				return err
 			}
 		}
@@ -16,7 +16,6 @@ package parser
 
 import (
 	"errors"
-	"os"
 	"os/exec"
 	"strings"
 	"testing"
@@ -24,18 +23,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/bufbuild/protocompile/internal"
 	"github.com/bufbuild/protocompile/internal/protoc"
 	"github.com/bufbuild/protocompile/reporter"
 )
 
-func TestMain(m *testing.M) {
-	// Enable just for tests.
-	internal.AllowEditions = true
-	status := m.Run()
-	os.Exit(status)
-}
-
 func TestBasicValidation(t *testing.T) {
 	t.Parallel()
 	testCases := map[string]struct {
@@ -1035,6 +1026,54 @@ func TestBasicValidation(t *testing.T) {
 	}
 }
 
+func TestValidationErrorCodes(t *testing.T) {
+	t.Parallel()
+	var errs []error
+	handler := reporter.NewHandler(reporter.NewReporter(
+		func(err reporter.ErrorWithPos) error {
+			errs = append(errs, err)
+			return nil
+		},
+		func(err reporter.ErrorWithPos) {
+			errs = append(errs, err)
+		},
+	))
+	contents := `message Foo {
+  optional string a = 1;
+  optional int32 b = 1;
+  reserved "c";
+  optional bool c = 2;
+  optional bool d = 3 4;
+}`
+	ast, err := Parse("test.proto", strings.NewReader(contents), handler)
+	require.ErrorIs(t, err, reporter.ErrInvalidSource)
+	_, _ = ResultFromAST(ast, true, handler)
+
+	codes := make([]reporter.Code, len(errs))
+	for i, err := range errs {
+		codes[i] = reporter.CodeOf(err)
+	}
+	assert.Equal(t, []reporter.Code{
+		reporter.CodeSyntax,
+		reporter.CodeSyntax,
+		reporter.CodeNoSyntax,
+		reporter.CodeTagCollision,
+		reporter.CodeReservedName,
+	}, codes)
+	assert.ErrorIs(t, errs[2], ErrNoSyntax)
+
+	var collision ErrorTagCollision
+	require.ErrorAs(t, errs[3], &collision)
+	assert.Equal(t, "test.proto:3:22: message Foo: fields a and b both have the same tag 1", errs[3].Error())
+	assert.Equal(t, "Foo", string(collision.Message()))
+	assert.Equal(t, int32(1), collision.Tag())
+	assert.Equal(t, "b", collision.Field())
+	assert.Equal(t, "a", collision.PreviousField())
+	assert.Equal(t, 3, collision.Span().Start().Line)
+	assert.Equal(t, 2, collision.PreviousSpan().Start().Line)
+	assert.Equal(t, 23, collision.PreviousSpan().Start().Col)
+}
+
 func testByProtoc(t *testing.T, fileContents string, expectSuccess bool) {
 	t.Helper()
 	stdout, err := protoc.Compile(map[string]string{"test.proto": fileContents}, nil)
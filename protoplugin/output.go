@@ -0,0 +1,162 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protoplugin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Output accumulates the files generated by one or more plugins for a
+// single output directory. Like protoc, files are kept in memory until
+// all plugins have run, so that a plugin can insert content into files
+// generated by an earlier plugin.
+//
+// The zero value is an empty output, ready to use.
+type Output struct {
+	files map[string][]byte
+	// names of files, in the order they were first generated
+	names []string
+	// the most recently generated file, to which content
+	// is appended when a response file has no name
+	last string
+}
+
+// Add adds the files in the given response to the output.
+//
+// A response file that has an insertion point is inserted into a file that
+// was generated earlier, immediately before the line that contains the text
+// "@@protoc_insertion_point(NAME)". Each line of inserted content is indented
+// the same as that line. But if the insertion point is inside an inline
+// comment, like "/* @@protoc_insertion_point(NAME) */", content is inserted
+// immediately before the comment, without indentation. A response file with
+// no name has its content appended to the previous file.
+//
+// An error is returned if a file is generated more than once, if an insertion
+// targets a file or insertion point that does not exist, or if a file name is
+// not a clean, relative path.
+func (o *Output) Add(resp *pluginpb.CodeGeneratorResponse) error {
+	if o.files == nil {
+		o.files = map[string][]byte{}
+	}
+	for _, file := range resp.File {
+		name := file.GetName()
+		switch {
+		case name == "" && file.GetInsertionPoint() == "":
+			if o.last == "" {
+				return fmt.Errorf("first file chunk returned by plugin did not specify a file name")
+			}
+			o.files[o.last] = append(o.files[o.last], file.GetContent()...)
+			continue
+		case !isValidName(name):
+			return fmt.Errorf("%q: output file name is not a clean, relative path", name)
+		case file.GetInsertionPoint() != "":
+			contents, ok := o.files[name]
+			if !ok {
+				return fmt.Errorf("%s: Tried to insert into file that doesn't exist.", name)
+			}
+			contents, err := insert(contents, file.GetInsertionPoint(), file.GetContent())
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			o.files[name] = contents
+		default:
+			if _, ok := o.files[name]; ok {
+				return fmt.Errorf("%s: Tried to write the same file twice.", name)
+			}
+			o.files[name] = []byte(file.GetContent())
+			o.names = append(o.names, name)
+		}
+		o.last = name
+	}
+	return nil
+}
+
+// Files returns the names of the generated files, in the order in which they
+// were first added.
+func (o *Output) Files() []string {
+	return append([]string(nil), o.names...)
+}
+
+// Content returns the content of the generated file with the given name, or
+// false if no such file was generated.
+func (o *Output) Content(name string) ([]byte, bool) {
+	contents, ok := o.files[name]
+	return contents, ok
+}
+
+// WriteToDir writes all generated files to the given directory, creating
+// parent directories as needed.
+func (o *Output) WriteToDir(dir string) error {
+	for _, name := range o.names {
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o777); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, o.files[name], 0o666); err != nil { //nolint:gosec // same permissions as protoc
+			return err
+		}
+	}
+	return nil
+}
+
+func isValidName(name string) bool {
+	return name != "" && !path.IsAbs(name) && path.Clean(name) == name &&
+		name != ".." && !strings.HasPrefix(name, "../") && !strings.Contains(name, "\\")
+}
+
+// insert inserts the given content into contents at the named insertion point.
+func insert(contents []byte, insertionPoint, content string) ([]byte, error) {
+	marker := []byte("@@protoc_insertion_point(" + insertionPoint + ")")
+	pos := bytes.Index(contents, marker)
+	if pos < 0 {
+		return nil, fmt.Errorf("insertion point %q not found", insertionPoint)
+	}
+	lineStart := bytes.LastIndexByte(contents[:pos], '\n') + 1
+	linePrefix := contents[lineStart:pos]
+
+	var insertAt int
+	var indent []byte
+	if bytes.HasSuffix(linePrefix, []byte("/* ")) {
+		// The insertion point is in an inline comment, so
+		// insert right before the comment.
+		insertAt = pos - 3
+	} else {
+		insertAt = lineStart
+		indent = linePrefix[:len(linePrefix)-len(bytes.TrimLeft(linePrefix, " \t"))]
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(contents) + len(content))
+	buf.Write(contents[:insertAt])
+	if len(indent) == 0 {
+		buf.WriteString(content)
+	} else {
+		for _, line := range strings.SplitAfter(content, "\n") {
+			if line != "" && line != "\n" {
+				buf.Write(indent)
+			}
+			buf.WriteString(line)
+		}
+	}
+	buf.Write(contents[insertAt:])
+	return buf.Bytes(), nil
+}
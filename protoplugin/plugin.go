@@ -0,0 +1,153 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protoplugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Plugin is a protoc plugin executable.
+type Plugin struct {
+	// The name of the plugin. If Path is empty, the plugin is found by
+	// searching the PATH environment variable for an executable named
+	// "protoc-gen-" followed by this name, the same way as protoc.
+	Name string
+	// The path to the plugin executable. If empty, it is found using Name.
+	Path string
+	// Optional arguments to pass to the plugin executable. Plugins are
+	// normally invoked without any arguments.
+	Args []string
+	// Optional writer to which the plugin's standard error is written. If
+	// nil, the plugin's standard error is instead included in the error
+	// returned from Run if the plugin fails.
+	Stderr io.Writer
+}
+
+func (p *Plugin) displayName() string {
+	if p.Path != "" {
+		return filepath.Base(p.Path)
+	}
+	return "protoc-gen-" + p.Name
+}
+
+// Run runs the plugin executable, sending it the given request on standard
+// input and reading its response from standard output.
+//
+// An error is returned if the plugin cannot be run, if it exits with a
+// non-zero status, or if its response indicates an error. Like protoc, this
+// also returns an error if any of the files to generate use a feature, such
+// as proto3 optional fields or editions, that the plugin does not report it
+// supports.
+func (p *Plugin) Run(ctx context.Context, req *pluginpb.CodeGeneratorRequest) (*pluginpb.CodeGeneratorResponse, error) {
+	name := p.displayName()
+	path := p.Path
+	if path == "" {
+		var err error
+		path, err = exec.LookPath(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: program not found or is not executable", name)
+		}
+	}
+
+	reqData, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, p.Args...)
+	cmd.Stdin = bytes.NewReader(reqData)
+	cmd.Stdout = &stdout
+	if p.Stderr != nil {
+		cmd.Stderr = p.Stderr
+	} else {
+		cmd.Stderr = &stderr
+	}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		msg := fmt.Sprintf("%s: Plugin failed with status code %d.", name, exitErr.ExitCode())
+		if details := strings.TrimSpace(stderr.String()); details != "" {
+			msg += "\n" + details
+		}
+		return nil, errors.New(msg)
+	}
+
+	var resp pluginpb.CodeGeneratorResponse
+	if err := proto.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("%s: plugin output is unparseable: %w", name, err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("%s: %s", name, resp.GetError())
+	}
+	if err := checkFeatures(name, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// checkFeatures returns an error if any of the files to generate use
+// features that the plugin does not support.
+func checkFeatures(name string, req *pluginpb.CodeGeneratorRequest, resp *pluginpb.CodeGeneratorResponse) error {
+	features := resp.GetSupportedFeatures()
+	toGenerate := make(map[string]struct{}, len(req.FileToGenerate))
+	for _, file := range req.FileToGenerate {
+		toGenerate[file] = struct{}{}
+	}
+	for _, fd := range req.ProtoFile {
+		if _, ok := toGenerate[fd.GetName()]; !ok {
+			continue
+		}
+		if fd.GetSyntax() == "editions" &&
+			features&uint64(pluginpb.CodeGeneratorResponse_FEATURE_SUPPORTS_EDITIONS) == 0 {
+			return fmt.Errorf("%s is an editions file, but code generator %s hasn't been updated to "+
+				"support editions yet. Please ask the owner of this code generator to add support or "+
+				"switch back to proto2/proto3.", fd.GetName(), name)
+		}
+		if fd.GetSyntax() == "proto3" && hasProto3Optional(fd.MessageType) &&
+			features&uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL) == 0 {
+			return fmt.Errorf("%s is a proto3 file that contains optional fields, but code generator %s "+
+				"hasn't been updated to support optional fields in proto3. Please ask the owner of this "+
+				"code generator to support proto3 optional.", fd.GetName(), name)
+		}
+	}
+	return nil
+}
+
+func hasProto3Optional(msgs []*descriptorpb.DescriptorProto) bool {
+	for _, msg := range msgs {
+		for _, fld := range msg.Field {
+			if fld.GetProto3Optional() {
+				return true
+			}
+		}
+		if hasProto3Optional(msg.NestedType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protoplugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+)
+
+// When the test binary is run with this environment variable set, it acts as
+// a plugin instead of running tests. The plugin's behavior is determined by
+// its first argument.
+const testPluginEnv = "PROTOPLUGIN_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) != "" {
+		os.Exit(runTestPlugin(os.Args[1]))
+	}
+	if err := os.Setenv(testPluginEnv, "1"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func runTestPlugin(mode string) int {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		panic(err)
+	}
+	var req pluginpb.CodeGeneratorRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		panic(err)
+	}
+	resp := &pluginpb.CodeGeneratorResponse{
+		SupportedFeatures: proto.Uint64(uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)),
+	}
+	switch mode {
+	case "fail":
+		_, _ = fmt.Fprintln(os.Stderr, "something went wrong")
+		return 1
+	case "error":
+		resp.Error = proto.String("bad parameter")
+	case "no-features":
+		resp.SupportedFeatures = nil
+	default:
+		for _, name := range req.FileToGenerate {
+			resp.File = append(resp.File, &pluginpb.CodeGeneratorResponse_File{
+				Name:    proto.String(strings.TrimSuffix(name, ".proto") + ".txt"),
+				Content: proto.String(fmt.Sprintf("%s %s %d\n", name, req.GetParameter(), len(req.ProtoFile))),
+			})
+		}
+	}
+	data, err = proto.Marshal(resp)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := os.Stdout.Write(data); err != nil {
+		panic(err)
+	}
+	return 0
+}
+
+func TestNewRequest(t *testing.T) {
+	t.Parallel()
+	files := compile(t, map[string]string{
+		"a.proto": `
+			syntax = "proto3";
+			import "b.proto";
+			import "google/protobuf/descriptor.proto";
+			extend google.protobuf.MessageOptions {
+				string tag = 50000 [retention = RETENTION_SOURCE];
+			}
+			// A is a message.
+			message A {
+				option (tag) = "abc";
+				B b = 1;
+			}`,
+		"b.proto": `
+			syntax = "proto3";
+			message B {}`,
+	}, "a.proto")
+
+	req, err := NewRequest(files, "foo=bar")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.proto"}, req.FileToGenerate)
+	assert.Equal(t, "foo=bar", req.GetParameter())
+	assert.True(t, proto.Equal(CompilerVersion(), req.CompilerVersion))
+	var names []string
+	for _, fd := range req.ProtoFile {
+		names = append(names, fd.GetName())
+	}
+	assert.Equal(t, []string{"b.proto", "google/protobuf/descriptor.proto", "a.proto"}, names)
+	// source info is retained, but source-retention options are not
+	assert.NotNil(t, req.ProtoFile[2].SourceCodeInfo)
+	assert.Nil(t, req.ProtoFile[2].MessageType[0].Options)
+	require.Len(t, req.SourceFileDescriptors, 1)
+	assert.Equal(t, "a.proto", req.SourceFileDescriptors[0].GetName())
+	assert.NotNil(t, req.SourceFileDescriptors[0].MessageType[0].Options)
+
+	req, err = NewRequest(files, "")
+	require.NoError(t, err)
+	assert.Nil(t, req.Parameter)
+}
+
+func TestPlugin_Run(t *testing.T) {
+	t.Parallel()
+	files := compile(t, map[string]string{
+		"a.proto": `
+			syntax = "proto3";
+			message A {
+				optional string name = 1;
+			}`,
+		"b.proto": `
+			syntax = "proto3";
+			import "a.proto";
+			message B {}`,
+	}, "b.proto")
+	req, err := NewRequest(files, "xyz")
+	require.NoError(t, err)
+
+	plugin := &Plugin{Name: "test", Path: os.Args[0], Args: []string{"ok"}}
+	resp, err := plugin.Run(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.File, 1)
+	assert.Equal(t, "b.txt", resp.File[0].GetName())
+	assert.Equal(t, "b.proto xyz 2\n", resp.File[0].GetContent())
+
+	name := filepath.Base(os.Args[0])
+	plugin.Args = []string{"fail"}
+	_, err = plugin.Run(context.Background(), req)
+	assert.EqualError(t, err, name+": Plugin failed with status code 1.\nsomething went wrong")
+
+	plugin.Args = []string{"error"}
+	_, err = plugin.Run(context.Background(), req)
+	assert.EqualError(t, err, name+": bad parameter")
+
+	// Files that don't use proto3 optional can be sent to plugins that don't support it.
+	plugin.Args = []string{"no-features"}
+	_, err = plugin.Run(context.Background(), req)
+	require.NoError(t, err)
+	req.FileToGenerate = []string{"a.proto"}
+	_, err = plugin.Run(context.Background(), req)
+	assert.ErrorContains(t, err, "a.proto is a proto3 file that contains optional fields, but code generator "+name+
+		" hasn't been updated to support optional fields in proto3.")
+
+	plugin = &Plugin{Name: "protocompile-test-does-not-exist"}
+	_, err = plugin.Run(context.Background(), req)
+	assert.EqualError(t, err, "protoc-gen-protocompile-test-does-not-exist: program not found or is not executable")
+}
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+	var output Output
+	require.NoError(t, output.Add(&pluginpb.CodeGeneratorResponse{
+		File: []*pluginpb.CodeGeneratorResponse_File{
+			{
+				Name:    proto.String("foo/a.txt"),
+				Content: proto.String("class A {\n  // @@protoc_insertion_point(members)\n}\n"),
+			},
+			{
+				// no name: appended to previous file
+				Content: proto.String("int x = 1; /* @@protoc_insertion_point(x) */\n"),
+			},
+			{
+				Name:    proto.String("b.txt"),
+				Content: proto.String("b\n"),
+			},
+		},
+	}))
+	require.NoError(t, output.Add(&pluginpb.CodeGeneratorResponse{
+		File: []*pluginpb.CodeGeneratorResponse_File{
+			{
+				Name:           proto.String("foo/a.txt"),
+				InsertionPoint: proto.String("members"),
+				Content:        proto.String("int a;\n\nint b;\n"),
+			},
+			{
+				Name:           proto.String("foo/a.txt"),
+				InsertionPoint: proto.String("x"),
+				Content:        proto.String("+ 2"),
+			},
+		},
+	}))
+	assert.Equal(t, []string{"foo/a.txt", "b.txt"}, output.Files())
+	contents, ok := output.Content("foo/a.txt")
+	require.True(t, ok)
+	assert.Equal(t, "class A {\n  int a;\n\n  int b;\n  // @@protoc_insertion_point(members)\n}\n"+
+		"int x = 1; + 2/* @@protoc_insertion_point(x) */\n", string(contents))
+
+	dir := t.TempDir()
+	require.NoError(t, output.WriteToDir(dir))
+	data, err := os.ReadFile(filepath.Join(dir, "foo", "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, contents, data)
+	data, err = os.ReadFile(filepath.Join(dir, "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "b\n", string(data))
+
+	testCases := map[string]*pluginpb.CodeGeneratorResponse_File{
+		"b.txt: Tried to write the same file twice.": {
+			Name: proto.String("b.txt"),
+		},
+		"c.txt: Tried to insert into file that doesn't exist.": {
+			Name:           proto.String("c.txt"),
+			InsertionPoint: proto.String("x"),
+		},
+		`b.txt: insertion point "x" not found`: {
+			Name:           proto.String("b.txt"),
+			InsertionPoint: proto.String("x"),
+		},
+		`"../c.txt": output file name is not a clean, relative path`: {
+			Name: proto.String("../c.txt"),
+		},
+		`"/c.txt": output file name is not a clean, relative path`: {
+			Name: proto.String("/c.txt"),
+		},
+	}
+	for expectedErr, file := range testCases {
+		err := output.Add(&pluginpb.CodeGeneratorResponse{File: []*pluginpb.CodeGeneratorResponse_File{file}})
+		assert.EqualError(t, err, expectedErr)
+	}
+}
+
+func compile(t *testing.T, sources map[string]string, names ...string) linker.Files {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), names...)
+	require.NoError(t, err)
+	return files
+}
@@ -0,0 +1,88 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package protoplugin supports running protoc plugins, which generate code
+// from compiled files. This allows the output of a protocompile.Compiler
+// to be used for code generation, the same way as protoc's --NAME_out
+// flags.
+//
+// The process involves three steps:
+//  1. Create a request that describes the files for which code should be
+//     generated. Also see: NewRequest
+//  2. Send the request to a plugin executable, which returns a response
+//     that contains the generated files. Also see: Plugin.Run
+//  3. Write the generated files to disk, applying any insertions into
+//     files generated earlier. Also see: Output
+package protoplugin
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/bufbuild/protocompile/internal/descset"
+	"github.com/bufbuild/protocompile/linker"
+)
+
+// CompilerVersion returns the version of protoc whose output is matched
+// by this module. This is the version reported to plugins in requests.
+func CompilerVersion() *pluginpb.Version {
+	return &pluginpb.Version{
+		Major: proto.Int32(25),
+		Minor: proto.Int32(0),
+		Patch: proto.Int32(0),
+	}
+}
+
+// NewRequest returns a request that asks a plugin to generate code for the
+// given files, using the given parameter. The parameter is a plugin-specific
+// string, which is omitted from the request if empty.
+//
+// The request's proto_file field contains the given files and all of their
+// transitive dependencies, in topological order. Like protoc, options with
+// source-only retention are removed from these. The given files are also
+// included in the source_file_descriptors field, with all options retained.
+//
+// Plugins generally use source code info to include comments in generated
+// code. So the given files should be compiled with source code info enabled.
+// The request's compiler_version field is set to the value returned by
+// CompilerVersion. Callers can modify the returned request if needed.
+func NewRequest(files linker.Files, parameter string) (*pluginpb.CodeGeneratorRequest, error) {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate:        make([]string, len(files)),
+		SourceFileDescriptors: make([]*descriptorpb.FileDescriptorProto, len(files)),
+		CompilerVersion:       CompilerVersion(),
+	}
+	if parameter != "" {
+		req.Parameter = proto.String(parameter)
+	}
+	for i, file := range files {
+		req.FileToGenerate[i] = file.Path()
+		fd, err := descset.FileProto(file, true)
+		if err != nil {
+			return nil, err
+		}
+		req.SourceFileDescriptors[i] = fd
+	}
+	allFiles := descset.Transitive(files)
+	req.ProtoFile = make([]*descriptorpb.FileDescriptorProto, len(allFiles))
+	for i, file := range allFiles {
+		fd, err := descset.FileProto(file, false)
+		if err != nil {
+			return nil, err
+		}
+		req.ProtoFile[i] = fd
+	}
+	return req, nil
+}
@@ -0,0 +1,284 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protoprint
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/bufbuild/protocompile/internal"
+)
+
+// option is a single option, as it appears in an option statement.
+type option struct {
+	name  string
+	value string
+	// the path of the option, relative to the options message
+	path []int32
+}
+
+// optionStatements prints the given options as option statements. The given
+// path is the path of the options message.
+func (p *printer) optionStatements(opts proto.Message, path []int32, scope string) {
+	for _, opt := range p.options(opts, scope) {
+		optPath := append(clonePath(path), opt.path...)
+		p.leadingComments(optPath)
+		p.linef("option %s = %s;%s", opt.name, opt.value, p.trailingComments(optPath))
+	}
+}
+
+// options returns the options set in the given options message, ordered by
+// field number. Repeated options result in an option for each element, and
+// features are flattened into an option for each feature.
+func (p *printer) options(opts proto.Message, scope string) []option {
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return nil
+	}
+	msg := p.interpret(opts)
+	if msg == nil {
+		return nil
+	}
+	var result []option
+	for _, fld := range sortedFields(msg) {
+		if !fld.IsExtension() && fld.Number() == internal.UninterpretedOptionsTag {
+			p.setErr(fmt.Errorf("%s: %s has options that have not been interpreted", p.fd.GetName(), msg.Descriptor().Name()))
+			continue
+		}
+		// features are flattened, since message literals are not allowed for them
+		flatten := !fld.IsExtension() && fld.Name() == "features"
+		result = p.appendOption(result, p.optionName(fld, scope), []int32{int32(fld.Number())}, fld, msg.Get(fld), scope, flatten)
+	}
+	return result
+}
+
+func (p *printer) appendOption(result []option, name string, path []int32, fld protoreflect.FieldDescriptor, val protoreflect.Value, scope string, flatten bool) []option {
+	switch {
+	case fld.IsList():
+		list := val.List()
+		for i := 0; i < list.Len(); i++ {
+			result = append(result, option{name: name, value: p.value(fld, list.Get(i)), path: append(clonePath(path), int32(i))})
+		}
+	case flatten && fld.Message() != nil && !fld.IsMap():
+		msg := val.Message()
+		fields := sortedFields(msg)
+		if len(fields) == 0 || len(msg.GetUnknown()) > 0 {
+			result = append(result, option{name: name, value: p.value(fld, val), path: path})
+			break
+		}
+		for _, subFld := range fields {
+			subName := name + "." + p.optionName(subFld, scope)
+			subPath := append(clonePath(path), int32(subFld.Number()))
+			result = p.appendOption(result, subName, subPath, subFld, msg.Get(subFld), scope, true)
+		}
+	default:
+		result = append(result, option{name: name, value: p.value(fld, val), path: path})
+	}
+	return result
+}
+
+func (p *printer) optionName(fld protoreflect.FieldDescriptor, scope string) string {
+	if fld.IsExtension() {
+		return "(" + p.typeName(string(fld.FullName()), scope) + ")"
+	}
+	return string(fld.Name())
+}
+
+// interpret returns the given options message as a dynamic message, using
+// the resolver to recognize custom options. The descriptor for the options
+// message also comes from the resolver, if it has one, so that options that
+// are newer than the descriptor.proto linked into this program are
+// recognized, too.
+func (p *printer) interpret(opts proto.Message) protoreflect.Message {
+	md := opts.ProtoReflect().Descriptor()
+	if p.resolver != nil {
+		if d, err := p.resolver.FindDescriptorByName(md.FullName()); err == nil {
+			if resolved, ok := d.(protoreflect.MessageDescriptor); ok {
+				md = resolved
+			}
+		}
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(opts)
+	if err != nil {
+		p.setErr(err)
+		return nil
+	}
+	msg := dynamicpb.NewMessage(md)
+	if err := (proto.UnmarshalOptions{Resolver: p.extTypes}).Unmarshal(data, msg); err != nil {
+		p.setErr(fmt.Errorf("%s: failed to interpret %s: %w", p.fd.GetName(), md.Name(), err))
+		return nil
+	}
+	if len(msg.GetUnknown()) > 0 {
+		p.setErr(fmt.Errorf("%s: %s has unrecognized options", p.fd.GetName(), md.Name()))
+	}
+	return msg
+}
+
+// value returns the given value in the syntax of an option value.
+func (p *printer) value(fld protoreflect.FieldDescriptor, val protoreflect.Value) string {
+	switch fld.Kind() {
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(val.Bool())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return strconv.FormatInt(val.Int(), 10)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(val.Uint(), 10)
+	case protoreflect.FloatKind:
+		return formatFloat(val.Float(), 32)
+	case protoreflect.DoubleKind:
+		return formatFloat(val.Float(), 64)
+	case protoreflect.StringKind:
+		return quote(val.String(), false)
+	case protoreflect.BytesKind:
+		return quote(string(val.Bytes()), true)
+	case protoreflect.EnumKind:
+		if enumVal := fld.Enum().Values().ByNumber(val.Enum()); enumVal != nil {
+			return string(enumVal.Name())
+		}
+		return strconv.FormatInt(int64(val.Enum()), 10)
+	default:
+		return p.messageLiteral(val.Message())
+	}
+}
+
+// messageLiteral returns the given message in the text format.
+func (p *printer) messageLiteral(msg protoreflect.Message) string {
+	if len(msg.GetUnknown()) > 0 {
+		p.setErr(fmt.Errorf("%s: option value of type %s has unrecognized fields", p.fd.GetName(), msg.Descriptor().FullName()))
+	}
+	fields := sortedFields(msg)
+	parts := make([]string, 0, len(fields))
+	for _, fld := range fields {
+		name := textName(fld)
+		val := msg.Get(fld)
+		switch {
+		case fld.IsMap():
+			parts = append(parts, p.mapEntries(name, fld, val.Map())...)
+		case fld.IsList():
+			list := val.List()
+			elems := make([]string, list.Len())
+			for i := range elems {
+				elems[i] = p.value(fld, list.Get(i))
+			}
+			parts = append(parts, fmt.Sprintf("%s: [%s]", name, strings.Join(elems, ", ")))
+		case fld.Message() != nil:
+			parts = append(parts, name+" "+p.value(fld, val))
+		default:
+			parts = append(parts, name+": "+p.value(fld, val))
+		}
+	}
+	if len(parts) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(parts, " ") + " }"
+}
+
+// mapEntries returns the entries of the given map, sorted by key, in the
+// text format.
+func (p *printer) mapEntries(name string, fld protoreflect.FieldDescriptor, m protoreflect.Map) []string {
+	keyFld, valFld := fld.MapKey(), fld.MapValue()
+	entries := make([]string, 0, m.Len())
+	m.Range(func(key protoreflect.MapKey, val protoreflect.Value) bool {
+		keyStr := p.value(keyFld, key.Value())
+		if valFld.Message() != nil {
+			entries = append(entries, fmt.Sprintf("%s { key: %s value %s }", name, keyStr, p.value(valFld, val)))
+		} else {
+			entries = append(entries, fmt.Sprintf("%s { key: %s value: %s }", name, keyStr, p.value(valFld, val)))
+		}
+		return true
+	})
+	sort.Strings(entries)
+	return entries
+}
+
+// textName returns the name of the given field in the text format.
+func textName(fld protoreflect.FieldDescriptor) string {
+	switch {
+	case fld.IsExtension():
+		return "[" + string(fld.FullName()) + "]"
+	case fld.Kind() == protoreflect.GroupKind && strings.ToLower(string(fld.Message().Name())) == string(fld.Name()):
+		// group fields are named after their message
+		return string(fld.Message().Name())
+	default:
+		return string(fld.Name())
+	}
+}
+
+func sortedFields(msg protoreflect.Message) []protoreflect.FieldDescriptor {
+	var fields []protoreflect.FieldDescriptor
+	msg.Range(func(fld protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		fields = append(fields, fld)
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Number() < fields[j].Number()
+	})
+	return fields
+}
+
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, bitSize)
+	}
+}
+
+// quote returns the given string as a string literal. If isBytes is true,
+// or the string is not valid UTF-8, bytes that are not printable ASCII are
+// escaped as octal escapes.
+func quote(s string, isBytes bool) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := rune(s[i]), 1
+		if !isBytes && r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRuneInString(s[i:])
+		}
+		switch {
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r == '"':
+			buf.WriteString(`\"`)
+		case r == '\\':
+			buf.WriteString(`\\`)
+		case r == utf8.RuneError && size == 1, r < ' ', r == 0x7f, isBytes && r >= utf8.RuneSelf:
+			fmt.Fprintf(&buf, `\%03o`, s[i])
+		default:
+			buf.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
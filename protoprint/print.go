@@ -0,0 +1,985 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package protoprint generates proto source code from descriptors. This is
+// useful for descriptors that don't come with their source, like those
+// downloaded from a server via the gRPC reflection service.
+//
+// The generated source compiles to a descriptor that is equivalent to the
+// original: it defines the same elements, with the same options. Options are
+// printed with their interpreted values, including custom options, whose
+// message values are printed as message literals. If the descriptor includes
+// source code info, its comments are printed with the elements they describe,
+// and elements are printed in their original order. Otherwise, elements are
+// printed in the order they appear in the descriptor.
+//
+// The output is formatted by package format, except that imports are kept in
+// the order of the file's dependencies.
+package protoprint
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/bufbuild/protocompile/format"
+	"github.com/bufbuild/protocompile/internal"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/protoutil"
+)
+
+// Print writes proto source for the given file to w. Custom options are
+// recognized using the file's dependencies.
+func Print(w io.Writer, file linker.File) error {
+	return PrintProto(w, protoutil.ProtoFromFileDescriptor(file), linker.ResolverFromFile(file))
+}
+
+// PrintProto writes proto source for the given file descriptor proto to w.
+//
+// The given resolver is used to recognize custom options, which options
+// messages may store as unrecognized fields. It should be able to resolve
+// the elements that are visible to the file, like the resolver returned by
+// linker.ResolverFromFile. If it is nil, only standard options and extensions
+// in protoregistry.GlobalTypes are recognized.
+//
+// An error is returned if the file has options that cannot be recognized, or
+// that are not interpreted (as in descriptors that were parsed but not
+// linked), since equivalent source cannot be generated for them.
+func PrintProto(w io.Writer, fd *descriptorpb.FileDescriptorProto, resolver linker.Resolver) error {
+	p := newPrinter(fd, resolver)
+	p.printFile()
+	if p.err != nil {
+		return p.err
+	}
+	// Imports are printed in the order of the file's dependencies, which
+	// formatting must not change.
+	formatted, err := format.Source(fd.GetName(), p.buf.Bytes(), format.KeepImportOrder())
+	if err != nil {
+		return fmt.Errorf("%s: generated source is invalid: %w", fd.GetName(), err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+type printer struct {
+	fd       *descriptorpb.FileDescriptorProto
+	resolver linker.Resolver
+	extTypes interface {
+		protoregistry.ExtensionTypeResolver
+		FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error)
+	}
+	syntax string
+	// source code info locations, by path; extend blocks in the same scope
+	// have the same path, so there may be more than one
+	locations map[string][]*descriptorpb.SourceCodeInfo_Location
+	// the fully-qualified names of all elements in the file
+	symbols map[string]struct{}
+	// the packages of the file and of the files it imports
+	packages map[string]struct{}
+
+	buf    bytes.Buffer
+	indent int
+	// the first error encountered
+	err error
+}
+
+func newPrinter(fd *descriptorpb.FileDescriptorProto, resolver linker.Resolver) *printer {
+	p := &printer{
+		fd:        fd,
+		resolver:  resolver,
+		extTypes:  protoregistry.GlobalTypes,
+		syntax:    fd.GetSyntax(),
+		locations: map[string][]*descriptorpb.SourceCodeInfo_Location{},
+		symbols:   map[string]struct{}{},
+		packages:  map[string]struct{}{fd.GetPackage(): {}},
+	}
+	if p.syntax == "" {
+		p.syntax = "proto2"
+	}
+	for _, loc := range fd.GetSourceCodeInfo().GetLocation() {
+		key := pathKey(loc.Path)
+		p.locations[key] = append(p.locations[key], loc)
+	}
+	if resolver != nil {
+		p.extTypes = resolver
+		p.addPackages(append(fd.Dependency, internal.OptionDependencies(fd)...))
+	}
+	p.addSymbols(fd.GetPackage(), fd.MessageType, fd.EnumType, fd.Extension)
+	for _, svc := range fd.Service {
+		svcName := qualify(fd.GetPackage(), svc.GetName())
+		p.symbols[svcName] = struct{}{}
+		for _, mtd := range svc.Method {
+			p.symbols[qualify(svcName, mtd.GetName())] = struct{}{}
+		}
+	}
+	return p
+}
+
+func (p *printer) addPackages(deps []string) {
+	for _, dep := range deps {
+		file, err := p.resolver.FindFileByPath(dep)
+		if err != nil {
+			continue
+		}
+		p.packages[string(file.Package())] = struct{}{}
+		imports := file.Imports()
+		paths := make([]string, imports.Len())
+		for i := range paths {
+			paths[i] = imports.Get(i).Path()
+		}
+		p.addPackages(paths)
+	}
+}
+
+func (p *printer) addSymbols(scope string, msgs []*descriptorpb.DescriptorProto, enums []*descriptorpb.EnumDescriptorProto, exts []*descriptorpb.FieldDescriptorProto) {
+	for _, msg := range msgs {
+		msgName := qualify(scope, msg.GetName())
+		p.symbols[msgName] = struct{}{}
+		for _, fld := range msg.Field {
+			p.symbols[qualify(msgName, fld.GetName())] = struct{}{}
+		}
+		for _, oneof := range msg.OneofDecl {
+			p.symbols[qualify(msgName, oneof.GetName())] = struct{}{}
+		}
+		p.addSymbols(msgName, msg.NestedType, msg.EnumType, msg.Extension)
+	}
+	for _, enum := range enums {
+		p.symbols[qualify(scope, enum.GetName())] = struct{}{}
+		// enum values are siblings of the enum, not children
+		for _, val := range enum.Value {
+			p.symbols[qualify(scope, val.GetName())] = struct{}{}
+		}
+	}
+	for _, ext := range exts {
+		p.symbols[qualify(scope, ext.GetName())] = struct{}{}
+	}
+}
+
+func (p *printer) setErr(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *printer) linef(format string, args ...interface{}) {
+	for i := 0; i < p.indent; i++ {
+		p.buf.WriteString("  ")
+	}
+	_, _ = fmt.Fprintf(&p.buf, format, args...)
+	p.buf.WriteByte('\n')
+}
+
+// element is a declaration that is printed in the body of a file or message.
+// Elements are printed in the order of their source locations, if available.
+type element struct {
+	path []int32
+	// non-empty for extensions, which are grouped into extend blocks
+	extendee string
+	ext      *descriptorpb.FieldDescriptorProto
+	// true for messages, whose index is the last element of path
+	isMsg bool
+	// the fields declared by the element, some of which may be groups or
+	// maps, whose messages are printed as part of the field
+	fields []*descriptorpb.FieldDescriptorProto
+	print  func()
+}
+
+// sortElements sorts the given elements by the position of their source
+// locations. If any element has no location, the elements are instead put in
+// the order given by orderSynthesized.
+func (p *printer) sortElements(elems []element, nested nestedTypes) {
+	spans := make([][]int32, len(elems))
+	for i, elem := range elems {
+		loc := p.location(elem.path)
+		if loc == nil || len(loc.Span) < 2 {
+			p.orderSynthesized(elems, nested)
+			return
+		}
+		spans[i] = loc.Span
+	}
+	indexes := make([]int, len(elems))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := spans[indexes[i]], spans[indexes[j]]
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		return a[1] < b[1]
+	})
+	sorted := make([]element, len(elems))
+	for i, index := range indexes {
+		sorted[i] = elems[index]
+	}
+	copy(elems, sorted)
+}
+
+// orderSynthesized orders the given elements, which have no source locations.
+// When compiling, the message for a group or map field is added to the
+// messages of the enclosing scope where the field is declared. So, to keep
+// the order of messages when the output is compiled, such a field must be
+// printed after the messages that come before its message, and before the
+// ones that come after it. Other than that, elements keep their order: the
+// messages are printed where they were in the given elements if possible.
+func (p *printer) orderSynthesized(elems []element, nested nestedTypes) {
+	var msgs, others []element
+	// for each element of others, whether it was before the messages
+	var othersFirst []bool
+	// for each element of others, the index of the first message that it
+	// synthesizes, or -1 if none
+	var synthesized []int32
+	for _, elem := range elems {
+		if elem.isMsg {
+			msgs = append(msgs, elem)
+			continue
+		}
+		first := int32(-1)
+		for _, fld := range elem.fields {
+			if nested.mapEntry(fld) == nil && nested.group(p.syntax, fld) == nil {
+				continue
+			}
+			msgPath := nested.paths[fld.GetTypeName()]
+			if index := msgPath[len(msgPath)-1]; first == -1 || index < first {
+				first = index
+			}
+		}
+		others = append(others, elem)
+		othersFirst = append(othersFirst, len(msgs) == 0)
+		synthesized = append(synthesized, first)
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	// minAfter[i] is the smallest index of a message synthesized by others[i:]
+	minAfter := make([]int32, len(others)+1)
+	minAfter[len(others)] = math.MaxInt32
+	for i := len(others) - 1; i >= 0; i-- {
+		minAfter[i] = minAfter[i+1]
+		if synthesized[i] >= 0 && synthesized[i] < minAfter[i] {
+			minAfter[i] = synthesized[i]
+		}
+	}
+	msgIndex := func(elem element) int32 {
+		return elem.path[len(elem.path)-1]
+	}
+	ordered := make([]element, 0, len(elems))
+	var i, j int
+	for i < len(others) || j < len(msgs) {
+		// A message can be printed once no remaining element synthesizes
+		// a message that comes before it.
+		msgOK := j < len(msgs) && msgIndex(msgs[j]) < minAfter[i]
+		// Another element can be printed once all messages that come before
+		// the ones it synthesizes have been printed.
+		otherOK := i < len(others) && (synthesized[i] < 0 || j == len(msgs) || msgIndex(msgs[j]) > synthesized[i])
+		var takeMsg bool
+		switch {
+		case msgOK && otherOK:
+			takeMsg = !othersFirst[i]
+		case msgOK:
+			takeMsg = true
+		case otherOK:
+			takeMsg = false
+		default:
+			// There is no order that works for both, so just keep the
+			// order of the other elements.
+			takeMsg = false
+		}
+		if takeMsg {
+			ordered = append(ordered, msgs[j])
+			j++
+		} else {
+			ordered = append(ordered, others[i])
+			i++
+		}
+	}
+	copy(elems, ordered)
+}
+
+// printElements prints the given elements. Consecutive extensions of the
+// same message are printed in a single extend block.
+func (p *printer) printElements(elems []element, scope string, nested nestedTypes) {
+	p.sortElements(elems, nested)
+	for i := 0; i < len(elems); {
+		if elems[i].extendee == "" {
+			elems[i].print()
+			i++
+			continue
+		}
+		extendee := elems[i].extendee
+		block := p.extendBlock(elems[i].path)
+		p.locationComments(block)
+		p.linef("extend %s {%s", p.typeName(extendee, scope), trailingComments(block))
+		p.indent++
+		for ; i < len(elems) && elems[i].extendee == extendee && p.extendBlock(elems[i].path) == block; i++ {
+			p.field(elems[i].ext, elems[i].path, scope, nested)
+		}
+		p.indent--
+		p.linef("}")
+	}
+}
+
+// extendBlock returns the location of the extend block that contains the
+// extension with the given path, or nil if unknown.
+func (p *printer) extendBlock(extPath []int32) *descriptorpb.SourceCodeInfo_Location {
+	extLoc := p.location(extPath)
+	if extLoc == nil {
+		return nil
+	}
+	for _, loc := range p.locations[pathKey(extPath[:len(extPath)-1])] {
+		if spanContains(loc.Span, extLoc.Span) {
+			return loc
+		}
+	}
+	return nil
+}
+
+func spanContains(outer, inner []int32) bool {
+	start := func(span []int32) [2]int32 {
+		return [2]int32{span[0], span[1]}
+	}
+	end := func(span []int32) [2]int32 {
+		if len(span) == 3 {
+			return [2]int32{span[0], span[2]}
+		}
+		return [2]int32{span[2], span[3]}
+	}
+	less := func(a, b [2]int32) bool {
+		return a[0] < b[0] || (a[0] == b[0] && a[1] <= b[1])
+	}
+	if len(outer) < 3 || len(inner) < 3 {
+		return false
+	}
+	return less(start(outer), start(inner)) && less(end(inner), end(outer))
+}
+
+func (p *printer) printFile() {
+	fd := p.fd
+	pkg := fd.GetPackage()
+	if p.syntax == "editions" {
+		path := []int32{internal.FileEditionTag}
+		p.leadingComments(path)
+		p.linef("edition = %q;%s", editionName(fd.GetEdition()), p.trailingComments(path))
+	} else {
+		path := []int32{internal.FileSyntaxTag}
+		p.leadingComments(path)
+		p.linef("syntax = %q;%s", p.syntax, p.trailingComments(path))
+	}
+	if pkg != "" {
+		path := []int32{internal.FilePackageTag}
+		p.leadingComments(path)
+		p.linef("package %s;%s", pkg, p.trailingComments(path))
+	}
+
+	modifiers := map[int32]string{}
+	for _, index := range fd.PublicDependency {
+		modifiers[index] = "public "
+	}
+	for _, index := range fd.WeakDependency {
+		modifiers[index] = "weak "
+	}
+	for i, dep := range fd.Dependency {
+		path := []int32{internal.FileDependencyTag, int32(i)}
+		p.leadingComments(path)
+		p.linef("import %s%s;%s", modifiers[int32(i)], quote(dep, false), p.trailingComments(path))
+	}
+	for i, dep := range internal.OptionDependencies(fd) {
+		path := []int32{internal.FileOptionDependencyTag, int32(i)}
+		p.leadingComments(path)
+		p.linef("import option %s;%s", quote(dep, false), p.trailingComments(path))
+	}
+
+	p.optionStatements(fd.Options, []int32{internal.FileOptionsTag}, pkg)
+
+	nested := p.nestedTypes(pkg, fd.MessageType, []int32{internal.FileMessagesTag})
+	nested.consume(p.syntax, fd.Extension)
+	var elems []element
+	for i, msg := range fd.MessageType {
+		msg, path := msg, []int32{internal.FileMessagesTag, int32(i)}
+		if nested.isConsumed(qualify(pkg, msg.GetName())) {
+			continue
+		}
+		elems = append(elems, element{path: path, isMsg: true, print: func() { p.message(msg, path, pkg) }})
+	}
+	for i, enum := range fd.EnumType {
+		enum, path := enum, []int32{internal.FileEnumsTag, int32(i)}
+		elems = append(elems, element{path: path, print: func() { p.enum(enum, path, pkg) }})
+	}
+	for i, ext := range fd.Extension {
+		path := []int32{internal.FileExtensionsTag, int32(i)}
+		elems = append(elems, element{path: path, extendee: ext.GetExtendee(), ext: ext, fields: []*descriptorpb.FieldDescriptorProto{ext}})
+	}
+	for i, svc := range fd.Service {
+		svc, path := svc, []int32{internal.FileServicesTag, int32(i)}
+		elems = append(elems, element{path: path, print: func() { p.service(svc, path, pkg) }})
+	}
+	p.printElements(elems, pkg, nested)
+}
+
+// nestedTypes are the messages defined in a scope, which is needed to identify
+// map entries and groups, which are printed as part of a field.
+type nestedTypes struct {
+	byName   map[string]*descriptorpb.DescriptorProto
+	paths    map[string][]int32
+	consumed map[string]bool
+}
+
+func (p *printer) nestedTypes(scope string, msgs []*descriptorpb.DescriptorProto, path []int32) nestedTypes {
+	nested := nestedTypes{
+		byName:   map[string]*descriptorpb.DescriptorProto{},
+		paths:    map[string][]int32{},
+		consumed: map[string]bool{},
+	}
+	for i, msg := range msgs {
+		name := "." + qualify(scope, msg.GetName())
+		nested.byName[name] = msg
+		nested.paths[name] = append(clonePath(path), int32(i))
+	}
+	return nested
+}
+
+func (n nestedTypes) isConsumed(name string) bool {
+	return n.consumed["."+name]
+}
+
+// mapEntry returns the map entry message for the given field, or nil if the
+// field is not a map.
+func (n nestedTypes) mapEntry(fld *descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	if fld.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED ||
+		fld.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		return nil
+	}
+	msg := n.byName[fld.GetTypeName()]
+	if !msg.GetOptions().GetMapEntry() {
+		return nil
+	}
+	return msg
+}
+
+// group returns the message for the given field if it is a group, or nil
+// otherwise. Groups are only printed using group syntax in proto2 files.
+func (n nestedTypes) group(syntax string, fld *descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	if syntax != "proto2" || fld.GetType() != descriptorpb.FieldDescriptorProto_TYPE_GROUP {
+		return nil
+	}
+	msg := n.byName[fld.GetTypeName()]
+	if msg == nil || strings.ToLower(msg.GetName()) != fld.GetName() {
+		return nil
+	}
+	return msg
+}
+
+// consume marks the messages used by map and group fields, which are not
+// printed as separate elements.
+func (n nestedTypes) consume(syntax string, fields []*descriptorpb.FieldDescriptorProto) {
+	for _, fld := range fields {
+		if n.mapEntry(fld) != nil || n.group(syntax, fld) != nil {
+			n.consumed[fld.GetTypeName()] = true
+		}
+	}
+}
+
+func (p *printer) message(msg *descriptorpb.DescriptorProto, path []int32, scope string) {
+	p.leadingComments(path)
+	p.linef("%smessage %s {%s", visibility(msg), msg.GetName(), p.trailingComments(path))
+	p.messageBody(msg, path, qualify(scope, msg.GetName()))
+	p.linef("}")
+}
+
+func (p *printer) messageBody(msg *descriptorpb.DescriptorProto, path []int32, name string) {
+	p.indent++
+	defer func() {
+		p.indent--
+	}()
+	p.optionStatements(msg.Options, append(clonePath(path), internal.MessageOptionsTag), name)
+
+	nested := p.nestedTypes(name, msg.NestedType, append(clonePath(path), internal.MessageNestedMessagesTag))
+	nested.consume(p.syntax, msg.Field)
+	nested.consume(p.syntax, msg.Extension)
+
+	var elems []element
+	printedOneofs := map[int32]bool{}
+	for i, fld := range msg.Field {
+		fld, fldPath := fld, append(clonePath(path), internal.MessageFieldsTag, int32(i))
+		if fld.OneofIndex == nil || fld.GetProto3Optional() {
+			elems = append(elems, element{path: fldPath, fields: []*descriptorpb.FieldDescriptorProto{fld}, print: func() { p.field(fld, fldPath, name, nested) }})
+			continue
+		}
+		index := fld.GetOneofIndex()
+		if printedOneofs[index] || int(index) >= len(msg.OneofDecl) {
+			continue
+		}
+		printedOneofs[index] = true
+		var oneofFields []*descriptorpb.FieldDescriptorProto
+		for _, oneofFld := range msg.Field[i:] {
+			if oneofFld.OneofIndex != nil && oneofFld.GetOneofIndex() == index {
+				oneofFields = append(oneofFields, oneofFld)
+			}
+		}
+		oneofPath := append(clonePath(path), internal.MessageOneofsTag, index)
+		elems = append(elems, element{path: oneofPath, fields: oneofFields, print: func() { p.oneof(msg, index, path, name, nested) }})
+	}
+	for i, nestedMsg := range msg.NestedType {
+		nestedMsg, msgPath := nestedMsg, append(clonePath(path), internal.MessageNestedMessagesTag, int32(i))
+		if nested.isConsumed(qualify(name, nestedMsg.GetName())) {
+			continue
+		}
+		elems = append(elems, element{path: msgPath, isMsg: true, print: func() { p.message(nestedMsg, msgPath, name) }})
+	}
+	for i, enum := range msg.EnumType {
+		enum, enumPath := enum, append(clonePath(path), internal.MessageEnumsTag, int32(i))
+		elems = append(elems, element{path: enumPath, print: func() { p.enum(enum, enumPath, name) }})
+	}
+	if len(msg.ExtensionRange) > 0 {
+		rangesPath := append(clonePath(path), internal.MessageExtensionRangesTag, 0)
+		elems = append(elems, element{path: rangesPath, print: func() { p.extensionRanges(msg, name) }})
+	}
+	if len(msg.ReservedRange) > 0 {
+		rangesPath := append(clonePath(path), internal.MessageReservedRangesTag, 0)
+		elems = append(elems, element{path: rangesPath, print: func() {
+			ranges := make([]string, len(msg.ReservedRange))
+			for i, rr := range msg.ReservedRange {
+				ranges[i] = tagRange(rr.GetStart(), rr.GetEnd()-1, maxTag(msg))
+			}
+			p.linef("reserved %s;", strings.Join(ranges, ", "))
+		}})
+	}
+	if len(msg.ReservedName) > 0 {
+		namesPath := append(clonePath(path), internal.MessageReservedNamesTag, 0)
+		elems = append(elems, element{path: namesPath, print: func() { p.reservedNames(msg.ReservedName) }})
+	}
+	for i, ext := range msg.Extension {
+		extPath := append(clonePath(path), internal.MessageExtensionsTag, int32(i))
+		elems = append(elems, element{path: extPath, extendee: ext.GetExtendee(), ext: ext, fields: []*descriptorpb.FieldDescriptorProto{ext}})
+	}
+	p.printElements(elems, name, nested)
+}
+
+func (p *printer) oneof(msg *descriptorpb.DescriptorProto, index int32, msgPath []int32, scope string, nested nestedTypes) {
+	oneof := msg.OneofDecl[index]
+	path := append(clonePath(msgPath), internal.MessageOneofsTag, index)
+	p.leadingComments(path)
+	p.linef("oneof %s {%s", oneof.GetName(), p.trailingComments(path))
+	p.indent++
+	p.optionStatements(oneof.Options, append(clonePath(path), internal.OneofOptionsTag), scope)
+	for i, fld := range msg.Field {
+		if fld.OneofIndex != nil && fld.GetOneofIndex() == index {
+			p.field(fld, append(clonePath(msgPath), internal.MessageFieldsTag, int32(i)), scope, nested)
+		}
+	}
+	p.indent--
+	p.linef("}")
+}
+
+func (p *printer) field(fld *descriptorpb.FieldDescriptorProto, path []int32, scope string, nested nestedTypes) {
+	var label string
+	switch {
+	case fld.OneofIndex != nil && !fld.GetProto3Optional():
+		// fields in a oneof have no label
+	case fld.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+		if nested.mapEntry(fld) == nil {
+			label = "repeated "
+		}
+	case p.syntax == "proto2" && fld.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REQUIRED:
+		label = "required "
+	case p.syntax == "proto2" || fld.GetProto3Optional():
+		label = "optional "
+	}
+
+	var typeName string
+	name := fld.GetName()
+	groupMsg := nested.group(p.syntax, fld)
+	switch entry := nested.mapEntry(fld); {
+	case entry != nil:
+		var keyType, valType string
+		for _, entryFld := range entry.Field {
+			switch entryFld.GetNumber() {
+			case 1:
+				keyType = p.fieldType(entryFld, scope)
+			case 2:
+				valType = p.fieldType(entryFld, scope)
+			}
+		}
+		typeName = fmt.Sprintf("map<%s, %s>", keyType, valType)
+	case groupMsg != nil:
+		typeName = "group"
+		name = groupMsg.GetName()
+	default:
+		typeName = p.fieldType(fld, scope)
+	}
+
+	opts := p.fieldOptions(fld, scope)
+	p.leadingComments(path)
+	if groupMsg == nil {
+		p.linef("%s%s %s = %d%s;%s", label, typeName, name, fld.GetNumber(), opts, p.trailingComments(path))
+		return
+	}
+	p.linef("%s%s %s = %d%s {%s", label, typeName, name, fld.GetNumber(), opts, p.trailingComments(path))
+	p.messageBody(groupMsg, nested.paths[fld.GetTypeName()], strings.TrimPrefix(fld.GetTypeName(), "."))
+	p.linef("}")
+}
+
+func (p *printer) fieldType(fld *descriptorpb.FieldDescriptorProto, scope string) string {
+	if fld.GetTypeName() != "" {
+		return p.typeName(fld.GetTypeName(), scope)
+	}
+	return strings.ToLower(strings.TrimPrefix(fld.GetType().String(), "TYPE_"))
+}
+
+// fieldOptions returns the compact options for the given field, including
+// the "default" and "json_name" pseudo-options.
+func (p *printer) fieldOptions(fld *descriptorpb.FieldDescriptorProto, scope string) string {
+	var opts []string
+	if fld.DefaultValue != nil {
+		opts = append(opts, "default = "+defaultValue(fld))
+	}
+	if fld.JsonName != nil && fld.Extendee == nil && fld.GetJsonName() != internal.JSONName(fld.GetName()) {
+		opts = append(opts, "json_name = "+quote(fld.GetJsonName(), false))
+	}
+	for _, opt := range p.options(fld.Options, scope) {
+		opts = append(opts, opt.name+" = "+opt.value)
+	}
+	return compactOptions(opts)
+}
+
+func compactOptions(opts []string) string {
+	if len(opts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(opts, ", ") + "]"
+}
+
+func defaultValue(fld *descriptorpb.FieldDescriptorProto) string {
+	val := fld.GetDefaultValue()
+	switch fld.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		return quote(val, false)
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		// already escaped
+		return `"` + val + `"`
+	default:
+		return val
+	}
+}
+
+func (p *printer) extensionRanges(msg *descriptorpb.DescriptorProto, scope string) {
+	// Consecutive ranges with the same options are printed together.
+	for i := 0; i < len(msg.ExtensionRange); {
+		er := msg.ExtensionRange[i]
+		ranges := []string{tagRange(er.GetStart(), er.GetEnd()-1, maxTag(msg))}
+		j := i + 1
+		for ; j < len(msg.ExtensionRange) && proto.Equal(msg.ExtensionRange[j].Options, er.Options); j++ {
+			next := msg.ExtensionRange[j]
+			ranges = append(ranges, tagRange(next.GetStart(), next.GetEnd()-1, maxTag(msg)))
+		}
+		var opts []string
+		for _, opt := range p.options(er.Options, scope) {
+			opts = append(opts, opt.name+" = "+opt.value)
+		}
+		p.linef("extensions %s%s;", strings.Join(ranges, ", "), compactOptions(opts))
+		i = j
+	}
+}
+
+func maxTag(msg *descriptorpb.DescriptorProto) int32 {
+	if msg.GetOptions().GetMessageSetWireFormat() {
+		return internal.MaxMessageSetTag
+	}
+	return internal.MaxNormalTag
+}
+
+// tagRange formats a range, where end is inclusive.
+func tagRange(start, end, maxVal int32) string {
+	switch {
+	case start == end:
+		return strconv.Itoa(int(start))
+	case end == maxVal:
+		return fmt.Sprintf("%d to max", start)
+	default:
+		return fmt.Sprintf("%d to %d", start, end)
+	}
+}
+
+func (p *printer) reservedNames(names []string) {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		if p.syntax == "editions" {
+			// editions uses identifiers instead of string literals
+			quoted[i] = name
+		} else {
+			quoted[i] = quote(name, false)
+		}
+	}
+	p.linef("reserved %s;", strings.Join(quoted, ", "))
+}
+
+func (p *printer) enum(enum *descriptorpb.EnumDescriptorProto, path []int32, scope string) {
+	name := qualify(scope, enum.GetName())
+	p.leadingComments(path)
+	p.linef("%senum %s {%s", visibility(enum), enum.GetName(), p.trailingComments(path))
+	p.indent++
+	p.optionStatements(enum.Options, append(clonePath(path), internal.EnumOptionsTag), name)
+	var elems []element
+	for i, val := range enum.Value {
+		val, valPath := val, append(clonePath(path), internal.EnumValuesTag, int32(i))
+		elems = append(elems, element{path: valPath, print: func() {
+			var opts []string
+			for _, opt := range p.options(val.Options, name) {
+				opts = append(opts, opt.name+" = "+opt.value)
+			}
+			p.leadingComments(valPath)
+			p.linef("%s = %d%s;%s", val.GetName(), val.GetNumber(), compactOptions(opts), p.trailingComments(valPath))
+		}})
+	}
+	if len(enum.ReservedRange) > 0 {
+		rangesPath := append(clonePath(path), internal.EnumReservedRangesTag, 0)
+		elems = append(elems, element{path: rangesPath, print: func() {
+			ranges := make([]string, len(enum.ReservedRange))
+			for i, rr := range enum.ReservedRange {
+				// enum reserved ranges are inclusive
+				ranges[i] = tagRange(rr.GetStart(), rr.GetEnd(), math.MaxInt32)
+			}
+			p.linef("reserved %s;", strings.Join(ranges, ", "))
+		}})
+	}
+	if len(enum.ReservedName) > 0 {
+		namesPath := append(clonePath(path), internal.EnumReservedNamesTag, 0)
+		elems = append(elems, element{path: namesPath, print: func() { p.reservedNames(enum.ReservedName) }})
+	}
+	p.printElements(elems, name, nestedTypes{})
+	p.indent--
+	p.linef("}")
+}
+
+func (p *printer) service(svc *descriptorpb.ServiceDescriptorProto, path []int32, scope string) {
+	name := qualify(scope, svc.GetName())
+	p.leadingComments(path)
+	p.linef("service %s {%s", svc.GetName(), p.trailingComments(path))
+	p.indent++
+	p.optionStatements(svc.Options, append(clonePath(path), internal.ServiceOptionsTag), name)
+	var elems []element
+	for i, mtd := range svc.Method {
+		mtd, mtdPath := mtd, append(clonePath(path), internal.ServiceMethodsTag, int32(i))
+		elems = append(elems, element{path: mtdPath, print: func() { p.method(mtd, mtdPath, name) }})
+	}
+	p.printElements(elems, name, nestedTypes{})
+	p.indent--
+	p.linef("}")
+}
+
+func (p *printer) method(mtd *descriptorpb.MethodDescriptorProto, path []int32, scope string) {
+	var inStream, outStream string
+	if mtd.GetClientStreaming() {
+		inStream = "stream "
+	}
+	if mtd.GetServerStreaming() {
+		outStream = "stream "
+	}
+	signature := fmt.Sprintf("rpc %s(%s%s) returns (%s%s)", mtd.GetName(),
+		inStream, p.typeName(mtd.GetInputType(), scope), outStream, p.typeName(mtd.GetOutputType(), scope))
+	p.leadingComments(path)
+	opts := p.options(mtd.Options, scope)
+	if len(opts) == 0 {
+		p.linef("%s;%s", signature, p.trailingComments(path))
+		return
+	}
+	p.linef("%s {%s", signature, p.trailingComments(path))
+	p.indent++
+	p.optionStatements(mtd.Options, append(clonePath(path), internal.MethodOptionsTag), scope)
+	p.indent--
+	p.linef("}")
+}
+
+func visibility(msg proto.Message) string {
+	switch internal.VisibilityOf(msg) {
+	case internal.VisibilityExport:
+		return "export "
+	case internal.VisibilityLocal:
+		return "local "
+	default:
+		return ""
+	}
+}
+
+// typeName returns the name to use to refer to the element with the given
+// fully-qualified name from the given scope. It is the shortest name that
+// is known to resolve to the element. Names that are not in the file's
+// package are fully-qualified, since other files can define elements in
+// enclosing packages that could change how a partial name resolves.
+func (p *printer) typeName(name, scope string) string {
+	fullName := strings.TrimPrefix(name, ".")
+	parts := strings.Split(fullName, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		relName := strings.Join(parts[i:], ".")
+		if p.resolvesTo(relName, scope, fullName) {
+			return relName
+		}
+	}
+	return "." + fullName
+}
+
+// resolvesTo returns true if the given relative name, referenced in the given
+// scope, is known to resolve to the given fully-qualified name. This mirrors
+// name resolution in the linker: the first component of the name is searched
+// in each enclosing scope, starting with the innermost.
+func (p *printer) resolvesTo(relName, scope, fullName string) bool {
+	firstName := relName
+	if pos := strings.IndexByte(relName, '.'); pos >= 0 {
+		firstName = relName[:pos]
+	}
+	pkg := p.fd.GetPackage()
+	inPackage := false
+	for s := scope; ; s = parentScope(s) {
+		if s == pkg {
+			inPackage = true
+		}
+		candidate := qualify(s, firstName)
+		if !inPackage {
+			// Scopes inside the package are defined in this file, so all of their
+			// symbols are known.
+			if _, ok := p.symbols[candidate]; ok {
+				return qualify(s, relName) == fullName
+			}
+			continue
+		}
+		// Other files can define symbols in the package and its parents, so
+		// a partial name is only accepted if nothing it could resolve to
+		// first is unknown.
+		if qualify(s, relName) == fullName {
+			return true
+		}
+		if s == "" || p.isKnown(candidate) {
+			return false
+		}
+	}
+}
+
+// isKnown returns true if the given name could be a symbol in the file or
+// its dependencies, including package names.
+func (p *printer) isKnown(name string) bool {
+	if _, ok := p.symbols[name]; ok {
+		return true
+	}
+	if p.resolver == nil {
+		// without a resolver, the file's dependencies are unknown
+		return true
+	}
+	if _, err := p.resolver.FindDescriptorByName(protoreflect.FullName(name)); err == nil {
+		return true
+	}
+	for pkg := range p.packages {
+		if pkg == name || strings.HasPrefix(pkg, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *printer) location(path []int32) *descriptorpb.SourceCodeInfo_Location {
+	if locs := p.locations[pathKey(path)]; len(locs) > 0 {
+		return locs[0]
+	}
+	return nil
+}
+
+func (p *printer) leadingComments(path []int32) {
+	p.locationComments(p.location(path))
+}
+
+func (p *printer) locationComments(loc *descriptorpb.SourceCodeInfo_Location) {
+	if loc == nil {
+		return
+	}
+	for _, comment := range loc.LeadingDetachedComments {
+		p.comment(comment)
+		p.buf.WriteByte('\n')
+	}
+	if loc.LeadingComments != nil {
+		p.comment(loc.GetLeadingComments())
+	}
+}
+
+func (p *printer) comment(text string) {
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		p.linef("//%s", line)
+	}
+}
+
+// trailingComments returns the trailing comment for the element with the
+// given path, to be printed at the end of its first line.
+func (p *printer) trailingComments(path []int32) string {
+	return trailingComments(p.location(path))
+}
+
+func trailingComments(loc *descriptorpb.SourceCodeInfo_Location) string {
+	if loc == nil || loc.TrailingComments == nil {
+		return ""
+	}
+	text := strings.TrimSuffix(loc.GetTrailingComments(), "\n")
+	switch {
+	case !strings.Contains(text, "\n"):
+		return " //" + text
+	case !strings.Contains(text, "*/"):
+		// A block comment keeps multiple lines attached to the element.
+		return " /*" + text + "*/"
+	default:
+		return " //" + strings.ReplaceAll(text, "\n", " ")
+	}
+}
+
+func editionName(edition descriptorpb.Edition) string {
+	if edition == internal.Edition2024 {
+		return "2024"
+	}
+	return strings.TrimPrefix(edition.String(), "EDITION_")
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func parentScope(scope string) string {
+	pos := strings.LastIndexByte(scope, '.')
+	if pos < 0 {
+		return ""
+	}
+	return scope[:pos]
+}
+
+func pathKey(path []int32) string {
+	var buf strings.Builder
+	for i, elem := range path {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(strconv.Itoa(int(elem)))
+	}
+	return buf.String()
+}
+
+func clonePath(path []int32) []int32 {
+	return append([]int32(nil), path...)
+}
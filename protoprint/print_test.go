@@ -0,0 +1,310 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protoprint
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/protoutil"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+const testdataDir = "../internal/testdata"
+
+func TestPrint(t *testing.T) {
+	t.Parallel()
+	const source = `syntax = "proto2";
+package foo.bar;
+import "google/protobuf/descriptor.proto";
+option go_package = "foo/bar";
+// Custom option.
+extend google.protobuf.MessageOptions {
+  optional Rule rule = 10101;
+}
+message Rule {
+  optional string name = 1;
+  repeated int32 ids = 2;
+  map<string, Rule> children = 3;
+}
+// Leading comment.
+message Foo { // Trailing comment.
+  option (rule) = { name: "foo" ids: [1, 2] children { key: "a" value { name: "x" } } };
+  map<string, Foo> map_field = 1;
+  oneof kind {
+    string name = 2 [default = "a\"b"];
+    group Data = 3 {
+      optional int32 x = 1;
+    }
+  }
+  extensions 100 to max;
+  reserved 10 to 20, 30;
+  reserved "a", "b";
+  message Nested {
+    optional Nested self = 1;
+  }
+  optional Nested nested = 4 [json_name = "NESTED", deprecated = true];
+}
+extend Foo {
+  optional .foo.bar.Foo.Nested ext = 100;
+}
+enum Kind {
+  KIND_UNSPECIFIED = 0;
+  KIND_A = 1 [deprecated = true];
+  reserved 5 to max;
+}
+service Svc {
+  rpc Do(stream Foo) returns (Foo) {
+    option deprecated = true;
+  }
+}
+`
+	const expected = `syntax = "proto2";
+
+package foo.bar;
+
+import "google/protobuf/descriptor.proto";
+
+option go_package = "foo/bar";
+
+// Custom option.
+extend google.protobuf.MessageOptions {
+  optional Rule rule = 10101;
+}
+
+message Rule {
+  optional string name = 1;
+  repeated int32 ids = 2;
+  map<string, Rule> children = 3;
+}
+
+// Leading comment.
+message Foo { // Trailing comment.
+  option (rule) = {
+    name: "foo"
+    ids: [1, 2]
+    children {
+      key: "a"
+      value {
+        name: "x"
+      }
+    }
+  };
+  map<string, Foo> map_field = 1;
+  oneof kind {
+    string name = 2 [default = "a\"b"];
+    group Data = 3 {
+      optional int32 x = 1;
+    }
+  }
+  extensions 100 to max;
+  reserved 10 to 20, 30;
+  reserved "a", "b";
+  message Nested {
+    optional Nested self = 1;
+  }
+  optional Nested nested = 4 [
+    json_name = "NESTED",
+    deprecated = true
+  ];
+}
+
+extend Foo {
+  optional Foo.Nested ext = 100;
+}
+
+enum Kind {
+  KIND_UNSPECIFIED = 0;
+  KIND_A = 1 [deprecated = true];
+  reserved 5 to max;
+}
+
+service Svc {
+  rpc Do(stream Foo) returns (Foo) {
+    option deprecated = true;
+  }
+}
+`
+	file := compile(t, testdataDir, map[string]string{"test.proto": source}, "test.proto", protocompile.SourceInfoStandard)
+	var buf bytes.Buffer
+	require.NoError(t, Print(&buf, file))
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestPrint_WithoutSourceInfo(t *testing.T) {
+	t.Parallel()
+	const source = `edition = "2023";
+package foo;
+option features.field_presence = IMPLICIT;
+message Foo {
+  reserved bar;
+  Foo foo = 1 [features.field_presence = EXPLICIT];
+  repeated int32 ids = 2 [features.repeated_field_encoding = EXPANDED];
+}
+`
+	const expected = `edition = "2023";
+
+package foo;
+
+option features.field_presence = IMPLICIT;
+
+message Foo {
+  Foo foo = 1 [features.field_presence = EXPLICIT];
+  repeated int32 ids = 2 [features.repeated_field_encoding = EXPANDED];
+  reserved bar;
+}
+`
+	file := compile(t, testdataDir, map[string]string{"test.proto": source}, "test.proto", protocompile.SourceInfoStandard)
+	fd := protoutil.ProtoFromFileDescriptor(file)
+	fd.SourceCodeInfo = nil
+	var buf bytes.Buffer
+	require.NoError(t, PrintProto(&buf, fd, nil))
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestPrintProto_UninterpretedOptions(t *testing.T) {
+	t.Parallel()
+	const source = `syntax = "proto3"; option (foo) = 1;`
+	handler := reporter.NewHandler(nil)
+	ast, err := parser.Parse("test.proto", strings.NewReader(source), handler)
+	require.NoError(t, err)
+	res, err := parser.ResultFromAST(ast, true, handler)
+	require.NoError(t, err)
+	err = PrintProto(io.Discard, res.FileDescriptorProto(), nil)
+	require.ErrorContains(t, err, "test.proto: FileOptions has options that have not been interpreted")
+}
+
+func TestPrint_RoundTrip(t *testing.T) {
+	t.Parallel()
+	var paths []string
+	err := filepath.WalkDir(testdataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".proto" {
+			rel, err := filepath.Rel(testdataDir, path)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	// Without source code info, elements are printed in the order they
+	// appear in the descriptor, instead of in the order of their locations.
+	sourceInfoModes := map[string]protocompile.SourceInfoMode{
+		"source_info":    protocompile.SourceInfoStandard,
+		"no_source_info": protocompile.SourceInfoNone,
+	}
+	for _, path := range paths {
+		for modeName, mode := range sourceInfoModes {
+			path, mode := path, mode
+			t.Run(path+"/"+modeName, func(t *testing.T) {
+				t.Parallel()
+				// files in these directories import each other relative to them
+				dir := testdataDir
+				for _, subdir := range []string{"more/", "options/"} {
+					if strings.HasPrefix(path, subdir) {
+						dir, path = filepath.Join(testdataDir, subdir), strings.TrimPrefix(path, subdir)
+					}
+				}
+				original := compile(t, dir, nil, path, mode)
+				var buf bytes.Buffer
+				require.NoError(t, Print(&buf, original))
+				printed := compile(t, dir, map[string]string{path: buf.String()}, path, mode)
+				diff := cmp.Diff(canonicalProto(t, original), canonicalProto(t, printed), protocmp.Transform())
+				assert.Empty(t, diff, buf.String())
+			})
+		}
+	}
+}
+
+// compile compiles the given file, using the given sources if present and
+// files in the given directory otherwise.
+func compile(t *testing.T, dir string, sources map[string]string, path string, mode protocompile.SourceInfoMode) linker.File {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: func(path string) (io.ReadCloser, error) {
+				if src, ok := sources[path]; ok {
+					return io.NopCloser(strings.NewReader(src)), nil
+				}
+				return os.Open(filepath.Join(dir, path))
+			},
+		}),
+		SourceInfoMode: mode,
+	}
+	files, err := compiler.Compile(context.Background(), path)
+	require.NoError(t, err)
+	return files[0]
+}
+
+// canonicalProto returns the descriptor proto for the given file without
+// source code info. Options are re-encoded in a canonical order, since the
+// order in which custom options are serialized depends on the source.
+func canonicalProto(t *testing.T, file linker.File) *descriptorpb.FileDescriptorProto {
+	t.Helper()
+	fd := proto.Clone(protoutil.ProtoFromFileDescriptor(file)).(*descriptorpb.FileDescriptorProto)
+	fd.SourceCodeInfo = nil
+	canonicalizeOptions(t, linker.ResolverFromFile(file), fd.ProtoReflect())
+	return fd
+}
+
+func canonicalizeOptions(t *testing.T, resolver linker.Resolver, msg protoreflect.Message) {
+	t.Helper()
+	if strings.HasSuffix(string(msg.Descriptor().FullName()), "Options") {
+		data, err := proto.Marshal(msg.Interface())
+		require.NoError(t, err)
+		dyn := dynamicpb.NewMessage(msg.Descriptor())
+		require.NoError(t, proto.UnmarshalOptions{Resolver: resolver}.Unmarshal(data, dyn))
+		data, err = proto.MarshalOptions{Deterministic: true}.Marshal(dyn)
+		require.NoError(t, err)
+		proto.Reset(msg.Interface())
+		require.NoError(t, proto.Unmarshal(data, msg.Interface()))
+		return
+	}
+	msg.Range(func(field protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+		switch {
+		case field.Message() == nil || field.IsMap():
+		case field.IsList():
+			list := val.List()
+			for i := 0; i < list.Len(); i++ {
+				canonicalizeOptions(t, resolver, list.Get(i).Message())
+			}
+		default:
+			canonicalizeOptions(t, resolver, val.Message())
+		}
+		return true
+	})
+}
@@ -0,0 +1,329 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package refactor provides refactoring operations that span all of the
+// files in a compiled workspace. The operations compute text edits, in the
+// form used by package astedit, and leave applying them to the caller.
+package refactor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/astedit"
+	"github.com/bufbuild/protocompile/astquery"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/protoutil"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// Rename computes the edits that rename the element with the given
+// fully-qualified name to newName, which is a simple name: the element stays
+// in the same scope. The element may be a message, enum, enum value, field,
+// extension, oneof, service, or method. Groups and map entries cannot be
+// renamed, since their names are derived from their fields.
+//
+// The given files are the results of compiling the workspace, which must
+// have been compiled with their ASTs retained. The returned edits, keyed by
+// file path, change the element's declaration and every reference to it or
+// to the elements nested in it. References keep their form where possible:
+// a relative reference is only qualified further, or made fully-qualified,
+// if the new name would not resolve to the element from its location. Other
+// references that the new name would shadow are qualified as well, so that
+// they keep referring to the same elements.
+//
+// An error is returned if the element can't be found or renamed, or if an
+// element with the new name already exists.
+func Rename(files linker.Files, name protoreflect.FullName, newName string) (map[string][]astedit.TextEdit, error) {
+	name = protoreflect.FullName(strings.TrimPrefix(string(name), "."))
+	if !protoreflect.Name(newName).IsValid() {
+		return nil, fmt.Errorf("%q is not a valid identifier", newName)
+	}
+	r := &renamer{
+		oldName: name,
+		newName: name.Parent().Append(protoreflect.Name(newName)),
+		edits:   map[string][]astedit.TextEdit{},
+	}
+	seen := map[string]struct{}{}
+	var decl linker.Result
+	for _, file := range files {
+		res, ok := file.(linker.Result)
+		if !ok {
+			continue
+		}
+		if _, ok := seen[res.Path()]; ok {
+			continue
+		}
+		seen[res.Path()] = struct{}{}
+		r.files = append(r.files, res)
+		if d := res.FindDescriptorByName(name); d != nil && d.ParentFile().Path() == res.Path() {
+			decl, r.target = res, d
+		}
+	}
+	if r.target == nil {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	if decl.AST() == nil {
+		return nil, fmt.Errorf("cannot rename %s: no source for %q", name, decl.Path())
+	}
+	if r.newName == r.oldName {
+		return r.edits, nil
+	}
+
+	// The element's declaration
+	nameNode, err := declaredName(decl, r.target)
+	if err != nil {
+		return nil, err
+	}
+	var syms linker.Symbols
+	for _, res := range r.files {
+		// Collisions that already exist are not our concern here.
+		_ = syms.Import(res, reporter.NewHandler(nil))
+	}
+	if span, ok := syms.Lookup(r.newName); ok {
+		return nil, fmt.Errorf("cannot rename %s to %s: symbol %q already defined at %v", name, newName, r.newName, span.Start())
+	}
+	r.addEdit(decl.AST(), decl.AST().NodeInfo(nameNode), newName)
+
+	for _, res := range r.files {
+		if err := r.renameReferences(res); err != nil {
+			return nil, err
+		}
+	}
+	for _, edits := range r.edits {
+		sort.Slice(edits, func(i, j int) bool {
+			return edits[i].Start < edits[j].Start
+		})
+	}
+	return r.edits, nil
+}
+
+type renamer struct {
+	files   []linker.Result
+	oldName protoreflect.FullName
+	newName protoreflect.FullName
+	target  protoreflect.Descriptor
+	edits   map[string][]astedit.TextEdit
+}
+
+// declaredName returns the name node in the declaration of the given
+// element.
+func declaredName(res linker.Result, d protoreflect.Descriptor) (ast.Node, error) {
+	switch node := res.Node(protoutil.ProtoFromDescriptor(d)).(type) {
+	case *ast.MessageNode:
+		return node.Name, nil
+	case *ast.EnumNode:
+		return node.Name, nil
+	case *ast.EnumValueNode:
+		return node.Name, nil
+	case *ast.FieldNode:
+		return node.Name, nil
+	case *ast.MapFieldNode:
+		if _, ok := d.(protoreflect.MessageDescriptor); ok {
+			return nil, fmt.Errorf("cannot rename map entry %s", d.FullName())
+		}
+		return node.Name, nil
+	case *ast.OneofNode:
+		return node.Name, nil
+	case *ast.ServiceNode:
+		return node.Name, nil
+	case *ast.RPCNode:
+		return node.Name, nil
+	case *ast.GroupNode:
+		return nil, fmt.Errorf("cannot rename group %s", d.FullName())
+	default:
+		return nil, fmt.Errorf("cannot rename %s: no source for its declaration", d.FullName())
+	}
+}
+
+// rename returns the name that the element with the given name has after
+// the rename.
+func (r *renamer) rename(name protoreflect.FullName) protoreflect.FullName {
+	if name == r.oldName {
+		return r.newName
+	}
+	if strings.HasPrefix(string(name), string(r.oldName)+".") {
+		return r.newName + name[len(r.oldName):]
+	}
+	return name
+}
+
+func (r *renamer) addEdit(file *ast.FileNode, span ast.SourceSpan, newText string) {
+	start := span.Start().Offset
+	end := span.End().Offset + 1
+	path := file.Name()
+	r.edits[path] = append(r.edits[path], astedit.TextEdit{Start: start, End: end, NewText: newText})
+}
+
+// renameReferences adds the edits for the references in the given file.
+func (r *renamer) renameReferences(res linker.Result) error {
+	before := newWorld(res, nil)
+	if _, ok := before.files[r.target.ParentFile().Path()]; !ok {
+		// the element isn't visible from this file, so nothing changes
+		return nil
+	}
+	after := newWorld(res, r.rename)
+	file := res.AST()
+	for _, ref := range res.References() {
+		if file == nil {
+			if r.rename(ref.Symbol) != ref.Symbol {
+				return fmt.Errorf("cannot rename %s: no source for %q", r.oldName, res.Path())
+			}
+			continue
+		}
+		ident, ok := ref.Node.(ast.IdentValueNode)
+		if !ok {
+			continue
+		}
+		path := astquery.NodeAtOffset(file, ref.Span.Start().Offset)
+		for len(path) > 0 && path.Node() != ref.Node {
+			path = path[:len(path)-1]
+		}
+		if len(path) < 2 {
+			continue
+		}
+		if fldRef, ok := path.Parent().(*ast.FieldReferenceNode); ok && !fldRef.IsExtension() {
+			// the name of a field in an option name, which is never qualified
+			if ref.Symbol == r.oldName {
+				r.addEdit(file, ref.Span, string(r.newName.Name()))
+			}
+			continue
+		}
+		text := string(ident.AsIdentifier())
+		if newText := r.referenceText(res, ref, path, text, before, after); newText != text {
+			r.addEdit(file, ref.Span, newText)
+		}
+	}
+	if file == nil {
+		return nil
+	}
+	switch r.target.(type) {
+	case protoreflect.FieldDescriptor, protoreflect.EnumValueDescriptor:
+		// Field names in message literals and enum values in option values
+		// aren't recorded as references, so we have to look for them.
+		r.renameInOptionValues(res)
+	}
+	return nil
+}
+
+// referenceText returns the text for the given reference after the rename.
+func (r *renamer) referenceText(res linker.Result, ref linker.Reference, path astquery.Path, text string, before, after *world) string {
+	scopes := referenceScopes(res, ref.Kind, path)
+	onlyTypes := ref.Kind == linker.ReferenceFieldType
+	if before.resolve(text, scopes, onlyTypes) != ref.Symbol {
+		// The reference doesn't resolve as expected, so we don't understand
+		// it well enough to check if it must change. Only change it if it
+		// refers to a renamed element, and then make it fully-qualified.
+		if r.rename(ref.Symbol) == ref.Symbol {
+			return text
+		}
+		return "." + string(r.rename(ref.Symbol))
+	}
+	target := r.rename(ref.Symbol)
+	if strings.HasPrefix(text, ".") {
+		return "." + string(target)
+	}
+	// The scopes are renamed, too, if they are in the renamed element.
+	for i, scope := range scopes {
+		scopes[i] = string(r.rename(protoreflect.FullName(scope)))
+	}
+	// Use the shortest suffix of the new name, with at least as many
+	// components as before, that resolves to the element.
+	parts := strings.Split(string(target), ".")
+	for i := len(parts) - strings.Count(text, ".") - 1; i >= 0; i-- {
+		candidate := strings.Join(parts[i:], ".")
+		if after.resolve(candidate, scopes, onlyTypes) == target {
+			return candidate
+		}
+	}
+	return "." + string(target)
+}
+
+// referenceScopes returns the names of the scopes in which the given
+// reference is resolved, from outermost to innermost. The first scope is the
+// package of the file.
+func referenceScopes(res linker.Result, kind linker.ReferenceKind, path astquery.Path) []string {
+	scopes := []string{string(res.Package())}
+	if kind == linker.ReferenceMessageLiteralExtension {
+		// these are always resolved relative to the package
+		return scopes
+	}
+	end := len(path) - 1
+	if kind == linker.ReferenceOptionName {
+		// The scopes are those enclosing the element that has the option,
+		// excluding the element itself.
+		for i := len(path) - 1; i > 0; i-- {
+			if _, ok := path[i].(*ast.OptionNode); ok {
+				end = i - 1
+				if _, ok := path[end].(*ast.CompactOptionsNode); ok {
+					end--
+				}
+				break
+			}
+		}
+	}
+	name := string(res.Package())
+	for _, node := range path[:end] {
+		var elemName string
+		switch node := node.(type) {
+		case *ast.MessageNode:
+			elemName = node.Name.Val
+		case *ast.GroupNode:
+			elemName = node.Name.Val
+		case *ast.ServiceNode:
+			elemName = node.Name.Val
+		default:
+			continue
+		}
+		if name != "" {
+			name += "."
+		}
+		name += elemName
+		scopes = append(scopes, name)
+	}
+	return scopes
+}
+
+// renameInOptionValues adds the edits for field names in message literals and
+// enum values in option values that refer to the renamed element.
+func (r *renamer) renameInOptionValues(res linker.Result) {
+	file := res.AST()
+	tracker := &ast.AncestorTracker{}
+	_ = ast.Walk(file, &ast.SimpleVisitor{
+		DoVisitIdentNode: func(node *ast.IdentNode) error {
+			switch parent := tracker.Parent().(type) {
+			case *ast.FieldReferenceNode:
+				if parent.IsExtension() || parent.IsAnyTypeReference() {
+					return nil
+				}
+				if _, ok := tracker.Path()[len(tracker.Path())-3].(*ast.MessageFieldNode); !ok {
+					// a field in an option name, which is a reference
+					return nil
+				}
+			case *ast.OptionNode, *ast.MessageFieldNode, *ast.ArrayLiteralNode:
+			default:
+				return nil
+			}
+			path := append(astquery.Path(nil), tracker.Path()...)
+			if d := astquery.Descriptor(res, path); d != nil && d.FullName() == r.oldName {
+				r.addEdit(file, file.NodeInfo(node), string(r.newName.Name()))
+			}
+			return nil
+		},
+	}, tracker.AsWalkOptions()...)
+}
@@ -0,0 +1,262 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package refactor
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/astedit"
+	"github.com/bufbuild/protocompile/linker"
+)
+
+var testSources = map[string]string{
+	"a.proto": `syntax = "proto2";
+package a;
+import "google/protobuf/descriptor.proto";
+message Msg {
+  optional string name = 1;
+  optional Msg.Inner inner = 2;
+  optional Kind kind = 3 [default = KIND_A];
+  message Inner {
+    optional Inner next = 1;
+  }
+  extensions 10 to 20;
+}
+enum Kind {
+  KIND_A = 0;
+  KIND_B = 1;
+}
+extend google.protobuf.MessageOptions {
+  optional Msg msg_opt = 5000;
+}
+`,
+	"b.proto": `syntax = "proto2";
+package b;
+import "a.proto";
+message Other {}
+message Req {
+  option (a.msg_opt) = { name: "x" kind: KIND_B };
+  message Local {}
+  optional a.Msg msg = 1;
+  optional .a.Msg.Inner inner = 2;
+  optional Other other = 3;
+  optional Local local = 4;
+  optional group Grp = 5 {}
+}
+message Resp {
+  option (a.msg_opt).name = "y";
+}
+extend a.Msg {
+  optional a.Kind kind = 10;
+}
+service Svc {
+  rpc Do(Req) returns (a.Msg);
+}
+`,
+}
+
+func TestRename(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		newName  string
+		expected map[string]string
+	}{
+		{
+			name:    "a.Msg",
+			newName: "Message",
+			expected: map[string]string{
+				"a.proto": `syntax = "proto2";
+package a;
+import "google/protobuf/descriptor.proto";
+message Message {
+  optional string name = 1;
+  optional Message.Inner inner = 2;
+  optional Kind kind = 3 [default = KIND_A];
+  message Inner {
+    optional Inner next = 1;
+  }
+  extensions 10 to 20;
+}
+enum Kind {
+  KIND_A = 0;
+  KIND_B = 1;
+}
+extend google.protobuf.MessageOptions {
+  optional Message msg_opt = 5000;
+}
+`,
+				"b.proto": `syntax = "proto2";
+package b;
+import "a.proto";
+message Other {}
+message Req {
+  option (a.msg_opt) = { name: "x" kind: KIND_B };
+  message Local {}
+  optional a.Message msg = 1;
+  optional .a.Message.Inner inner = 2;
+  optional Other other = 3;
+  optional Local local = 4;
+  optional group Grp = 5 {}
+}
+message Resp {
+  option (a.msg_opt).name = "y";
+}
+extend a.Message {
+  optional a.Kind kind = 10;
+}
+service Svc {
+  rpc Do(Req) returns (a.Message);
+}
+`,
+			},
+		},
+		{
+			// The new name shadows b.Other in b.Req.
+			name:    "b.Req.Local",
+			newName: "Other",
+			expected: map[string]string{
+				"b.proto": `syntax = "proto2";
+package b;
+import "a.proto";
+message Other {}
+message Req {
+  option (a.msg_opt) = { name: "x" kind: KIND_B };
+  message Other {}
+  optional a.Msg msg = 1;
+  optional .a.Msg.Inner inner = 2;
+  optional b.Other other = 3;
+  optional Other local = 4;
+  optional group Grp = 5 {}
+}
+message Resp {
+  option (a.msg_opt).name = "y";
+}
+extend a.Msg {
+  optional a.Kind kind = 10;
+}
+service Svc {
+  rpc Do(Req) returns (a.Msg);
+}
+`,
+			},
+		},
+		{
+			name:    "a.Msg.name",
+			newName: "title",
+			expected: map[string]string{
+				"a.proto": `message Msg {
+  optional string title = 1;`,
+				"b.proto": `  option (a.msg_opt) = { title: "x" kind: KIND_B };`,
+			},
+		},
+		{
+			name:    "a.KIND_B",
+			newName: "KIND_C",
+			expected: map[string]string{
+				"a.proto": `  KIND_C = 1;`,
+				"b.proto": `  option (a.msg_opt) = { name: "x" kind: KIND_C };`,
+			},
+		},
+		{
+			name:    "a.msg_opt",
+			newName: "opt",
+			expected: map[string]string{
+				"a.proto": `  optional Msg opt = 5000;`,
+				"b.proto": `  option (a.opt).name = "y";`,
+			},
+		},
+		{
+			name:    "b.Svc.Do",
+			newName: "Call",
+			expected: map[string]string{
+				"b.proto": `  rpc Call(Req) returns (a.Msg);`,
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			files := compile(t, testSources)
+			edits, err := Rename(files, protoreflect.FullName(testCase.name), testCase.newName)
+			require.NoError(t, err)
+			assert.Equal(t, sortedKeys(testCase.expected), sortedKeys(edits))
+			results := map[string]string{}
+			for path, source := range testSources {
+				results[path] = string(astedit.Apply([]byte(source), edits[path]))
+			}
+			for path, expected := range testCase.expected {
+				assert.Contains(t, results[path], expected)
+			}
+			// the result compiles, and the element has its new name
+			files = compile(t, results)
+			renamed := protoreflect.FullName(testCase.name).Parent().Append(protoreflect.Name(testCase.newName))
+			_, err = files.AsResolver().FindDescriptorByName(renamed)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRename_Errors(t *testing.T) {
+	t.Parallel()
+	files := compile(t, testSources)
+	_, err := Rename(files, "a.Missing", "Foo")
+	require.EqualError(t, err, "a.Missing not found")
+	_, err = Rename(files, "a.Msg", "not valid")
+	require.EqualError(t, err, `"not valid" is not a valid identifier`)
+	_, err = Rename(files, "a.Msg", "Kind")
+	require.EqualError(t, err, `cannot rename a.Msg to Kind: symbol "a.Kind" already defined at a.proto:13:6`)
+	_, err = Rename(files, "a.KIND_A", "Msg")
+	require.ErrorContains(t, err, `symbol "a.Msg" already defined`)
+	_, err = Rename(files, "b.Req.Grp", "Group")
+	require.EqualError(t, err, "cannot rename group b.Req.Grp")
+	_, err = Rename(files, "b.Req.grp", "group")
+	require.EqualError(t, err, "cannot rename group b.Req.grp")
+
+	// a name that is unchanged is fine
+	edits, err := Rename(files, "a.Msg", "Msg")
+	require.NoError(t, err)
+	assert.Empty(t, edits)
+}
+
+func compile(t *testing.T, sources map[string]string) linker.Files {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+		RetainASTs: true,
+	}
+	files, err := compiler.Compile(context.Background(), sortedKeys(sources)...)
+	require.NoError(t, err)
+	return files
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,170 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package refactor
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/bufbuild/protocompile/internal"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/walk"
+)
+
+type symbolKind int
+
+const (
+	// a field, enum value, oneof, or method
+	symbolLeaf = symbolKind(iota)
+	// a message or enum
+	symbolType
+	// a service
+	symbolService
+	// a package, which is not a descriptor
+	symbolPackage
+)
+
+// world is the set of symbols that are visible from a file, used to check
+// how names resolve in that file, under the same rules as the linker.
+type world struct {
+	files   map[string]struct{}
+	symbols map[protoreflect.FullName]symbolKind
+}
+
+// newWorld returns the symbols that are visible from the given file. If
+// rename is not nil, it is applied to the name of every symbol.
+func newWorld(res linker.Result, rename func(protoreflect.FullName) protoreflect.FullName) *world {
+	w := &world{
+		files:   map[string]struct{}{},
+		symbols: map[protoreflect.FullName]symbolKind{},
+	}
+	if rename == nil {
+		rename = func(name protoreflect.FullName) protoreflect.FullName { return name }
+	}
+	w.addFile(res, false, rename)
+	// Elements in option dependencies are visible, too.
+	for _, dep := range internal.OptionDependencies(res.FileDescriptorProto()) {
+		if file := res.FindImportByPath(dep); file != nil {
+			w.addFile(file, true, rename)
+		}
+	}
+	return w
+}
+
+func (w *world) addFile(file protoreflect.FileDescriptor, publicOnly bool, rename func(protoreflect.FullName) protoreflect.FullName) {
+	if _, ok := w.files[file.Path()]; ok {
+		return
+	}
+	w.files[file.Path()] = struct{}{}
+	for pkg := file.Package(); pkg != ""; pkg = pkg.Parent() {
+		w.symbols[pkg] = symbolPackage
+	}
+	_ = walk.Descriptors(file, func(d protoreflect.Descriptor) error {
+		kind := symbolLeaf
+		switch d.(type) {
+		case protoreflect.MessageDescriptor, protoreflect.EnumDescriptor:
+			kind = symbolType
+		case protoreflect.ServiceDescriptor:
+			kind = symbolService
+		}
+		w.symbols[rename(d.FullName())] = kind
+		return nil
+	})
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		imp := imports.Get(i)
+		if (publicOnly && !imp.IsPublic) || imp.FileDescriptor == nil || imp.IsPlaceholder() {
+			continue
+		}
+		w.addFile(imp.FileDescriptor, true, rename)
+	}
+}
+
+// resolve returns the name of the symbol that the given reference resolves
+// to, or the empty string if it doesn't resolve. The scopes are the names of
+// the enclosing scopes, from outermost to innermost, where the first is the
+// package of the referencing file. If onlyTypes is true, an unqualified
+// reference skips over symbols that are not messages or enums.
+func (w *world) resolve(name string, scopes []string, onlyTypes bool) protoreflect.FullName {
+	if strings.HasPrefix(name, ".") {
+		if kind, ok := w.symbols[protoreflect.FullName(name[1:])]; ok && kind != symbolPackage {
+			return protoreflect.FullName(name[1:])
+		}
+		return ""
+	}
+	firstName := name
+	if pos := strings.IndexByte(name, '.'); pos >= 0 {
+		firstName = name[:pos]
+	}
+	for i := len(scopes) - 1; i >= 0; i-- {
+		var found protoreflect.FullName
+		var ok bool
+		if i == 0 {
+			// The package scope also includes the packages that enclose
+			// the file's package.
+			for _, prefix := range internal.CreatePrefixList(scopes[0]) {
+				if found, ok = w.resolveRelative(prefix, firstName, name); ok {
+					break
+				}
+			}
+		} else {
+			found, ok = w.resolveRelative(scopes[i], firstName, name)
+		}
+		if !ok {
+			continue
+		}
+		if found == "" {
+			// the name matched a scope that doesn't contain the rest of it
+			return ""
+		}
+		kind := w.symbols[found]
+		if !onlyTypes || kind == symbolType || firstName != name {
+			if kind == symbolPackage {
+				return ""
+			}
+			return found
+		}
+	}
+	return ""
+}
+
+// resolveRelative resolves the given name relative to the given scope. It
+// returns false if the first component of the name isn't in the scope. It
+// returns an empty name if the first component is found but the rest isn't.
+func (w *world) resolveRelative(scope, firstName, name string) (protoreflect.FullName, bool) {
+	if scope == "" {
+		// at the root, the whole name is looked up at once
+		firstName = name
+	} else {
+		firstName = scope + "." + firstName
+		name = scope + "." + name
+	}
+	kind, ok := w.symbols[protoreflect.FullName(firstName)]
+	if !ok {
+		return "", false
+	}
+	if firstName == name {
+		return protoreflect.FullName(name), true
+	}
+	if kind == symbolLeaf {
+		// the rest of the name can't be in a leaf, so keep looking
+		return "", false
+	}
+	if _, ok := w.symbols[protoreflect.FullName(name)]; !ok {
+		return "", true
+	}
+	return protoreflect.FullName(name), true
+}
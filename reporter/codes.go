@@ -0,0 +1,202 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code identifies the kind of problem that a diagnostic describes. Unlike
+// the text of error messages, codes are stable, so they can be used to tell
+// kinds of errors apart, for example to suppress or to specially handle some
+// of them.
+//
+// Use CodeOf to get the code of an error. Some kinds of errors also have a
+// typed error, with more details about the problem, which can be found with
+// errors.As. For example, an error with code CodeSymbolCollision is a
+// linker.ErrorSymbolCollision.
+type Code string
+
+// The codes of the diagnostics that are reported when compiling.
+const (
+	// CodeUnknown is the code of an error that doesn't have one, like an
+	// error returned by a resolver.
+	CodeUnknown = Code("")
+
+	// CodeSyntax indicates a syntax error, including an invalid token.
+	CodeSyntax = Code("syntax-error")
+	// CodeNoSyntax is the code of the warning that a file has no syntax
+	// declaration.
+	CodeNoSyntax = Code("no-syntax")
+	// CodeUnknownSyntax indicates a syntax declaration with a value other
+	// than "proto2" or "proto3".
+	CodeUnknownSyntax = Code("unknown-syntax")
+	// CodeUnknownEdition indicates an edition declaration with an edition
+	// that isn't supported.
+	CodeUnknownEdition = Code("unknown-edition")
+	// CodeNotAllowedInSyntax indicates a language element that isn't allowed
+	// in the file's syntax or edition, like a group in proto3.
+	CodeNotAllowedInSyntax = Code("not-allowed-in-syntax")
+	// CodeLimitExceeded indicates that an implementation limit was exceeded,
+	// like the length of a package name or the nesting depth of messages.
+	CodeLimitExceeded = Code("limit-exceeded")
+	// CodeDuplicatePackage indicates a file with more than one package
+	// declaration.
+	CodeDuplicatePackage = Code("duplicate-package")
+	// CodeDuplicateImport indicates a file that imports the same file more
+	// than once.
+	CodeDuplicateImport = Code("duplicate-import")
+	// CodeEmptyDeclaration indicates an element that must not be empty, like
+	// an enum without values or an extend block without extensions.
+	CodeEmptyDeclaration = Code("empty-declaration")
+	// CodeInvalidName indicates a name that isn't valid, like a reserved name
+	// that isn't an identifier.
+	CodeInvalidName = Code("invalid-name")
+	// CodeInvalidTag indicates a field number that is missing or outside the
+	// range of valid field numbers.
+	CodeInvalidTag = Code("invalid-tag")
+	// CodeInvalidEnumValue indicates an enum value number that isn't valid,
+	// like a first value that isn't zero in an open enum.
+	CodeInvalidEnumValue = Code("invalid-enum-value")
+	// CodeInvalidRange indicates a reserved or extension range whose bounds
+	// aren't valid.
+	CodeInvalidRange = Code("invalid-range")
+	// CodeOverlappingRanges indicates reserved or extension ranges that
+	// overlap.
+	CodeOverlappingRanges = Code("overlapping-ranges")
+	// CodeDuplicateReservedName indicates a name that is reserved more than
+	// once.
+	CodeDuplicateReservedName = Code("duplicate-reserved-name")
+	// CodeReservedName indicates a field or enum value that uses a reserved
+	// name.
+	CodeReservedName = Code("reserved-name")
+	// CodeReservedNumber indicates a field or enum value that uses a reserved
+	// number, or a field that uses a number in an extension range.
+	CodeReservedNumber = Code("reserved-number")
+	// CodeTagCollision indicates two fields, or two extensions of the same
+	// message, with the same field number.
+	CodeTagCollision = Code("tag-collision")
+	// CodeEnumValueCollision indicates two values of an enum with the same
+	// number, in an enum that doesn't allow aliases.
+	CodeEnumValueCollision = Code("enum-value-collision")
+	// CodeInvalidLabel indicates a field label that isn't allowed, or a field
+	// that is missing a required label.
+	CodeInvalidLabel = Code("invalid-label")
+	// CodeInvalidMessageSet indicates a message with message-set wire format
+	// that doesn't follow its rules.
+	CodeInvalidMessageSet = Code("invalid-message-set")
+	// CodeJSONNameConflict indicates fields or enum values whose JSON names
+	// conflict.
+	CodeJSONNameConflict = Code("json-name-conflict")
+	// CodeClosedEnum indicates a closed enum that is used where only open
+	// enums are allowed.
+	CodeClosedEnum = Code("closed-enum")
+
+	// CodeUnresolvedImport indicates an import of a file that couldn't be
+	// found.
+	CodeUnresolvedImport = Code("unresolved-import")
+	// CodeImportCycle indicates files that import each other.
+	CodeImportCycle = Code("import-cycle")
+	// CodeUnusedImport is the code of the warning that an import isn't used.
+	CodeUnusedImport = Code("unused-import")
+	// CodeSymbolCollision indicates two elements with the same fully-qualified
+	// name.
+	CodeSymbolCollision = Code("symbol-collision")
+	// CodeUnresolvedReference indicates a reference to a type or extension
+	// that couldn't be resolved.
+	CodeUnresolvedReference = Code("unresolved-reference")
+	// CodeInvalidReference indicates a reference that resolved to the wrong
+	// kind of element, like a field type that refers to a service.
+	CodeInvalidReference = Code("invalid-reference")
+	// CodeInaccessibleType indicates a reference to a type that can't be used
+	// in the file, because it is local to another file or it is only
+	// imported for options.
+	CodeInaccessibleType = Code("inaccessible-type")
+	// CodeExtensionOutOfRange indicates an extension whose number isn't in an
+	// extension range of the extended message.
+	CodeExtensionOutOfRange = Code("extension-out-of-range")
+	// CodeInvalidDescriptor indicates a descriptor proto that is inconsistent,
+	// like a field whose type doesn't match its type name.
+	CodeInvalidDescriptor = Code("invalid-descriptor")
+
+	// CodeUnknownOption indicates an option, or a field in a message literal,
+	// that doesn't exist.
+	CodeUnknownOption = Code("unknown-option")
+	// CodeInvalidOption indicates an option that isn't allowed where it is
+	// used.
+	CodeInvalidOption = Code("invalid-option")
+	// CodeInvalidOptionValue indicates an option value that isn't valid for
+	// the option's type.
+	CodeInvalidOptionValue = Code("invalid-option-value")
+	// CodeDuplicateOption indicates an option that is set more than once.
+	CodeDuplicateOption = Code("duplicate-option")
+	// CodeMissingRequiredField indicates an option message without a value
+	// for a required field.
+	CodeMissingRequiredField = Code("missing-required-field")
+	// CodeInvalidFeature indicates a feature that isn't valid, either in its
+	// definition or where it is used.
+	CodeInvalidFeature = Code("invalid-feature")
+	// CodeDeprecatedFeature is the code of the warning that a feature is
+	// deprecated in the file's edition.
+	CodeDeprecatedFeature = Code("deprecated-feature")
+)
+
+// ErrorWithCode is an error that indicates the kind of problem it describes.
+type ErrorWithCode interface {
+	error
+	// Code returns the code of the error.
+	Code() Code
+}
+
+// CodeOf returns the code of the given error, which is the code of the first
+// error in its chain that is an ErrorWithCode. If there is no such error, it
+// returns CodeUnknown.
+func CodeOf(err error) Code {
+	var errWithCode ErrorWithCode
+	if errors.As(err, &errWithCode) {
+		return errWithCode.Code()
+	}
+	return CodeUnknown
+}
+
+// WithCode returns an error that wraps the given one and has the given code.
+// Its message is the same as that of the given error.
+func WithCode(code Code, err error) ErrorWithCode {
+	return &errorWithCode{code: code, underlying: err}
+}
+
+// Codef creates a new error with the given code, whose underlying error is
+// created using the given message format and arguments (via fmt.Errorf).
+func Codef(code Code, format string, args ...interface{}) ErrorWithCode {
+	return &errorWithCode{code: code, underlying: fmt.Errorf(format, args...)}
+}
+
+type errorWithCode struct {
+	code       Code
+	underlying error
+}
+
+func (e *errorWithCode) Error() string {
+	return e.underlying.Error()
+}
+
+func (e *errorWithCode) Code() Code {
+	return e.code
+}
+
+func (e *errorWithCode) Unwrap() error {
+	return e.underlying
+}
@@ -16,12 +16,16 @@ package protocompile
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"strings"
 
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 	"google.golang.org/protobuf/types/descriptorpb"
@@ -161,6 +165,162 @@ func (r *SourceResolver) accessFile(path string) (io.ReadCloser, error) {
 	return os.Open(path)
 }
 
+// FSResolver can resolve file names by returning source code loaded from a
+// file system. This can be used with any fs.FS implementation, such as an
+// embed.FS or a file system returned by os.DirFS.
+//
+// Unlike SourceResolver, all paths are resolved using the path conventions
+// of io/fs: they must be unrooted, slash-separated paths. A file that cannot
+// be found, including one whose path is not valid for io/fs (such as an
+// absolute path or one that refers to a parent directory), results in an
+// error that wraps fs.ErrNotExist.
+type FSResolver struct {
+	// The file system from which to load files. This field is required.
+	FS fs.FS
+	// Optional list of import paths, which are directories in FS. If present
+	// and not empty, then all file paths to find are assumed to be relative
+	// to one of these paths, which are searched in order. If nil or empty,
+	// all file paths to find are assumed to be relative to the root of FS.
+	ImportPaths []string
+}
+
+var _ Resolver = (*FSResolver)(nil)
+
+// FindFileByPath implements the Resolver interface. The given path is not
+// cleaned: it must already be a valid io/fs path (see fs.ValidPath), so a
+// path like "./foo.proto" or "foo/../bar.proto" is not found. If there are
+// import paths, the path is joined with each of them, and the result is
+// cleaned. The first import path that contains the file is used.
+//
+// If the file is not found, including when the path names a directory, the
+// returned error wraps fs.ErrNotExist. When there are import paths, this is
+// the error for the last one searched. Any other error from the file system,
+// such as a permission error, is returned immediately.
+func (r *FSResolver) FindFileByPath(path string) (SearchResult, error) {
+	if !fs.ValidPath(path) {
+		return SearchResult{}, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	if len(r.ImportPaths) == 0 {
+		reader, err := r.openFile(path)
+		if err != nil {
+			return SearchResult{}, err
+		}
+		return SearchResult{Source: reader}, nil
+	}
+
+	var e error
+	for _, importPath := range r.ImportPaths {
+		reader, err := r.openFile(pathpkg.Join(importPath, path))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				e = err
+				continue
+			}
+			return SearchResult{}, err
+		}
+		return SearchResult{Source: reader}, nil
+	}
+	return SearchResult{}, e
+}
+
+func (r *FSResolver) openFile(path string) (fs.File, error) {
+	if !fs.ValidPath(path) {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	f, err := r.FS.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		_ = f.Close()
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return f, nil
+}
+
+// DescriptorSetResolver can resolve file names using the files in a
+// FileDescriptorSet, such as a "protoset" file produced by protoc's
+// --descriptor_set_out flag. This allows compiling against dependencies
+// for which source code is not available, similar to protoc's
+// --descriptor_set_in flag.
+//
+// If the set is self-contained, meaning it includes all dependencies of all
+// files therein, then the files are linked when the resolver is created, and
+// FindFileByPath returns results with the Desc field set. Otherwise, it
+// returns results with the Proto field set, so the compiler will link them
+// with dependencies provided by other resolvers.
+type DescriptorSetResolver struct {
+	protos map[string]*descriptorpb.FileDescriptorProto
+	// nil if the set could not be linked
+	files *protoregistry.Files
+}
+
+var _ Resolver = (*DescriptorSetResolver)(nil)
+
+// NewDescriptorSetResolver returns a resolver for the files in the given set.
+// It returns an error if the set contains more than one file with the same
+// name. The given set must not be mutated after calling this function.
+func NewDescriptorSetResolver(fds *descriptorpb.FileDescriptorSet) (*DescriptorSetResolver, error) {
+	protos := make(map[string]*descriptorpb.FileDescriptorProto, len(fds.GetFile()))
+	for _, fd := range fds.GetFile() {
+		if _, ok := protos[fd.GetName()]; ok {
+			return nil, fmt.Errorf("file descriptor set contains multiple files named %q", fd.GetName())
+		}
+		protos[fd.GetName()] = fd
+	}
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		// Set is not self-contained or could not otherwise be linked. So we'll
+		// provide protos and let the compiler link them.
+		files = nil
+	}
+	return &DescriptorSetResolver{protos: protos, files: files}, nil
+}
+
+// DescriptorSetResolverFromBytes returns a resolver for the files in the
+// given serialized FileDescriptorSet.
+func DescriptorSetResolverFromBytes(data []byte) (*DescriptorSetResolver, error) {
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &fds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal file descriptor set: %w", err)
+	}
+	return NewDescriptorSetResolver(&fds)
+}
+
+// DescriptorSetResolverFromFile returns a resolver for the files in the
+// serialized FileDescriptorSet stored in the file at the given path.
+func DescriptorSetResolverFromFile(path string) (*DescriptorSetResolver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	res, err := DescriptorSetResolverFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return res, nil
+}
+
+func (r *DescriptorSetResolver) FindFileByPath(path string) (SearchResult, error) {
+	if r.files != nil {
+		fd, err := r.files.FindFileByPath(path)
+		if err != nil {
+			return SearchResult{}, err
+		}
+		return SearchResult{Desc: fd}, nil
+	}
+	fd, ok := r.protos[path]
+	if !ok {
+		return SearchResult{}, protoregistry.NotFound
+	}
+	return SearchResult{Proto: fd}, nil
+}
+
 // SourceAccessorFromMap returns a function that can be used as the Accessor
 // field of a SourceResolver that uses the given map to load source. The map
 // keys are file names and the values are the corresponding file contents.
@@ -0,0 +1,135 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocompile
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestFSResolver(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"protos/a.proto":      {Data: []byte(`syntax = "proto3"; import "b.proto"; message A { B b = 1; }`)},
+		"vendor/b.proto":      {Data: []byte(`syntax = "proto3"; message B {}`)},
+		"vendor/c.proto/x":    {Data: []byte(`not a proto`)},
+		"protos/b.proto.orig": {Data: []byte(`syntax = "proto3"; message Unused {}`)},
+	}
+	resolver := &FSResolver{FS: fsys, ImportPaths: []string{"protos", "vendor"}}
+
+	res, err := resolver.FindFileByPath("b.proto")
+	require.NoError(t, err)
+	data, err := io.ReadAll(res.Source)
+	require.NoError(t, err)
+	assert.Equal(t, `syntax = "proto3"; message B {}`, string(data))
+
+	for _, path := range []string{"d.proto", "c.proto", "../protos/a.proto", "/a.proto"} {
+		_, err = resolver.FindFileByPath(path)
+		assert.ErrorIs(t, err, fs.ErrNotExist, "path %q", path)
+	}
+
+	// without import paths, names are relative to the root
+	_, err = (&FSResolver{FS: fsys}).FindFileByPath("vendor/b.proto")
+	require.NoError(t, err)
+	_, err = (&FSResolver{FS: fsys}).FindFileByPath("b.proto")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = (&FSResolver{FS: fsys}).FindFileByPath("/vendor/b.proto")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	files, err := (&Compiler{Resolver: resolver}).Compile(context.Background(), "a.proto")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.NotNil(t, files[0].Messages().ByName("A"))
+}
+
+func TestDescriptorSetResolver(t *testing.T) {
+	t.Parallel()
+	resolver, err := DescriptorSetResolverFromFile("./internal/testdata/all.protoset")
+	require.NoError(t, err)
+	res, err := resolver.FindFileByPath("desc_test_wellknowntypes.proto")
+	require.NoError(t, err)
+	require.NotNil(t, res.Desc)
+	assert.Equal(t, "desc_test_wellknowntypes.proto", res.Desc.Path())
+	_, err = resolver.FindFileByPath("does_not_exist.proto")
+	assert.ErrorIs(t, err, protoregistry.NotFound)
+
+	_, err = DescriptorSetResolverFromFile("./internal/testdata/does_not_exist.protoset")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = DescriptorSetResolverFromBytes([]byte("not a protoset"))
+	assert.ErrorContains(t, err, "failed to unmarshal file descriptor set")
+	_, err = NewDescriptorSetResolver(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{{Name: proto.String("a.proto")}, {Name: proto.String("a.proto")}},
+	})
+	assert.ErrorContains(t, err, `multiple files named "a.proto"`)
+}
+
+func TestDescriptorSetResolver_NotSelfContained(t *testing.T) {
+	t.Parallel()
+	// b.proto depends on a.proto, which is not in the set
+	resolver, err := NewDescriptorSetResolver(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:       proto.String("b.proto"),
+				Syntax:     proto.String("proto3"),
+				Dependency: []string{"a.proto"},
+				MessageType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("B"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:     proto.String("a"),
+								Number:   proto.Int32(1),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+								TypeName: proto.String(".A"),
+								JsonName: proto.String("a"),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	res, err := resolver.FindFileByPath("b.proto")
+	require.NoError(t, err)
+	assert.Nil(t, res.Desc)
+	require.NotNil(t, res.Proto)
+
+	compiler := &Compiler{
+		Resolver: CompositeResolver{
+			resolver,
+			&SourceResolver{Accessor: SourceAccessorFromMap(map[string]string{
+				"a.proto": `syntax = "proto3"; message A {}`,
+				"c.proto": `syntax = "proto3"; import "b.proto"; message C { B b = 1; }`,
+			})},
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "c.proto")
+	require.NoError(t, err)
+	b := files[0].FindImportByPath("b.proto")
+	require.NotNil(t, b)
+	assert.Equal(t, protoreflect.FullName("A"), b.Messages().ByName("B").Fields().ByName("a").Message().FullName())
+}
@@ -0,0 +1,168 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocompile
+
+import (
+	"context"
+
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// FileResult is the outcome of compiling a single file.
+type FileResult struct {
+	// The path of the file.
+	Path string
+	// The compiled file. This is nil if the file could not be compiled,
+	// unless the compiler allows unresolvable references and the file could
+	// still be linked, in which case Errors and Err are also set.
+	File linker.File
+	// The errors that were reported while compiling this file. This will be
+	// empty if the file was compiled successfully. It may also be empty for a
+	// file that could not be compiled if it was never parsed (for example,
+	// if it could not be resolved) or if it failed because one of its
+	// imports could not be compiled.
+	Errors []reporter.ErrorWithPos
+	// If the file could not be compiled because one of its imports could not
+	// be compiled, this is the path of that import. The errors for the import
+	// can be queried using CompileResults.Lookup.
+	FailedImport string
+	// The error that caused compilation of this file to fail, or nil if the
+	// file was compiled successfully.
+	Err error
+}
+
+// CompileResults contains the outcome of a call to Compiler.CompileAll.
+type CompileResults struct {
+	// The results for the files that were requested to be compiled, in the
+	// same order in which they were requested.
+	Files []FileResult
+
+	all map[string]*result
+}
+
+// Lookup returns the result for the file with the given path. This can be
+// used to query the results of imports, in addition to the files that were
+// requested to be compiled. This returns false if the given file was not
+// processed during the compilation operation.
+func (r *CompileResults) Lookup(path string) (FileResult, bool) {
+	res, ok := r.all[path]
+	if !ok {
+		return FileResult{}, false
+	}
+	return res.fileResult(), true
+}
+
+// Linked returns the files that were successfully compiled, in the order
+// in which they were requested.
+func (r *CompileResults) Linked() linker.Files {
+	var files linker.Files
+	for _, res := range r.Files {
+		if res.File != nil {
+			files = append(files, res.File)
+		}
+	}
+	return files
+}
+
+// Err returns the error for the first requested file that could not be
+// compiled. If all files were compiled successfully, it returns nil.
+func (r *CompileResults) Err() error {
+	for _, res := range r.Files {
+		if res.Err != nil {
+			return res.Err
+		}
+	}
+	return nil
+}
+
+// CompileAll compiles the given file names into fully-linked descriptors,
+// like Compile. But instead of returning only the first error, the returned
+// results include the outcome of every requested file, with errors attributed
+// to the file in which they were found.
+//
+// Errors in one file do not prevent other files from being compiled, even if
+// the compiler's reporter returns a non-nil error. Such an error only aborts
+// the compilation of the file that reported it (and of any files that import
+// it). All errors and warnings are still passed to the compiler's reporter.
+//
+// The returned error will only be non-nil if the given context is cancelled
+// before compilation completes.
+func (c *Compiler) CompileAll(ctx context.Context, files ...string) (*CompileResults, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e := c.newExecutor(cancel, &linker.Symbols{}, map[string]*result{})
+	e.partial = true
+	results := e.startFiles(ctx, files)
+
+	res := &CompileResults{Files: make([]FileResult, len(files))}
+	for i, r := range results {
+		select {
+		case <-r.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		res.Files[i] = r.fileResult()
+	}
+	// A file that fails may not wait for all of its imports. So we wait
+	// for any stragglers, so that all results can be queried via Lookup.
+	e.wg.Wait()
+	res.all = e.results
+	return res, nil
+}
+
+// fileResult returns the FileResult for r, which must be ready.
+func (r *result) fileResult() FileResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return FileResult{
+		Path:         r.name,
+		File:         r.res,
+		Errors:       r.errs,
+		FailedImport: r.failedImport,
+		Err:          r.err,
+	}
+}
+
+// newHandler returns the handler used by the task that compiles the file for
+// the given result.
+func (e *executor) newHandler(r *result) *reporter.Handler {
+	if !e.partial {
+		return e.h.SubHandler()
+	}
+	// In partial mode, each file gets its own handler, so that an error
+	// reported for one file does not abort the others.
+	rep := e.c.Reporter
+	if rep == nil {
+		rep = reporter.NewReporter(nil, nil)
+	}
+	return reporter.NewHandler(reporter.NewReporter(
+		func(err reporter.ErrorWithPos) error {
+			r.mu.Lock()
+			r.errs = append(r.errs, err)
+			r.mu.Unlock()
+
+			e.reporterMu.Lock()
+			defer e.reporterMu.Unlock()
+			return rep.Error(err)
+		},
+		func(err reporter.ErrorWithPos) {
+			e.reporterMu.Lock()
+			defer e.reporterMu.Unlock()
+			rep.Warning(err)
+		},
+	))
+}
@@ -0,0 +1,97 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocompile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+func TestCompileAll(t *testing.T) {
+	t.Parallel()
+	srcs := map[string]string{
+		"a.proto": `syntax = "proto3"; package a; message A {}`,
+		"b.proto": `syntax = "proto3"; package b; message B { Unknown u = 1; Other o = 2; }`,
+		"c.proto": `syntax = "proto3"; package c; import "b.proto"; message C { b.B b = 1; }`,
+		"d.proto": `syntax = "proto3"; package d; import "a.proto"; message D { a.A a = 1; }`,
+		"e.proto": `syntax = "proto3"; package e; message E { Unknown u = 1; }`,
+	}
+	testCases := []struct {
+		name     string
+		reporter reporter.Reporter
+		bErrors  int
+	}{
+		{
+			name:    "default reporter",
+			bErrors: 1,
+		},
+		{
+			name:     "lenient reporter",
+			reporter: reporter.NewReporter(func(reporter.ErrorWithPos) error { return nil }, nil),
+			bErrors:  2,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			compiler := &Compiler{
+				Resolver: &SourceResolver{Accessor: SourceAccessorFromMap(srcs)},
+				Reporter: tc.reporter,
+			}
+			results, err := compiler.CompileAll(context.Background(), "a.proto", "b.proto", "c.proto", "d.proto", "e.proto", "f.proto")
+			require.NoError(t, err)
+			require.Len(t, results.Files, 6)
+
+			linked := results.Linked()
+			require.Len(t, linked, 2)
+			assert.Equal(t, "a.proto", linked[0].Path())
+			assert.Equal(t, "d.proto", linked[1].Path())
+
+			b := results.Files[1]
+			assert.Equal(t, "b.proto", b.Path)
+			assert.Nil(t, b.File)
+			require.Len(t, b.Errors, tc.bErrors)
+			assert.ErrorContains(t, b.Errors[0], "field b.B.u: unknown type Unknown")
+			assert.Error(t, b.Err)
+			assert.Equal(t, b.Err, results.Err())
+
+			c := results.Files[2]
+			assert.Empty(t, c.Errors)
+			assert.Equal(t, "b.proto", c.FailedImport)
+			assert.Error(t, c.Err)
+
+			// errors in e.proto are reported even though b.proto failed first
+			e := results.Files[4]
+			require.Len(t, e.Errors, 1)
+			assert.ErrorContains(t, e.Errors[0], "field e.E.u: unknown type Unknown")
+
+			f := results.Files[5]
+			assert.Empty(t, f.Errors)
+			assert.ErrorContains(t, f.Err, `could not resolve path "f.proto"`)
+
+			imported, ok := results.Lookup("b.proto")
+			require.True(t, ok)
+			assert.Equal(t, b, imported)
+			_, ok = results.Lookup("g.proto")
+			assert.False(t, ok)
+		})
+	}
+}
@@ -0,0 +1,329 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package semantic classifies the tokens in a proto source file by what they
+// mean, for syntax highlighting. Unlike a grammar based on regular
+// expressions, the classification uses the AST, so it handles contextual
+// keywords correctly: a field named "message" is a field name, not a
+// keyword.
+package semantic
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/astquery"
+	"github.com/bufbuild/protocompile/linker"
+)
+
+// Kind is the kind of a token.
+type Kind int
+
+// The kinds of tokens.
+const (
+	// KindKeyword is a keyword, like "message" or "optional", or one of the
+	// identifiers "true" and "false" in an option value.
+	KindKeyword = Kind(iota + 1)
+	// KindComment is a line or block comment.
+	KindComment
+	// KindString is a string literal.
+	KindString
+	// KindNumber is a numeric literal, including "inf" and "nan".
+	KindNumber
+	// KindPunctuation is punctuation, like braces, semicolons, and the dots
+	// in qualified names.
+	KindPunctuation
+	// KindType is a reference to a type: a field's type, an extendee, or a
+	// method's input or output type. Tokens only have this kind if the file
+	// wasn't linked, or if the reference couldn't be resolved. Otherwise,
+	// they have one of the following kinds.
+	KindType
+	// KindScalarType is a reference to a built-in scalar type, like "int32".
+	KindScalarType
+	// KindMessageType is the name of a message, in its declaration or in a
+	// reference to it.
+	KindMessageType
+	// KindEnumType is the name of an enum, in its declaration or in a
+	// reference to it.
+	KindEnumType
+	// KindFieldName is the name of a field or extension, in its declaration,
+	// in a reserved range, or in a message literal.
+	KindFieldName
+	// KindEnumValue is the name of an enum value, in its declaration, in a
+	// reserved range, or in an option value.
+	KindEnumValue
+	// KindOptionName is a component of an option's name.
+	KindOptionName
+	// KindIdentifier is any other identifier, like the name of a package,
+	// service, method, or oneof.
+	KindIdentifier
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindKeyword:
+		return "keyword"
+	case KindComment:
+		return "comment"
+	case KindString:
+		return "string"
+	case KindNumber:
+		return "number"
+	case KindPunctuation:
+		return "punctuation"
+	case KindType:
+		return "type"
+	case KindScalarType:
+		return "scalar type"
+	case KindMessageType:
+		return "message type"
+	case KindEnumType:
+		return "enum type"
+	case KindFieldName:
+		return "field name"
+	case KindEnumValue:
+		return "enum value"
+	case KindOptionName:
+		return "option name"
+	case KindIdentifier:
+		return "identifier"
+	default:
+		return fmt.Sprintf("unknown kind (%d)", int(k))
+	}
+}
+
+// Token is a classified token or comment.
+type Token struct {
+	// The token or comment.
+	Item ast.Item
+	// The location and text of the token or comment.
+	Info ast.ItemInfo
+	Kind Kind
+	// True if the token is the name in the declaration of an element, as
+	// opposed to a reference to the element.
+	Declaration bool
+}
+
+// Tokens classifies every token and comment in the given file, which need not
+// be linked. The tokens are returned in the order in which they appear in the
+// file.
+//
+// Since the file isn't linked, type references have kind KindType. Use
+// LinkedTokens to tell apart scalar types and references to messages and
+// enums.
+func Tokens(file *ast.FileNode) []Token {
+	c := &classifier{file: file, kinds: map[ast.Token]classification{}}
+	return c.tokens()
+}
+
+// LinkedTokens is like Tokens, but it uses the given linked file to resolve
+// type references, so that they have the kind KindScalarType,
+// KindMessageType, or KindEnumType. The file must have been compiled with its
+// AST retained.
+func LinkedTokens(res linker.Result) []Token {
+	c := &classifier{file: res.AST(), res: res, kinds: map[ast.Token]classification{}}
+	return c.tokens()
+}
+
+var scalarTypes = map[string]struct{}{
+	"double": {}, "float": {}, "int32": {}, "int64": {}, "uint32": {}, "uint64": {},
+	"sint32": {}, "sint64": {}, "fixed32": {}, "fixed64": {}, "sfixed32": {},
+	"sfixed64": {}, "bool": {}, "string": {}, "bytes": {},
+}
+
+type classification struct {
+	kind        Kind
+	declaration bool
+}
+
+type classifier struct {
+	file  *ast.FileNode
+	res   linker.Result
+	kinds map[ast.Token]classification
+}
+
+func (c *classifier) tokens() []Token {
+	if c.file == nil {
+		return nil
+	}
+	var path []ast.Node
+	_ = ast.Walk(c.file, &ast.SimpleVisitor{},
+		ast.WithBefore(func(n ast.Node) error {
+			path = append(path, n)
+			if term, ok := n.(ast.TerminalNode); ok {
+				c.kinds[term.Token()] = c.classify(term, path)
+			}
+			return nil
+		}),
+		ast.WithAfter(func(ast.Node) error {
+			path = path[:len(path)-1]
+			return nil
+		}),
+	)
+
+	var tokens []Token
+	items := c.file.Items()
+	for item, ok := items.First(); ok; item, ok = items.Next(item) {
+		info := c.file.ItemInfo(item)
+		if info.RawText() == "" {
+			// the EOF token
+			continue
+		}
+		tok, comment := c.file.GetItem(item)
+		if comment.IsValid() {
+			tokens = append(tokens, Token{Item: item, Info: info, Kind: KindComment})
+			continue
+		}
+		class, ok := c.kinds[tok]
+		if !ok {
+			// not in the AST, which can happen after a syntax error
+			class = classification{kind: lexicalKind(info.RawText())}
+		}
+		tokens = append(tokens, Token{Item: item, Info: info, Kind: class.kind, Declaration: class.declaration})
+	}
+	return tokens
+}
+
+// classify returns the classification of the given terminal node, which is
+// the last node in the given path.
+func (c *classifier) classify(node ast.TerminalNode, path []ast.Node) classification {
+	switch node := node.(type) {
+	case *ast.StringLiteralNode:
+		return classification{kind: KindString}
+	case *ast.UintLiteralNode, *ast.FloatLiteralNode, *ast.SpecialFloatLiteralNode:
+		return classification{kind: KindNumber}
+	case *ast.KeywordNode:
+		return classification{kind: KindKeyword}
+	case *ast.RuneNode:
+		return classification{kind: KindPunctuation}
+	case *ast.IdentNode:
+		return c.classifyIdent(node, path)
+	default:
+		return classification{kind: KindIdentifier}
+	}
+}
+
+// classifyIdent returns the classification of the given identifier, which
+// is the last node in the given path, based on the node that contains it.
+func (c *classifier) classifyIdent(ident *ast.IdentNode, path []ast.Node) classification {
+	// A component of a qualified name is classified like the whole name.
+	i := len(path) - 2
+	var child ast.Node = ident
+	if compound, ok := path[i].(*ast.CompoundIdentNode); ok {
+		child = compound
+		i--
+	}
+	decl := func(kind Kind) classification {
+		return classification{kind: kind, declaration: true}
+	}
+	switch owner := path[i].(type) {
+	case *ast.MessageNode:
+		return decl(KindMessageType)
+	case *ast.GroupNode:
+		// the name of a group is also the name of its message
+		return decl(KindMessageType)
+	case *ast.EnumNode:
+		return decl(KindEnumType)
+	case *ast.EnumValueNode:
+		return decl(KindEnumValue)
+	case *ast.FieldNode:
+		if child == owner.Name {
+			return decl(KindFieldName)
+		}
+		return c.typeReference(ident, path, true)
+	case *ast.MapFieldNode:
+		return decl(KindFieldName)
+	case *ast.MapTypeNode:
+		return c.typeReference(ident, path, true)
+	case *ast.ExtendNode, *ast.RPCTypeNode:
+		return c.typeReference(ident, path, false)
+	case *ast.FieldReferenceNode:
+		switch {
+		case i > 0 && isOptionName(path[i-1]):
+			return classification{kind: KindOptionName}
+		case owner.IsAnyTypeReference() && child == owner.Name:
+			return c.typeReference(ident, path, false)
+		case owner.IsAnyTypeReference():
+			// the type URL's prefix, like "type.googleapis.com"
+			return classification{kind: KindIdentifier}
+		default:
+			return classification{kind: KindFieldName}
+		}
+	case *ast.OptionNode, *ast.MessageFieldNode, *ast.ArrayLiteralNode:
+		// a value
+		switch ident.Val {
+		case "true", "false":
+			return classification{kind: KindKeyword}
+		case "inf", "infinity", "nan":
+			return classification{kind: KindNumber}
+		default:
+			return classification{kind: KindEnumValue}
+		}
+	case *ast.ReservedNode:
+		if i > 0 {
+			if _, ok := path[i-1].(*ast.EnumNode); ok {
+				return classification{kind: KindEnumValue}
+			}
+		}
+		return classification{kind: KindFieldName}
+	case *ast.PackageNode, *ast.ServiceNode, *ast.RPCNode, *ast.OneofNode:
+		return decl(KindIdentifier)
+	default:
+		return classification{kind: KindIdentifier}
+	}
+}
+
+func isOptionName(node ast.Node) bool {
+	_, ok := node.(*ast.OptionNameNode)
+	return ok
+}
+
+// typeReference returns the classification of the given identifier in a
+// reference to a type, which is the last node in the given path. If
+// allowScalar is false, the type must be a message.
+func (c *classifier) typeReference(ident *ast.IdentNode, path []ast.Node, allowScalar bool) classification {
+	if c.res == nil {
+		return classification{kind: KindType}
+	}
+	if _, ok := scalarTypes[ident.Val]; ok && allowScalar {
+		if _, ok := path[len(path)-2].(*ast.CompoundIdentNode); !ok {
+			return classification{kind: KindScalarType}
+		}
+	}
+	switch astquery.Descriptor(c.res, append(astquery.Path(nil), path...)).(type) {
+	case protoreflect.MessageDescriptor:
+		return classification{kind: KindMessageType}
+	case protoreflect.EnumDescriptor:
+		return classification{kind: KindEnumType}
+	default:
+		return classification{kind: KindType}
+	}
+}
+
+// lexicalKind returns the kind of a token that isn't in the AST, based only
+// on its text.
+func lexicalKind(text string) Kind {
+	switch c := text[0]; {
+	case c == '"' || c == '\'':
+		return KindString
+	case c >= '0' && c <= '9' || (c == '.' && len(text) > 1):
+		return KindNumber
+	case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return KindIdentifier
+	default:
+		return KindPunctuation
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+const testSource = `// A file.
+syntax = "proto2";
+package foo.bar;
+import "google/protobuf/descriptor.proto";
+message message {
+  optional string message = 1 [default = "x", deprecated = true];
+  optional .foo.bar.Kind kind = 2 [default = ONE];
+  map<string, message> enums = 3;
+  reserved "enum";
+  extensions 10 to max;
+}
+enum Kind {
+  reserved ZERO;
+  ONE = 1; /* one */
+}
+extend google.protobuf.MessageOptions {
+  optional message meta = 10000;
+}
+message Other {
+  option (meta) = { message: "a" kind: ONE };
+  optional float ratio = 1 [default = inf];
+  oneof choice {
+    string name = 2;
+  }
+}
+service Svc {
+  rpc Get(Other) returns (stream message);
+}
+`
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+	handler := reporter.NewHandler(nil)
+	file, err := parser.Parse("test.proto", strings.NewReader(testSource), handler)
+	require.NoError(t, err)
+	tokens := Tokens(file)
+	// the first and last tokens
+	require.NotEmpty(t, tokens)
+	assert.Equal(t, KindComment, tokens[0].Kind)
+	assert.Equal(t, "// A file.", tokens[0].Info.RawText())
+	assert.Equal(t, "}", tokens[len(tokens)-1].Info.RawText())
+
+	expected := []string{
+		"syntax:keyword", "\"proto2\":string",
+		"package:keyword", "foo:identifier*", ".:punctuation", "bar:identifier*",
+		"import:keyword", "\"google/protobuf/descriptor.proto\":string",
+		// contextual keywords
+		"message:keyword", "message:message type*",
+		"optional:keyword", "string:type", "message:field name*", "1:number",
+		"default:option name", "\"x\":string", "deprecated:option name", "true:keyword",
+		"optional:keyword", ".:punctuation", "foo:type", ".:punctuation", "bar:type", ".:punctuation", "Kind:type",
+		"kind:field name*", "2:number", "default:option name", "ONE:enum value",
+		"map:keyword", "string:type", "message:type", "enums:field name*", "3:number",
+		"reserved:keyword", "\"enum\":string",
+		"extensions:keyword", "10:number", "to:keyword", "max:keyword",
+		"enum:keyword", "Kind:enum type*",
+		"reserved:keyword", "ZERO:enum value",
+		"ONE:enum value*", "1:number", "/* one */:comment",
+		"extend:keyword", "google:type", ".:punctuation", "protobuf:type", ".:punctuation", "MessageOptions:type",
+		"optional:keyword", "message:type", "meta:field name*", "10000:number",
+		"message:keyword", "Other:message type*",
+		"option:keyword", "meta:option name", "message:field name", "\"a\":string", "kind:field name", "ONE:enum value",
+		"optional:keyword", "float:type", "ratio:field name*", "1:number", "default:option name", "inf:number",
+		"oneof:keyword", "choice:identifier*",
+		"string:type", "name:field name*", "2:number",
+		"service:keyword", "Svc:identifier*",
+		"rpc:keyword", "Get:identifier*", "Other:type", "returns:keyword", "stream:keyword", "message:type",
+	}
+	assert.Equal(t, expected, describe(tokens))
+}
+
+func TestLinkedTokens(t *testing.T) {
+	t.Parallel()
+	// reserved identifiers are only allowed in editions
+	source := strings.Replace(testSource, "reserved ZERO;", "reserved 0;", 1)
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{"test.proto": source}),
+		}),
+		RetainASTs: true,
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	res, ok := files[0].(linker.Result)
+	require.True(t, ok)
+	tokens := LinkedTokens(res)
+
+	var types []string
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case KindType, KindScalarType, KindMessageType, KindEnumType:
+			if !tok.Declaration {
+				types = append(types, tok.Info.RawText()+":"+tok.Kind.String())
+			}
+		}
+	}
+	expected := []string{
+		"string:scalar type",
+		"foo:enum type", "bar:enum type", "Kind:enum type",
+		"string:scalar type", "message:message type",
+		"google:message type", "protobuf:message type", "MessageOptions:message type", "message:message type",
+		"float:scalar type", "string:scalar type",
+		"Other:message type", "message:message type",
+	}
+	assert.Equal(t, expected, types)
+}
+
+func TestTokens_SyntaxError(t *testing.T) {
+	t.Parallel()
+	handler := reporter.NewHandler(reporter.NewReporter(func(reporter.ErrorWithPos) error { return nil }, nil))
+	file, err := parser.Parse("test.proto", strings.NewReader("syntax = \"proto3\";\nmessage Foo {\n  string name = 1 2;\n}\n"), handler)
+	require.Error(t, err)
+	var kinds []string
+	for _, tok := range Tokens(file) {
+		kinds = append(kinds, tok.Info.RawText()+":"+tok.Kind.String())
+	}
+	// every token has a kind, even the ones that aren't in the AST
+	expected := []string{
+		"syntax:keyword", "=:punctuation", "\"proto3\":string", ";:punctuation",
+		"message:keyword", "Foo:message type", "{:punctuation",
+		"string:type", "name:field name", "=:punctuation", "1:number", "2:number", ";:punctuation",
+		"}:punctuation",
+	}
+	assert.Equal(t, expected, kinds)
+}
+
+// describe returns the text and kind of the given tokens, except for the
+// leading comment and the punctuation that isn't part of a name. Declarations are marked with "*".
+func describe(tokens []Token) []string {
+	var strs []string
+	for i, tok := range tokens {
+		text := tok.Info.RawText()
+		if tok.Kind == KindComment && i == 0 {
+			continue
+		}
+		if tok.Kind == KindPunctuation && text != "." {
+			continue
+		}
+		str := text + ":" + tok.Kind.String()
+		if tok.Declaration {
+			str += "*"
+		}
+		strs = append(strs, str)
+	}
+	return strs
+}
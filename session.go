@@ -0,0 +1,147 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocompile
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/bufbuild/protocompile/linker"
+)
+
+// Session is a long-lived compilation context that supports incremental
+// compilation. Unlike Compiler.Compile, which starts from scratch with every
+// call, a Session retains the results of successfully compiled files, along
+// with the symbol table used to link them, across calls to Compile. So each
+// call only needs to process files that have not yet been compiled.
+//
+// When source files change, callers must notify the session via Invalidate.
+// That discards the results for the changed files and for all files that
+// depend on them, so they will be recompiled the next time they are needed.
+//
+// Since results of previous compilations are re-used, errors and warnings for
+// such files are only reported to the compiler's reporter the first time they
+// are compiled. Files that fail to compile are not retained, so they will be
+// re-processed (and their errors reported again) on every call to Compile
+// until they succeed.
+//
+// A Session is safe for concurrent use. But calls to Compile are serialized,
+// so only one compilation operation is ever running at a time.
+type Session struct {
+	c *Compiler
+
+	mu      sync.Mutex
+	sym     *linker.Symbols
+	results map[string]*result
+}
+
+// NewSession returns a new incremental compilation session that uses c to
+// compile files. The compiler's configuration must not be changed while the
+// session is in use.
+func (c *Compiler) NewSession() *Session {
+	return &Session{
+		c:       c,
+		sym:     &linker.Symbols{},
+		results: map[string]*result{},
+	}
+}
+
+// Compile compiles the given file names into fully-linked descriptors. It is
+// the same as Compiler.Compile except that files which were successfully
+// compiled by a prior call (and not since invalidated) are re-used instead of
+// being resolved and compiled again.
+func (s *Session) Compile(ctx context.Context, files ...string) (linker.Files, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(map[string]*result, len(s.results))
+	for name, r := range s.results {
+		results[name] = r
+	}
+	e := s.c.newExecutor(cancel, s.sym, results)
+	descs, err := e.compileFiles(ctx, files)
+
+	// Stop any tasks that are still running and wait for them to finish, so
+	// that nothing can modify the symbol table after we return.
+	cancel()
+	e.wg.Wait()
+
+	for name, r := range e.results {
+		if _, ok := s.results[name]; ok {
+			continue
+		}
+		if r.err != nil {
+			// A file that failed to link may have already added some of its
+			// symbols to the table. Remove them so they don't conflict with
+			// its next attempt.
+			s.sym.Delete(name)
+			continue
+		}
+		s.results[name] = r
+	}
+	return descs, err
+}
+
+// Invalidate notifies the session that the given files have changed. Results
+// for those files, and for all files that directly or transitively import
+// them, are discarded. They will be recompiled by the next call to Compile
+// that needs them.
+//
+// The paths of all files whose results were discarded are returned, sorted.
+func (s *Session) Invalidate(paths ...string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dependents := map[string][]string{}
+	for name, r := range s.results {
+		imports := r.res.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			dep := imports.Get(i).Path()
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	invalid := map[string]struct{}{}
+	queue := append([]string(nil), paths...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if _, ok := invalid[name]; ok {
+			continue
+		}
+		invalid[name] = struct{}{}
+		queue = append(queue, dependents[name]...)
+	}
+
+	var discarded []string
+	for name := range invalid {
+		if _, ok := s.results[name]; !ok {
+			continue
+		}
+		delete(s.results, name)
+		s.sym.Delete(name)
+		discarded = append(discarded, name)
+	}
+	sort.Strings(discarded)
+	return discarded
+}
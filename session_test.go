@@ -0,0 +1,121 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocompile
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingSources struct {
+	mu       sync.Mutex
+	srcs     map[string]string
+	resolved []string
+}
+
+func (c *countingSources) set(name, src string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.srcs[name] = src
+}
+
+func (c *countingSources) take() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resolved := c.resolved
+	c.resolved = nil
+	sort.Strings(resolved)
+	return resolved
+}
+
+func (c *countingSources) resolver() Resolver {
+	return ResolverFunc(func(path string) (SearchResult, error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if path != descriptorProtoPath {
+			// the compiler checks for an override of descriptor.proto on
+			// every compile operation, so we don't count it
+			c.resolved = append(c.resolved, path)
+		}
+		return (&SourceResolver{Accessor: SourceAccessorFromMap(c.srcs)}).FindFileByPath(path)
+	})
+}
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+	srcs := &countingSources{srcs: map[string]string{
+		"a.proto": `syntax = "proto3"; package test; message A {}`,
+		"b.proto": `syntax = "proto3"; package test; import "a.proto"; message B { A a = 1; }`,
+		"c.proto": `syntax = "proto3"; package test; import "b.proto"; message C { B b = 1; }`,
+		"d.proto": `syntax = "proto3"; package test; import "a.proto"; message D { A a = 1; }`,
+	}}
+	session := (&Compiler{Resolver: srcs.resolver()}).NewSession()
+	ctx := context.Background()
+
+	files, err := session.Compile(ctx, "c.proto", "d.proto")
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, []string{"a.proto", "b.proto", "c.proto", "d.proto"}, srcs.take())
+
+	// nothing changed, so nothing is re-compiled
+	again, err := session.Compile(ctx, "c.proto", "d.proto")
+	require.NoError(t, err)
+	assert.Empty(t, srcs.take())
+	assert.Same(t, files[0], again[0])
+	assert.Same(t, files[1], again[1])
+
+	// changing b only invalidates b and its dependents
+	srcs.set("b.proto", `syntax = "proto3"; package test; import "a.proto"; message B { A a = 1; string s = 2; }`)
+	assert.Equal(t, []string{"b.proto", "c.proto"}, session.Invalidate("b.proto"))
+	again, err = session.Compile(ctx, "c.proto", "d.proto")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.proto", "c.proto"}, srcs.take())
+	assert.NotSame(t, files[0], again[0])
+	assert.Same(t, files[1], again[1])
+	b := again[0].Imports().Get(0).FileDescriptor
+	assert.Equal(t, 2, b.Messages().ByName("B").Fields().Len())
+}
+
+func TestSession_RecoversFromErrors(t *testing.T) {
+	t.Parallel()
+	srcs := &countingSources{srcs: map[string]string{
+		"a.proto": `syntax = "proto3"; package test; message A {}`,
+		"b.proto": `syntax = "proto3"; package test; import "a.proto"; message B { Unknown a = 1; }`,
+	}}
+	session := (&Compiler{Resolver: srcs.resolver()}).NewSession()
+	ctx := context.Background()
+
+	_, err := session.Compile(ctx, "b.proto")
+	require.ErrorContains(t, err, "unknown type Unknown")
+	assert.Equal(t, []string{"a.proto", "b.proto"}, srcs.take())
+
+	// failed files are not retained, so they are compiled again
+	srcs.set("b.proto", `syntax = "proto3"; package test; import "a.proto"; message B { A a = 1; }`)
+	files, err := session.Compile(ctx, "b.proto")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.proto"}, srcs.take())
+	assert.NotNil(t, files[0].Messages().ByName("B"))
+
+	// symbols of invalidated files can be redefined
+	assert.Equal(t, []string{"a.proto", "b.proto"}, session.Invalidate("a.proto"))
+	_, err = session.Compile(ctx, "a.proto", "b.proto")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.proto", "b.proto"}, srcs.take())
+}
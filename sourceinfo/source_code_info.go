@@ -143,11 +143,16 @@ func generateSourceInfoForFile(opts OptionIndex, sci *sourceCodeInfo, file *ast.
 		sci.newLocWithComments(file.Edition, append(path, internal.FileEditionTag))
 	}
 
-	var depIndex, pubDepIndex, weakDepIndex, optIndex, msgIndex, enumIndex, extendIndex, svcIndex int32
+	var depIndex, pubDepIndex, weakDepIndex, optDepIndex, optIndex, msgIndex, enumIndex, extendIndex, svcIndex int32
 
 	for _, child := range file.Decls {
 		switch child := child.(type) {
 		case *ast.ImportNode:
+			if child.Option != nil {
+				sci.newLocWithComments(child, append(path, internal.FileOptionDependencyTag, optDepIndex))
+				optDepIndex++
+				continue
+			}
 			sci.newLocWithComments(child, append(path, internal.FileDependencyTag, depIndex))
 			depIndex++
 			if child.Public != nil {